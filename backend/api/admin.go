@@ -0,0 +1,324 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type adminUser struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+type adminUserDetails struct {
+	User            adminUser        `json:"user"`
+	Emails          []userEmail      `json:"emails"`
+	TwitterAccounts []twitterAccount `json:"twitter_accounts"`
+	Subscriptions   []subscription   `json:"subscriptions"`
+}
+
+// adminIssue - issue of any user, so it refers to its subscription
+type adminIssue struct {
+	issue
+	SubscriptionID string `json:"subscription_id"`
+}
+
+type adminAudit struct {
+	ID        uint      `json:"id"`
+	AdminID   string    `json:"admin_id"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func adaptAdminUser(u models.User) adminUser {
+	return adminUser{
+		ID:         u.ID.String(),
+		Name:       u.Name,
+		Email:      u.Email,
+		Role:       u.Role,
+		DisabledAt: u.DisabledAt,
+	}
+}
+
+func adaptAdminIssue(s models.SubscriptionState) adminIssue {
+	return adminIssue{issue: adaptIssue(s), SubscriptionID: s.SubscriptionID.String()}
+}
+
+func adaptAdminIssues(states []models.SubscriptionState) []adminIssue {
+	res := make([]adminIssue, 0, len(states))
+	for _, s := range states {
+		res = append(res, adaptAdminIssue(s))
+	}
+	return res
+}
+
+func adaptAdminAudit(a models.AdminAudit) adminAudit {
+	return adminAudit{
+		ID:        a.ID,
+		AdminID:   a.AdminID.String(),
+		Action:    a.Action,
+		Target:    a.Target,
+		Details:   a.Details,
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+// getAdminRequest returns the admin id and the context with the transaction, responds with error if it fails
+func getAdminRequest(c *gin.Context) (uuid.UUID, context.Context, bool) {
+	adminID, err := uuid.Parse(getUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+		return adminID, nil, false
+	}
+
+	ctx, err := getContextWithTransaction(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+		return adminID, nil, false
+	}
+	return adminID, ctx, true
+}
+
+// getPage returns limit and offset query parameters, responds with error if they are invalid
+func getPage(c *gin.Context) (uint, uint, bool) {
+	limit, err := getUintQuery(c, "limit", defaultIssuesLimit)
+	if err != nil || limit == 0 || limit > maxIssuesLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": "Invalid limit"})
+		return 0, 0, false
+	}
+
+	offset, err := getUintQuery(c, "offset", 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": "Invalid offset"})
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
+
+func adminSearchUsers(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ctx, ok := getAdminRequest(c)
+		if !ok {
+			return
+		}
+
+		users, err := usecases.AdminSearchUsers(ctx, adminID, c.Query("q"))
+		if err != nil {
+			log.Errorf("Can not search users, got error %s", err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]adminUser, 0, len(users))
+		for _, u := range users {
+			res = append(res, adaptAdminUser(u))
+		}
+		c.JSON(http.StatusOK, gin.H{"users": res})
+	}
+}
+
+func adminGetUser(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ctx, ok := getAdminRequest(c)
+		if !ok {
+			return
+		}
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		details, err := usecases.AdminGetUser(ctx, adminID, userID)
+		if err != nil {
+			log.Errorf("Can not get user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := adminUserDetails{
+			User:            adaptAdminUser(details.User),
+			Emails:          make([]userEmail, 0, len(details.Emails)),
+			TwitterAccounts: make([]twitterAccount, 0, len(details.TwitterAccounts)),
+			Subscriptions:   adaptSubscriptions(details.Subscriptions),
+		}
+		for _, e := range details.Emails {
+			res.Emails = append(res.Emails, adaptUserEmail(e))
+		}
+		for _, a := range details.TwitterAccounts {
+			res.TwitterAccounts = append(res.TwitterAccounts, adaptTwitterAccount(a))
+		}
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// adminSetUserDisabled disables or enables the user, disabling signs it out everywhere
+func adminSetUserDisabled(usecases models.SystemUseCase, disabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ctx, ok := getAdminRequest(c)
+		if !ok {
+			return
+		}
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		user, err := usecases.AdminSetUserDisabled(ctx, adminID, userID, disabled)
+		if err != nil {
+			log.Errorf("Can not set user %s disabled %t, got error %s", userID, disabled, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"user": adaptAdminUser(user)})
+	}
+}
+
+func adminGetSubscriptionIssues(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ctx, ok := getAdminRequest(c)
+		if !ok {
+			return
+		}
+
+		subscriptionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		limit, offset, ok := getPage(c)
+		if !ok {
+			return
+		}
+
+		states, total, err := usecases.AdminGetSubscriptionIssues(ctx, adminID, subscriptionID, limit, offset)
+		if err != nil {
+			log.Errorf("Can not get issues of subscription %s, got error %s", subscriptionID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := issuesPage{Issues: make([]issue, 0, len(states)), Total: total, Limit: limit, Offset: offset}
+		for _, s := range states {
+			res.Issues = append(res.Issues, adaptIssue(s))
+		}
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+func adminGetFailedIssues(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ctx, ok := getAdminRequest(c)
+		if !ok {
+			return
+		}
+
+		limit, offset, ok := getPage(c)
+		if !ok {
+			return
+		}
+
+		states, err := usecases.AdminGetFailedIssues(ctx, adminID, limit, offset)
+		if err != nil {
+			log.Errorf("Can not get failed issues, got error %s", err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"issues": adaptAdminIssues(states), "limit": limit, "offset": offset})
+	}
+}
+
+// adminResendIssue sends the issue again, the response has the issue state after sending
+func adminResendIssue(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ctx, ok := getAdminRequest(c)
+		if !ok {
+			return
+		}
+
+		issueID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		state, err := usecases.AdminResendIssue(ctx, adminID, uint(issueID))
+		if err != nil {
+			log.Errorf("Can not resend issue %d, got error %s", issueID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"issue": adaptAdminIssue(state)})
+	}
+}
+
+func adminForceReconfirmation(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ctx, ok := getAdminRequest(c)
+		if !ok {
+			return
+		}
+
+		var req emailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondWithError(c, errors.NewValidation(err.Error(), map[string]string{"email": "must be email"}))
+			return
+		}
+
+		email, err := usecases.AdminForceReconfirmation(ctx, adminID, req.Email)
+		if err != nil {
+			log.Errorf("Can not force reconfirmation of %s, got error %s", req.Email, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"email": adaptUserEmail(email)})
+	}
+}
+
+func adminGetAudit(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ctx, ok := getAdminRequest(c)
+		if !ok {
+			return
+		}
+
+		limit, offset, ok := getPage(c)
+		if !ok {
+			return
+		}
+
+		audit, err := usecases.AdminGetAudit(ctx, adminID, limit, offset)
+		if err != nil {
+			log.Errorf("Can not get admin audit, got error %s", err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]adminAudit, 0, len(audit))
+		for _, a := range audit {
+			res = append(res, adaptAdminAudit(a))
+		}
+		c.JSON(http.StatusOK, gin.H{"audit": res, "limit": limit, "offset": offset})
+	}
+}
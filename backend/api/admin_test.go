@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockAdmin(datastoreMock *mocks.UserDatastore, role string) uuid.UUID {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetUser", mock.Anything, uid).Return(models.User{ID: uid, Role: role}, nil)
+	datastoreMock.On("InsertAdminAudit", mock.Anything, mock.MatchedBy(func(a models.AdminAudit) bool {
+		return a.AdminID == uid
+	})).Return(models.AdminAudit{ID: 1}, nil)
+	return uid
+}
+
+func testAdminForbiddenForUser(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	mockAdmin(datastoreMock, models.UserRoleUser)
+
+	w := performGetRequest(router, "/admin/users?q=test", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	datastoreMock.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything, mock.Anything)
+	datastoreMock.AssertNotCalled(t, "InsertAdminAudit", mock.Anything, mock.Anything)
+}
+
+func testAdminSearchUsers(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	mockAdmin(datastoreMock, models.UserRoleAdmin)
+	user := models.User{ID: uuid.New(), Name: "Test", Email: "test@example.com", Role: models.UserRoleUser}
+	datastoreMock.On("SearchUsers", mock.Anything, "test", mock.Anything).Return([]models.User{user}, nil)
+
+	w := performGetRequest(router, "/admin/users?q=test", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		Users []adminUser `json:"users"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Users, 1)
+	assert.Equal(t, user.ID.String(), res.Users[0].ID)
+	datastoreMock.AssertNumberOfCalls(t, "InsertAdminAudit", 1)
+
+	w = performGetRequest(router, "/admin/users", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func testAdminGetFailedIssues(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	mockAdmin(datastoreMock, models.UserRoleAdmin)
+	subscriptionID := uuid.New()
+	datastoreMock.On("GetFailedSubscriptionStates", mock.Anything, uint(20), uint(0)).Return(
+		[]models.SubscriptionState{{ID: 7, SubscriptionID: subscriptionID, Status: models.Failed, FailureReason: "Can not send email"}}, nil)
+
+	w := performGetRequest(router, "/admin/issues/failed", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		Issues []adminIssue `json:"issues"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Issues, 1)
+	assert.Equal(t, subscriptionID.String(), res.Issues[0].SubscriptionID)
+	assert.Equal(t, "Can not send email", res.Issues[0].FailureReason)
+}
+
+func testAdminDisableUser(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	adminID := mockAdmin(datastoreMock, models.UserRoleAdmin)
+	userID := uuid.New()
+	datastoreMock.On("SetUserDisabled", mock.Anything, userID, true).Return(models.User{ID: userID}, nil)
+	datastoreMock.On("RevokeUserSessions", mock.Anything, userID).Return(uint(1), nil)
+	datastoreMock.On("RevokeUserAPITokens", mock.Anything, userID).Return(uint(0), nil)
+
+	w := performPostRequest(router, "/admin/users/"+userID.String()+"/disable", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "RevokeUserSessions", 1)
+
+	w = performPostRequest(router, "/admin/users/"+adminID.String()+"/disable", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func testAdminForceReconfirmation(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	mockAdmin(datastoreMock, models.UserRoleAdmin)
+	email := models.UserEmail{UserID: uuid.New(), Email: "test@example.com", Status: models.EmailStatusConfirmed}
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: email.Email}).Return(email, nil)
+	datastoreMock.On("UpdateUserEmail", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, e models.UserEmail) models.UserEmail { return e }, nil)
+
+	w := performPostRequest(router, "/admin/emails/reconfirm", bytes.NewBufferString(`{"email": "test@example.com"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), models.EmailStatusNew)
+
+	w = performPostRequest(router, "/admin/emails/reconfirm", bytes.NewBufferString(`{"email": "not email"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestAdminForbiddenForUser":    testAdminForbiddenForUser,
+		"TestAdminSearchUsers":         testAdminSearchUsers,
+		"TestAdminGetFailedIssues":     testAdminGetFailedIssues,
+		"TestAdminDisableUser":         testAdminDisableUser,
+		"TestAdminForceReconfirmation": testAdminForceReconfirmation,
+	}
+	runTests(tests, t)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"html/template"
+	"net/http"
+	"path/filepath"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type confirmEmailPage struct {
+	Confirmed bool
+	Error     string
+}
+
+func renderConfirmEmailPage(c *gin.Context, conf *config.Config, status int, page confirmEmailPage) {
+	tmpl, err := template.ParseFiles(filepath.Join(conf.TemplatePath, "confirm_email.html"))
+	if err != nil {
+		log.Errorf("Can not parse template, got error %s", err)
+		c.String(http.StatusInternalServerError, "Server error")
+		return
+	}
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err = tmpl.Execute(c.Writer, page)
+	if err != nil {
+		log.Errorf("Can not execute template, got error %s", err)
+	}
+}
+
+// showConfirmEmailPage asks for confirmation, GET requests must not use the link
+// because links in emails are opened by scanners
+func showConfirmEmailPage(conf *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("token") == "" {
+			c.String(http.StatusBadRequest, "Bad request")
+			return
+		}
+
+		renderConfirmEmailPage(c, conf, http.StatusOK, confirmEmailPage{})
+	}
+}
+
+// confirmEmail uses the single-use confirmation link
+func confirmEmail(conf *config.Config, usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.String(http.StatusBadRequest, "Bad request")
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Server error")
+			return
+		}
+
+		err = usecases.ConfirmEmail(ctx, token)
+		if err != nil {
+			log.Errorf("Can't confirm email, got error %s", err)
+			if errors.GetErrorCode(err) == errors.AuthRequired {
+				renderConfirmEmailPage(c, conf, http.StatusUnauthorized, confirmEmailPage{Error: "The link is invalid, used or expired."})
+			} else {
+				renderConfirmEmailPage(c, conf, http.StatusInternalServerError, confirmEmailPage{Error: "Something went wrong, please try again later."})
+			}
+			return
+		}
+
+		renderConfirmEmailPage(c, conf, http.StatusOK, confirmEmailPage{Confirmed: true})
+	}
+}
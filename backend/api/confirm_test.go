@@ -0,0 +1,71 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/dmtr/mail_me_all/backend/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getTestEmailConfirmationToken() string {
+	claims := usecases.EmailConfirmationClaims{
+		Nonce: "nonce",
+		StandardClaims: jwt.StandardClaims{
+			Subject:   "email_confirmation",
+			ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		},
+	}
+	conf := config.GetConfig()
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(conf.EncryptKey))
+	return token
+}
+
+func testShowConfirmEmailPage(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performGetRequest(router, "/confirm/email?token="+getTestEmailConfirmationToken(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<form method="post">`)
+	datastoreMock.AssertNumberOfCalls(t, "UseEmailConfirmation", 0)
+}
+
+func testConfirmEmail(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	confirmation := models.EmailConfirmation{ID: 1, UserID: uuid.New(), Email: "test@example.com"}
+	datastoreMock.On("UseEmailConfirmation", mock.Anything, mock.Anything).Return(confirmation, nil)
+	email := models.UserEmail{UserID: confirmation.UserID, Email: confirmation.Email, Status: models.EmailStatusSent}
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: confirmation.Email}).Return(email, nil)
+	email.Status = models.EmailStatusConfirmed
+	datastoreMock.On("UpdateUserEmail", mock.Anything, email).Return(email, nil)
+
+	w := performRequest(router, "POST", "/confirm/email?token="+getTestEmailConfirmationToken(), nil, false, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "is confirmed")
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 1)
+}
+
+func testConfirmEmailUsedLink(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	datastoreMock.On("UseEmailConfirmation", mock.Anything, mock.Anything).Return(models.EmailConfirmation{}, &db.DbError{Err: sql.ErrNoRows})
+
+	w := performRequest(router, "POST", "/confirm/email?token="+getTestEmailConfirmationToken(), nil, false, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "used or expired")
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 0)
+}
+
+func TestConfirmEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestShowConfirmEmailPage": testShowConfirmEmailPage,
+		"TestConfirmEmail":         testConfirmEmail,
+		"TestConfirmEmailUsedLink": testConfirmEmailUsedLink,
+	}
+	runTests(tests, t)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type dataExport struct {
+	ID            uint       `json:"id"`
+	Status        string     `json:"status"`
+	FailureReason string     `json:"failure_reason"`
+	DownloadLink  string     `json:"download_link"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+func adaptDataExport(e models.DataExport) dataExport {
+	return dataExport{
+		ID:            e.ID,
+		Status:        e.Status,
+		FailureReason: e.FailureReason,
+		DownloadLink:  e.DownloadLink,
+		CreatedAt:     e.CreatedAt,
+		ExpiresAt:     e.ExpiresAt,
+	}
+}
+
+// exportUserData starts building the archive, the download link is emailed when it's ready
+func exportUserData(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		export, err := usecases.ExportUserData(userID)
+		if err != nil {
+			log.Errorf("Can not export data of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"export": adaptDataExport(export)})
+	}
+}
+
+func getDataExports(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		exports, err := usecases.GetDataExports(ctx, userID)
+		if err != nil {
+			log.Errorf("Can not get data exports of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]dataExport, 0, len(exports))
+		for _, e := range exports {
+			res = append(res, adaptDataExport(e))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"exports": res})
+	}
+}
+
+// downloadDataExport serves the archive by the signed link from the email
+func downloadDataExport(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Server error")
+			return
+		}
+
+		archive, err := usecases.DownloadDataExport(ctx, c.Param("token"))
+		if err != nil {
+			log.Errorf("Can not download data export, got error %s", err)
+			status := getErrorStatus(err)
+			c.String(status, http.StatusText(status))
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": archive.Filename}))
+		c.Data(http.StatusOK, archive.ContentType, archive.Data)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testExportUserDataInProgress(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetDataExports", mock.Anything, uid).Return(
+		[]models.DataExport{{ID: 1, UserID: uid, Status: models.DataExportStatusPending, CreatedAt: time.Now()}}, nil)
+
+	w := performPostRequest(router, "/api/user/export", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func testGetAndDownloadDataExport(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	expiresAt := time.Now().Add(time.Hour)
+	ready := models.DataExport{ID: 2, UserID: uid, Status: models.DataExportStatusReady, ExpiresAt: &expiresAt}
+	failed := models.DataExport{ID: 1, UserID: uid, Status: models.DataExportStatusFailed, FailureReason: "Can not build the archive"}
+	datastoreMock.On("GetDataExports", mock.Anything, uid).Return([]models.DataExport{ready, failed}, nil)
+
+	w := performGetRequest(router, "/api/user/export", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		Exports []dataExport `json:"exports"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Exports, 2)
+	assert.Empty(t, res.Exports[1].DownloadLink)
+
+	link, err := url.Parse(res.Exports[0].DownloadLink)
+	assert.NoError(t, err)
+
+	ready.Archive = []byte("zip")
+	datastoreMock.On("GetDataExport", mock.Anything, ready.ID).Return(ready, nil)
+
+	w = performGetRequest(router, link.Path, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "zip", w.Body.String())
+}
+
+func testDownloadDataExportInvalidToken(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performGetRequest(router, "/exports/invalid", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	datastoreMock.AssertNotCalled(t, "GetDataExport", mock.Anything, mock.Anything)
+}
+
+func TestDataExportEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestExportUserDataInProgress":       testExportUserDataInProgress,
+		"TestGetAndDownloadDataExport":       testGetAndDownloadDataExport,
+		"TestDownloadDataExportInvalidToken": testDownloadDataExportInvalidToken,
+	}
+	runTests(tests, t)
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type userEmail struct {
+	Email              string     `json:"email"`
+	Status             string     `json:"status"`
+	CreatedAt          time.Time  `json:"created_at"`
+	ConfirmationSentAt *time.Time `json:"confirmation_sent_at"`
+}
+
+type emailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// deleteEmailConflict - error envelope listing subscriptions which use the email
+type deleteEmailConflict struct {
+	errorResponse
+	Subscriptions []subscription `json:"subscriptions"`
+}
+
+func adaptUserEmail(e models.UserEmail) userEmail {
+	return userEmail{
+		Email:              e.Email,
+		Status:             e.Status,
+		CreatedAt:          e.CreatedAt,
+		ConfirmationSentAt: e.ConfirmationSentAt,
+	}
+}
+
+func getEmails(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		emails, err := usecases.GetEmails(ctx, userID)
+		if err != nil {
+			log.Errorf("Can not get emails of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]userEmail, 0, len(emails))
+		for _, e := range emails {
+			res = append(res, adaptUserEmail(e))
+		}
+		c.JSON(http.StatusOK, gin.H{"emails": res})
+	}
+}
+
+// addEmail adds the address, the confirmation email is sent by the confirmation job
+func addEmail(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		var req emailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondWithError(c, errors.NewValidation(err.Error(), map[string]string{"email": "must be email"}))
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		email, err := usecases.AddEmail(ctx, userID, req.Email)
+		if err != nil {
+			log.Errorf("Can not add email %s, got error %s", req.Email, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"email": adaptUserEmail(email)})
+	}
+}
+
+// deleteEmail removes the address, subscriptions using it are moved to reassign_to address if it's given
+func deleteEmail(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		subscriptions, err := usecases.DeleteEmail(ctx, userID, c.Param("email"), c.Query("reassign_to"))
+		if err != nil {
+			log.Errorf("Can not delete email %s, got error %s", c.Param("email"), err)
+			if errors.GetErrorCode(err) == errors.Conflict {
+				c.JSON(http.StatusConflict, deleteEmailConflict{
+					errorResponse: errorResponse{Code: errors.Conflict, Error: errors.Conflict.String(), Message: err.Error()},
+					Subscriptions: adaptSubscriptions(subscriptions),
+				})
+				return
+			}
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"subscriptions": adaptSubscriptions(subscriptions)})
+	}
+}
+
+func resendConfirmationEmail(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		email, err := usecases.ResendConfirmationEmail(ctx, userID, c.Param("email"))
+		if err != nil {
+			log.Errorf("Can not resend confirmation to %s, got error %s", c.Param("email"), err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"email": adaptUserEmail(email)})
+	}
+}
+
+func reactivateEmail(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		email, err := usecases.ReactivateEmail(ctx, userID, c.Param("email"))
+		if err != nil {
+			log.Errorf("Can not reactivate %s, got error %s", c.Param("email"), err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"email": adaptUserEmail(email)})
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testGetEmails(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetUserEmailsByUserID", mock.Anything, uid).Return([]models.UserEmail{
+		{UserID: uid, Email: "test@example.com", Status: models.EmailStatusConfirmed},
+		{UserID: uid, Email: "new@example.com", Status: models.EmailStatusNew},
+	}, nil)
+
+	w := performGetRequest(router, "/api/emails", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		Emails []userEmail `json:"emails"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Emails, 2)
+	assert.Equal(t, models.EmailStatusConfirmed, res.Emails[0].Status)
+}
+
+func testAddEmail(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "new@example.com"}).Return(models.UserEmail{}, &db.DbError{Err: sql.ErrNoRows})
+	datastoreMock.On("CountUserEmailsSince", mock.Anything, uid, mock.Anything).Return(uint(0), nil)
+	datastoreMock.On("InsertUserEmail", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, e models.UserEmail) models.UserEmail { return e }, nil)
+
+	w := performPostRequest(router, "/api/emails", bytes.NewBufferString(`{"email": "new@example.com"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), models.EmailStatusNew)
+
+	w = performPostRequest(router, "/api/emails", bytes.NewBufferString(`{"email": "not email"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "InsertUserEmail", 1)
+}
+
+func testDeleteEmailConflict(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "test@example.com"}).Return(
+		models.UserEmail{UserID: uid, Email: "test@example.com", Status: models.EmailStatusConfirmed}, nil)
+	datastoreMock.On("GetSubscriptions", mock.Anything, uid).Return(
+		[]models.Subscription{{ID: uuid.New(), UserID: uid, Title: "News", Email: "test@example.com"}}, nil)
+
+	w := performDeleteRequest(router, "/api/emails/test@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var res deleteEmailConflict
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.Subscriptions, 1)
+	assert.Equal(t, "News", res.Subscriptions[0].Title)
+	datastoreMock.AssertNotCalled(t, "DeleteUserEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func testResendConfirmationEmailNotFound(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "other@example.com"}).Return(
+		models.UserEmail{UserID: uuid.New(), Email: "other@example.com", Status: models.EmailStatusSent}, nil)
+
+	w := performPostRequest(router, "/api/emails/other@example.com/resend", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestEmailEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestGetEmails":                       testGetEmails,
+		"TestAddEmail":                        testAddEmail,
+		"TestDeleteEmailConflict":             testDeleteEmailConflict,
+		"TestResendConfirmationEmailNotFound": testResendConfirmationEmailNotFound,
+	}
+	runTests(tests, t)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+var errorStatuses = map[errors.ErrorCode]int{
+	errors.BadRequest:   http.StatusBadRequest,
+	errors.Validation:   http.StatusBadRequest,
+	errors.NotFound:     http.StatusNotFound,
+	errors.AuthRequired: http.StatusUnauthorized,
+	errors.Forbidden:    http.StatusForbidden,
+	errors.Conflict:     http.StatusConflict,
+	errors.RateLimited:  http.StatusTooManyRequests,
+	errors.Upstream:     http.StatusBadGateway,
+}
+
+// errorResponse - error envelope returned by the API
+type errorResponse struct {
+	Code       errors.ErrorCode  `json:"code"`
+	Error      string            `json:"error"`
+	Message    string            `json:"message,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	RetryAfter int               `json:"retry_after,omitempty"`
+}
+
+// getErrorStatus returns http status for the error
+func getErrorStatus(err error) int {
+	if status, ok := errorStatuses[errors.GetErrorCode(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// genericMessages - messages of errors which details must not be exposed, e.g. ids of other users' objects
+var genericMessages = map[errors.ErrorCode]string{
+	errors.AuthRequired: "Authentication required",
+	errors.Forbidden:    "Access denied",
+}
+
+// respondWithError writes the error envelope, messages of server errors and access errors are not exposed
+func respondWithError(c *gin.Context, err error) {
+	code := errors.GetErrorCode(err)
+	status := getErrorStatus(err)
+	res := errorResponse{Code: code, Error: code.String()}
+
+	if msg, ok := genericMessages[code]; ok {
+		log.Warningf("%s: %s", code, err)
+		res.Message = msg
+	} else if status < http.StatusInternalServerError {
+		res.Message = err.Error()
+	}
+
+	if e, ok := err.(*errors.Error); ok {
+		res.Fields = e.Fields()
+		if e.RetryAfter() > 0 {
+			res.RetryAfter = int(math.Ceil(e.RetryAfter().Seconds()))
+			c.Header("Retry-After", strconv.Itoa(res.RetryAfter))
+		}
+	}
+
+	c.JSON(status, res)
+}
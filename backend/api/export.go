@@ -0,0 +1,396 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	exportVersion     = 1
+	exportFormatJSON  = "json"
+	exportFormatOPML  = "opml"
+	twitterURL        = "https://twitter.com/"
+	maxImportFileSize = 1 << 20
+)
+
+// exportedAccount - the handle is enough to import the account, the id is used if present
+type exportedAccount struct {
+	TwitterID  string `json:"twitter_id,omitempty"`
+	ScreenName string `json:"screen_name"`
+	Name       string `json:"name,omitempty"`
+}
+
+type exportedSubscription struct {
+	Title         string            `json:"title"`
+	Email         string            `json:"email"`
+	Day           string            `json:"day"`
+	IgnoreRT      bool              `json:"ignore_rt"`
+	IgnoreReplies bool              `json:"ignore_replies"`
+	EreaderEmail  string            `json:"ereader_email,omitempty"`
+	Paused        bool              `json:"paused"`
+	TwitterListID string            `json:"twitter_list_id,omitempty"`
+	Accounts      []exportedAccount `json:"accounts"`
+}
+
+type subscriptionsExport struct {
+	Version       int                    `json:"version"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Subscriptions []exportedSubscription `json:"subscriptions"`
+}
+
+// opmlOutline - a subscription is a top level outline, its accounts are nested outlines,
+// attributes other than text, title, type and htmlUrl are ignored by feed readers
+type opmlOutline struct {
+	Text          string        `xml:"text,attr"`
+	Title         string        `xml:"title,attr,omitempty"`
+	Type          string        `xml:"type,attr,omitempty"`
+	HTMLURL       string        `xml:"htmlUrl,attr,omitempty"`
+	TwitterID     string        `xml:"twitterId,attr,omitempty"`
+	ScreenName    string        `xml:"screenName,attr,omitempty"`
+	Email         string        `xml:"email,attr,omitempty"`
+	Day           string        `xml:"day,attr,omitempty"`
+	IgnoreRT      string        `xml:"ignoreRt,attr,omitempty"`
+	IgnoreReplies string        `xml:"ignoreReplies,attr,omitempty"`
+	EreaderEmail  string        `xml:"ereaderEmail,attr,omitempty"`
+	Paused        string        `xml:"paused,attr,omitempty"`
+	TwitterListID string        `xml:"twitterListId,attr,omitempty"`
+	Outlines      []opmlOutline `xml:"outline"`
+}
+
+type opml struct {
+	XMLName     xml.Name      `xml:"opml"`
+	Version     string        `xml:"version,attr"`
+	Title       string        `xml:"head>title"`
+	DateCreated string        `xml:"head>dateCreated,omitempty"`
+	Outlines    []opmlOutline `xml:"body>outline"`
+}
+
+type importedSubscription struct {
+	ID         string            `json:"id,omitempty"`
+	Title      string            `json:"title"`
+	Valid      bool              `json:"valid"`
+	Accounts   int               `json:"accounts"`
+	Resolved   int               `json:"resolved"`
+	Unresolved []string          `json:"unresolved"`
+	Errors     map[string]string `json:"errors"`
+}
+
+type subscriptionsImportReport struct {
+	DryRun        bool                   `json:"dry_run"`
+	Valid         bool                   `json:"valid"`
+	Subscriptions []importedSubscription `json:"subscriptions"`
+}
+
+func adaptExportedSubscription(s models.Subscription) exportedSubscription {
+	res := exportedSubscription{
+		Title:         s.Title,
+		Email:         s.Email,
+		Day:           s.Day,
+		IgnoreRT:      s.IgnoreRT,
+		IgnoreReplies: s.IgnoreReplies,
+		EreaderEmail:  s.EreaderEmail,
+		Paused:        s.Paused,
+		TwitterListID: s.TwitterListID,
+		Accounts:      make([]exportedAccount, 0, len(s.UserList)),
+	}
+
+	for _, u := range s.UserList {
+		res.Accounts = append(res.Accounts, exportedAccount{TwitterID: u.TwitterID, ScreenName: u.ScreenName, Name: u.Name})
+	}
+	return res
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+func adaptOPMLOutline(s exportedSubscription) opmlOutline {
+	o := opmlOutline{
+		Text:          s.Title,
+		Title:         s.Title,
+		Email:         s.Email,
+		Day:           s.Day,
+		IgnoreRT:      formatBool(s.IgnoreRT),
+		IgnoreReplies: formatBool(s.IgnoreReplies),
+		EreaderEmail:  s.EreaderEmail,
+		Paused:        formatBool(s.Paused),
+		TwitterListID: s.TwitterListID,
+	}
+
+	for _, a := range s.Accounts {
+		o.Outlines = append(o.Outlines, opmlOutline{
+			Text:       "@" + a.ScreenName,
+			Title:      a.Name,
+			Type:       "link",
+			HTMLURL:    twitterURL + a.ScreenName,
+			TwitterID:  a.TwitterID,
+			ScreenName: a.ScreenName,
+		})
+	}
+	return o
+}
+
+// getOutlineScreenName returns the handle of the account outline written by other tools too
+func getOutlineScreenName(o opmlOutline) string {
+	if o.ScreenName != "" {
+		return o.ScreenName
+	}
+
+	if strings.HasPrefix(o.HTMLURL, twitterURL) {
+		return strings.Trim(strings.TrimPrefix(o.HTMLURL, twitterURL), "/")
+	}
+	return strings.TrimPrefix(o.Text, "@")
+}
+
+func adaptOutlineSubscription(o opmlOutline) (exportedSubscription, error) {
+	s := exportedSubscription{
+		Title:         o.Title,
+		Email:         o.Email,
+		Day:           o.Day,
+		EreaderEmail:  o.EreaderEmail,
+		TwitterListID: o.TwitterListID,
+	}
+	if s.Title == "" {
+		s.Title = o.Text
+	}
+
+	for attr, value := range map[string]string{"ignoreRt": o.IgnoreRT, "ignoreReplies": o.IgnoreReplies, "paused": o.Paused} {
+		if value == "" {
+			continue
+		}
+
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return s, fmt.Errorf("%s of %s must be true or false", attr, s.Title)
+		}
+
+		switch attr {
+		case "ignoreRt":
+			s.IgnoreRT = b
+		case "ignoreReplies":
+			s.IgnoreReplies = b
+		case "paused":
+			s.Paused = b
+		}
+	}
+
+	for _, a := range o.Outlines {
+		s.Accounts = append(s.Accounts, exportedAccount{TwitterID: a.TwitterID, ScreenName: getOutlineScreenName(a), Name: a.Title})
+	}
+	return s, nil
+}
+
+// parseSubscriptionsFile reads subscriptions from the JSON or OPML file, the format is detected by the content
+func parseSubscriptionsFile(data []byte) ([]exportedSubscription, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.NewValidation("File is empty", map[string]string{"file": "required"})
+	}
+
+	if data[0] == '<' {
+		var doc opml
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, errors.NewValidation("Invalid OPML: "+err.Error(), map[string]string{"file": "must be JSON or OPML"})
+		}
+
+		res := make([]exportedSubscription, 0, len(doc.Outlines))
+		for _, o := range doc.Outlines {
+			s, err := adaptOutlineSubscription(o)
+			if err != nil {
+				return nil, errors.NewValidation(err.Error(), map[string]string{"file": "invalid outline"})
+			}
+			res = append(res, s)
+		}
+		return res, nil
+	}
+
+	var export subscriptionsExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, errors.NewValidation("Invalid JSON: "+err.Error(), map[string]string{"file": "must be JSON or OPML"})
+	}
+
+	if export.Version > exportVersion {
+		err := fmt.Sprintf("Unsupported file version %d", export.Version)
+		return nil, errors.NewValidation(err, map[string]string{"version": fmt.Sprintf("up to %d", exportVersion)})
+	}
+	return export.Subscriptions, nil
+}
+
+func adaptImportedSubscription(s exportedSubscription) models.Subscription {
+	res := models.Subscription{
+		Title:         s.Title,
+		Email:         s.Email,
+		Day:           s.Day,
+		IgnoreRT:      s.IgnoreRT,
+		IgnoreReplies: s.IgnoreReplies,
+		EreaderEmail:  s.EreaderEmail,
+		Paused:        s.Paused,
+		TwitterListID: s.TwitterListID,
+	}
+
+	for _, a := range s.Accounts {
+		res.UserList = append(res.UserList, models.TwitterUserSearchResult{
+			TwitterID:  a.TwitterID,
+			ScreenName: strings.TrimPrefix(a.ScreenName, "@"),
+			Name:       a.Name,
+		})
+	}
+	return res
+}
+
+func adaptSubscriptionsImportReport(report models.SubscriptionsImportReport) subscriptionsImportReport {
+	res := subscriptionsImportReport{DryRun: report.DryRun, Valid: report.Valid()}
+	for _, r := range report.Results {
+		s := importedSubscription{
+			Title:      r.Subscription.Title,
+			Valid:      r.Valid(),
+			Accounts:   len(r.Subscription.UserList),
+			Resolved:   r.Resolved,
+			Unresolved: r.Unresolved,
+			Errors:     r.Errors,
+		}
+
+		if r.Subscription.ID != uuid.Nil {
+			s.ID = r.Subscription.ID.String()
+		}
+		res.Subscriptions = append(res.Subscriptions, s)
+	}
+	return res
+}
+
+func exportSubscriptions(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		format := c.DefaultQuery("format", exportFormatJSON)
+		if format != exportFormatJSON && format != exportFormatOPML {
+			respondWithError(c, errors.NewValidation("Unknown format "+format, map[string]string{"format": "must be json or opml"}))
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		subscriptions, err := usecases.GetSubscriptions(ctx, userID)
+		if err != nil {
+			log.Errorf("Can not export subscriptions of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		export := subscriptionsExport{Version: exportVersion, ExportedAt: time.Now().UTC(), Subscriptions: make([]exportedSubscription, 0, len(subscriptions))}
+		for _, s := range subscriptions {
+			export.Subscriptions = append(export.Subscriptions, adaptExportedSubscription(s))
+		}
+
+		c.Header("Content-Disposition", "attachment; filename=subscriptions."+format)
+		if format == exportFormatJSON {
+			c.JSON(http.StatusOK, export)
+			return
+		}
+
+		doc := opml{Version: "2.0", Title: "Mail me all subscriptions", DateCreated: export.ExportedAt.Format(time.RFC1123Z)}
+		for _, s := range export.Subscriptions {
+			doc.Outlines = append(doc.Outlines, adaptOPMLOutline(s))
+		}
+
+		data, err := xml.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			log.Errorf("Can not marshal OPML of user %s, got error %s", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+		c.Data(http.StatusOK, "text/x-opml; charset=utf-8", append([]byte(xml.Header), data...))
+	}
+}
+
+// readImportFile reads the file from the multipart form or the whole request body
+func readImportFile(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return ioutil.ReadAll(c.Request.Body)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+func importSubscriptions(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+		if err != nil {
+			respondWithError(c, errors.NewValidation(err.Error(), map[string]string{"dry_run": "must be true or false"}))
+			return
+		}
+
+		data, err := readImportFile(c)
+		if err != nil {
+			respondWithError(c, errors.NewValidation(err.Error(), map[string]string{"file": fmt.Sprintf("required, up to %d bytes", maxImportFileSize)}))
+			return
+		}
+
+		exported, err := parseSubscriptionsFile(data)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		subscriptions := make([]models.Subscription, 0, len(exported))
+		for _, s := range exported {
+			subscriptions = append(subscriptions, adaptImportedSubscription(s))
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		report, err := usecases.ImportSubscriptions(ctx, userID, subscriptions, dryRun)
+		if err != nil {
+			log.Errorf("Can not import subscriptions of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, adaptSubscriptionsImportReport(report))
+	}
+}
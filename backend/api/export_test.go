@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockExportedSubscriptions(datastoreMock *mocks.UserDatastore) []models.Subscription {
+	uid, _ := uuid.Parse(testUserID)
+	subscriptions := []models.Subscription{
+		models.Subscription{
+			ID:       uuid.New(),
+			UserID:   uid,
+			Title:    "golang",
+			Email:    "test@example.com",
+			Day:      "monday",
+			IgnoreRT: true,
+			UserList: models.UserList{models.TwitterUserSearchResult{TwitterID: "1", ScreenName: "golang", Name: "Go"}},
+		},
+	}
+	datastoreMock.On("GetSubscriptions", mock.Anything, uid).Return(subscriptions, nil)
+	return subscriptions
+}
+
+func testExportSubscriptionsJSON(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	mockExportedSubscriptions(datastoreMock)
+
+	w := performGetRequest(router, "/api/subscriptions-export", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "subscriptions.json")
+
+	var export subscriptionsExport
+	err := json.Unmarshal(w.Body.Bytes(), &export)
+	assert.NoError(t, err)
+	assert.Equal(t, exportVersion, export.Version)
+	assert.Len(t, export.Subscriptions, 1)
+	assert.True(t, export.Subscriptions[0].IgnoreRT)
+	assert.Equal(t, exportedAccount{TwitterID: "1", ScreenName: "golang", Name: "Go"}, export.Subscriptions[0].Accounts[0])
+}
+
+func testExportImportOPML(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	subscriptions := mockExportedSubscriptions(datastoreMock)
+
+	w := performGetRequest(router, "/api/subscriptions-export?format=opml", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `htmlUrl="https://twitter.com/golang"`)
+
+	parsed, err := parseSubscriptionsFile(w.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, parsed, 1)
+
+	s := adaptImportedSubscription(parsed[0])
+	s.ID = subscriptions[0].ID
+	s.UserID = subscriptions[0].UserID
+	assert.Equal(t, subscriptions[0], s)
+}
+
+func testImportSubscriptionsDryRun(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	mockTwitterUser(datastoreMock)
+	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(models.UserEmail{}, &db.DbError{Err: sql.ErrNoRows})
+
+	file := `{"version": 1, "subscriptions": [{"title": "golang", "email": "test@example.com", "day": "monday", "accounts": [{"twitter_id": "1", "screen_name": "golang"}]}]}`
+	w := performPostRequest(router, "/api/subscriptions-import?dry_run=true", bytes.NewBufferString(file))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report subscriptionsImportReport
+	err := json.Unmarshal(w.Body.Bytes(), &report)
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.True(t, report.Valid)
+	assert.Equal(t, 1, report.Subscriptions[0].Accounts)
+	assert.Empty(t, report.Subscriptions[0].ID)
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscription", 0)
+}
+
+func testImportSubscriptionsInvalidFile(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performPostRequest(router, "/api/subscriptions-import", bytes.NewBufferString("{not json"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var r errorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &r)
+	assert.NoError(t, err)
+	assert.Contains(t, r.Fields, "file")
+}
+
+func testSubscriptionsUnknownSegment(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performGetRequest(router, "/api/subscriptions/"+uuid.New().String(), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestExportEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestExportSubscriptionsJSON":        testExportSubscriptionsJSON,
+		"TestExportImportOPML":               testExportImportOPML,
+		"TestImportSubscriptionsDryRun":      testImportSubscriptionsDryRun,
+		"TestImportSubscriptionsInvalidFile": testImportSubscriptionsInvalidFile,
+		"TestSubscriptionsUnknownSegment":    testSubscriptionsUnknownSegment,
+	}
+	runTests(tests, t)
+}
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type twitterList struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	OwnerScreenName string `json:"owner_screen_name"`
+	MemberCount     int64  `json:"member_count"`
+	Private         bool   `json:"private"`
+}
+
+// usersPage - cursor is a string, Twitter cursors don't fit into javascript numbers
+type usersPage struct {
+	Users      []twitterUser `json:"users"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// importSettings - settings of the new subscription created by the import
+type importSettings struct {
+	Title         string `json:"title" binding:"required"`
+	Email         string `json:"email" binding:"required,email"`
+	Day           string `json:"day" binding:"required"`
+	IgnoreRT      bool   `json:"ignore_rt"`
+	IgnoreReplies bool   `json:"ignore_replies"`
+	EreaderEmail  string `json:"ereader_email" binding:"omitempty,email"`
+}
+
+// importRequest - either subscription_id of the existing subscription or settings of the new one is required,
+// user_ids and query select some of the followings, sync keeps the subscription synced with the list
+type importRequest struct {
+	SubscriptionID string          `json:"subscription_id"`
+	Subscription   *importSettings `json:"subscription"`
+	UserIDs        []string        `json:"user_ids"`
+	Query          string          `json:"query"`
+	Sync           bool            `json:"sync"`
+}
+
+type listChange struct {
+	TwitterListID string    `json:"twitter_list_id"`
+	TwitterID     string    `json:"twitter_id"`
+	ScreenName    string    `json:"screen_name"`
+	Name          string    `json:"name"`
+	Kind          string    `json:"kind"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func adaptTwitterList(l models.TwitterList) twitterList {
+	return twitterList{
+		ID:              l.ID,
+		Name:            l.Name,
+		Description:     l.Description,
+		OwnerScreenName: l.OwnerScreenName,
+		MemberCount:     l.MemberCount,
+		Private:         l.Private,
+	}
+}
+
+func adaptUsersPage(users []models.TwitterUserSearchResult, nextCursor int64) usersPage {
+	res := usersPage{Users: make([]twitterUser, 0, len(users)), NextCursor: strconv.FormatInt(nextCursor, 10)}
+	for _, user := range users {
+		res.Users = append(res.Users, adaptTwitterUserSearchResult(user))
+	}
+	return res
+}
+
+func getCursor(c *gin.Context) (int64, error) {
+	cursor := c.Query("cursor")
+	if cursor == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(cursor, 10, 64)
+}
+
+func getTwitterLists(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		lists, err := usecases.GetTwitterLists(context.Background(), userID)
+		if err != nil {
+			log.Errorf("Can not get twitter lists of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]twitterList, 0, len(lists))
+		for _, l := range lists {
+			res = append(res, adaptTwitterList(l))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"lists": res})
+	}
+}
+
+func getTwitterListMembers(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		cursor, err := getCursor(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		users, next, err := usecases.GetTwitterListMembers(context.Background(), userID, c.Param("id"), cursor)
+		if err != nil {
+			log.Errorf("Can not get members of twitter list %s, got error %s", c.Param("id"), err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, adaptUsersPage(users, next))
+	}
+}
+
+func getTwitterFriends(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		cursor, err := getCursor(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		users, next, err := usecases.GetTwitterFriends(context.Background(), userID, cursor)
+		if err != nil {
+			log.Errorf("Can not get twitter friends of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, adaptUsersPage(users, next))
+	}
+}
+
+// getImportSubscription returns the existing subscription with the id only or the new subscription
+func getImportSubscription(req importRequest, userID uuid.UUID) (models.Subscription, error) {
+	if req.SubscriptionID != "" {
+		id, err := uuid.Parse(req.SubscriptionID)
+		if err != nil {
+			return models.Subscription{}, errors.NewValidation(err.Error(), map[string]string{"subscription_id": "must be uuid"})
+		}
+		return models.Subscription{ID: id, UserID: userID}, nil
+	}
+
+	if req.Subscription == nil {
+		return models.Subscription{}, errors.NewValidation("Subscription is required", map[string]string{
+			"subscription_id": "required without subscription",
+			"subscription":    "required without subscription_id",
+		})
+	}
+
+	s := req.Subscription
+	return models.Subscription{
+		UserID:        userID,
+		Title:         s.Title,
+		Email:         s.Email,
+		Day:           strings.ToLower(s.Day),
+		IgnoreRT:      s.IgnoreRT,
+		IgnoreReplies: s.IgnoreReplies,
+		EreaderEmail:  s.EreaderEmail,
+	}, nil
+}
+
+func importTwitterUsers(usecases models.UserUseCase, kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		var req importRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		s, err := getImportSubscription(req, userID)
+		if err != nil {
+			respondWithError(c, err)
+			return
+		}
+
+		source := models.ImportSource{Kind: kind, ListID: c.Param("id"), Sync: req.Sync}
+		if kind == models.ImportSourceFollowing {
+			source.UserIDs = req.UserIDs
+			source.Query = req.Query
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		s, imported, err := usecases.ImportTwitterUsers(ctx, userID, source, s)
+		if err != nil {
+			log.Errorf("Can not import %s into subscription %s, got error %s", kind, s, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"subscription": adaptSubscription(s), "imported": imported})
+	}
+}
+
+func adaptListChange(c models.SubscriptionListChange) listChange {
+	return listChange{
+		TwitterListID: c.TwitterListID,
+		TwitterID:     c.TwitterID,
+		ScreenName:    c.ScreenName,
+		Name:          c.Name,
+		Kind:          strings.ToLower(c.Kind),
+		CreatedAt:     c.CreatedAt,
+	}
+}
+
+func getSubscriptionListChanges(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		subscriptionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		limit, err := getUintQuery(c, "limit", defaultIssuesLimit)
+		if err != nil || limit == 0 || limit > maxIssuesLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": "Invalid limit"})
+			return
+		}
+
+		offset, err := getUintQuery(c, "offset", 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": "Invalid offset"})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		changes, err := usecases.GetSubscriptionListChanges(ctx, userID, subscriptionID, limit, offset)
+		if err != nil {
+			log.Errorf("Can not get list changes of subscription %s, got error %s", subscriptionID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]listChange, 0, len(changes))
+		for _, change := range changes {
+			res = append(res, adaptListChange(change))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"changes": res, "limit": limit, "offset": offset})
+	}
+}
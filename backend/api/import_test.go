@@ -0,0 +1,205 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func mockTwitterUser(datastoreMock *mocks.UserDatastore) models.TwitterUser {
+	uid, _ := uuid.Parse(testUserID)
+	user := models.TwitterUser{UserID: uid, TwitterID: "111", AccessToken: "token", TokenSecret: "secret"}
+	datastoreMock.On("GetTwitterUser", mock.Anything, uid).Return(user, nil)
+	return user
+}
+
+func testGetTwitterListsOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	user := mockTwitterUser(datastoreMock)
+	req := pb.ListsRequest{TwitterId: user.TwitterID, AccessToken: user.AccessToken, AccessSecret: user.TokenSecret}
+	res := pb.ListsResponse{Lists: []*pb.TwitterList{&pb.TwitterList{IdStr: "42", Name: "golang", MemberCount: 2}}}
+	clientMock.On("GetLists", mock.Anything, &req).Return(&res, nil)
+
+	w := performGetRequest(router, "/api/twitter-lists", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var r struct {
+		Lists []twitterList
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &r)
+	assert.NoError(t, err)
+	assert.Equal(t, []twitterList{twitterList{ID: "42", Name: "golang", MemberCount: 2}}, r.Lists)
+}
+
+func testGetTwitterFriendsPage(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	user := mockTwitterUser(datastoreMock)
+	req := pb.FriendsRequest{TwitterId: user.TwitterID, AccessToken: user.AccessToken, AccessSecret: user.TokenSecret, Cursor: 1634211722318513939, Count: 200}
+	res := pb.UsersPage{Users: []*pb.UserInfo{&pb.UserInfo{TwitterId: "222", ScreenName: "foo"}}, NextCursor: 1634211722318513940}
+	clientMock.On("GetFriends", mock.Anything, &req).Return(&res, nil)
+
+	w := performGetRequest(router, "/api/twitter-friends?cursor=1634211722318513939", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var r usersPage
+	err := json.Unmarshal(w.Body.Bytes(), &r)
+	assert.NoError(t, err)
+	assert.Equal(t, "1634211722318513940", r.NextCursor)
+	assert.Equal(t, 1, len(r.Users))
+}
+
+func testGetTwitterFriendsRateLimited(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	mockTwitterUser(datastoreMock)
+	clientMock.On("GetFriends", mock.Anything, mock.Anything).Return(nil, status.Error(codes.ResourceExhausted, "twitter: 88 Rate limit exceeded"))
+
+	w := performGetRequest(router, "/api/twitter-friends", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func testImportListNewSubscription(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	user := mockTwitterUser(datastoreMock)
+	first := pb.ListMembersRequest{TwitterId: user.TwitterID, AccessToken: user.AccessToken, AccessSecret: user.TokenSecret, ListId: "42", Count: 200}
+	clientMock.On("GetListMembers", mock.Anything, &first).Return(&pb.UsersPage{Users: []*pb.UserInfo{&pb.UserInfo{TwitterId: "222"}}, NextCursor: 7}, nil)
+	second := first
+	second.Cursor = 7
+	clientMock.On("GetListMembers", mock.Anything, &second).Return(&pb.UsersPage{Users: []*pb.UserInfo{&pb.UserInfo{TwitterId: "333"}}}, nil)
+
+	datastoreMock.On("GetUser", mock.Anything, user.UserID).Return(models.User{ID: user.UserID}, nil)
+	datastoreMock.On("InsertSubscription", mock.Anything, mock.MatchedBy(func(s models.Subscription) bool {
+		return s.Title == "golang" && s.Day == "monday" && len(s.UserList) == 2
+	})).Return(func(ctx context.Context, s models.Subscription) models.Subscription {
+		s.ID = uuid.New()
+		return s
+	}, nil)
+	datastoreMock.On("InsertUserEmail", mock.Anything, mock.Anything).Return(models.UserEmail{}, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"subscription": map[string]interface{}{"title": "golang", "email": "test@example.com", "day": "Monday"},
+	})
+	w := performPostRequest(router, "/api/twitter-lists/42/import", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var r struct {
+		Subscription subscription
+		Imported     int
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &r)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r.Imported)
+	assert.Equal(t, 2, len(r.Subscription.UserList))
+	clientMock.AssertNumberOfCalls(t, "GetListMembers", 2)
+}
+
+func testImportFollowingIntoExisting(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	user := mockTwitterUser(datastoreMock)
+	s := models.Subscription{ID: uuid.New(), UserID: user.UserID, Title: "test", UserList: models.UserList{models.TwitterUserSearchResult{TwitterID: "222"}}}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	res := pb.UsersPage{Users: []*pb.UserInfo{
+		&pb.UserInfo{TwitterId: "222", ScreenName: "gopher"},
+		&pb.UserInfo{TwitterId: "333", ScreenName: "golang"},
+		&pb.UserInfo{TwitterId: "444", ScreenName: "rustlang"},
+	}}
+	clientMock.On("GetFriends", mock.Anything, mock.Anything).Return(&res, nil)
+
+	updated := s
+	updated.UserList = append(updated.UserList, models.TwitterUserSearchResult{TwitterID: "333", ScreenName: "golang"})
+	datastoreMock.On("UpdateSubscription", mock.Anything, updated).Return(updated, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"subscription_id": s.ID.String(), "query": "go"})
+	w := performPostRequest(router, "/api/twitter-friends/import", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 1)
+}
+
+func testImportSyncListWithOtherAccounts(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	user := mockTwitterUser(datastoreMock)
+	s := models.Subscription{ID: uuid.New(), UserID: user.UserID, Title: "test", UserList: models.UserList{models.TwitterUserSearchResult{TwitterID: "222"}}}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+	clientMock.On("GetListMembers", mock.Anything, mock.Anything).Return(&pb.UsersPage{Users: []*pb.UserInfo{&pb.UserInfo{TwitterId: "333"}}}, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"subscription_id": s.ID.String(), "sync": true})
+	w := performPostRequest(router, "/api/twitter-lists/42/import", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var r errorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &r)
+	assert.NoError(t, err)
+	assert.Contains(t, r.Fields, "sync")
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 0)
+}
+
+func testImportFollowingNotReached(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	user := mockTwitterUser(datastoreMock)
+	s := models.Subscription{ID: uuid.New(), UserID: user.UserID, Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+	res := pb.UsersPage{Users: []*pb.UserInfo{&pb.UserInfo{TwitterId: "222"}}, NextCursor: 7}
+	clientMock.On("GetFriends", mock.Anything, mock.Anything).Return(&res, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"subscription_id": s.ID.String(), "user_ids": []string{"222", "333"}})
+	w := performPostRequest(router, "/api/twitter-friends/import", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var r errorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &r)
+	assert.NoError(t, err)
+	assert.Contains(t, r.Fields, "user_ids")
+	clientMock.AssertNumberOfCalls(t, "GetFriends", 15)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 0)
+}
+
+func testImportNoSubscription(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performPostRequest(router, "/api/twitter-friends/import", bytes.NewBufferString("{}"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var r errorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &r)
+	assert.NoError(t, err)
+	assert.Contains(t, r.Fields, "subscription_id")
+	clientMock.AssertNumberOfCalls(t, "GetFriends", 0)
+}
+
+func testGetSubscriptionListChanges(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := mockOwnSubscription(datastoreMock)
+	changes := []models.SubscriptionListChange{
+		models.SubscriptionListChange{SubscriptionID: s.ID, TwitterListID: "42", TwitterID: "111", ScreenName: "gopher", Kind: models.ListChangeAdded},
+	}
+	datastoreMock.On("GetSubscriptionListChanges", mock.Anything, s.ID, uint(20), uint(0)).Return(changes, nil)
+
+	w := performGetRequest(router, "/api/subscriptions/"+s.ID.String()+"/list-changes?limit=20", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var r struct {
+		Changes []listChange `json:"changes"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &r)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(r.Changes))
+	assert.Equal(t, "added", r.Changes[0].Kind)
+	assert.Equal(t, "gopher", r.Changes[0].ScreenName)
+}
+
+func TestImportEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestGetTwitterListsOk":               testGetTwitterListsOk,
+		"TestImportFollowingNotReached":       testImportFollowingNotReached,
+		"TestImportSyncListWithOtherAccounts": testImportSyncListWithOtherAccounts,
+		"TestGetTwitterFriendsPage":           testGetTwitterFriendsPage,
+		"TestGetTwitterFriendsRateLimited":    testGetTwitterFriendsRateLimited,
+		"TestImportListNewSubscription":       testImportListNewSubscription,
+		"TestImportFollowingIntoExisting":     testImportFollowingIntoExisting,
+		"TestImportNoSubscription":            testImportNoSubscription,
+		"TestGetSubscriptionListChanges":      testGetSubscriptionListChanges,
+	}
+	runTests(tests, t)
+}
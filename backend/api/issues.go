@@ -0,0 +1,364 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultIssuesLimit uint = 20
+	maxIssuesLimit     uint = 100
+)
+
+type issue struct {
+	ID            uint       `json:"id"`
+	Date          string     `json:"date"`
+	Status        string     `json:"status"`
+	TweetCount    uint       `json:"tweet_count"`
+	FailureReason string     `json:"failure_reason"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+type issuesPage struct {
+	Issues []issue `json:"issues"`
+	Total  uint    `json:"total"`
+	Limit  uint    `json:"limit"`
+	Offset uint    `json:"offset"`
+}
+
+func adaptIssue(s models.SubscriptionState) issue {
+	return issue{
+		ID:            s.ID,
+		Date:          s.CreatedAt.Format(dateFormat),
+		Status:        s.Status,
+		TweetCount:    s.TweetCount,
+		FailureReason: s.FailureReason,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+		SentAt:        s.SentAt,
+	}
+}
+
+func getUintQuery(c *gin.Context, key string, defaultValue uint) (uint, error) {
+	v := c.Query(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	res, err := strconv.ParseUint(v, 10, 32)
+	return uint(res), err
+}
+
+func getSubscriptionIssues(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := getUserID(c)
+		userID, err := uuid.Parse(uid)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		subscriptionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		limit, err := getUintQuery(c, "limit", defaultIssuesLimit)
+		if err != nil || limit == 0 || limit > maxIssuesLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": "Invalid limit"})
+			return
+		}
+
+		offset, err := getUintQuery(c, "offset", 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": "Invalid offset"})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		states, total, err := usecases.GetSubscriptionIssues(ctx, userID, subscriptionID, limit, offset)
+		if err != nil {
+			log.Errorf("Can not get issues of subscription %s, got error %s", subscriptionID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := issuesPage{Issues: make([]issue, 0, len(states)), Total: total, Limit: limit, Offset: offset}
+		for _, s := range states {
+			res.Issues = append(res.Issues, adaptIssue(s))
+		}
+
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+type issueTweet struct {
+	ID                  string `json:"id"`
+	Text                string `json:"text"`
+	InReplyToStatusID   string `json:"in_reply_to_status_id"`
+	UserName            string `json:"user_name"`
+	UserScreenName      string `json:"user_screen_name"`
+	UserProfileImageURL string `json:"user_profile_image_url"`
+	URL                 string `json:"url"`
+}
+
+type issueDetails struct {
+	issue
+	SubscriptionID string       `json:"subscription_id"`
+	Title          string       `json:"title"`
+	ShareLink      string       `json:"share_link"`
+	Tweets         []issueTweet `json:"tweets"`
+}
+
+func adaptIssueDetails(i models.Issue) issueDetails {
+	res := issueDetails{
+		issue:          adaptIssue(i.State),
+		SubscriptionID: i.Subscription.ID.String(),
+		Title:          i.Subscription.Title,
+		ShareLink:      i.ShareLink,
+		Tweets:         make([]issueTweet, 0, len(i.Tweets)),
+	}
+
+	for _, t := range i.Tweets {
+		res.Tweets = append(res.Tweets, issueTweet{
+			ID:                  t.TweetID,
+			Text:                t.Tweet.FullText,
+			InReplyToStatusID:   t.Tweet.InReplyToStatusIdStr,
+			UserName:            t.Tweet.UserName,
+			UserScreenName:      t.Tweet.UserScreenName,
+			UserProfileImageURL: t.Tweet.UserProfileImageUrl,
+			URL:                 fmt.Sprintf("https://twitter.com/%s/status/%s", t.Tweet.UserScreenName, t.TweetID),
+		})
+	}
+	return res
+}
+
+func getIssueID(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	return uint(id), err
+}
+
+func getIssue(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		issueID, err := getIssueID(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		issue, err := usecases.GetIssue(ctx, userID, issueID)
+		if err != nil {
+			log.Errorf("Can not get issue %d, got error %s", issueID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, adaptIssueDetails(issue))
+	}
+}
+
+func shareIssue(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		issueID, err := getIssueID(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		link, err := usecases.ShareIssue(ctx, userID, issueID)
+		if err != nil {
+			log.Errorf("Can not share issue %d, got error %s", issueID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"share_link": link})
+	}
+}
+
+func unshareIssue(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		issueID, err := getIssueID(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		err = usecases.UnshareIssue(ctx, userID, issueID)
+		if err != nil {
+			log.Errorf("Can not unshare issue %d, got error %s", issueID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+// viewIssue renders the issue page for its owner
+func viewIssue(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.String(http.StatusBadRequest, "Bad request")
+			return
+		}
+
+		issueID, err := getIssueID(c)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Bad request")
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Server error")
+			return
+		}
+
+		html, err := usecases.GetIssueHTML(ctx, userID, issueID)
+		if err != nil {
+			log.Errorf("Can not render issue %d, got error %s", issueID, err)
+			status := getErrorStatus(err)
+			c.String(status, http.StatusText(status))
+			return
+		}
+
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+	}
+}
+
+// viewSharedIssue renders the issue page for everyone who has the share link
+func viewSharedIssue(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Server error")
+			return
+		}
+
+		html, err := usecases.GetSharedIssueHTML(ctx, c.Param("token"))
+		if err != nil {
+			log.Errorf("Can not render shared issue, got error %s", err)
+			status := getErrorStatus(err)
+			c.String(status, http.StatusText(status))
+			return
+		}
+
+		c.Header("X-Robots-Tag", "noindex")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+	}
+}
+
+// previewSettings - unsaved subscription settings used for preview
+type previewSettings struct {
+	IgnoreRT      bool          `json:"ignore_rt"`
+	IgnoreReplies bool          `json:"ignore_replies"`
+	UserList      []twitterUser `json:"userList"`
+}
+
+func previewSubscription(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		subscriptionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		var settings *models.Subscription
+		var p previewSettings
+		err = io.EOF
+		if c.Request.ContentLength != 0 {
+			err = c.ShouldBindJSON(&p)
+		}
+
+		if err != nil && err != io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		} else if err == nil {
+			settings = &models.Subscription{IgnoreRT: p.IgnoreRT, IgnoreReplies: p.IgnoreReplies}
+			for _, u := range p.UserList {
+				settings.UserList = append(settings.UserList, models.TwitterUserSearchResult{
+					TwitterID:     u.ID,
+					Name:          u.Name,
+					ScreenName:    u.ScreenName,
+					ProfileIMGURL: u.ProfileIMGURL,
+				})
+			}
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		issue, html, err := usecases.PreviewSubscription(ctx, userID, subscriptionID, settings)
+		if err != nil {
+			log.Errorf("Can not preview subscription %s, got error %s", subscriptionID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"html": html, "tweet_count": len(issue.Tweets)})
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testGetSubscriptionIssuesNotAuth(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := models.Subscription{ID: uuid.New(), UserID: uuid.New(), Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	w := performGetRequest(router, "/api/subscriptions/"+s.ID.String()+"/issues", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "GetSubscriptionStates", 0)
+}
+
+func testGetSubscriptionIssuesBadLimit(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performGetRequest(router, "/api/subscriptions/"+uuid.New().String()+"/issues?limit=1000", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func testGetSubscriptionIssuesOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	s := models.Subscription{ID: uuid.New(), UserID: uid, Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+	datastoreMock.On("CountSubscriptionStates", mock.Anything, s.ID).Return(uint(3), nil)
+
+	sentAt := time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC)
+	states := []models.SubscriptionState{
+		models.SubscriptionState{ID: 3, SubscriptionID: s.ID, Status: models.Failed, FailureReason: "Can not send email", CreatedAt: sentAt},
+		models.SubscriptionState{ID: 2, SubscriptionID: s.ID, Status: models.Sent, TweetCount: 5, CreatedAt: sentAt.AddDate(0, 0, -7), SentAt: &sentAt},
+	}
+	datastoreMock.On("GetSubscriptionStates", mock.Anything, s.ID, uint(2), uint(0)).Return(states, nil)
+
+	w := performGetRequest(router, "/api/subscriptions/"+s.ID.String()+"/issues?limit=2", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res issuesPage
+	err := json.Unmarshal([]byte(w.Body.String()), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), res.Total)
+	assert.Equal(t, uint(2), res.Limit)
+	assert.Len(t, res.Issues, 2)
+	assert.Equal(t, "2020-03-02", res.Issues[0].Date)
+	assert.Equal(t, "Can not send email", res.Issues[0].FailureReason)
+	assert.Nil(t, res.Issues[0].SentAt)
+	assert.Equal(t, uint(5), res.Issues[1].TweetCount)
+	assert.True(t, sentAt.Equal(*res.Issues[1].SentAt))
+}
+
+func mockOwnIssue(datastoreMock *mocks.UserDatastore, state models.SubscriptionState) models.Subscription {
+	uid, _ := uuid.Parse(testUserID)
+	s := models.Subscription{ID: state.SubscriptionID, UserID: uid, Title: "test"}
+	datastoreMock.On("GetSubscriptionState", mock.Anything, state.ID).Return(state, nil)
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+	return s
+}
+
+func testGetIssueOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	state := models.SubscriptionState{ID: 1, SubscriptionID: uuid.New(), Status: models.Sent, ShareToken: "abc"}
+	mockOwnIssue(datastoreMock, state)
+
+	tweets := []models.Tweet{
+		models.Tweet{ID: 1, TweetID: "1", Tweet: models.TweetAttrs{IdStr: "1", FullText: "foo", UserId: "10", UserScreenName: "foo"}},
+	}
+	datastoreMock.On("GetSubscriptionTweets", mock.Anything, state.ID).Return(tweets, nil)
+
+	w := performGetRequest(router, "/api/issues/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res issueDetails
+	err := json.Unmarshal([]byte(w.Body.String()), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "test", res.Title)
+	assert.Contains(t, res.ShareLink, "/shared/abc")
+	assert.Len(t, res.Tweets, 1)
+	assert.Equal(t, "https://twitter.com/foo/status/1", res.Tweets[0].URL)
+}
+
+func testShareIssueOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	state := models.SubscriptionState{ID: 1, SubscriptionID: uuid.New(), Status: models.Sent}
+	mockOwnIssue(datastoreMock, state)
+	datastoreMock.On("UpdateSubscriptionState", mock.Anything, mock.MatchedBy(func(s models.SubscriptionState) bool {
+		return s.ID == state.ID && len(s.ShareToken) > 0
+	})).Return(state, nil)
+
+	w := performPostRequest(router, "/api/issues/1/share", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/shared/")
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscriptionState", 1)
+}
+
+func testUnshareIssueNotAuth(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	state := models.SubscriptionState{ID: 1, SubscriptionID: uuid.New(), Status: models.Sent, ShareToken: "abc"}
+	datastoreMock.On("GetSubscriptionState", mock.Anything, state.ID).Return(state, nil)
+	s := models.Subscription{ID: state.SubscriptionID, UserID: uuid.New(), Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	w := performDeleteRequest(router, "/api/issues/1/share", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscriptionState", 0)
+}
+
+func testViewIssueOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	state := models.SubscriptionState{ID: 1, SubscriptionID: uuid.New(), Status: models.Sent}
+	mockOwnIssue(datastoreMock, state)
+
+	tweets := []models.Tweet{
+		models.Tweet{ID: 1, TweetID: "1", Tweet: models.TweetAttrs{IdStr: "1", FullText: "hello world", UserId: "10", UserScreenName: "foo", UserProfileImageUrl: "https://example.com/1.png"}},
+	}
+	datastoreMock.On("GetSubscriptionTweets", mock.Anything, state.ID).Return(tweets, nil)
+
+	w := performGetRequest(router, "/issues/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "hello world")
+	assert.Contains(t, w.Body.String(), "https://example.com/1.png")
+	assert.NotContains(t, w.Body.String(), "Unsubscribe")
+}
+
+func testViewSharedIssueNotFound(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	e := &db.DbError{Err: sql.ErrNoRows}
+	datastoreMock.On("GetSubscriptionStateByShareToken", mock.Anything, "abc").Return(models.SubscriptionState{}, e)
+
+	w := performGetRequest(router, "/shared/abc", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func testPreviewSubscriptionNotAuth(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := models.Subscription{ID: uuid.New(), UserID: uuid.New(), Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/preview", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	clientMock.AssertNumberOfCalls(t, "GetUserTimeline", 0)
+}
+
+func testPreviewSubscriptionOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	s := models.Subscription{
+		ID:       uuid.New(),
+		UserID:   uid,
+		Title:    "test",
+		UserList: []models.TwitterUserSearchResult{models.TwitterUserSearchResult{TwitterID: "10", ScreenName: "foo"}},
+	}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+	datastoreMock.On("GetTwitterUser", mock.Anything, uid).Return(models.TwitterUser{UserID: uid, TwitterID: "1"}, nil)
+
+	// bar isn't saved in the subscription yet
+	recent := pb.UserTimelineResponse{Tweets: []*pb.Tweet{&pb.Tweet{IdStr: "50", FullText: "recent", UserId: "20", UserScreenName: "bar"}}}
+	clientMock.On("GetUserTimeline", mock.Anything, mock.MatchedBy(func(req *pb.UserTimelineRequest) bool {
+		return req.SinceId == 0 && req.ScreenName == "bar" && req.Count > 0
+	})).Return(&recent, nil)
+
+	userTweets := models.SubscriptionUserTweets{
+		SubscriptionID: s.ID,
+		Tweets:         map[string]models.UserLastTweet{"10": models.UserLastTweet{ScreenName: "foo", LastTweetID: "100"}},
+	}
+	datastoreMock.On("GetSubscriptionUserTweets", mock.Anything, s.ID).Return(userTweets, nil)
+
+	timeline := pb.UserTimelineResponse{Tweets: []*pb.Tweet{
+		&pb.Tweet{IdStr: "102", FullText: "second", UserId: "10", UserScreenName: "foo"},
+		&pb.Tweet{IdStr: "101", FullText: "first", UserId: "10", UserScreenName: "foo"},
+	}}
+	clientMock.On("GetUserTimeline", mock.Anything, mock.MatchedBy(func(req *pb.UserTimelineRequest) bool {
+		return req.SinceId == 100 && req.ScreenName == "foo" && req.IgnoreRt
+	})).Return(&timeline, nil)
+
+	body := []byte(`{"ignore_rt": true, "ignore_replies": false, "userList": [{"id": "10", "screen_name": "foo"}, {"id": "20", "screen_name": "bar"}]}`)
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/preview", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		HTML       string `json:"html"`
+		TweetCount int    `json:"tweet_count"`
+	}
+	err := json.Unmarshal([]byte(w.Body.String()), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.TweetCount)
+	assert.Contains(t, res.HTML, "first")
+	assert.Contains(t, res.HTML, "recent")
+	assert.True(t, strings.Index(res.HTML, "first") < strings.Index(res.HTML, "second"))
+
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscriptionState", 0)
+	datastoreMock.AssertNumberOfCalls(t, "InsertTweet", 0)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscriptionUserState", 0)
+}
+
+func TestIssueEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestGetSubscriptionIssuesNotAuth":  testGetSubscriptionIssuesNotAuth,
+		"TestGetSubscriptionIssuesBadLimit": testGetSubscriptionIssuesBadLimit,
+		"TestGetSubscriptionIssuesOk":       testGetSubscriptionIssuesOk,
+		"TestGetIssueOk":                    testGetIssueOk,
+		"TestShareIssueOk":                  testShareIssueOk,
+		"TestUnshareIssueNotAuth":           testUnshareIssueNotAuth,
+		"TestViewIssueOk":                   testViewIssueOk,
+		"TestViewSharedIssueNotFound":       testViewSharedIssueNotFound,
+		"TestPreviewSubscriptionNotAuth":    testPreviewSubscriptionNotAuth,
+		"TestPreviewSubscriptionOk":         testPreviewSubscriptionOk,
+	}
+	runTests(tests, t)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const dateFormat = "2006-01-02"
+
+// Ways to resume a subscription
+const (
+	resumeCatchUp = "catch_up"
+	resumeFresh   = "fresh"
+)
+
+type resumeOptions struct {
+	Mode string `json:"mode" binding:"required"`
+}
+
+type vacation struct {
+	Start string `json:"start" binding:"required"`
+	End   string `json:"end" binding:"required"`
+}
+
+func pauseSubscription(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		subscriptionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		subscription, err := usecases.PauseSubscription(ctx, userID, subscriptionID)
+		if err != nil {
+			log.Errorf("Can not pause subscription %s, got error %s", subscriptionID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, adaptSubscription(subscription))
+	}
+}
+
+func resumeSubscription(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		subscriptionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		var opts resumeOptions
+		if err := c.ShouldBindJSON(&opts); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		if opts.Mode != resumeCatchUp && opts.Mode != resumeFresh {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": "Unknown resume mode " + opts.Mode})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		subscription, err := usecases.ResumeSubscription(ctx, userID, subscriptionID, opts.Mode == resumeCatchUp)
+		if err != nil {
+			log.Errorf("Can not resume subscription %s, got error %s", subscriptionID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, adaptSubscription(subscription))
+	}
+}
+
+func updateVacation(usecases models.UserUseCase, remove bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		var start, end *time.Time
+		if !remove {
+			var v vacation
+			if err := c.ShouldBindJSON(&v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+				return
+			}
+
+			s, err := time.Parse(dateFormat, v.Start)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+				return
+			}
+
+			e, err := time.Parse(dateFormat, v.End)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+				return
+			}
+			start, end = &s, &e
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		user, err := usecases.SetVacation(ctx, userID, start, end)
+		if err != nil {
+			log.Errorf("Can not update vacation of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, adaptUser(user, true))
+	}
+}
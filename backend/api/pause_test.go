@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testPauseSubscriptionNotAuth(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := models.Subscription{ID: uuid.New(), UserID: uuid.New(), Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/pause", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 0)
+}
+
+func testPauseSubscriptionOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := mockOwnSubscription(datastoreMock)
+	paused := s
+	paused.Paused = true
+	datastoreMock.On("UpdateSubscription", mock.Anything, paused).Return(paused, nil)
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/pause", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res subscription
+	err := json.Unmarshal([]byte(w.Body.String()), &res)
+	assert.NoError(t, err)
+	assert.True(t, res.Paused)
+}
+
+func testResumeSubscriptionBadMode(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	body := []byte(`{"mode": "later"}`)
+	w := performPostRequest(router, "/api/subscriptions/"+uuid.New().String()+"/resume", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func testResumeSubscriptionFresh(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	s := models.Subscription{
+		ID:       uuid.New(),
+		UserID:   uid,
+		Title:    "test",
+		Paused:   true,
+		UserList: []models.TwitterUserSearchResult{models.TwitterUserSearchResult{TwitterID: "10", ScreenName: "foo"}},
+	}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+	datastoreMock.On("GetTwitterUser", mock.Anything, uid).Return(models.TwitterUser{UserID: uid, TwitterID: "1"}, nil)
+
+	timeline := pb.UserTimelineResponse{Tweets: []*pb.Tweet{&pb.Tweet{IdStr: "200"}}}
+	clientMock.On("GetUserTimeline", mock.Anything, mock.MatchedBy(func(req *pb.UserTimelineRequest) bool {
+		return req.ScreenName == "foo" && req.Count == 1
+	})).Return(&timeline, nil)
+	datastoreMock.On("UpdateSubscriptionUserState", mock.Anything, s.ID, "10", "200").Return(nil)
+
+	resumed := s
+	resumed.Paused = false
+	datastoreMock.On("UpdateSubscription", mock.Anything, resumed).Return(resumed, nil)
+
+	body := []byte(`{"mode": "fresh"}`)
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/resume", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	datastoreMock.AssertCalled(t, "UpdateSubscriptionUserState", mock.Anything, s.ID, "10", "200")
+}
+
+func testResumeSubscriptionTimelineFailed(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	s := models.Subscription{
+		ID:     uuid.New(),
+		UserID: uid,
+		Title:  "test",
+		Paused: true,
+		UserList: []models.TwitterUserSearchResult{
+			models.TwitterUserSearchResult{TwitterID: "10", ScreenName: "foo"},
+			models.TwitterUserSearchResult{TwitterID: "20", ScreenName: "bar"},
+		},
+	}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+	datastoreMock.On("GetTwitterUser", mock.Anything, uid).Return(models.TwitterUser{UserID: uid, TwitterID: "1"}, nil)
+
+	clientMock.On("GetUserTimeline", mock.Anything, mock.MatchedBy(func(req *pb.UserTimelineRequest) bool {
+		return req.ScreenName == "foo"
+	})).Return(nil, status.Error(codes.NotFound, "twitter: 34 Sorry, that page does not exist"))
+	timeline := pb.UserTimelineResponse{Tweets: []*pb.Tweet{&pb.Tweet{IdStr: "300"}}}
+	clientMock.On("GetUserTimeline", mock.Anything, mock.MatchedBy(func(req *pb.UserTimelineRequest) bool {
+		return req.ScreenName == "bar"
+	})).Return(&timeline, nil)
+	datastoreMock.On("UpdateSubscriptionUserState", mock.Anything, s.ID, "20", "300").Return(nil)
+
+	resumed := s
+	resumed.Paused = false
+	datastoreMock.On("UpdateSubscription", mock.Anything, resumed).Return(resumed, nil)
+
+	body := []byte(`{"mode": "fresh"}`)
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/resume", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscriptionUserState", 1)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 1)
+}
+
+func testResumeSubscriptionCatchUp(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	s := models.Subscription{ID: uuid.New(), UserID: uid, Title: "test", Paused: true}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	resumed := s
+	resumed.Paused = false
+	datastoreMock.On("UpdateSubscription", mock.Anything, resumed).Return(resumed, nil)
+
+	body := []byte(`{"mode": "catch_up"}`)
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/resume", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	clientMock.AssertNumberOfCalls(t, "GetUserTimeline", 0)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscriptionUserState", 0)
+}
+
+func testSetVacationOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	start := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2020, 7, 14, 0, 0, 0, 0, time.UTC)
+	datastoreMock.On("UpdateUserVacation", mock.Anything, uid, &start, &end).Return(nil)
+	datastoreMock.On("GetUser", mock.Anything, uid).Return(models.User{ID: uid, Name: "test", VacationStart: &start, VacationEnd: &end}, nil)
+
+	body := []byte(`{"start": "2020-07-01", "end": "2020-07-14"}`)
+	w := performPutRequest(router, "/api/user/vacation", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res appUser
+	err := json.Unmarshal([]byte(w.Body.String()), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-07-01", res.VacationStart)
+	assert.Equal(t, "2020-07-14", res.VacationEnd)
+}
+
+func testSetVacationEndsBeforeStart(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	body := []byte(`{"start": "2020-07-14", "end": "2020-07-01"}`)
+	w := performPutRequest(router, "/api/user/vacation", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserVacation", 0)
+}
+
+func testRemoveVacation(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	var noDate *time.Time
+	datastoreMock.On("UpdateUserVacation", mock.Anything, uid, noDate, noDate).Return(nil)
+	datastoreMock.On("GetUser", mock.Anything, uid).Return(models.User{ID: uid, Name: "test"}, nil)
+
+	w := performDeleteRequest(router, "/api/user/vacation", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "vacation_start")
+}
+
+func TestPauseEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestPauseSubscriptionNotAuth":         testPauseSubscriptionNotAuth,
+		"TestPauseSubscriptionOk":              testPauseSubscriptionOk,
+		"TestResumeSubscriptionBadMode":        testResumeSubscriptionBadMode,
+		"TestResumeSubscriptionFresh":          testResumeSubscriptionFresh,
+		"TestResumeSubscriptionTimelineFailed": testResumeSubscriptionTimelineFailed,
+		"TestResumeSubscriptionCatchUp":        testResumeSubscriptionCatchUp,
+		"TestSetVacationOk":                    testSetVacationOk,
+		"TestSetVacationEndsBeforeStart":       testSetVacationEndsBeforeStart,
+		"TestRemoveVacation":                   testRemoveVacation,
+	}
+	runTests(tests, t)
+}
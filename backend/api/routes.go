@@ -7,6 +7,7 @@ import (
 	"github.com/dghubble/oauth1"
 	twitterOAuth1 "github.com/dghubble/oauth1/twitter"
 	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/mail"
 	"github.com/dmtr/mail_me_all/backend/middlewares"
 	"github.com/dmtr/mail_me_all/backend/models"
 	"github.com/gin-contrib/sessions"
@@ -27,7 +28,7 @@ func getSessionStore(authKey string, encryptKey string) cookie.Store {
 }
 
 // GetRouter - returns router
-func GetRouter(conf *config.Config, db *sqlx.DB, usecases models.UserUseCase) *gin.Engine {
+func GetRouter(conf *config.Config, db *sqlx.DB, usecases *models.UseCases) *gin.Engine {
 	router := gin.Default()
 	sessionStore := getSessionStore(conf.AuthKey, conf.EncryptKey)
 	router.Use(sessions.Sessions("session", sessionStore))
@@ -35,8 +36,16 @@ func GetRouter(conf *config.Config, db *sqlx.DB, usecases models.UserUseCase) *g
 	return router
 }
 
+// registerOutbox shows emails captured by the file sender to admins, the emails have sign-in links and
+// confirmation tokens of all users, so the outbox is available in debug mode only
+func registerOutbox(admin *gin.RouterGroup, conf *config.Config) {
+	if conf.Debug != 0 && conf.EmailSender == mail.FileSender {
+		admin.GET("/outbox/*path", gin.WrapH(mail.NewOutboxHandler(conf.OutboxPath)))
+	}
+}
+
 //RegisterRoutes setups routes
-func RegisterRoutes(router *gin.Engine, conf *config.Config, db *sqlx.DB, usecases models.UserUseCase, testing bool) {
+func RegisterRoutes(router *gin.Engine, conf *config.Config, db *sqlx.DB, usecases *models.UseCases, testing bool) {
 	router.GET("/healthcheck", func(c *gin.Context) { c.String(http.StatusOK, "Ok") })
 
 	oauth1Config := &oauth1.Config{
@@ -46,27 +55,143 @@ func RegisterRoutes(router *gin.Engine, conf *config.Config, db *sqlx.DB, usecas
 		Endpoint:       twitterOAuth1.AuthorizeEndpoint,
 	}
 
+	// one-click unsubscribe and webhooks are posted by other servers, so they aren't checked
+	csrf := middlewares.CSRFMiddleware(conf.Domain)
+
 	if testing { // unit tests
-		router.GET("/confirm/email", middlewares.TestTransactionlMiddleware(), confirmEmail(usecases))
-		api := router.Group("/api", middlewares.TestSessionMiddleware(testUserID))
+		router.GET("/confirm/email", showConfirmEmailPage(conf))
+		router.POST("/confirm/email", csrf, middlewares.TestTransactionlMiddleware(), confirmEmail(conf, usecases))
+		router.POST("/webhooks/mailgun", middlewares.TestTransactionlMiddleware(), processMailgunWebhook(conf, usecases))
+		router.GET("/unsubscribe", showUnsubscribePage(conf))
+		router.POST("/unsubscribe", middlewares.TestTransactionlMiddleware(), unsubscribe(conf, usecases))
+		router.POST("/signin/email/link", csrf, middlewares.TestTransactionlMiddleware(), requestMagicLink(usecases))
+		router.GET("/signin/email", showSignInPage(conf))
+		router.POST("/signin/email", csrf, middlewares.TestTransactionlMiddleware(), signInWithMagicLink(conf, usecases))
+		api := router.Group("/api", middlewares.TestSessionMiddleware(testUserID), csrf)
 		api.GET("/user", middlewares.TestTransactionlMiddleware(), getUser(usecases))
 		api.GET("/twitter-users", searchTwitterUsers(usecases))
+		api.GET("/twitter-lists", getTwitterLists(usecases))
+		api.GET("/twitter-lists/:id/members", getTwitterListMembers(usecases))
+		api.POST("/twitter-lists/:id/import", middlewares.TestTransactionlMiddleware(), importTwitterUsers(usecases, models.ImportSourceList))
+		api.GET("/twitter-friends", getTwitterFriends(usecases))
+		api.POST("/twitter-friends/import", middlewares.TestTransactionlMiddleware(), importTwitterUsers(usecases, models.ImportSourceFollowing))
+		api.GET("/twitter-accounts", middlewares.TestTransactionlMiddleware(), getTwitterAccounts(usecases))
+		api.GET("/twitter-accounts/:id/subscriptions", middlewares.TestTransactionlMiddleware(), getTwitterAccountSubscriptions(usecases))
+		api.DELETE("/twitter-accounts/:id", middlewares.TestTransactionlMiddleware(), unlinkTwitterAccount(usecases))
 		api.POST("/subscriptions", middlewares.TestTransactionlMiddleware(), addSubscription(usecases))
 		api.PUT("/subscriptions", middlewares.TestTransactionlMiddleware(), updateSubscription(usecases))
+		api.GET("/subscriptions-export", middlewares.TestTransactionlMiddleware(), exportSubscriptions(usecases))
+		api.POST("/subscriptions-import", middlewares.TestTransactionlMiddleware(), importSubscriptions(usecases))
 		api.DELETE("/subscriptions/:id", middlewares.TestTransactionlMiddleware(), deleteSubscription(usecases))
+		api.GET("/subscriptions/:id/issues", middlewares.TestTransactionlMiddleware(), getSubscriptionIssues(usecases))
+		api.GET("/subscriptions/:id/list-changes", middlewares.TestTransactionlMiddleware(), getSubscriptionListChanges(usecases))
+		api.POST("/subscriptions/:id/preview", middlewares.TestTransactionlMiddleware(), previewSubscription(usecases))
+		api.POST("/subscriptions/:id/send-now", sendSubscriptionNow(usecases))
+		api.POST("/subscriptions/:id/pause", middlewares.TestTransactionlMiddleware(), pauseSubscription(usecases))
+		api.POST("/subscriptions/:id/resume", middlewares.TestTransactionlMiddleware(), resumeSubscription(usecases))
 		api.DELETE("/user", middlewares.TestTransactionlMiddleware(), deleteAccount(usecases))
+		api.PUT("/user/vacation", middlewares.TestTransactionlMiddleware(), updateVacation(usecases, false))
+		api.DELETE("/user/vacation", middlewares.TestTransactionlMiddleware(), updateVacation(usecases, true))
+		api.POST("/user/export", middlewares.CookieSessionMiddleware(), exportUserData(usecases))
+		api.GET("/user/export", middlewares.CookieSessionMiddleware(), middlewares.TestTransactionlMiddleware(), getDataExports(usecases))
+		api.GET("/emails", middlewares.TestTransactionlMiddleware(), getEmails(usecases))
+		api.POST("/emails", middlewares.TestTransactionlMiddleware(), addEmail(usecases))
+		api.DELETE("/emails/:email", middlewares.TestTransactionlMiddleware(), deleteEmail(usecases))
+		api.POST("/emails/:email/resend", middlewares.TestTransactionlMiddleware(), resendConfirmationEmail(usecases))
+		api.POST("/emails/:email/reactivate", middlewares.TestTransactionlMiddleware(), reactivateEmail(usecases))
+		api.GET("/tokens", middlewares.CookieSessionMiddleware(), middlewares.TestTransactionlMiddleware(), getAPITokens(usecases))
+		api.POST("/tokens", middlewares.CookieSessionMiddleware(), middlewares.TestTransactionlMiddleware(), createAPIToken(usecases))
+		api.DELETE("/tokens/:id", middlewares.CookieSessionMiddleware(), middlewares.TestTransactionlMiddleware(), revokeAPIToken(usecases))
+		api.GET("/sessions", middlewares.CookieSessionMiddleware(), middlewares.TestTransactionlMiddleware(), getSessions(usecases))
+		api.DELETE("/sessions", middlewares.CookieSessionMiddleware(), middlewares.TestTransactionlMiddleware(), revokeSessions(usecases))
+		api.DELETE("/sessions/:id", middlewares.CookieSessionMiddleware(), middlewares.TestTransactionlMiddleware(), revokeSession(usecases))
+		api.GET("/issues/:id/epub", middlewares.TestTransactionlMiddleware(), getIssueEpub(usecases))
+		api.GET("/issues/:id", middlewares.TestTransactionlMiddleware(), getIssue(usecases))
+		api.POST("/issues/:id/share", middlewares.TestTransactionlMiddleware(), shareIssue(usecases))
+		api.DELETE("/issues/:id/share", middlewares.TestTransactionlMiddleware(), unshareIssue(usecases))
+		router.GET("/issues/:id", middlewares.TestSessionMiddleware(testUserID), middlewares.TestTransactionlMiddleware(), viewIssue(usecases))
+		router.GET("/shared/:token", middlewares.TestTransactionlMiddleware(), viewSharedIssue(usecases))
+		router.GET("/exports/:token", middlewares.TestTransactionlMiddleware(), downloadDataExport(usecases))
+		admin := router.Group("/admin", middlewares.TestSessionMiddleware(testUserID), csrf, middlewares.AdminMiddleware(usecases), middlewares.TestTransactionlMiddleware())
+		registerOutbox(admin, conf)
+		admin.GET("/users", adminSearchUsers(usecases))
+		admin.GET("/users/:id", adminGetUser(usecases))
+		admin.POST("/users/:id/disable", adminSetUserDisabled(usecases, true))
+		admin.POST("/users/:id/enable", adminSetUserDisabled(usecases, false))
+		admin.GET("/subscriptions/:id/issues", adminGetSubscriptionIssues(usecases))
+		admin.GET("/issues/failed", adminGetFailedIssues(usecases))
+		admin.POST("/issues/:id/resend", adminResendIssue(usecases))
+		admin.POST("/emails/reconfirm", adminForceReconfirmation(usecases))
+		admin.GET("/audit", adminGetAudit(usecases))
 	} else {
 		router.GET("/oauth/tw/signin", gin.WrapH(twitter.LoginHandler(oauth1Config, nil)))
 		router.GET("/oauth/tw/callback", middlewares.TransactionlMiddleware(db), processTwitterCallback(conf, oauth1Config, usecases))
-		router.GET("/confirm/email", middlewares.TransactionlMiddleware(db), confirmEmail(usecases))
+		router.GET("/oauth/tw/link", middlewares.SessionMiddleware(usecases), middlewares.CookieSessionMiddleware(), startTwitterLink(oauth1Config))
+		router.GET("/confirm/email", showConfirmEmailPage(conf))
+		router.POST("/confirm/email", csrf, middlewares.TransactionlMiddleware(db), confirmEmail(conf, usecases))
+		router.POST("/webhooks/mailgun", middlewares.TransactionlMiddleware(db), processMailgunWebhook(conf, usecases))
+		router.GET("/unsubscribe", showUnsubscribePage(conf))
+		router.POST("/unsubscribe", middlewares.TransactionlMiddleware(db), unsubscribe(conf, usecases))
+		router.POST("/signin/email/link", csrf, middlewares.TransactionlMiddleware(db), requestMagicLink(usecases))
+		router.GET("/signin/email", showSignInPage(conf))
+		router.POST("/signin/email", csrf, middlewares.TransactionlMiddleware(db), signInWithMagicLink(conf, usecases))
 
-		api := router.Group("/api", middlewares.SessionMiddleware())
+		api := router.Group("/api", middlewares.SessionMiddleware(usecases), csrf)
 		api.GET("/user", middlewares.TransactionlMiddleware(db), getUser(usecases))
 		api.GET("/twitter-users", searchTwitterUsers(usecases))
+		api.GET("/twitter-lists", getTwitterLists(usecases))
+		api.GET("/twitter-lists/:id/members", getTwitterListMembers(usecases))
+		api.POST("/twitter-lists/:id/import", middlewares.TransactionlMiddleware(db), importTwitterUsers(usecases, models.ImportSourceList))
+		api.GET("/twitter-friends", getTwitterFriends(usecases))
+		api.POST("/twitter-friends/import", middlewares.TransactionlMiddleware(db), importTwitterUsers(usecases, models.ImportSourceFollowing))
+		api.GET("/twitter-accounts", middlewares.TransactionlMiddleware(db), getTwitterAccounts(usecases))
+		api.GET("/twitter-accounts/:id/subscriptions", middlewares.TransactionlMiddleware(db), getTwitterAccountSubscriptions(usecases))
+		api.DELETE("/twitter-accounts/:id", middlewares.TransactionlMiddleware(db), unlinkTwitterAccount(usecases))
 		api.POST("/subscriptions", addSubscription(usecases))
 		api.GET("/subscriptions", middlewares.TransactionlMiddleware(db), getSubscriptions(usecases))
 		api.PUT("/subscriptions", updateSubscription(usecases))
+		api.GET("/subscriptions-export", middlewares.TransactionlMiddleware(db), exportSubscriptions(usecases))
+		api.POST("/subscriptions-import", middlewares.TransactionlMiddleware(db), importSubscriptions(usecases))
 		api.DELETE("/subscriptions/:id", middlewares.TransactionlMiddleware(db), deleteSubscription(usecases))
+		api.GET("/subscriptions/:id/issues", middlewares.TransactionlMiddleware(db), getSubscriptionIssues(usecases))
+		api.GET("/subscriptions/:id/list-changes", middlewares.TransactionlMiddleware(db), getSubscriptionListChanges(usecases))
+		api.POST("/subscriptions/:id/preview", middlewares.TransactionlMiddleware(db), previewSubscription(usecases))
+		api.POST("/subscriptions/:id/send-now", sendSubscriptionNow(usecases))
+		api.POST("/subscriptions/:id/pause", middlewares.TransactionlMiddleware(db), pauseSubscription(usecases))
+		api.POST("/subscriptions/:id/resume", middlewares.TransactionlMiddleware(db), resumeSubscription(usecases))
 		api.DELETE("/user", middlewares.TransactionlMiddleware(db), deleteAccount(usecases))
+		api.PUT("/user/vacation", middlewares.TransactionlMiddleware(db), updateVacation(usecases, false))
+		api.DELETE("/user/vacation", middlewares.TransactionlMiddleware(db), updateVacation(usecases, true))
+		api.POST("/user/export", middlewares.CookieSessionMiddleware(), exportUserData(usecases))
+		api.GET("/user/export", middlewares.CookieSessionMiddleware(), middlewares.TransactionlMiddleware(db), getDataExports(usecases))
+		api.GET("/emails", middlewares.TransactionlMiddleware(db), getEmails(usecases))
+		api.POST("/emails", middlewares.TransactionlMiddleware(db), addEmail(usecases))
+		api.DELETE("/emails/:email", middlewares.TransactionlMiddleware(db), deleteEmail(usecases))
+		api.POST("/emails/:email/resend", middlewares.TransactionlMiddleware(db), resendConfirmationEmail(usecases))
+		api.POST("/emails/:email/reactivate", middlewares.TransactionlMiddleware(db), reactivateEmail(usecases))
+		api.GET("/tokens", middlewares.CookieSessionMiddleware(), middlewares.TransactionlMiddleware(db), getAPITokens(usecases))
+		api.POST("/tokens", middlewares.CookieSessionMiddleware(), middlewares.TransactionlMiddleware(db), createAPIToken(usecases))
+		api.DELETE("/tokens/:id", middlewares.CookieSessionMiddleware(), middlewares.TransactionlMiddleware(db), revokeAPIToken(usecases))
+		api.GET("/sessions", middlewares.CookieSessionMiddleware(), middlewares.TransactionlMiddleware(db), getSessions(usecases))
+		api.DELETE("/sessions", middlewares.CookieSessionMiddleware(), middlewares.TransactionlMiddleware(db), revokeSessions(usecases))
+		api.DELETE("/sessions/:id", middlewares.CookieSessionMiddleware(), middlewares.TransactionlMiddleware(db), revokeSession(usecases))
+		api.GET("/issues/:id/epub", middlewares.TransactionlMiddleware(db), getIssueEpub(usecases))
+		api.GET("/issues/:id", middlewares.TransactionlMiddleware(db), getIssue(usecases))
+		api.POST("/issues/:id/share", middlewares.TransactionlMiddleware(db), shareIssue(usecases))
+		api.DELETE("/issues/:id/share", middlewares.TransactionlMiddleware(db), unshareIssue(usecases))
+		router.GET("/issues/:id", middlewares.SessionMiddleware(usecases), middlewares.TransactionlMiddleware(db), viewIssue(usecases))
+		router.GET("/shared/:token", middlewares.TransactionlMiddleware(db), viewSharedIssue(usecases))
+		router.GET("/exports/:token", middlewares.TransactionlMiddleware(db), downloadDataExport(usecases))
+		admin := router.Group("/admin", middlewares.SessionMiddleware(usecases), middlewares.CookieSessionMiddleware(), csrf, middlewares.AdminMiddleware(usecases), middlewares.TransactionlMiddleware(db))
+		registerOutbox(admin, conf)
+		admin.GET("/users", adminSearchUsers(usecases))
+		admin.GET("/users/:id", adminGetUser(usecases))
+		admin.POST("/users/:id/disable", adminSetUserDisabled(usecases, true))
+		admin.POST("/users/:id/enable", adminSetUserDisabled(usecases, false))
+		admin.GET("/subscriptions/:id/issues", adminGetSubscriptionIssues(usecases))
+		admin.GET("/issues/failed", adminGetFailedIssues(usecases))
+		admin.POST("/issues/:id/resend", adminResendIssue(usecases))
+		admin.POST("/emails/reconfirm", adminForceReconfirmation(usecases))
+		admin.GET("/audit", adminGetAudit(usecases))
 	}
 }
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const sendProgressEvent = "progress"
+
+// sendSubscriptionNow sends the next issue right away and streams progress as Server-Sent Events
+func sendSubscriptionNow(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		subscriptionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		progress, err := usecases.SendSubscriptionNow(userID, subscriptionID)
+		if err != nil {
+			log.Errorf("Can not send subscription %s, got error %s", subscriptionID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.Header("Cache-Control", "no-cache")
+		// nginx must not buffer the stream
+		c.Header("X-Accel-Buffering", "no")
+		for p := range progress {
+			c.SSEvent(sendProgressEvent, p)
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockOwnSubscription(datastoreMock *mocks.UserDatastore) models.Subscription {
+	uid, _ := uuid.Parse(testUserID)
+	s := models.Subscription{ID: uuid.New(), UserID: uid, Title: "test", Email: "test@example.com"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+	return s
+}
+
+func testSendNowNotAuth(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := models.Subscription{ID: uuid.New(), UserID: uuid.New(), Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/send-now", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscriptionState", 0)
+}
+
+func testSendNowInProgress(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := mockOwnSubscription(datastoreMock)
+	datastoreMock.On("HoldLock", mock.Anything, mock.Anything).Return(nil, false, nil)
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/send-now", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "HoldLock", 1)
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscriptionState", 0)
+}
+
+func testSendNowUserInProgress(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := mockOwnSubscription(datastoreMock)
+	unlocked := 0
+	datastoreMock.On("HoldLock", mock.Anything, mock.Anything).Return(func() { unlocked++ }, true, nil).Once()
+	datastoreMock.On("HoldLock", mock.Anything, mock.Anything).Return(nil, false, nil).Once()
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/send-now", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 1, unlocked)
+
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscriptionState", 0)
+}
+
+func testSendNowPaused(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	s := models.Subscription{ID: uuid.New(), UserID: uid, Title: "test", Email: "test@example.com", Paused: true}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/send-now", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "HoldLock", 0)
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscriptionState", 0)
+}
+
+func testSendNowRateLimited(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := mockOwnSubscription(datastoreMock)
+	unlocked := 0
+	datastoreMock.On("HoldLock", mock.Anything, mock.Anything).Return(func() { unlocked++ }, true, nil)
+	datastoreMock.On("CountOnDemandSubscriptionStates", mock.Anything, s.UserID, mock.Anything).Return(uint(3), nil)
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/send-now", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	var res errorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, errors.RateLimited, res.Code)
+	assert.Equal(t, "rate_limited", res.Error)
+	assert.Greater(t, res.RetryAfter, 0)
+	assert.Equal(t, 2, unlocked)
+
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscriptionState", 0)
+}
+
+func testSendNowOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	s := mockOwnSubscription(datastoreMock)
+	unlocked := 0
+	datastoreMock.On("HoldLock", mock.Anything, mock.Anything).Return(func() { unlocked++ }, true, nil)
+	datastoreMock.On("CountOnDemandSubscriptionStates", mock.Anything, s.UserID, mock.Anything).Return(uint(0), nil)
+	email := models.UserEmail{UserID: s.UserID, Email: s.Email, Status: models.EmailStatusConfirmed}
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{UserID: s.UserID, Email: s.Email}).Return(email, nil)
+	datastoreMock.On("GetTwitterUser", mock.Anything, s.UserID).Return(models.TwitterUser{UserID: s.UserID}, nil)
+
+	state := models.SubscriptionState{ID: 5, SubscriptionID: s.ID, Status: models.Preparing, OnDemand: true}
+	datastoreMock.On("InsertSubscriptionState", mock.Anything, models.SubscriptionState{SubscriptionID: s.ID, Status: models.Preparing, OnDemand: true}).Return(state, nil)
+	datastoreMock.On("GetSubscriptionUserTweets", mock.Anything, s.ID).Return(models.SubscriptionUserTweets{SubscriptionID: s.ID}, nil)
+	datastoreMock.On("UpdateSubscriptionState", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, st models.SubscriptionState) models.SubscriptionState { return st }, nil)
+	datastoreMock.On("GetSubscriptionTweets", mock.Anything, state.ID).Return([]models.Tweet{}, nil)
+	datastoreMock.On("UpdateIssueUserStateTweets", mock.Anything, state.ID).Return(nil)
+
+	w := performPostRequest(router, "/api/subscriptions/"+s.ID.String()+"/send-now", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "event:progress")
+	assert.Contains(t, body, `"stage":"fetching"`)
+	assert.Contains(t, body, `"stage":"stored"`)
+	assert.True(t, strings.Index(body, `"stage":"stored"`) < strings.Index(body, `"stage":"sent"`))
+	assert.Contains(t, body, `"issue_id":5`)
+	assert.Equal(t, 2, unlocked)
+
+	datastoreMock.AssertCalled(t, "UpdateIssueUserStateTweets", mock.Anything, state.ID)
+	datastoreMock.AssertNotCalled(t, "HoldLock", mock.Anything, uint(2))
+	datastoreMock.AssertNotCalled(t, "HoldLock", mock.Anything, uint(3))
+	datastoreMock.AssertNotCalled(t, "UpdateSubscriptionUserStateTweets", mock.Anything)
+}
+
+func TestSendNowEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestSendNowNotAuth":        testSendNowNotAuth,
+		"TestSendNowInProgress":     testSendNowInProgress,
+		"TestSendNowUserInProgress": testSendNowUserInProgress,
+		"TestSendNowRateLimited":    testSendNowRateLimited,
+		"TestSendNowPaused":         testSendNowPaused,
+		"TestSendNowOk":             testSendNowOk,
+	}
+	runTests(tests, t)
+}
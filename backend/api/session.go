@@ -0,0 +1,144 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/middlewares"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type userSession struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func adaptUserSession(s models.UserSession, currentID uint) userSession {
+	return userSession{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		Current:    s.ID == currentID,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+}
+
+// getSessionID returns id of the session authenticating the request
+func getSessionID(c *gin.Context) uint {
+	id, _ := c.Get(middlewares.SessionIDKey)
+	sessionID, _ := id.(uint)
+	return sessionID
+}
+
+func clearSessionCookie(c *gin.Context) {
+	s := sessions.Default(c)
+	s.Clear()
+	if err := s.Save(); err != nil {
+		log.Errorf("Can not save session %s", err)
+	}
+}
+
+func getSessions(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		userSessions, err := usecases.GetSessions(ctx, userID)
+		if err != nil {
+			log.Errorf("Can not get sessions of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]userSession, 0, len(userSessions))
+		for _, s := range userSessions {
+			res = append(res, adaptUserSession(s, getSessionID(c)))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"sessions": res})
+	}
+}
+
+// revokeSession signs out the session, the cookie is cleared if it's the current one
+func revokeSession(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		err = usecases.RevokeSession(ctx, userID, uint(sessionID))
+		if err != nil {
+			log.Errorf("Can not revoke session %d, got error %s", sessionID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		if uint(sessionID) == getSessionID(c) {
+			clearSessionCookie(c)
+		}
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+// revokeSessions logs out everywhere including the current session
+func revokeSessions(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		n, err := usecases.RevokeSessions(ctx, userID)
+		if err != nil {
+			log.Errorf("Can not revoke sessions of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		clearSessionCookie(c)
+		c.JSON(http.StatusOK, gin.H{"revoked": n})
+	}
+}
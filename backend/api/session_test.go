@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testGetSessions(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	sessions := []models.UserSession{{ID: 1, UserID: uid, TokenHash: "secret", UserAgent: "Mozilla/5.0", IP: "127.0.0.1", ExpiresAt: time.Now().Add(time.Hour)}}
+	datastoreMock.On("GetUserSessions", mock.Anything, uid).Return(sessions, nil)
+
+	w := performGetRequest(router, "/api/sessions", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_agent":"Mozilla/5.0"`)
+	assert.NotContains(t, w.Body.String(), "secret")
+}
+
+func testRevokeSessionNotFound(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("RevokeUserSession", mock.Anything, uid, uint(7)).Return(false, nil)
+
+	w := performDeleteRequest(router, "/api/sessions/7", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func testRevokeSessions(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("RevokeUserSessions", mock.Anything, uid).Return(uint(3), nil)
+
+	w := performDeleteRequest(router, "/api/sessions", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"revoked":3`)
+	datastoreMock.AssertNumberOfCalls(t, "RevokeUserSessions", 1)
+}
+
+func TestSessionsEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestGetSessions":           testGetSessions,
+		"TestRevokeSessionNotFound": testRevokeSessionNotFound,
+		"TestRevokeSessions":        testRevokeSessions,
+	}
+	runTests(tests, t)
+}
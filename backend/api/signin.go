@@ -0,0 +1,114 @@
+package api
+
+import (
+	"html/template"
+	"net/http"
+	"path/filepath"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type magicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type signInPage struct {
+	Error string
+}
+
+func renderSignInPage(c *gin.Context, conf *config.Config, status int, page signInPage) {
+	tmpl, err := template.ParseFiles(filepath.Join(conf.TemplatePath, "signin_email.html"))
+	if err != nil {
+		log.Errorf("Can not parse template, got error %s", err)
+		c.String(http.StatusInternalServerError, "Server error")
+		return
+	}
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err = tmpl.Execute(c.Writer, page)
+	if err != nil {
+		log.Errorf("Can not execute template, got error %s", err)
+	}
+}
+
+// requestMagicLink sends a sign-in link to the confirmed email, the response is the same for unknown emails
+func requestMagicLink(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req magicLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondWithError(c, errors.NewValidation(err.Error(), map[string]string{"email": "must be email"}))
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		err = usecases.SendMagicLink(ctx, req.Email)
+		if err != nil {
+			log.Errorf("Can not send sign-in link, got error %s", err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "If the email is confirmed, the sign-in link is sent to it"})
+	}
+}
+
+// showSignInPage asks for confirmation, GET requests must not use the link
+// because links in emails are opened by scanners
+func showSignInPage(conf *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("token") == "" {
+			c.String(http.StatusBadRequest, "Bad request")
+			return
+		}
+
+		renderSignInPage(c, conf, http.StatusOK, signInPage{})
+	}
+}
+
+// signInWithMagicLink starts the same session as Sign in with Twitter
+func signInWithMagicLink(conf *config.Config, usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.String(http.StatusBadRequest, "Bad request")
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Server error")
+			return
+		}
+
+		user, err := usecases.SignInWithMagicLink(ctx, token)
+		if err != nil {
+			log.Errorf("Can not sign in with link, got error %s", err)
+			if errors.GetErrorCode(err) == errors.AuthRequired {
+				renderSignInPage(c, conf, http.StatusUnauthorized, signInPage{Error: "The link is invalid, used or expired."})
+			} else {
+				renderSignInPage(c, conf, http.StatusInternalServerError, signInPage{Error: "Something went wrong, please try again later."})
+			}
+			return
+		}
+
+		if err := setSessionCookie(ctx, c, conf, usecases, user.ID); err != nil {
+			if errors.GetErrorCode(err) == errors.Forbidden {
+				renderSignInPage(c, conf, http.StatusForbidden, signInPage{Error: "The account is disabled."})
+			} else {
+				renderSignInPage(c, conf, http.StatusInternalServerError, signInPage{Error: "Something went wrong, please try again later."})
+			}
+			return
+		}
+		c.Redirect(http.StatusFound, "/")
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/dmtr/mail_me_all/backend/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getTestMagicLinkToken() string {
+	claims := usecases.MagicLinkClaims{
+		Nonce: "nonce",
+		StandardClaims: jwt.StandardClaims{
+			Subject:   "magic_link",
+			ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		},
+	}
+	conf := config.GetConfig()
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(conf.EncryptKey))
+	return token
+}
+
+func testRequestMagicLinkUnknownEmail(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	datastoreMock.On("CountMagicLinkRequests", mock.Anything, "unknown@example.com", mock.Anything).Return(uint(0), nil)
+	datastoreMock.On("InsertMagicLinkRequest", mock.Anything, "unknown@example.com", mock.Anything).Return(nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(models.UserEmail{}, &db.DbError{Err: sql.ErrNoRows})
+
+	w := performPostRequest(router, "/signin/email/link", bytes.NewBufferString(`{"email": "unknown@example.com"}`))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "InsertMagicLinkRequest", 1)
+	datastoreMock.AssertNumberOfCalls(t, "InsertMagicLink", 0)
+}
+
+func testRequestMagicLinkInvalidEmail(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performPostRequest(router, "/signin/email/link", bytes.NewBufferString(`{"email": "not email"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "GetUserEmail", 0)
+}
+
+func testShowSignInPage(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performGetRequest(router, "/signin/email?token="+getTestMagicLinkToken(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<form method="post">`)
+	datastoreMock.AssertNumberOfCalls(t, "UseMagicLink", 0)
+}
+
+func testSignInWithMagicLink(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	link := models.MagicLink{ID: 1, UserID: uuid.New(), Email: "test@example.com"}
+	datastoreMock.On("UseMagicLink", mock.Anything, mock.Anything).Return(link, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: link.Email}).Return(models.UserEmail{UserID: link.UserID, Email: link.Email, Status: models.EmailStatusConfirmed}, nil)
+	datastoreMock.On("GetUser", mock.Anything, link.UserID).Return(models.User{ID: link.UserID}, nil)
+	datastoreMock.On("InsertUserSession", mock.Anything, mock.MatchedBy(func(s models.UserSession) bool {
+		return s.UserID == link.UserID && len(s.TokenHash) == 64
+	})).Return(models.UserSession{ID: 1, UserID: link.UserID}, nil)
+
+	w := performRequest(router, "POST", "/signin/email?token="+getTestMagicLinkToken(), nil, false, nil)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/", w.Header().Get("Location"))
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "session=")
+	datastoreMock.AssertNumberOfCalls(t, "InsertUserSession", 1)
+}
+
+func testSignInWithUsedMagicLink(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	datastoreMock.On("UseMagicLink", mock.Anything, mock.Anything).Return(models.MagicLink{}, &db.DbError{Err: sql.ErrNoRows})
+
+	w := performRequest(router, "POST", "/signin/email?token="+getTestMagicLinkToken(), nil, false, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+}
+
+func TestSignInEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestRequestMagicLinkUnknownEmail": testRequestMagicLinkUnknownEmail,
+		"TestRequestMagicLinkInvalidEmail": testRequestMagicLinkInvalidEmail,
+		"TestShowSignInPage":               testShowSignInPage,
+		"TestSignInWithMagicLink":          testSignInWithMagicLink,
+		"TestSignInWithUsedMagicLink":      testSignInWithUsedMagicLink,
+	}
+	runTests(tests, t)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/dmtr/mail_me_all/backend/config"
 	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
 	"github.com/dmtr/mail_me_all/backend/usecases"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
@@ -16,10 +17,16 @@ import (
 
 const testUserID string = "15b24dd0-1f38-4e0a-8d6f-8df509051279"
 
+const testWebhookKey string = "webhook-key"
+
+const testDomain string = "localhost"
+
 type testFunc func(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient)
 
 func performRequest(r http.Handler, method, path string, body io.Reader, json bool, cookie *http.Cookie) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest(method, path, body)
+	// requests come from the app pages as browsers send them
+	req.Header.Set("Origin", "https://"+testDomain)
 	if body != nil && json {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -67,19 +74,25 @@ func runTests(tests map[string]testFunc, t *testing.T) {
 
 	conf := config.GetConfig()
 	conf.Testing = true
+	conf.TemplatePath = "../templates"
+	conf.MgWebhookKey = testWebhookKey
+	conf.Domain = testDomain
 
 	datastoreMock := new(mocks.UserDatastore)
 	clientMock := new(mocks.TwProxyServiceClient)
 	userUseCase := usecases.NewUserUseCase(datastoreMock, clientMock, &conf)
-	router := GetRouter(&conf, nil, userUseCase)
+	systemUseCase := usecases.NewSystemUseCase(datastoreMock, clientMock, &conf, new(mocks.EmailSender))
+	router := GetRouter(&conf, nil, models.NewUseCases(userUseCase, systemUseCase))
 
 	for name, fn := range tests {
 		f := func(t *testing.T) {
 			datastoreMock := new(mocks.UserDatastore)
 			userUseCase.UserDatastore = datastoreMock
+			systemUseCase.UserDatastore = datastoreMock
 
 			clientMock = new(mocks.TwProxyServiceClient)
 			userUseCase.RpcClient = clientMock
+			systemUseCase.RpcClient = clientMock
 
 			fn(t, router, datastoreMock, clientMock)
 		}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type apiToken struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name" binding:"required"`
+	Scope      string     `json:"scope" binding:"required"`
+	Token      string     `json:"token,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func adaptAPIToken(t models.APIToken) apiToken {
+	return apiToken{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scope:      strings.ToLower(t.Scope),
+		CreatedAt:  t.CreatedAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+}
+
+func getAPITokens(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		tokens, err := usecases.GetAPITokens(ctx, userID)
+		if err != nil {
+			log.Errorf("Can not get tokens of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]apiToken, 0, len(tokens))
+		for _, t := range tokens {
+			res = append(res, adaptAPIToken(t))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"tokens": res})
+	}
+}
+
+func createAPIToken(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		var t apiToken
+		if err := c.ShouldBindJSON(&t); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		token, plain, err := usecases.CreateAPIToken(ctx, userID, t.Name, t.Scope)
+		if err != nil {
+			log.Errorf("Can not create token for user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := adaptAPIToken(token)
+		res.Token = plain
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+func revokeAPIToken(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		err = usecases.RevokeAPIToken(ctx, userID, uint(tokenID))
+		if err != nil {
+			log.Errorf("Can not revoke token %d, got error %s", tokenID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testCreateAPITokenOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("InsertAPIToken", mock.Anything, mock.MatchedBy(func(token models.APIToken) bool {
+		return token.UserID == uid && token.Name == "script" && token.Scope == models.APITokenScopeWrite && len(token.TokenHash) == 64
+	})).Return(models.APIToken{ID: 1, UserID: uid, Name: "script", Scope: models.APITokenScopeWrite}, nil)
+
+	body := []byte(`{"name": "script", "scope": "write"}`)
+	w := performPostRequest(router, "/api/tokens", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res apiToken
+	err := json.Unmarshal([]byte(w.Body.String()), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), res.ID)
+	assert.Equal(t, "write", res.Scope)
+	assert.True(t, strings.HasPrefix(res.Token, "mma_"))
+}
+
+func testCreateAPITokenBadScope(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	body := []byte(`{"name": "script", "scope": "admin"}`)
+	w := performPostRequest(router, "/api/tokens", bytes.NewBuffer(body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "InsertAPIToken", 0)
+}
+
+func testGetAPITokens(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	tokens := []models.APIToken{models.APIToken{ID: 1, UserID: uid, Name: "script", TokenHash: "secret", Scope: models.APITokenScopeRead}}
+	datastoreMock.On("GetAPITokens", mock.Anything, uid).Return(tokens, nil)
+
+	w := performGetRequest(router, "/api/tokens", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"scope":"read"`)
+	assert.NotContains(t, w.Body.String(), "secret")
+}
+
+func testRevokeAPITokenNotFound(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("RevokeAPIToken", mock.Anything, uid, uint(7)).Return(false, nil)
+
+	w := performDeleteRequest(router, "/api/tokens/7", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPITokenEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestCreateAPITokenOk":       testCreateAPITokenOk,
+		"TestCreateAPITokenBadScope": testCreateAPITokenBadScope,
+		"TestGetAPITokens":           testGetAPITokens,
+		"TestRevokeAPITokenNotFound": testRevokeAPITokenNotFound,
+	}
+	runTests(tests, t)
+}
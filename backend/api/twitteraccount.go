@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/dghubble/gologin/v2/twitter"
+	"github.com/dghubble/oauth1"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/middlewares"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// linkTwitterKey - session key set while the signed in user links another twitter account,
+// Sign in with Twitter and linking share the callback
+const linkTwitterKey = "link_twitter"
+
+type twitterAccount struct {
+	ID            string `json:"id"`
+	ScreenName    string `json:"screen_name"`
+	ProfileIMGURL string `json:"profile_image_url"`
+	Primary       bool   `json:"primary"`
+}
+
+// unlinkConflict - error envelope listing subscriptions which use the twitter account
+type unlinkConflict struct {
+	errorResponse
+	Subscriptions []subscription `json:"subscriptions"`
+}
+
+func adaptTwitterAccount(u models.TwitterUser) twitterAccount {
+	return twitterAccount{
+		ID:            u.TwitterID,
+		ScreenName:    u.ScreenName,
+		ProfileIMGURL: u.ProfileIMGURL,
+		Primary:       u.Primary,
+	}
+}
+
+func adaptSubscriptions(subscriptions []models.Subscription) []subscription {
+	res := make([]subscription, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		res = append(res, adaptSubscription(s))
+	}
+	return res
+}
+
+// startTwitterLink marks the session, so the callback links the account to the signed in user
+func startTwitterLink(oauth1Config *oauth1.Config) gin.HandlerFunc {
+	login := twitter.LoginHandler(oauth1Config, nil)
+	return func(c *gin.Context) {
+		s := sessions.Default(c)
+		s.Set(linkTwitterKey, true)
+		if err := s.Save(); err != nil {
+			log.Errorf("Can not save session %s", err)
+			c.String(http.StatusInternalServerError, "Server Error")
+			return
+		}
+		login.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// getLinkingUserID returns the signed in user linking a twitter account and resets the session mark
+func getLinkingUserID(ctx context.Context, c *gin.Context, usecases models.UserUseCase) (uuid.UUID, bool) {
+	s := sessions.Default(c)
+	link, _ := s.Get(linkTwitterKey).(bool)
+	if !link {
+		return uuid.Nil, false
+	}
+
+	s.Delete(linkTwitterKey)
+	if err := s.Save(); err != nil {
+		log.Errorf("Can not save session %s", err)
+	}
+
+	session, err := usecases.AuthenticateSession(ctx, middlewares.GetSessionToken(c))
+	if err != nil {
+		log.Warningf("Linking twitter account without signed in user, got error %s", err)
+		return uuid.Nil, false
+	}
+	return session.UserID, true
+}
+
+func getTwitterAccounts(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		accounts, err := usecases.GetTwitterAccounts(ctx, userID)
+		if err != nil {
+			log.Errorf("Can not get twitter accounts of user %s, got error %s", userID, err)
+			respondWithError(c, err)
+			return
+		}
+
+		res := make([]twitterAccount, 0, len(accounts))
+		for _, a := range accounts {
+			res = append(res, adaptTwitterAccount(a))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"accounts": res})
+	}
+}
+
+func getTwitterAccountSubscriptions(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		subscriptions, err := usecases.GetTwitterAccountSubscriptions(ctx, userID, c.Param("id"))
+		if err != nil {
+			log.Errorf("Can not get subscriptions of twitter account %s, got error %s", c.Param("id"), err)
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"subscriptions": adaptSubscriptions(subscriptions)})
+	}
+}
+
+// unlinkTwitterAccount switches subscriptions to the primary account if force is set,
+// otherwise conflict lists the subscriptions using the account
+func unlinkTwitterAccount(usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(getUserID(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		force, _ := strconv.ParseBool(c.Query("force"))
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		subscriptions, err := usecases.UnlinkTwitterAccount(ctx, userID, c.Param("id"), force)
+		if err != nil {
+			log.Errorf("Can not unlink twitter account %s, got error %s", c.Param("id"), err)
+			if errors.GetErrorCode(err) == errors.Conflict {
+				c.JSON(http.StatusConflict, unlinkConflict{
+					errorResponse: errorResponse{Code: errors.Conflict, Error: errors.Conflict.String(), Message: err.Error()},
+					Subscriptions: adaptSubscriptions(subscriptions),
+				})
+				return
+			}
+			respondWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"subscriptions": adaptSubscriptions(subscriptions)})
+	}
+}
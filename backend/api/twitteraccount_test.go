@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testGetTwitterAccounts(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	accounts := []models.TwitterUser{
+		{UserID: uid, TwitterID: "111", ScreenName: "first", AccessToken: "secret", Primary: true},
+		{UserID: uid, TwitterID: "222", ScreenName: "second", AccessToken: "secret"},
+	}
+	datastoreMock.On("GetTwitterUsers", mock.Anything, uid).Return(accounts, nil)
+
+	w := performGetRequest(router, "/api/twitter-accounts", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	var res struct {
+		Accounts []twitterAccount `json:"accounts"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, []twitterAccount{{ID: "111", ScreenName: "first", Primary: true}, {ID: "222", ScreenName: "second"}}, res.Accounts)
+}
+
+func testUnlinkTwitterAccountInUse(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "222").Return(models.TwitterUser{UserID: uid, TwitterID: "222"}, nil)
+	subscriptions := []models.Subscription{{ID: uuid.New(), UserID: uid, Title: "protected", TwitterAccountID: "222"}}
+	datastoreMock.On("GetSubscriptions", mock.Anything, uid).Return(subscriptions, nil)
+
+	w := performDeleteRequest(router, "/api/twitter-accounts/222", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var res unlinkConflict
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Len(t, res.Subscriptions, 1)
+	assert.Equal(t, "222", res.Subscriptions[0].TwitterAccountID)
+	datastoreMock.AssertNumberOfCalls(t, "UnlinkTwitterUser", 0)
+}
+
+func testUnlinkTwitterAccountForce(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "222").Return(models.TwitterUser{UserID: uid, TwitterID: "222"}, nil)
+	subscriptions := []models.Subscription{{ID: uuid.New(), UserID: uid, Title: "protected", TwitterAccountID: "222"}}
+	datastoreMock.On("GetSubscriptions", mock.Anything, uid).Return(subscriptions, nil)
+	datastoreMock.On("UnlinkTwitterUser", mock.Anything, uid, "222").Return(true, nil)
+
+	w := performDeleteRequest(router, "/api/twitter-accounts/222?force=true", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "UnlinkTwitterUser", 1)
+}
+
+func testUnlinkTwitterAccountOfAnotherUser(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "333").Return(models.TwitterUser{UserID: uuid.New(), TwitterID: "333"}, nil)
+
+	w := performDeleteRequest(router, "/api/twitter-accounts/333?force=true", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "UnlinkTwitterUser", 0)
+}
+
+func TestTwitterAccountsEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestGetTwitterAccounts":                testGetTwitterAccounts,
+		"TestUnlinkTwitterAccountInUse":         testUnlinkTwitterAccountInUse,
+		"TestUnlinkTwitterAccountForce":         testUnlinkTwitterAccountForce,
+		"TestUnlinkTwitterAccountOfAnotherUser": testUnlinkTwitterAccountOfAnotherUser,
+	}
+	runTests(tests, t)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"html/template"
+	"net/http"
+	"path/filepath"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// unsubscribeActionRemove - form value to remove the subscription, any other value pauses it
+const unsubscribeActionRemove = "remove"
+
+type unsubscribePage struct {
+	Title   string
+	Paused  bool
+	Removed bool
+	Error   string
+}
+
+func renderUnsubscribePage(c *gin.Context, conf *config.Config, status int, page unsubscribePage) {
+	tmpl, err := template.ParseFiles(filepath.Join(conf.TemplatePath, "unsubscribe.html"))
+	if err != nil {
+		log.Errorf("Can not parse template, got error %s", err)
+		c.String(http.StatusInternalServerError, "Server error")
+		return
+	}
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err = tmpl.Execute(c.Writer, page)
+	if err != nil {
+		log.Errorf("Can not execute template, got error %s", err)
+	}
+}
+
+// showUnsubscribePage asks for confirmation, GET requests must not change anything
+// because links in emails are opened by scanners
+func showUnsubscribePage(conf *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("token") == "" {
+			c.String(http.StatusBadRequest, "Bad request")
+			return
+		}
+
+		renderUnsubscribePage(c, conf, http.StatusOK, unsubscribePage{})
+	}
+}
+
+// unsubscribe pauses or removes subscription, one-click requests (RFC 8058) pause subscription
+func unsubscribe(conf *config.Config, usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.String(http.StatusBadRequest, "Bad request")
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Server error")
+			return
+		}
+
+		remove := c.PostForm("action") == unsubscribeActionRemove
+		s, err := usecases.Unsubscribe(ctx, token, remove)
+		if err != nil {
+			log.Errorf("Can not unsubscribe, got error %s", err)
+			switch errors.GetErrorCode(err) {
+			case errors.BadRequest:
+				renderUnsubscribePage(c, conf, http.StatusBadRequest, unsubscribePage{Error: "The link is invalid."})
+			case errors.NotFound:
+				renderUnsubscribePage(c, conf, http.StatusNotFound, unsubscribePage{Error: "The subscription was already removed."})
+			default:
+				renderUnsubscribePage(c, conf, http.StatusInternalServerError, unsubscribePage{Error: "Something went wrong, please try again later."})
+			}
+			return
+		}
+
+		renderUnsubscribePage(c, conf, http.StatusOK, unsubscribePage{Title: s.Title, Paused: !remove, Removed: remove})
+	}
+}
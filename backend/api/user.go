@@ -3,7 +3,9 @@ package api
 import (
 	"context"
 	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 
 	oauth1Login "github.com/dghubble/gologin/v2/oauth1"
@@ -11,8 +13,8 @@ import (
 	"github.com/dghubble/oauth1"
 	"github.com/dmtr/mail_me_all/backend/config"
 	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/middlewares"
 	"github.com/dmtr/mail_me_all/backend/models"
-	useCases "github.com/dmtr/mail_me_all/backend/usecases"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -22,9 +24,12 @@ import (
 )
 
 type appUser struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	SignedIn bool   `json:"signedIn"`
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	SignedIn      bool   `json:"signedIn"`
+	VacationStart string `json:"vacation_start,omitempty"`
+	VacationEnd   string `json:"vacation_end,omitempty"`
+	IsAdmin       bool   `json:"is_admin"`
 }
 
 type twitterUser struct {
@@ -35,21 +40,32 @@ type twitterUser struct {
 }
 
 type subscription struct {
-	ID            string        `json:"id"`
-	Title         string        `json:"title" binding:"required"`
-	Email         string        `json:"email" binding:"required"`
-	Day           string        `json:"day" binding:"required"`
-	IgnoreRT      bool          `json:"ignore_rt"`
-	IgnoreReplies bool          `json:"ignore_replies"`
-	UserList      []twitterUser `json:"userList" binding:"required"`
+	ID               string        `json:"id"`
+	Title            string        `json:"title" binding:"required"`
+	Email            string        `json:"email" binding:"required"`
+	Day              string        `json:"day" binding:"required"`
+	IgnoreRT         bool          `json:"ignore_rt"`
+	IgnoreReplies    bool          `json:"ignore_replies"`
+	EreaderEmail     string        `json:"ereader_email" binding:"omitempty,email"`
+	Paused           bool          `json:"paused"`
+	EmailStatus      string        `json:"email_status"`
+	TwitterListID    string        `json:"twitter_list_id"`
+	TwitterAccountID string        `json:"twitter_account_id"`
+	UserList         []twitterUser `json:"userList" binding:"required"`
 }
 
 func adaptUser(user models.User, signedIn bool) appUser {
-	return appUser{
+	u := appUser{
 		ID:       user.ID.String(),
 		Name:     user.Name,
 		SignedIn: signedIn,
+		IsAdmin:  user.IsAdmin(),
+	}
+	if user.VacationStart != nil && user.VacationEnd != nil {
+		u.VacationStart = user.VacationStart.Format(dateFormat)
+		u.VacationEnd = user.VacationEnd.Format(dateFormat)
 	}
+	return u
 
 }
 
@@ -64,12 +80,17 @@ func adaptTwitterUserSearchResult(user models.TwitterUserSearchResult) twitterUs
 
 func adaptSubscription(s models.Subscription) subscription {
 	subcr := subscription{
-		ID:            s.ID.String(),
-		Title:         s.Title,
-		Email:         s.Email,
-		Day:           s.Day,
-		IgnoreRT:      s.IgnoreRT,
-		IgnoreReplies: s.IgnoreReplies,
+		ID:               s.ID.String(),
+		Title:            s.Title,
+		Email:            s.Email,
+		Day:              s.Day,
+		IgnoreRT:         s.IgnoreRT,
+		IgnoreReplies:    s.IgnoreReplies,
+		EreaderEmail:     s.EreaderEmail,
+		Paused:           s.Paused,
+		EmailStatus:      s.EmailStatus,
+		TwitterListID:    s.TwitterListID,
+		TwitterAccountID: s.TwitterAccountID,
 	}
 
 	for _, u := range s.UserList {
@@ -91,7 +112,14 @@ func getUserID(c *gin.Context) string {
 	return u
 }
 
-func setSessionCookie(c *gin.Context, conf *config.Config, userID string) error {
+// setSessionCookie starts server-side session, the cookie keeps only the session token
+func setSessionCookie(ctx context.Context, c *gin.Context, conf *config.Config, usecases models.UserUseCase, userID uuid.UUID) error {
+	token, err := usecases.StartSession(ctx, userID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		log.Errorf("Can not start session, error %s", err)
+		return err
+	}
+
 	s := sessions.Default(c)
 	s.Options(sessions.Options{
 		Path:     conf.Path,
@@ -100,8 +128,8 @@ func setSessionCookie(c *gin.Context, conf *config.Config, userID string) error
 		Secure:   conf.Secure,
 		HttpOnly: conf.HttpOnly,
 	})
-	s.Set("userid", userID)
-	err := s.Save()
+	s.Set(middlewares.SessionTokenKey, token)
+	err = s.Save()
 	if err != nil {
 		log.Errorf("Can not start session, error %s", err)
 	}
@@ -156,6 +184,19 @@ func processTwitterCallback(conf *config.Config, oauth1Config *oauth1.Config, us
 				return
 			}
 
+			if userID, ok := getLinkingUserID(contxt, c, usecases); ok {
+				_, err := usecases.LinkTwitterAccount(
+					contxt, userID, twitterUser.IDStr, twitterUser.ScreenName, accessToken, accessSecret)
+				if err != nil {
+					log.Errorf("Can not link Twitter account: %s", err.Error())
+					respondWithError(c, err)
+					return
+				}
+
+				c.Redirect(http.StatusFound, "/settings")
+				return
+			}
+
 			user, err := usecases.SignInWithTwitter(
 				contxt, twitterUser.IDStr, twitterUser.Name, twitterUser.Email, twitterUser.ScreenName, accessToken, accessSecret)
 
@@ -165,7 +206,14 @@ func processTwitterCallback(conf *config.Config, oauth1Config *oauth1.Config, us
 				return
 			}
 
-			setSessionCookie(c, conf, user.ID.String())
+			if err := setSessionCookie(contxt, c, conf, usecases, user.ID); err != nil {
+				if errors.GetErrorCode(err) == errors.Forbidden {
+					c.String(http.StatusForbidden, "Account is disabled")
+				} else {
+					c.String(http.StatusInternalServerError, "Server Error")
+				}
+				return
+			}
 			c.Redirect(http.StatusFound, "/")
 		}
 
@@ -199,12 +247,7 @@ func getUser(usecases models.UserUseCase) gin.HandlerFunc {
 		user, err := usecases.GetUserByID(ctx, userID)
 		if err != nil {
 			log.Errorf("Can not get user, got error %s", err)
-			e, _ := err.(*useCases.UseCaseError)
-			status := http.StatusInternalServerError
-			if e.Code() == errors.NotFound {
-				status = http.StatusNotFound
-			}
-			c.JSON(status, gin.H{"code": e.Code()})
+			respondWithError(c, err)
 			return
 		}
 
@@ -235,8 +278,7 @@ func searchTwitterUsers(usecases models.UserUseCase) gin.HandlerFunc {
 		users, err := usecases.SearchTwitterUsers(context.Background(), userID, query)
 		if err != nil {
 			log.Errorf("Got error searching users %s", err)
-			e, _ := err.(*useCases.UseCaseError)
-			c.JSON(http.StatusInternalServerError, gin.H{"code": e.Code()})
+			respondWithError(c, err)
 			return
 		}
 
@@ -268,12 +310,7 @@ func addSubscription(usecases models.UserUseCase) gin.HandlerFunc {
 
 		if err != nil {
 			log.Errorf("Can not add subscription %s, got error %s", newSubscription, err)
-			e, _ := err.(*useCases.UseCaseError)
-			status := http.StatusInternalServerError
-			if e.Code() == errors.NotFound {
-				status = http.StatusNotFound
-			}
-			c.JSON(status, gin.H{"code": e.Code()})
+			respondWithError(c, err)
 			return
 		}
 
@@ -299,12 +336,7 @@ func getSubscriptions(usecases models.UserUseCase) gin.HandlerFunc {
 		subscriptions, err := usecases.GetSubscriptions(ctx, userID)
 		if err != nil {
 			log.Errorf("Can not find subscriptions for user %s, got error %s", userID, err)
-			e, _ := err.(*useCases.UseCaseError)
-			status := http.StatusInternalServerError
-			if e.Code() == errors.NotFound {
-				status = http.StatusNotFound
-			}
-			c.JSON(status, gin.H{"code": e.Code()})
+			respondWithError(c, err)
 			return
 		}
 
@@ -325,13 +357,17 @@ func getSubscription(c *gin.Context, userID uuid.UUID) (models.Subscription, err
 
 	id, _ := uuid.Parse(s.ID)
 	newSubscription := models.Subscription{
-		ID:            id,
-		UserID:        userID,
-		Title:         s.Title,
-		Email:         s.Email,
-		Day:           strings.ToLower(s.Day),
-		IgnoreRT:      s.IgnoreRT,
-		IgnoreReplies: s.IgnoreReplies,
+		ID:               id,
+		UserID:           userID,
+		Title:            s.Title,
+		Email:            s.Email,
+		Day:              strings.ToLower(s.Day),
+		IgnoreRT:         s.IgnoreRT,
+		IgnoreReplies:    s.IgnoreReplies,
+		EreaderEmail:     s.EreaderEmail,
+		Paused:           s.Paused,
+		TwitterListID:    s.TwitterListID,
+		TwitterAccountID: s.TwitterAccountID,
 	}
 
 	for _, u := range s.UserList {
@@ -363,12 +399,7 @@ func updateSubscription(usecases models.UserUseCase) gin.HandlerFunc {
 		updatedSubscription, err = usecases.UpdateSubscription(context.Background(), userID, updatedSubscription)
 		if err != nil {
 			log.Errorf("Can not add subscription %s, got error %s", updatedSubscription, err)
-			e, _ := err.(*useCases.UseCaseError)
-			status := http.StatusInternalServerError
-			if e.Code() == errors.NotFound {
-				status = http.StatusNotFound
-			}
-			c.JSON(status, gin.H{"code": e.Code()})
+			respondWithError(c, err)
 			return
 		}
 
@@ -396,16 +427,7 @@ func deleteSubscription(usecases models.UserUseCase) gin.HandlerFunc {
 		err = usecases.DeleteSubscription(ctx, userID, subscriptionID)
 		if err != nil {
 			log.Errorf("Can not delete subscription %s, got error %s", subscriptionID, err)
-			e, _ := err.(*useCases.UseCaseError)
-			status := http.StatusInternalServerError
-
-			if e.Code() == errors.NotFound {
-				status = http.StatusNotFound
-			} else if e.Code() == errors.AuthRequired {
-				status = http.StatusUnauthorized
-			}
-
-			c.JSON(status, gin.H{"code": e.Code()})
+			respondWithError(c, err)
 			return
 		}
 
@@ -431,16 +453,7 @@ func deleteAccount(usecases models.UserUseCase) gin.HandlerFunc {
 		err = usecases.DeleteAccount(ctx, userID)
 		if err != nil {
 			log.Errorf("Can not delete account %s, got error %s", userID, err)
-			e, _ := err.(*useCases.UseCaseError)
-			status := http.StatusInternalServerError
-
-			if e.Code() == errors.NotFound {
-				status = http.StatusNotFound
-			} else if e.Code() == errors.AuthRequired {
-				status = http.StatusUnauthorized
-			}
-
-			c.JSON(status, gin.H{"code": e.Code()})
+			respondWithError(c, err)
 			return
 		}
 
@@ -455,27 +468,35 @@ func deleteAccount(usecases models.UserUseCase) gin.HandlerFunc {
 	}
 }
 
-func confirmEmail(usecases models.UserUseCase) gin.HandlerFunc {
+func getIssueEpub(usecases models.UserUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.Query("token")
-		if token == "" {
-			c.String(http.StatusBadRequest, "Bad request")
+		uid := getUserID(c)
+		userID, err := uuid.Parse(uid)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		issueID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
 			return
 		}
 
 		ctx, err := getContextWithTransaction(c)
 		if err != nil {
-			c.String(http.StatusInternalServerError, "Server error")
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
 			return
 		}
 
-		err = usecases.ConfirmEmail(ctx, token)
+		book, err := usecases.GetIssueEpub(ctx, userID, uint(issueID))
 		if err != nil {
-			log.Errorf("Can't confirm email, got error %s", err)
-			c.String(http.StatusInternalServerError, "Server error")
+			log.Errorf("Can not get epub for issue %d, got error %s", issueID, err)
+			respondWithError(c, err)
 			return
 		}
 
-		c.String(http.StatusOK, "Email confirmed")
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": book.Filename}))
+		c.Data(http.StatusOK, book.ContentType, book.Data)
 	}
 }
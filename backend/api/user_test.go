@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/dmtr/mail_me_all/backend/db"
@@ -99,10 +101,54 @@ func testAddSubscriptionUserNotFound(t *testing.T, router *gin.Engine, datastore
 
 func testUpdateSubscriptionNotFound(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
 	e := &db.DbError{Err: sql.ErrNoRows}
-	datastoreMock.On("UpdateSubscription", mock.Anything, mock.Anything).Return(models.Subscription{}, e)
+	datastoreMock.On("GetSubscription", mock.Anything, mock.Anything).Return(models.Subscription{}, e)
+
+	req := map[string]interface{}{
+		"id": uuid.New().String(), "title": "abc", "email": "test@example.com", "day": "monday", "ignore_rt": false, "ignore_replies": false,
+		"userList": []twitterUser{twitterUser{ID: "123", Name: "test", ScreenName: "test", ProfileIMGURL: "url"}}}
+	reqJson, _ := json.Marshal(req)
+
+	w := performPutRequest(router, "/api/subscriptions", bytes.NewBuffer(reqJson))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "GetSubscription", 1)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 0)
+}
+
+func testUpdateSubscriptionKeepPaused(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	id := uuid.New()
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetSubscription", mock.Anything, id).Return(models.Subscription{ID: id, UserID: uid, Paused: true}, nil)
+	datastoreMock.On("UpdateSubscription", mock.Anything, mock.MatchedBy(func(s models.Subscription) bool {
+		return s.ID == id && s.Paused
+	})).Return(models.Subscription{ID: id, Paused: true}, nil)
 
 	email := "test@example.com"
+	userEmail := models.UserEmail{
+		UserID: uid,
+		Email:  email,
+		Status: models.EmailStatusConfirmed,
+	}
+	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(userEmail, nil)
+
+	req := map[string]interface{}{
+		"id": id.String(), "title": "abc", "email": email, "day": "monday",
+		"userList": []twitterUser{twitterUser{ID: "123", Name: "test", ScreenName: "test", ProfileIMGURL: "url"}}}
+	reqJson, _ := json.Marshal(req)
+
+	w := performPutRequest(router, "/api/subscriptions", bytes.NewBuffer(reqJson))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 1)
+}
+
+func testUpdateSubscriptionNoRows(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	e := &db.DbError{Err: sql.ErrNoRows}
 	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetSubscription", mock.Anything, mock.Anything).Return(models.Subscription{UserID: uid}, nil)
+	datastoreMock.On("UpdateSubscription", mock.Anything, mock.Anything).Return(models.Subscription{}, e)
+
+	email := "test@example.com"
 	userEmail := models.UserEmail{
 		UserID: uid,
 		Email:  email,
@@ -125,6 +171,8 @@ func testUpdateSubscriptionSameEmail(t *testing.T, router *gin.Engine, datastore
 	title := "abc"
 	email := "test@example.com"
 	userID := uuid.New()
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetSubscription", mock.Anything, mock.Anything).Return(models.Subscription{UserID: uid}, nil)
 	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(models.UserEmail{Email: email, UserID: userID}, nil)
 
 	req := map[string]interface{}{
@@ -133,7 +181,7 @@ func testUpdateSubscriptionSameEmail(t *testing.T, router *gin.Engine, datastore
 	reqJson, _ := json.Marshal(req)
 
 	w := performPutRequest(router, "/api/subscriptions", bytes.NewBuffer(reqJson))
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, http.StatusForbidden, w.Code)
 
 	datastoreMock.AssertNumberOfCalls(t, "GetUserEmail", 1)
 	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 0)
@@ -141,10 +189,11 @@ func testUpdateSubscriptionSameEmail(t *testing.T, router *gin.Engine, datastore
 
 func testUpdateSubscriptionOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
 	id := uuid.New()
+	uid, _ := uuid.Parse(testUserID)
+	datastoreMock.On("GetSubscription", mock.Anything, id).Return(models.Subscription{ID: id, UserID: uid}, nil)
 	datastoreMock.On("UpdateSubscription", mock.Anything, mock.Anything).Return(models.Subscription{ID: id}, nil)
 
 	email := "test@example.com"
-	uid, _ := uuid.Parse(testUserID)
 	userEmail := models.UserEmail{
 		UserID: uid,
 		Email:  email,
@@ -178,17 +227,85 @@ func testDeleteSubscriptionNotAuth(t *testing.T, router *gin.Engine, datastoreMo
 	w := performDeleteRequest(router, fmt.Sprintf("/api/subscriptions/%s", s.ID.String()), bytes.NewBuffer(reqJson))
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
+	// ids of other users' subscriptions aren't exposed
+	var res errorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "Authentication required", res.Message)
+	assert.NotContains(t, w.Body.String(), s.ID.String())
+
 	datastoreMock.AssertNumberOfCalls(t, "GetSubscription", 1)
 }
 
 func testDeleteAccountOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
 	uid, _ := uuid.Parse(testUserID)
-	datastoreMock.On("RemoveUser", mock.Anything, uid).Return(nil)
+	datastoreMock.On("GetTwitterUsers", mock.Anything, uid).Return([]models.TwitterUser{{UserID: uid, TwitterID: "111", AccessToken: "token", TokenSecret: "secret"}}, nil)
+	clientMock.On("InvalidateToken", mock.Anything, &pb.InvalidateTokenRequest{AccessToken: "token", AccessSecret: "secret", TwitterId: "111"}).Return(&pb.InvalidateTokenResponse{}, nil)
+	datastoreMock.On("RemoveUser", mock.Anything, models.AccountDeletion{UserID: uid}).Return(models.AccountDeletion{ID: 1, UserID: uid}, nil)
+	datastoreMock.On("UpdateAccountDeletion", mock.Anything, models.AccountDeletion{ID: 1, UserID: uid, TokensRevoked: 1}).Return(models.AccountDeletion{ID: 1, UserID: uid, TokensRevoked: 1}, nil)
 
 	w := performDeleteRequest(router, "/api/user", nil)
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func testDeleteAccountCrossSite(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	req, _ := http.NewRequest("DELETE", "/api/user", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "RemoveUser", 0)
+}
+
+func testGetIssueEpubNotAuth(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	state := models.SubscriptionState{ID: 1, SubscriptionID: uuid.New(), Status: models.Sent}
+	datastoreMock.On("GetSubscriptionState", mock.Anything, state.ID).Return(state, nil)
+
+	s := models.Subscription{ID: state.SubscriptionID, UserID: uuid.New(), Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	w := performGetRequest(router, "/api/issues/1/epub", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "GetSubscriptionTweets", 0)
+}
+
+func testGetIssueEpubOk(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid, _ := uuid.Parse(testUserID)
+	state := models.SubscriptionState{ID: 1, SubscriptionID: uuid.New(), Status: models.Sent}
+	datastoreMock.On("GetSubscriptionState", mock.Anything, state.ID).Return(state, nil)
+
+	s := models.Subscription{ID: state.SubscriptionID, UserID: uid, Title: "test"}
+	datastoreMock.On("GetSubscription", mock.Anything, s.ID).Return(s, nil)
+
+	tweets := []models.Tweet{
+		models.Tweet{ID: 1, TweetID: "1", Tweet: models.TweetAttrs{IdStr: "1", FullText: "foo", UserId: "10", UserScreenName: "foo"}},
+		models.Tweet{ID: 2, TweetID: "2", Tweet: models.TweetAttrs{IdStr: "2", FullText: "bar", UserId: "20", UserScreenName: "bar"}},
+	}
+	datastoreMock.On("GetSubscriptionTweets", mock.Anything, state.ID).Return(tweets, nil)
+
+	w := performGetRequest(router, "/api/issues/1/epub", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/epub+zip", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+}
+
+func testShowUnsubscribePage(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performGetRequest(router, "/unsubscribe?token=abc", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<form method=\"post\">")
+
+	datastoreMock.AssertNumberOfCalls(t, "GetSubscription", 0)
+}
+
+func testUnsubscribeInvalidToken(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	w := performRequest(router, "POST", "/unsubscribe?token=abc", strings.NewReader("List-Unsubscribe=One-Click"), false, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "GetSubscription", 0)
+}
+
 func TestUserEndpoints(t *testing.T) {
 	tests := map[string]testFunc{
 		"TestGetUserOk":                    testGetUserOk,
@@ -196,10 +313,17 @@ func TestUserEndpoints(t *testing.T) {
 		"TestSearchTwitterUsersOk":         testSearchTwitterUsersOk,
 		"TestSearchTwitterUsersBadRequest": testSearchTwitterUsersBadRequest,
 		"TestUpdateSubscriptionNotFound":   testUpdateSubscriptionNotFound,
+		"TestUpdateSubscriptionNoRows":     testUpdateSubscriptionNoRows,
+		"TestUpdateSubscriptionKeepPaused": testUpdateSubscriptionKeepPaused,
 		"TestAddSubscriptionUserNotFound":  testAddSubscriptionUserNotFound,
 		"TestDeleteSubscriptionNotAuth":    testDeleteSubscriptionNotAuth,
 		"TestDeleteAccountOk":              testDeleteAccountOk,
+		"TestDeleteAccountCrossSite":       testDeleteAccountCrossSite,
 		"TestUpdateSubscriptionSameEmail":  testUpdateSubscriptionSameEmail,
+		"TestGetIssueEpubNotAuth":          testGetIssueEpubNotAuth,
+		"TestGetIssueEpubOk":               testGetIssueEpubOk,
+		"TestShowUnsubscribePage":          testShowUnsubscribePage,
+		"TestUnsubscribeInvalidToken":      testUnsubscribeInvalidToken,
 	}
 	runTests(tests, t)
 }
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/mail"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// processMailgunWebhook records bounces, complaints and unsubscribes reported by Mailgun
+func processMailgunWebhook(conf *config.Config, usecases models.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		change, err := mail.ParseMailgunWebhook(conf, body)
+		if err == mail.ErrInvalidSignature || err == mail.ErrStaleWebhook {
+			// Mailgun doesn't retry webhooks rejected with 406
+			log.Warningf("Got webhook with invalid signature or timestamp: %s", err)
+			c.JSON(http.StatusNotAcceptable, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		} else if err != nil {
+			log.Errorf("Can't parse webhook, got error %s", err)
+			c.JSON(http.StatusBadRequest, gin.H{"code": errors.BadRequest, "message": err.Error()})
+			return
+		}
+
+		if change.Status == "" {
+			c.JSON(http.StatusOK, gin.H{})
+			return
+		}
+
+		ctx, err := getContextWithTransaction(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		err = usecases.UseWebhookToken(ctx, change.Token)
+		if errors.GetErrorCode(err) == errors.Conflict {
+			log.Warningf("Got replayed webhook: %s", err)
+			c.JSON(http.StatusNotAcceptable, gin.H{"code": errors.BadRequest, "message": "Webhook is already processed"})
+			return
+		} else if err != nil {
+			log.Errorf("Can not record webhook token, got error %s", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		err = usecases.UpdateEmailStatus(ctx, change.Email, change.Status)
+		if err != nil {
+			log.Errorf("Can not update email %s status, got error %s", change.Email, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": errors.ServerError})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getMailgunPayload(key string, event map[string]interface{}) []byte {
+	return getSignedMailgunPayload(key, time.Now(), "a8ce0edb2dd8301dee6c2405235584e45aa91d1e9f979f3de0", event)
+}
+
+func getSignedMailgunPayload(key string, signedAt time.Time, token string, event map[string]interface{}) []byte {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(timestamp + token))
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"signature": map[string]string{
+			"timestamp": timestamp,
+			"token":     token,
+			"signature": hex.EncodeToString(h.Sum(nil)),
+		},
+		"event-data": event,
+	})
+	return payload
+}
+
+func testMailgunWebhookBounce(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	email := models.UserEmail{Email: "test@example.com", Status: models.EmailStatusBounced}
+	datastoreMock.On("InsertWebhookToken", mock.Anything, mock.Anything).Return(nil)
+	datastoreMock.On("UpdateUserEmail", mock.Anything, email).Return(email, nil)
+
+	event := map[string]interface{}{"event": "failed", "severity": "permanent", "recipient": email.Email}
+	w := performPostRequest(router, "/webhooks/mailgun", bytes.NewReader(getMailgunPayload(testWebhookKey, event)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 1)
+}
+
+func testMailgunWebhookComplaint(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	email := models.UserEmail{Email: "test@example.com", Status: models.EmailStatusComplained}
+	datastoreMock.On("InsertWebhookToken", mock.Anything, mock.Anything).Return(nil)
+	datastoreMock.On("UpdateUserEmail", mock.Anything, email).Return(email, nil)
+
+	event := map[string]interface{}{"event": "complained", "recipient": email.Email}
+	w := performPostRequest(router, "/webhooks/mailgun", bytes.NewReader(getMailgunPayload(testWebhookKey, event)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 1)
+}
+
+func testMailgunWebhookTemporaryFailure(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	event := map[string]interface{}{"event": "failed", "severity": "temporary", "recipient": "test@example.com"}
+	w := performPostRequest(router, "/webhooks/mailgun", bytes.NewReader(getMailgunPayload(testWebhookKey, event)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 0)
+}
+
+func testMailgunWebhookInvalidSignature(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	event := map[string]interface{}{"event": "complained", "recipient": "test@example.com"}
+	w := performPostRequest(router, "/webhooks/mailgun", bytes.NewReader(getMailgunPayload("wrong-key", event)))
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 0)
+}
+
+func testMailgunWebhookStale(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	event := map[string]interface{}{"event": "complained", "recipient": "test@example.com"}
+	payload := getSignedMailgunPayload(testWebhookKey, time.Now().Add(-time.Hour), "token", event)
+	w := performPostRequest(router, "/webhooks/mailgun", bytes.NewReader(payload))
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "InsertWebhookToken", 0)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 0)
+}
+
+func testMailgunWebhookReplayed(t *testing.T, router *gin.Engine, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	e := &db.DbError{PqError: &pq.Error{Code: "23505"}}
+	datastoreMock.On("InsertWebhookToken", mock.Anything, "used-token").Return(e)
+
+	event := map[string]interface{}{"event": "complained", "recipient": "test@example.com"}
+	payload := getSignedMailgunPayload(testWebhookKey, time.Now(), "used-token", event)
+	w := performPostRequest(router, "/webhooks/mailgun", bytes.NewReader(payload))
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 0)
+}
+
+func TestWebhookEndpoints(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestMailgunWebhookBounce":           testMailgunWebhookBounce,
+		"TestMailgunWebhookComplaint":        testMailgunWebhookComplaint,
+		"TestMailgunWebhookTemporaryFailure": testMailgunWebhookTemporaryFailure,
+		"TestMailgunWebhookInvalidSignature": testMailgunWebhookInvalidSignature,
+		"TestMailgunWebhookStale":            testMailgunWebhookStale,
+		"TestMailgunWebhookReplayed":         testMailgunWebhookReplayed,
+	}
+	runTests(tests, t)
+}
@@ -45,7 +45,15 @@ func initLogger(loglevel log.Level) {
 }
 
 func getUseCases(db_ *sqlx.DB, client pb.TwProxyServiceClient, conf config.Config) *models.UseCases {
-	userDatastore := db.NewUserDatastore(db_)
+	keyring, err := db.NewTokenKeyring(conf.TokenKeys, conf.TokenKeyID)
+	if err != nil {
+		log.Fatalf("Can't load token keys %s", err)
+	}
+	if keyring == nil {
+		log.Warning("Token keys are not configured, twitter tokens are stored unencrypted")
+	}
+
+	userDatastore := db.NewUserDatastore(db_, keyring)
 	userUseCase := usecases.NewUserUseCase(userDatastore, client, &conf)
 	es := mail.NewEmailSender(&conf)
 	systemUseCase := usecases.NewSystemUseCase(userDatastore, client, &conf, es)
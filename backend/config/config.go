@@ -37,10 +37,27 @@ type Config struct {
 	TemplatePath     string
 	MgDomain         string
 	MgAPIKEY         string
+	MgWebhookKey     string
 	From             string
 	PemFile          string
 	KeyFile          string
 	TweetTTL         int
+	EmailSender      string
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUser         string
+	SMTPPassword     string
+	InlineImages     bool
+	OutboxPath       string
+	SendNowLimit     int
+	MagicLinkTTL     int
+	MagicLinkLimit   int
+	TokenKeys        string
+	TokenKeyID       string
+	DataExportTTL    int
+	EmailResendDelay int
+	EmailAddLimit    int
+	EmailConfirmTTL  int
 }
 
 // GetConfig returns app config
@@ -63,10 +80,26 @@ func GetConfig() Config {
 	viper.SetDefault("TEMPLATE_PATH", "/app/templates/")
 	viper.SetDefault("MG_DOMAIN", "")
 	viper.SetDefault("MG_APIKEY", "")
+	viper.SetDefault("MG_WEBHOOK_KEY", "")
 	viper.SetDefault("FROM", "")
 	viper.SetDefault("PEM_FILE", "")
 	viper.SetDefault("KEY_FILE", "")
 	viper.SetDefault("TWEET_TTL", 7)
+	viper.SetDefault("EMAIL_SENDER", "mailgun")
+	viper.SetDefault("SMTP_HOST", "localhost")
+	viper.SetDefault("SMTP_PORT", 25)
+	viper.SetDefault("SMTP_USER", "")
+	viper.SetDefault("INLINE_IMAGES", 0)
+	viper.SetDefault("OUTBOX_PATH", "/tmp/mailmeapp-outbox")
+	viper.SetDefault("SEND_NOW_LIMIT", 3)
+	viper.SetDefault("MAGIC_LINK_TTL", 15)
+	viper.SetDefault("MAGIC_LINK_LIMIT", 3)
+	viper.SetDefault("TOKEN_KEYS", "")
+	viper.SetDefault("TOKEN_KEY_ID", "")
+	viper.SetDefault("DATA_EXPORT_TTL", 24)
+	viper.SetDefault("EMAIL_RESEND_DELAY", 10)
+	viper.SetDefault("EMAIL_ADD_LIMIT", 5)
+	viper.SetDefault("EMAIL_CONFIRM_TTL", 24)
 	viper.AutomaticEnv()
 
 	loglevel, err := log.ParseLevel(viper.GetString("LOGLEVEL"))
@@ -96,10 +129,27 @@ func GetConfig() Config {
 		TemplatePath:     viper.GetString("TEMPLATE_PATH"),
 		MgDomain:         viper.GetString("MG_DOMAIN"),
 		MgAPIKEY:         viper.GetString("MG_APIKEY"),
+		MgWebhookKey:     viper.GetString("MG_WEBHOOK_KEY"),
 		From:             viper.GetString("FROM"),
 		PemFile:          viper.GetString("PEM_FILE"),
 		KeyFile:          viper.GetString("KEY_FILE"),
 		TweetTTL:         viper.GetInt("TWEET_TTL"),
+		EmailSender:      viper.GetString("EMAIL_SENDER"),
+		SMTPHost:         viper.GetString("SMTP_HOST"),
+		SMTPPort:         viper.GetInt("SMTP_PORT"),
+		SMTPUser:         viper.GetString("SMTP_USER"),
+		SMTPPassword:     viper.GetString("smtp-password"),
+		InlineImages:     viper.GetBool("INLINE_IMAGES"),
+		OutboxPath:       viper.GetString("OUTBOX_PATH"),
+		SendNowLimit:     viper.GetInt("SEND_NOW_LIMIT"),
+		MagicLinkTTL:     viper.GetInt("MAGIC_LINK_TTL"),
+		MagicLinkLimit:   viper.GetInt("MAGIC_LINK_LIMIT"),
+		TokenKeys:        viper.GetString("TOKEN_KEYS"),
+		TokenKeyID:       viper.GetString("TOKEN_KEY_ID"),
+		DataExportTTL:    viper.GetInt("DATA_EXPORT_TTL"),
+		EmailResendDelay: viper.GetInt("EMAIL_RESEND_DELAY"),
+		EmailAddLimit:    viper.GetInt("EMAIL_ADD_LIMIT"),
+		EmailConfirmTTL:  viper.GetInt("EMAIL_CONFIRM_TTL"),
 	}
 
 	return conf
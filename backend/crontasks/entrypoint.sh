@@ -7,6 +7,8 @@ export MAILME_APP_DSN=${MAILME_APP_DSN}
 export MAILME_APP_PEM_FILE=${MAILME_APP_PEM_FILE}
 export MAILME_APP_KEY_FILE=${MAILME_APP_KEY_FILE}
 export ENCRYPT_KEY=${ENCRYPT_KEY}
+export MAILME_APP_TOKEN_KEYS=${MAILME_APP_TOKEN_KEYS:-}
+export MAILME_APP_TOKEN_KEY_ID=${MAILME_APP_TOKEN_KEY_ID:-}
 
 CRON_CONFIRM_SEND='*/3 * * * *'
 echo "$CRON_CONFIRM_SEND /app/mailmeapp --encrypt-key=$ENCRYPT_KEY send-confirmation" >> /var/spool/cron/crontabs/root
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+)
+
+const userColumns = "id, name, email, vacation_start, vacation_end, role, disabled_at"
+
+func (d *UserDatastore) UpdateUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var id uuid.UUID
+	err = t.tx.Get(&id, "UPDATE user_account SET role = $1 WHERE id = $2 RETURNING id", role, userID)
+	return t.getError()
+}
+
+// SetUserDisabled disables or enables the user and returns the updated user
+func (d *UserDatastore) SetUserDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (models.User, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var user models.User
+	err = t.tx.Get(&user,
+		"UPDATE user_account SET disabled_at = CASE WHEN $1 THEN COALESCE(disabled_at, NOW()) ELSE NULL END WHERE id = $2 RETURNING "+userColumns,
+		disabled, userID)
+	return user, t.getError()
+}
+
+// SearchUsers looks up users by id, name, email or any of their email addresses
+func (d *UserDatastore) SearchUsers(ctx context.Context, query string, limit uint) ([]models.User, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res := make([]models.User, 0)
+	err = t.tx.Select(&res,
+		"SELECT "+userColumns+" FROM user_account u "+
+			"WHERE u.id::TEXT = $1 OR u.name ILIKE '%' || $1 || '%' OR u.email ILIKE '%' || $1 || '%' "+
+			"OR EXISTS (SELECT 1 FROM user_email_m2m e WHERE e.user_id = u.id AND e.email ILIKE '%' || $1 || '%') "+
+			"ORDER BY u.name, u.id LIMIT $2", query, limit)
+	return res, t.getError()
+}
+
+func (d *UserDatastore) InsertAdminAudit(ctx context.Context, audit models.AdminAudit) (models.AdminAudit, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.AdminAudit
+	rows, err := t.tx.NamedQuery(
+		"INSERT INTO admin_audit (admin_id, action, target, details) VALUES (:admin_id, :action, :target, :details) "+
+			"RETURNING id, admin_id, action, target, details, created_at", audit)
+	if err != nil {
+		return res, t.getError()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.StructScan(&res)
+		if err != nil {
+			return res, t.getError()
+		}
+	}
+
+	return res, t.getError()
+}
+
+func (d *UserDatastore) GetAdminAudit(ctx context.Context, limit, offset uint) ([]models.AdminAudit, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res := make([]models.AdminAudit, 0)
+	err = t.tx.Select(&res,
+		"SELECT id, admin_id, action, target, details, created_at FROM admin_audit "+
+			"ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2", limit, offset)
+	return res, t.getError()
+}
+
+// GetFailedSubscriptionStates returns failed issues of all users, latest first
+func (d *UserDatastore) GetFailedSubscriptionStates(ctx context.Context, limit, offset uint) ([]models.SubscriptionState, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res := make([]models.SubscriptionState, 0)
+	err = t.tx.Select(&res,
+		"SELECT id, subscription_id, status, tweet_count, failure_reason, created_at, updated_at, sent_at, share_token, on_demand FROM subscription_state "+
+			"WHERE status = 'FAILED' "+
+			"ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2", limit, offset)
+	return res, t.getError()
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+)
+
+const apiTokenColumns = "id, user_id, name, token_hash, scope, last_used_at, revoked_at, created_at"
+
+func (d *UserDatastore) InsertAPIToken(ctx context.Context, token models.APIToken) (models.APIToken, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.APIToken
+	rows, err := t.tx.NamedQuery(
+		"INSERT INTO api_token (user_id, name, token_hash, scope) VALUES (:user_id, :name, :token_hash, :scope) RETURNING "+apiTokenColumns, token)
+	if err != nil {
+		return res, t.getError()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.StructScan(&res)
+		if err != nil {
+			return res, t.getError()
+		}
+	}
+
+	return res, t.getError()
+}
+
+func (d *UserDatastore) GetAPITokens(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res := make([]models.APIToken, 0)
+	err = t.tx.Select(&res, "SELECT "+apiTokenColumns+" FROM api_token WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at", userID)
+	return res, t.getError()
+}
+
+func (d *UserDatastore) GetAPITokenByHash(ctx context.Context, tokenHash string) (models.APIToken, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.APIToken
+	err = t.tx.Get(&res, "SELECT "+apiTokenColumns+" FROM api_token WHERE token_hash = $1", tokenHash)
+	return res, t.getError()
+}
+
+func (d *UserDatastore) RevokeAPIToken(ctx context.Context, userID uuid.UUID, tokenID uint) (bool, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("UPDATE api_token SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", tokenID, userID)
+	if err != nil {
+		return false, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, t.getError()
+}
+
+func (d *UserDatastore) UpdateAPITokenLastUsed(ctx context.Context, tokenID uint) error {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	_, err = t.tx.Exec("UPDATE api_token SET last_used_at = NOW() WHERE id = $1", tokenID)
+	return t.getError()
+}
+
+// RevokeUserAPITokens revokes all tokens of the user and returns their number
+func (d *UserDatastore) RevokeUserAPITokens(ctx context.Context, userID uuid.UUID) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("UPDATE api_token SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	if err != nil {
+		return 0, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return uint(n), t.getError()
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+)
+
+// dataExportColumns - columns without the archive, so listing exports doesn't load archives
+const dataExportColumns = "id, user_id, status, failure_reason, expires_at, created_at, updated_at"
+
+func (d *UserDatastore) InsertDataExport(ctx context.Context, export models.DataExport) (models.DataExport, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.DataExport
+	rows, err := t.tx.NamedQuery(
+		"INSERT INTO data_export (user_id, status) VALUES (:user_id, :status) RETURNING "+dataExportColumns, export)
+	if err != nil {
+		return res, t.getError()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.StructScan(&res)
+		if err != nil {
+			return res, t.getError()
+		}
+	}
+
+	return res, t.getError()
+}
+
+func (d *UserDatastore) UpdateDataExport(ctx context.Context, export models.DataExport) (models.DataExport, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.DataExport
+	rows, err := t.tx.NamedQuery(
+		"UPDATE data_export SET status = :status, archive = :archive, failure_reason = :failure_reason, expires_at = :expires_at, updated_at = NOW() "+
+			"WHERE id = :id RETURNING "+dataExportColumns, export)
+	if err != nil {
+		return res, t.getError()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.StructScan(&res)
+		if err != nil {
+			return res, t.getError()
+		}
+	}
+
+	return res, t.getError()
+}
+
+// GetDataExport returns the export with its archive
+func (d *UserDatastore) GetDataExport(ctx context.Context, exportID uint) (models.DataExport, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.DataExport
+	err = t.tx.Get(&res, "SELECT "+dataExportColumns+", archive FROM data_export WHERE id = $1", exportID)
+	return res, t.getError()
+}
+
+// GetDataExports returns exports of the user without archives, the latest first
+func (d *UserDatastore) GetDataExports(ctx context.Context, userID uuid.UUID) ([]models.DataExport, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res := make([]models.DataExport, 0)
+	err = t.tx.Select(&res, "SELECT "+dataExportColumns+" FROM data_export WHERE user_id = $1 ORDER BY created_at DESC, id DESC", userID)
+	return res, t.getError()
+}
+
+// RemoveExpiredDataExports removes expired archives and returns their number
+func (d *UserDatastore) RemoveExpiredDataExports(ctx context.Context) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("DELETE FROM data_export WHERE expires_at < NOW()")
+	if err != nil {
+		return 0, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return uint(n), t.getError()
+}
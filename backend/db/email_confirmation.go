@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+)
+
+const emailConfirmationColumns = "id, user_id, email, nonce_hash, expires_at, used_at, created_at"
+
+func (d *UserDatastore) InsertEmailConfirmation(ctx context.Context, confirmation models.EmailConfirmation) (models.EmailConfirmation, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.EmailConfirmation
+	rows, err := t.tx.NamedQuery(
+		"INSERT INTO email_confirmation (user_id, email, nonce_hash, expires_at) VALUES (:user_id, :email, :nonce_hash, :expires_at) RETURNING "+emailConfirmationColumns, confirmation)
+	if err != nil {
+		return res, t.getError()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.StructScan(&res)
+		if err != nil {
+			return res, t.getError()
+		}
+	}
+
+	return res, t.getError()
+}
+
+// UseEmailConfirmation marks the confirmation as used, returns no rows error if it is used or expired
+func (d *UserDatastore) UseEmailConfirmation(ctx context.Context, nonceHash string) (models.EmailConfirmation, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.EmailConfirmation
+	err = t.tx.Get(&res, "UPDATE email_confirmation SET used_at = NOW() "+
+		"WHERE nonce_hash = $1 AND used_at IS NULL AND expires_at > NOW() RETURNING "+emailConfirmationColumns, nonceHash)
+	return res, t.getError()
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+)
+
+func (d *UserDatastore) InsertSubscriptionListChanges(ctx context.Context, changes []models.SubscriptionListChange) error {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	for _, c := range changes {
+		_, err = t.tx.NamedExec(
+			"INSERT INTO subscription_list_change (subscription_id, twitter_list_id, user_twitter_id, screen_name, name, kind) "+
+				"VALUES (:subscription_id, :twitter_list_id, :user_twitter_id, :screen_name, :name, :kind)", c)
+		if err != nil {
+			return t.getError()
+		}
+	}
+
+	return t.getError()
+}
+
+func (d *UserDatastore) GetSubscriptionListChanges(ctx context.Context, subscriptionID uuid.UUID, limit, offset uint) ([]models.SubscriptionListChange, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res := make([]models.SubscriptionListChange, 0)
+	err = t.tx.Select(&res,
+		"SELECT id, subscription_id, twitter_list_id, user_twitter_id, screen_name, name, kind, created_at FROM subscription_list_change "+
+			"WHERE subscription_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3", subscriptionID, limit, offset)
+	return res, t.getError()
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+)
+
+const magicLinkColumns = "id, user_id, email, nonce_hash, expires_at, used_at, created_at"
+
+func (d *UserDatastore) InsertMagicLink(ctx context.Context, link models.MagicLink) (models.MagicLink, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.MagicLink
+	rows, err := t.tx.NamedQuery(
+		"INSERT INTO magic_link (user_id, email, nonce_hash, expires_at) VALUES (:user_id, :email, :nonce_hash, :expires_at) RETURNING "+magicLinkColumns, link)
+	if err != nil {
+		return res, t.getError()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.StructScan(&res)
+		if err != nil {
+			return res, t.getError()
+		}
+	}
+
+	return res, t.getError()
+}
+
+// InsertMagicLinkRequest records the sign-in link request, requests older than the time are removed
+func (d *UserDatastore) InsertMagicLinkRequest(ctx context.Context, email string, removeBefore time.Time) error {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	_, err = t.tx.Exec("DELETE FROM magic_link_request WHERE created_at < $1", removeBefore)
+	if err != nil {
+		return t.getError()
+	}
+
+	_, err = t.tx.Exec("INSERT INTO magic_link_request (email) VALUES ($1)", email)
+	return t.getError()
+}
+
+// CountMagicLinkRequests returns the number of sign-in links requested for the email since the time
+func (d *UserDatastore) CountMagicLinkRequests(ctx context.Context, email string, since time.Time) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var count uint
+	err = t.tx.Get(&count, "SELECT COUNT(*) FROM magic_link_request WHERE lower(email) = lower($1) AND created_at > $2", email, since)
+	return count, t.getError()
+}
+
+// UseMagicLink marks the link as used, returns no rows error if the link is used or expired
+func (d *UserDatastore) UseMagicLink(ctx context.Context, nonceHash string) (models.MagicLink, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.MagicLink
+	err = t.tx.Get(&res, "UPDATE magic_link SET used_at = NOW() "+
+		"WHERE nonce_hash = $1 AND used_at IS NULL AND expires_at > NOW() RETURNING "+magicLinkColumns, nonceHash)
+	return res, t.getError()
+}
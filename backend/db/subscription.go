@@ -8,13 +8,18 @@ import (
 )
 
 type subscription struct {
-	SubscriptionID uuid.UUID `db:"subscription_id"`
-	Title          string    `db:"title"`
-	Email          string    `db:"email"`
-	Day            string    `db:"day"`
-	UserID         uuid.UUID `db:"user_id"`
-	IgnoreRT       bool      `db:"ignore_rt"`
-	IgnoreReplies  bool      `db:"ignore_replies"`
+	SubscriptionID   uuid.UUID `db:"subscription_id"`
+	Title            string    `db:"title"`
+	Email            string    `db:"email"`
+	Day              string    `db:"day"`
+	UserID           uuid.UUID `db:"user_id"`
+	IgnoreRT         bool      `db:"ignore_rt"`
+	IgnoreReplies    bool      `db:"ignore_replies"`
+	EreaderEmail     string    `db:"ereader_email"`
+	Paused           bool      `db:"paused"`
+	EmailStatus      string    `db:"email_status"`
+	TwitterListID    string    `db:"twitter_list_id"`
+	TwitterAccountID string    `db:"twitter_account_id"`
 }
 
 type subscriptionUser struct {
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+)
+
+const dataKeySize = 32
+
+// TokenKeyring encrypts twitter tokens with envelope encryption: every row has its own data key,
+// the data key is stored encrypted with the key-encryption key identified by key_id of the row.
+// Rotation only re-encrypts data keys, so the old key must stay in the keyring until rotate-keys is done.
+type TokenKeyring struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// twitterUserRow - tw_account row, tokens are encrypted if KeyID is set
+type twitterUserRow struct {
+	models.TwitterUser
+	KeyID   string `db:"key_id"`
+	DataKey string `db:"data_key"`
+}
+
+// NewTokenKeyring parses keys formatted as "id:base64 key,id:base64 key", the current key encrypts new data keys.
+// Returns nil keyring if no keys are configured, tokens are stored in plaintext then.
+func NewTokenKeyring(keys, currentID string) (*TokenKeyring, error) {
+	if keys == "" {
+		return nil, nil
+	}
+
+	k := &TokenKeyring{currentID: currentID, keys: make(map[string][]byte)}
+	for _, pair := range strings.Split(keys, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid token key, expected id:base64 key")
+		}
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid token key %s, got error %s", parts[0], err)
+		}
+
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("Invalid token key %s, expected %d bytes", parts[0], dataKeySize)
+		}
+		k.keys[parts[0]] = key
+	}
+
+	if _, ok := k.keys[currentID]; !ok {
+		return nil, fmt.Errorf("Current token key %s is not configured", currentID)
+	}
+	return k, nil
+}
+
+// CurrentKeyID returns id of the key encrypting new data keys
+func (k *TokenKeyring) CurrentKeyID() string {
+	return k.currentID
+}
+
+// keyIDs returns ids of all configured keys
+func (k *TokenKeyring) keyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func seal(key, plaintext, additionalData []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, additionalData)), nil
+}
+
+func open(key []byte, ciphertext string, additionalData []byte) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("Ciphertext is too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], additionalData)
+}
+
+// wrapDataKey encrypts the data key with the current key
+func (k *TokenKeyring) wrapDataKey(dataKey []byte) (string, error) {
+	return seal(k.keys[k.currentID], dataKey, []byte(k.currentID))
+}
+
+func (k *TokenKeyring) unwrapDataKey(keyID, wrapped string) ([]byte, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("Token key %s is not configured", keyID)
+	}
+	return open(key, wrapped, []byte(keyID))
+}
+
+// encrypt returns the row with tokens encrypted by a new data key
+func (k *TokenKeyring) encrypt(twitterUser models.TwitterUser) (twitterUserRow, error) {
+	if k == nil {
+		return twitterUserRow{TwitterUser: twitterUser}, nil
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return twitterUserRow{}, err
+	}
+
+	row := twitterUserRow{TwitterUser: twitterUser, KeyID: k.currentID}
+	var err error
+	// tokens are bound to the twitter account, so they can't be swapped between rows
+	if row.AccessToken, err = seal(dataKey, []byte(twitterUser.AccessToken), []byte(twitterUser.TwitterID)); err != nil {
+		return row, err
+	}
+
+	if row.TokenSecret, err = seal(dataKey, []byte(twitterUser.TokenSecret), []byte(twitterUser.TwitterID)); err != nil {
+		return row, err
+	}
+
+	row.DataKey, err = k.wrapDataKey(dataKey)
+	return row, err
+}
+
+// decrypt returns twitter user with plaintext tokens, rows without key id are not encrypted yet
+func (k *TokenKeyring) decrypt(row twitterUserRow) (models.TwitterUser, error) {
+	twitterUser := row.TwitterUser
+	if row.KeyID == "" {
+		return twitterUser, nil
+	}
+
+	if k == nil {
+		return twitterUser, fmt.Errorf("Tokens of %s are encrypted, but token keys are not configured", twitterUser)
+	}
+
+	dataKey, err := k.unwrapDataKey(row.KeyID, row.DataKey)
+	if err != nil {
+		return twitterUser, err
+	}
+
+	accessToken, err := open(dataKey, row.AccessToken, []byte(twitterUser.TwitterID))
+	if err != nil {
+		return twitterUser, err
+	}
+
+	tokenSecret, err := open(dataKey, row.TokenSecret, []byte(twitterUser.TwitterID))
+	if err != nil {
+		return twitterUser, err
+	}
+
+	twitterUser.AccessToken = string(accessToken)
+	twitterUser.TokenSecret = string(tokenSecret)
+	return twitterUser, nil
+}
+
+// rotate re-encrypts the row under the current key, the data key of encrypted rows is kept
+func (k *TokenKeyring) rotate(row twitterUserRow) (twitterUserRow, error) {
+	if row.KeyID == "" {
+		return k.encrypt(row.TwitterUser)
+	}
+
+	dataKey, err := k.unwrapDataKey(row.KeyID, row.DataKey)
+	if err != nil {
+		return row, err
+	}
+
+	row.KeyID = k.currentID
+	row.DataKey, err = k.wrapDataKey(dataKey)
+	return row, err
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), dataKeySize)))
+}
+
+func TestTokenKeyring(t *testing.T) {
+	keyring, err := NewTokenKeyring("old:"+getTestKey('a'), "old")
+	assert.NoError(t, err)
+
+	twitterUser := models.TwitterUser{UserID: uuid.New(), TwitterID: "111", AccessToken: "token", TokenSecret: "secret"}
+	row, err := keyring.encrypt(twitterUser)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", row.KeyID)
+	assert.NotContains(t, row.AccessToken, "token")
+	assert.NotContains(t, row.TokenSecret, "secret")
+
+	res, err := keyring.decrypt(row)
+	assert.NoError(t, err)
+	assert.Equal(t, twitterUser, res)
+
+	rotated, err := NewTokenKeyring("old:"+getTestKey('a')+", new:"+getTestKey('b'), "new")
+	assert.NoError(t, err)
+
+	row, err = rotated.rotate(row)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", row.KeyID)
+
+	res, err = rotated.decrypt(row)
+	assert.NoError(t, err)
+	assert.Equal(t, twitterUser, res)
+
+	_, err = keyring.decrypt(row)
+	assert.Error(t, err)
+}
+
+func TestTokenKeyringPlaintextRows(t *testing.T) {
+	keyring, err := NewTokenKeyring("k1:"+getTestKey('a'), "k1")
+	assert.NoError(t, err)
+
+	twitterUser := models.TwitterUser{UserID: uuid.New(), TwitterID: "111", AccessToken: "token", TokenSecret: "secret"}
+	res, err := keyring.decrypt(twitterUserRow{TwitterUser: twitterUser})
+	assert.NoError(t, err)
+	assert.Equal(t, twitterUser, res)
+
+	row, err := keyring.rotate(twitterUserRow{TwitterUser: twitterUser})
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", row.KeyID)
+
+	var noKeys *TokenKeyring
+	_, err = noKeys.decrypt(row)
+	assert.Error(t, err)
+}
+
+func TestTokenKeyringSwappedTokens(t *testing.T) {
+	keyring, err := NewTokenKeyring("k1:"+getTestKey('a'), "k1")
+	assert.NoError(t, err)
+
+	first, err := keyring.encrypt(models.TwitterUser{TwitterID: "111", AccessToken: "token"})
+	assert.NoError(t, err)
+	second, err := keyring.encrypt(models.TwitterUser{TwitterID: "222", AccessToken: "other"})
+	assert.NoError(t, err)
+
+	second.AccessToken, second.TokenSecret, second.DataKey = first.AccessToken, first.TokenSecret, first.DataKey
+	_, err = keyring.decrypt(second)
+	assert.Error(t, err)
+}
+
+func TestNewTokenKeyringErrors(t *testing.T) {
+	keyring, err := NewTokenKeyring("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, keyring)
+
+	_, err = NewTokenKeyring("k1:"+getTestKey('a'), "k2")
+	assert.Error(t, err)
+
+	_, err = NewTokenKeyring("k1:short", "k1")
+	assert.Error(t, err)
+
+	_, err = NewTokenKeyring(getTestKey('a'), "k1")
+	assert.Error(t, err)
+}
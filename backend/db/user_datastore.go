@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/dmtr/mail_me_all/backend/models"
@@ -61,11 +62,12 @@ func (t *currentTx) getError() error {
 }
 
 type UserDatastore struct {
-	DB *sqlx.DB
+	DB      *sqlx.DB
+	Keyring *TokenKeyring
 }
 
-func NewUserDatastore(db *sqlx.DB) *UserDatastore {
-	return &UserDatastore{DB: db}
+func NewUserDatastore(db *sqlx.DB, keyring *TokenKeyring) *UserDatastore {
+	return &UserDatastore{DB: db, Keyring: keyring}
 }
 
 func (d *UserDatastore) InsertUser(ctx context.Context, user models.User) (models.User, error) {
@@ -126,13 +128,12 @@ func (d *UserDatastore) GetUser(ctx context.Context, userID uuid.UUID) (models.U
 	}()
 
 	var user models.User
-	err = t.tx.Get(&user, "SELECT id, name, email FROM user_account WHERE id=$1", userID)
+	err = t.tx.Get(&user, "SELECT "+userColumns+" FROM user_account WHERE id=$1", userID)
 	return user, t.getError()
 
 }
 
-func (d *UserDatastore) RemoveUser(ctx context.Context, userID uuid.UUID) error {
-	log.Debugf("Going to remove user %s", userID)
+func (d *UserDatastore) UpdateUserVacation(ctx context.Context, userID uuid.UUID, start, end *time.Time) error {
 	var err error
 	t := getTransaction(ctx, d.DB, &err)
 
@@ -140,15 +141,101 @@ func (d *UserDatastore) RemoveUser(ctx context.Context, userID uuid.UUID) error
 		t.commitOrRollback()
 	}()
 
-	_, err = t.tx.Exec("DELETE FROM user_account WHERE id = $1", userID)
+	_, err = t.tx.Exec("UPDATE user_account SET vacation_start = $1, vacation_end = $2 WHERE id = $3", start, end, userID)
+	return t.getError()
+}
+
+// RemoveUser removes the user with everything the cascade removes, then tweets and subscription users
+// no other subscription references, and records the deletion audit
+func (d *UserDatastore) RemoveUser(ctx context.Context, deletion models.AccountDeletion) (models.AccountDeletion, error) {
+	log.Debugf("Going to remove user %s", deletion.UserID)
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var tweetIDs []int64
+	err = t.tx.Select(&tweetIDs, "SELECT DISTINCT m.tweet_id FROM subscription_state_tweet_m2m m "+
+		"INNER JOIN subscription_state ss ON ss.id = m.subscription_state_id "+
+		"INNER JOIN subscription s ON s.id = ss.subscription_id WHERE s.user_id = $1", deletion.UserID)
 	if err != nil {
-		log.Error(err.Error() + fmt.Sprintf(" removing user: %s", userID))
-		return t.getError()
+		return deletion, t.getError()
+	}
+
+	var subscriptionUserIDs []int64
+	err = t.tx.Select(&subscriptionUserIDs, "SELECT DISTINCT m.user_id FROM subscription_user_m2m m "+
+		"INNER JOIN subscription s ON s.id = m.subscription_id WHERE s.user_id = $1", deletion.UserID)
+	if err != nil {
+		return deletion, t.getError()
+	}
+
+	_, err = t.tx.Exec("DELETE FROM user_account WHERE id = $1", deletion.UserID)
+	if err != nil {
+		log.Error(err.Error() + fmt.Sprintf(" removing user: %s", deletion.UserID))
+		return deletion, t.getError()
+	}
+
+	res, err := t.tx.Exec("DELETE FROM tweet t WHERE t.id = ANY($1) "+
+		"AND NOT EXISTS (SELECT 1 FROM subscription_state_tweet_m2m m WHERE m.tweet_id = t.id)", pq.Array(tweetIDs))
+	if err != nil {
+		return deletion, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return deletion, t.getError()
+	}
+	deletion.TweetsRemoved = uint(n)
+
+	res, err = t.tx.Exec("DELETE FROM subscription_user u WHERE u.id = ANY($1) "+
+		"AND NOT EXISTS (SELECT 1 FROM subscription_user_m2m m WHERE m.user_id = u.id) "+
+		"AND NOT EXISTS (SELECT 1 FROM subscription_user_state st WHERE st.user_twitter_id = u.twitter_id)", pq.Array(subscriptionUserIDs))
+	if err != nil {
+		return deletion, t.getError()
 	}
-	return t.getError()
 
+	n, err = res.RowsAffected()
+	if err != nil {
+		return deletion, t.getError()
+	}
+	deletion.SubscriptionUsersRemoved = uint(n)
+
+	rows, err := t.tx.NamedQuery(
+		"INSERT INTO account_deletion (user_id, tokens_revoked, tokens_failed, tweets_removed, subscription_users_removed) "+
+			"VALUES (:user_id, :tokens_revoked, :tokens_failed, :tweets_removed, :subscription_users_removed) RETURNING id, deleted_at", deletion)
+	if err != nil {
+		return deletion, t.getError()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.Scan(&deletion.ID, &deletion.DeletedAt)
+		if err != nil {
+			return deletion, t.getError()
+		}
+	}
+
+	return deletion, t.getError()
+}
+
+// UpdateAccountDeletion records tokens invalidated after the user was removed
+func (d *UserDatastore) UpdateAccountDeletion(ctx context.Context, deletion models.AccountDeletion) (models.AccountDeletion, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	_, err = t.tx.NamedExec(
+		"UPDATE account_deletion SET tokens_revoked = :tokens_revoked, tokens_failed = :tokens_failed WHERE id = :id", deletion)
+	return deletion, t.getError()
 }
 
+const twitterUserColumns = "user_id, social_account_id, access_token, token_secret, profile_image_url, screen_name, is_primary, key_id, data_key"
+
 func (d *UserDatastore) GetTwitterUserByID(ctx context.Context, twitterUserID string) (models.TwitterUser, error) {
 	var err error
 	t := getTransaction(ctx, d.DB, &err)
@@ -157,9 +244,15 @@ func (d *UserDatastore) GetTwitterUserByID(ctx context.Context, twitterUserID st
 		t.commitOrRollback()
 	}()
 
-	var user models.TwitterUser
+	var row twitterUserRow
 	err = t.tx.Get(
-		&user, "SELECT user_id, social_account_id, access_token, token_secret, profile_image_url FROM tw_account WHERE social_account_id=$1", twitterUserID)
+		&row, "SELECT "+twitterUserColumns+" FROM tw_account WHERE social_account_id=$1", twitterUserID)
+	if err != nil {
+		return models.TwitterUser{}, t.getError()
+	}
+
+	var user models.TwitterUser
+	user, err = d.Keyring.decrypt(row)
 	return user, t.getError()
 
 }
@@ -172,7 +265,14 @@ func (d *UserDatastore) InsertTwitterUser(ctx context.Context, twitterUser model
 		t.commitOrRollback()
 	}()
 
-	_, err = t.tx.NamedExec("INSERT INTO tw_account (user_id, social_account_id, access_token, token_secret, profile_image_url) VALUES (:user_id, :social_account_id, :access_token, :token_secret, :profile_image_url)", twitterUser)
+	var row twitterUserRow
+	row, err = d.Keyring.encrypt(twitterUser)
+	if err != nil {
+		return models.TwitterUser{}, t.getError()
+	}
+
+	_, err = t.tx.NamedExec("INSERT INTO tw_account (user_id, social_account_id, access_token, token_secret, profile_image_url, screen_name, is_primary, key_id, data_key) "+
+		"VALUES (:user_id, :social_account_id, :access_token, :token_secret, :profile_image_url, :screen_name, :is_primary, :key_id, :data_key)", row)
 	if err != nil {
 		log.Error(err.Error() + fmt.Sprintf(" inserting twitterUser: %s", twitterUser))
 		return models.TwitterUser{}, t.getError()
@@ -189,7 +289,14 @@ func (d *UserDatastore) UpdateTwitterUser(ctx context.Context, twitterUser model
 	defer func() {
 		t.commitOrRollback()
 	}()
-	_, err = t.tx.NamedExec("UPDATE tw_account SET access_token=:access_token, token_secret=:token_secret, profile_image_url=:profile_image_url WHERE user_id = :user_id", twitterUser)
+	var row twitterUserRow
+	row, err = d.Keyring.encrypt(twitterUser)
+	if err != nil {
+		return models.TwitterUser{}, t.getError()
+	}
+
+	_, err = t.tx.NamedExec("UPDATE tw_account SET access_token=:access_token, token_secret=:token_secret, profile_image_url=:profile_image_url, screen_name=:screen_name, "+
+		"key_id=:key_id, data_key=:data_key WHERE social_account_id = :social_account_id AND user_id = :user_id", row)
 	if err != nil {
 		log.Error(err.Error() + fmt.Sprintf(" uptating twitterUser: %s", twitterUser))
 		return models.TwitterUser{}, t.getError()
@@ -197,6 +304,7 @@ func (d *UserDatastore) UpdateTwitterUser(ctx context.Context, twitterUser model
 	return twitterUser, t.getError()
 }
 
+// GetTwitterUser returns the primary twitter account of the user
 func (d *UserDatastore) GetTwitterUser(ctx context.Context, userID uuid.UUID) (models.TwitterUser, error) {
 	var err error
 	t := getTransaction(ctx, d.DB, &err)
@@ -205,12 +313,143 @@ func (d *UserDatastore) GetTwitterUser(ctx context.Context, userID uuid.UUID) (m
 		t.commitOrRollback()
 	}()
 
+	var row twitterUserRow
+	err = t.tx.Get(&row, "SELECT "+twitterUserColumns+" FROM tw_account WHERE user_id = $1 AND is_primary", userID)
+	if err != nil {
+		return models.TwitterUser{}, t.getError()
+	}
+
 	var twitterUser models.TwitterUser
-	err = t.tx.Get(&twitterUser, "SELECT user_id, social_account_id, access_token, token_secret, profile_image_url FROM tw_account WHERE user_id = $1", userID)
+	twitterUser, err = d.Keyring.decrypt(row)
 	return twitterUser, t.getError()
 
 }
 
+// GetTwitterUsers returns all twitter accounts linked to the user, the primary one is the first
+func (d *UserDatastore) GetTwitterUsers(ctx context.Context, userID uuid.UUID) ([]models.TwitterUser, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var rows []twitterUserRow
+	err = t.tx.Select(&rows, "SELECT "+twitterUserColumns+" FROM tw_account WHERE user_id = $1 ORDER BY is_primary DESC, created_at", userID)
+	if err != nil {
+		return nil, t.getError()
+	}
+
+	res := make([]models.TwitterUser, 0, len(rows))
+	for _, row := range rows {
+		var twitterUser models.TwitterUser
+		twitterUser, err = d.Keyring.decrypt(row)
+		if err != nil {
+			return nil, t.getError()
+		}
+		res = append(res, twitterUser)
+	}
+	return res, t.getError()
+}
+
+// rotatedKeysCondition selects rows encrypted with known keys but the current one
+const rotatedKeysCondition = "key_id <> $1 AND (key_id = '' OR key_id = ANY($2))"
+
+// CountTwitterUsersToRotate returns the number of rows encrypted with known keys but the current one, locked rows are counted too
+func (d *UserDatastore) CountTwitterUsersToRotate(ctx context.Context) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	if d.Keyring == nil {
+		err = fmt.Errorf("Token keys are not configured")
+		return 0, t.getError()
+	}
+
+	var count uint
+	err = t.tx.Get(&count, "SELECT COUNT(*) FROM tw_account WHERE "+rotatedKeysCondition, d.Keyring.CurrentKeyID(), pq.Array(d.Keyring.keyIDs()))
+	return count, t.getError()
+}
+
+// RotateTwitterUserKeys re-encrypts up to limit rows which are not encrypted with the current key
+// and returns the number of rotated rows. Locked rows are skipped, so rotation doesn't block sign-ins.
+func (d *UserDatastore) RotateTwitterUserKeys(ctx context.Context, limit uint) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	if d.Keyring == nil {
+		err = fmt.Errorf("Token keys are not configured")
+		return 0, t.getError()
+	}
+
+	// rows encrypted with keys which aren't configured can't be rotated, they are skipped so they don't stop the rotation
+	knownKeys := pq.Array(d.Keyring.keyIDs())
+	var rows []twitterUserRow
+	err = t.tx.Select(&rows, "SELECT "+twitterUserColumns+" FROM tw_account WHERE "+rotatedKeysCondition+" "+
+		"ORDER BY social_account_id LIMIT $3 FOR UPDATE SKIP LOCKED", d.Keyring.CurrentKeyID(), knownKeys, limit)
+	if err != nil {
+		return 0, t.getError()
+	}
+
+	if len(rows) == 0 {
+		var unknown uint
+		err = t.tx.Get(&unknown, "SELECT COUNT(*) FROM tw_account WHERE key_id <> '' AND NOT key_id = ANY($1)", knownKeys)
+		if err != nil {
+			return 0, t.getError()
+		}
+
+		if unknown > 0 {
+			log.Warningf("Tokens of %d twitter accounts are encrypted with not configured keys, they are not rotated", unknown)
+		}
+		return 0, t.getError()
+	}
+
+	for _, row := range rows {
+		row, err = d.Keyring.rotate(row)
+		if err != nil {
+			log.Errorf("Can not rotate key of %s, got error %s", row.TwitterUser, err)
+			return 0, t.getError()
+		}
+
+		_, err = t.tx.NamedExec("UPDATE tw_account SET access_token=:access_token, token_secret=:token_secret, key_id=:key_id, data_key=:data_key "+
+			"WHERE social_account_id = :social_account_id", row)
+		if err != nil {
+			return 0, t.getError()
+		}
+	}
+	return uint(len(rows)), t.getError()
+}
+
+// UnlinkTwitterUser removes not primary twitter account, subscriptions using the account fall back to the primary one
+func (d *UserDatastore) UnlinkTwitterUser(ctx context.Context, userID uuid.UUID, twitterID string) (bool, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	_, err = t.tx.Exec("UPDATE subscription SET twitter_account_id = '' WHERE user_id = $1 AND twitter_account_id = $2", userID, twitterID)
+	if err != nil {
+		return false, t.getError()
+	}
+
+	res, err := t.tx.Exec("DELETE FROM tw_account WHERE user_id = $1 AND social_account_id = $2 AND NOT is_primary", userID, twitterID)
+	if err != nil {
+		return false, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, t.getError()
+}
+
 func (d *UserDatastore) InsertSubscription(ctx context.Context, subscription models.Subscription) (models.Subscription, error) {
 	var err error
 	t := getTransaction(ctx, d.DB, &err)
@@ -220,7 +459,7 @@ func (d *UserDatastore) InsertSubscription(ctx context.Context, subscription mod
 	}()
 
 	tx := t.tx
-	res, err := tx.NamedQuery("INSERT INTO subscription (user_id, title, email, day, ignore_rt, ignore_replies) VALUES (:user_id, :title, :email, :day, :ignore_rt, :ignore_replies) RETURNING id", subscription)
+	res, err := tx.NamedQuery("INSERT INTO subscription (user_id, title, email, day, ignore_rt, ignore_replies, ereader_email, paused, twitter_list_id, twitter_account_id) VALUES (:user_id, :title, :email, :day, :ignore_rt, :ignore_replies, :ereader_email, :paused, :twitter_list_id, :twitter_account_id) RETURNING id", subscription)
 	if err != nil {
 		log.Error(err.Error() + fmt.Sprintf(" inserting subscription: %s", subscription))
 		return models.Subscription{}, t.getError()
@@ -259,10 +498,13 @@ func (d *UserDatastore) GetSubscriptions(ctx context.Context, userID uuid.UUID)
 	}
 
 	rows, err := t.tx.Queryx(
-		"SELECT s.id AS subscription_id, s.user_id, s.title, s.email, s.day, s.ignore_rt, s.ignore_replies, u.id, u.name, u.twitter_id, u.screen_name, u.profile_image_url "+
+		"SELECT s.id AS subscription_id, s.user_id, s.title, s.email, s.day, s.ignore_rt, s.ignore_replies, s.ereader_email, s.paused, s.twitter_list_id, s.twitter_account_id, COALESCE(e.status::text, '') AS email_status, "+
+			"COALESCE(u.id, 0) AS id, COALESCE(u.name, '') AS name, COALESCE(u.twitter_id, '') AS twitter_id, "+
+			"COALESCE(u.screen_name, '') AS screen_name, COALESCE(u.profile_image_url, '') AS profile_image_url "+
 			"FROM subscription s "+
-			"INNER JOIN subscription_user_m2m m2m ON m2m.subscription_id = s.id "+
-			"INNER JOIN subscription_user u ON u.id = m2m.user_id "+
+			"LEFT JOIN subscription_user_m2m m2m ON m2m.subscription_id = s.id "+
+			"LEFT JOIN subscription_user u ON u.id = m2m.user_id "+
+			"LEFT JOIN user_email_m2m e ON e.email = s.email "+
 			"WHERE s.user_id = $1 "+
 			"ORDER BY s.updated_at DESC", userID)
 
@@ -278,13 +520,18 @@ func (d *UserDatastore) GetSubscriptions(ctx context.Context, userID uuid.UUID)
 		err = rows.StructScan(&row)
 
 		s := models.Subscription{
-			ID:            row.SubscriptionID,
-			Title:         row.Title,
-			Email:         row.Email,
-			Day:           row.Day,
-			UserID:        row.UserID,
-			IgnoreRT:      row.IgnoreRT,
-			IgnoreReplies: row.IgnoreReplies,
+			ID:               row.SubscriptionID,
+			Title:            row.Title,
+			Email:            row.Email,
+			Day:              row.Day,
+			UserID:           row.UserID,
+			IgnoreRT:         row.IgnoreRT,
+			IgnoreReplies:    row.IgnoreReplies,
+			EreaderEmail:     row.EreaderEmail,
+			Paused:           row.Paused,
+			EmailStatus:      row.EmailStatus,
+			TwitterListID:    row.TwitterListID,
+			TwitterAccountID: row.TwitterAccountID,
 		}
 		u := models.TwitterUserSearchResult{
 			TwitterID:     row.TwitterID,
@@ -292,12 +539,15 @@ func (d *UserDatastore) GetSubscriptions(ctx context.Context, userID uuid.UUID)
 			ProfileIMGURL: row.ProfileIMGURL,
 			ScreenName:    row.ScreenName,
 		}
+		// subscriptions backed by a twitter list have no users until the list is synced
 		processedSubscription, ok := processed[s.ID]
 		if ok {
 			processedSubscription.UserList = append(processedSubscription.UserList, u)
 			processed[s.ID] = processedSubscription
 		} else {
-			s.UserList = append(s.UserList, u)
+			if u.TwitterID != "" {
+				s.UserList = append(s.UserList, u)
+			}
 			processed[s.ID] = s
 			processedKeys = append(processedKeys, s.ID)
 		}
@@ -321,7 +571,7 @@ func (d *UserDatastore) GetSubscription(ctx context.Context, subscriptionID uuid
 
 	var subscription models.Subscription
 
-	err = t.tx.Get(&subscription, "SELECT id, user_id, title, email, day, ignore_rt, ignore_replies FROM subscription WHERE id=$1", subscriptionID)
+	err = t.tx.Get(&subscription, "SELECT id, user_id, title, email, day, ignore_rt, ignore_replies, ereader_email, paused, twitter_list_id, twitter_account_id FROM subscription WHERE id=$1", subscriptionID)
 
 	if err != nil {
 		return subscription, t.getError()
@@ -370,7 +620,7 @@ func (d *UserDatastore) UpdateSubscription(ctx context.Context, subscription mod
 	}
 
 	tx := t.tx
-	_, err = tx.NamedExec("UPDATE subscription SET title=:title, email=:email, day=:day, ignore_rt=:ignore_rt, ignore_replies=:ignore_replies WHERE id = :id", subscription)
+	_, err = tx.NamedExec("UPDATE subscription SET title=:title, email=:email, day=:day, ignore_rt=:ignore_rt, ignore_replies=:ignore_replies, ereader_email=:ereader_email, paused=:paused, twitter_list_id=:twitter_list_id, twitter_account_id=:twitter_account_id WHERE id = :id", subscription)
 	if err != nil {
 		return subscription, t.getError()
 	}
@@ -500,6 +750,15 @@ func (d *UserDatastore) UpdateSubscriptionUserState(ctx context.Context, subscri
 }
 
 func (d *UserDatastore) UpdateSubscriptionUserStateTweets(ctx context.Context) error {
+	return d.updateSubscriptionUserStateTweets(ctx, "st.created_at::DATE = NOW()::DATE")
+}
+
+// UpdateIssueUserStateTweets moves the subscription users' last tweets to the newest tweets of the sent issue
+func (d *UserDatastore) UpdateIssueUserStateTweets(ctx context.Context, subscriptionStateID uint) error {
+	return d.updateSubscriptionUserStateTweets(ctx, "st.id = $1", subscriptionStateID)
+}
+
+func (d *UserDatastore) updateSubscriptionUserStateTweets(ctx context.Context, cond string, args ...interface{}) error {
 	var err error
 	t := getTransaction(ctx, d.DB, &err)
 
@@ -507,12 +766,12 @@ func (d *UserDatastore) UpdateSubscriptionUserStateTweets(ctx context.Context) e
 		t.commitOrRollback()
 	}()
 
-	rows, err := t.tx.Queryx("SELECT t.tweet->>'user_id' AS user_id, st.subscription_id, MAX(t.tweet_id::BIGINT) AS tweet_id " +
-		"FROM subscription_state st " +
-		"INNER JOIN subscription_state_tweet_m2m m ON m.subscription_state_id = st.id " +
-		"INNER JOIN tweet t ON t.id = m.tweet_id " +
-		"WHERE st.status = 'SENT' AND st.created_at::DATE = NOW()::DATE " +
-		"GROUP  BY t.tweet->>'user_id', subscription_id")
+	rows, err := t.tx.Queryx("SELECT t.tweet->>'user_id' AS user_id, st.subscription_id, MAX(t.tweet_id::BIGINT) AS tweet_id "+
+		"FROM subscription_state st "+
+		"INNER JOIN subscription_state_tweet_m2m m ON m.subscription_state_id = st.id "+
+		"INNER JOIN tweet t ON t.id = m.tweet_id "+
+		"WHERE st.status = 'SENT' AND "+cond+" "+
+		"GROUP  BY t.tweet->>'user_id', subscription_id", args...)
 
 	if err != nil {
 		return err
@@ -555,10 +814,13 @@ func (d *UserDatastore) GetTodaySubscriptionsIDs(ctx context.Context) ([]uuid.UU
 
 	rows, err := t.tx.Queryx("WITH t AS " +
 		"(SELECT subscription_id FROM subscription_state st " +
-		"WHERE st.created_at::DATE = NOW()::DATE) " +
+		"WHERE st.created_at::DATE = NOW()::DATE AND NOT st.on_demand) " +
 		"SELECT s.id FROM subscription s " +
+		"INNER JOIN user_account u ON u.id = s.user_id " +
 		"LEFT JOIN t ON s.id = t.subscription_id " +
-		"WHERE s.day = get_day_of_week(NOW()) " +
+		"WHERE s.day = get_day_of_week(NOW()) AND NOT s.paused " +
+		"AND NOT COALESCE(NOW()::DATE BETWEEN u.vacation_start AND u.vacation_end, FALSE) " +
+		"AND u.disabled_at IS NULL " +
 		"GROUP BY s.id HAVING count(t.*) = 0",
 	)
 
@@ -590,7 +852,7 @@ func (d *UserDatastore) UpdateSubscriptionState(ctx context.Context, state model
 	}()
 
 	_, err = t.tx.NamedExec(
-		"UPDATE subscription_state SET status = (:status) WHERE id = :id ", state)
+		"UPDATE subscription_state SET status = (:status), tweet_count = :tweet_count, failure_reason = :failure_reason, sent_at = :sent_at, share_token = :share_token WHERE id = :id ", state)
 	if err != nil {
 		return state, err
 	}
@@ -598,6 +860,19 @@ func (d *UserDatastore) UpdateSubscriptionState(ctx context.Context, state model
 	return state, err
 }
 
+func (d *UserDatastore) GetSubscriptionState(ctx context.Context, subscriptionStateID uint) (models.SubscriptionState, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var state models.SubscriptionState
+	err = t.tx.Get(&state, "SELECT id, subscription_id, status, tweet_count, failure_reason, created_at, updated_at, sent_at, share_token, on_demand FROM subscription_state WHERE id=$1", subscriptionStateID)
+	return state, t.getError()
+}
+
 func (d *UserDatastore) InsertSubscriptionState(ctx context.Context, state models.SubscriptionState) (models.SubscriptionState, error) {
 	var err error
 	t := getTransaction(ctx, d.DB, &err)
@@ -607,7 +882,7 @@ func (d *UserDatastore) InsertSubscriptionState(ctx context.Context, state model
 	}()
 
 	res, err := t.tx.NamedQuery(
-		"INSERT INTO subscription_state (subscription_id, status) VALUES (:subscription_id, :status) RETURNING id", state)
+		"INSERT INTO subscription_state (subscription_id, status, on_demand) VALUES (:subscription_id, :status, :on_demand) RETURNING id", state)
 	if err != nil {
 		return state, err
 	}
@@ -622,7 +897,7 @@ func (d *UserDatastore) InsertSubscriptionState(ctx context.Context, state model
 	}
 
 	var fromDB models.SubscriptionState
-	err = t.tx.Get(&fromDB, "SELECT id, subscription_id, status, created_at, updated_at FROM subscription_state WHERE id=$1", id)
+	err = t.tx.Get(&fromDB, "SELECT id, subscription_id, status, tweet_count, failure_reason, created_at, updated_at, sent_at, share_token, on_demand FROM subscription_state WHERE id=$1", id)
 	if err != nil {
 		return state, err
 	}
@@ -748,7 +1023,7 @@ func (d *UserDatastore) GetReadySubscriptionsStates(ctx context.Context, subscri
 
 	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
-	q := psql.Select("id, subscription_id, status, created_at, updated_at FROM subscription_state").
+	q := psql.Select("id, subscription_id, status, tweet_count, failure_reason, created_at, updated_at, sent_at, share_token, on_demand FROM subscription_state").
 		Where("status = 'READY'")
 
 	if len(subscriptionIDs) > 0 {
@@ -787,6 +1062,64 @@ func (d *UserDatastore) GetReadySubscriptionsStates(ctx context.Context, subscri
 	return res, t.getError()
 }
 
+func (d *UserDatastore) GetSubscriptionStates(ctx context.Context, subscriptionID uuid.UUID, limit, offset uint) ([]models.SubscriptionState, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res := make([]models.SubscriptionState, 0)
+	err = t.tx.Select(&res,
+		"SELECT id, subscription_id, status, tweet_count, failure_reason, created_at, updated_at, sent_at, share_token, on_demand FROM subscription_state "+
+			"WHERE subscription_id = $1 "+
+			"ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3", subscriptionID, limit, offset)
+
+	return res, t.getError()
+}
+
+func (d *UserDatastore) GetSubscriptionStateByShareToken(ctx context.Context, shareToken string) (models.SubscriptionState, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var state models.SubscriptionState
+	err = t.tx.Get(&state, "SELECT id, subscription_id, status, tweet_count, failure_reason, created_at, updated_at, sent_at, share_token, on_demand FROM subscription_state WHERE share_token <> '' AND share_token=$1", shareToken)
+	return state, t.getError()
+}
+
+func (d *UserDatastore) CountSubscriptionStates(ctx context.Context, subscriptionID uuid.UUID) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var count uint
+	err = t.tx.Get(&count, "SELECT COUNT(*) FROM subscription_state WHERE subscription_id = $1", subscriptionID)
+	return count, t.getError()
+}
+
+func (d *UserDatastore) CountOnDemandSubscriptionStates(ctx context.Context, userID uuid.UUID, since time.Time) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var count uint
+	err = t.tx.Get(&count, "SELECT COUNT(*) FROM subscription_state st "+
+		"INNER JOIN subscription s ON s.id = st.subscription_id "+
+		"WHERE s.user_id = $1 AND st.on_demand AND st.created_at >= $2", userID, since)
+	return count, t.getError()
+}
+
 func (d *UserDatastore) GetSubscriptionTweets(ctx context.Context, subscriptionStateID uint) ([]models.Tweet, error) {
 	var err error
 	t := getTransaction(ctx, d.DB, &err)
@@ -833,6 +1166,31 @@ func (d *UserDatastore) AcquireLock(ctx context.Context, key uint) (bool, error)
 	return res, err
 }
 
+// HoldLock takes the lock on a connection reserved till the returned unlock is called,
+// so the lock is released by the same session in a long running process
+func (d *UserDatastore) HoldLock(ctx context.Context, key uint) (func(), bool, error) {
+	conn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, getDbError(err)
+	}
+
+	var res bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&res)
+	if err != nil || !res {
+		conn.Close()
+		return nil, false, getDbError(err)
+	}
+
+	unlock := func() {
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		if err != nil {
+			log.Errorf("Can not release lock %d, got error %s", key, err)
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
 func (d *UserDatastore) ReleaseLock(ctx context.Context, key uint) (bool, error) {
 	var err error
 	t := getTransaction(ctx, d.DB, &err)
@@ -872,7 +1230,7 @@ func (d *UserDatastore) GetUserEmail(ctx context.Context, userEmail models.UserE
 	}()
 
 	var email models.UserEmail
-	err = t.tx.Get(&email, "SELECT user_id, email, status FROM user_email_m2m WHERE email=$1", userEmail.Email)
+	err = t.tx.Get(&email, "SELECT "+userEmailColumns+" FROM user_email_m2m WHERE email=$1", userEmail.Email)
 	return email, t.getError()
 }
 
@@ -900,7 +1258,7 @@ func (d *UserDatastore) GetUserEmails(ctx context.Context, status string) ([]mod
 		t.commitOrRollback()
 	}()
 
-	rows, err := t.tx.Queryx("SELECT user_id, email, status FROM user_email_m2m WHERE status=$1", status)
+	rows, err := t.tx.Queryx("SELECT "+userEmailColumns+" FROM user_email_m2m WHERE status=$1", status)
 
 	emails := make([]models.UserEmail, 0)
 	for rows.Next() {
@@ -916,6 +1274,19 @@ func (d *UserDatastore) GetUserEmails(ctx context.Context, status string) ([]mod
 	return emails, t.getError()
 }
 
+func (d *UserDatastore) GetUserEmailsByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserEmail, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	emails := make([]models.UserEmail, 0)
+	err = t.tx.Select(&emails, "SELECT "+userEmailColumns+" FROM user_email_m2m WHERE user_id=$1 ORDER BY email", userID)
+	return emails, t.getError()
+}
+
 func (d *UserDatastore) RemoveOldTweets(ctx context.Context, tweetTTL int) error {
 	log.Debugf("Going to remove tweets older than %d", tweetTTL)
 	var err error
@@ -28,7 +28,7 @@ func runTests(tests map[string]testFunc, t *testing.T) {
 	}
 	defer db.Close()
 
-	d := NewUserDatastore(db)
+	d := NewUserDatastore(db, nil)
 
 	for name, fn := range tests {
 		tx := db.MustBegin()
@@ -67,6 +67,41 @@ func insertUserAndSubscription(d *UserDatastore, ctx context.Context) (models.Us
 	return u, fromDb, err
 }
 
+func testRemoveUserGarbageCollects(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, s, err := insertUserAndSubscription(d, ctx)
+	assert.NoError(t, err)
+
+	// another user reads one of the accounts, so it must be kept
+	other, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+	_, err = d.InsertSubscription(ctx, models.Subscription{
+		UserID:   other.ID,
+		Title:    "other",
+		Email:    "other@mail.com",
+		Day:      "monday",
+		UserList: []models.TwitterUserSearchResult{{TwitterID: "121", Name: "foo", ProfileIMGURL: "some_url", ScreenName: "foo_name"}},
+	})
+	assert.NoError(t, err)
+
+	state, err := d.InsertSubscriptionState(ctx, models.SubscriptionState{SubscriptionID: s.ID, Status: "PREPARING"})
+	assert.NoError(t, err)
+	_, err = d.InsertTweet(ctx, models.Tweet{TweetID: "1001", Tweet: models.TweetAttrs{IdStr: "1001", UserId: "322"}}, state.ID)
+	assert.NoError(t, err)
+
+	deletion, err := d.RemoveUser(ctx, models.AccountDeletion{UserID: u.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), deletion.TweetsRemoved)
+	assert.Equal(t, uint(1), deletion.SubscriptionUsersRemoved)
+
+	var count int
+	assert.NoError(t, tx.Get(&count, "SELECT COUNT(*) FROM subscription_user WHERE twitter_id IN ('121', '322')"))
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, tx.Get(&count, "SELECT COUNT(*) FROM tweet WHERE tweet_id = '1001'"))
+	assert.Equal(t, 0, count)
+}
+
 func testGetUser(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
 	uid := uuid.New()
 	ctx := context.WithValue(context.Background(), "Tx", tx)
@@ -142,6 +177,7 @@ func testInsertAndUpdateTwitterUser(t *testing.T, tx *sqlx.Tx, d *UserDatastore)
 		TwitterID:   "111",
 		AccessToken: "some-token",
 		TokenSecret: "some-secret",
+		Primary:     true,
 	}
 
 	res, err := d.InsertTwitterUser(ctx, twitterUser)
@@ -174,8 +210,18 @@ func testRemoveUser(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
 	_, err = d.InsertTwitterUser(ctx, twitterUser)
 	assert.NoError(t, err)
 
-	err = d.RemoveUser(ctx, u.ID)
+	deletion, err := d.RemoveUser(ctx, models.AccountDeletion{UserID: u.ID})
 	assert.NoError(t, err)
+	assert.NotEqual(t, uint(0), deletion.ID)
+
+	deletion.TokensRevoked = 1
+	_, err = d.UpdateAccountDeletion(ctx, deletion)
+	assert.NoError(t, err)
+
+	var revoked uint
+	err = tx.Get(&revoked, "SELECT tokens_revoked FROM account_deletion WHERE id = $1", deletion.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), revoked)
 
 	_, err = d.GetUser(ctx, u.ID)
 	assert.Error(t, err)
@@ -341,6 +387,119 @@ func testInsertSubscriptionState(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
 	assert.NotEmpty(t, state.CreatedAt)
 }
 
+func testGetSubscriptionStates(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	_, s, err := insertUserAndSubscription(d, ctx)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = d.InsertSubscriptionState(ctx, models.SubscriptionState{SubscriptionID: s.ID, Status: "PREPARING"})
+		assert.NoError(t, err)
+	}
+
+	state, err := d.InsertSubscriptionState(ctx, models.SubscriptionState{SubscriptionID: s.ID, Status: "PREPARING"})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	state.Status = "SENT"
+	state.TweetCount = 10
+	state.SentAt = &now
+	_, err = d.UpdateSubscriptionState(ctx, state)
+	assert.NoError(t, err)
+
+	count, err := d.CountSubscriptionStates(ctx, s.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), count)
+
+	states, err := d.GetSubscriptionStates(ctx, s.ID, 2, 0)
+	assert.NoError(t, err)
+	assert.Len(t, states, 2)
+	assert.Equal(t, state.ID, states[0].ID)
+	assert.Equal(t, uint(10), states[0].TweetCount)
+	assert.NotNil(t, states[0].SentAt)
+	assert.Nil(t, states[1].SentAt)
+
+	states, err = d.GetSubscriptionStates(ctx, s.ID, 2, 3)
+	assert.NoError(t, err)
+	assert.Len(t, states, 1)
+}
+
+func testUpdateUserVacation(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+
+	start := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 13)
+	err = d.UpdateUserVacation(ctx, u.ID, &start, &end)
+	assert.NoError(t, err)
+
+	u, err = d.GetUser(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-07-01", u.VacationStart.Format("2006-01-02"))
+	assert.Equal(t, "2020-07-14", u.VacationEnd.Format("2006-01-02"))
+
+	err = d.UpdateUserVacation(ctx, u.ID, nil, nil)
+	assert.NoError(t, err)
+
+	u, err = d.GetUser(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, u.VacationStart)
+	assert.Nil(t, u.VacationEnd)
+}
+
+func testAPITokens(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+
+	token, err := d.InsertAPIToken(ctx, models.APIToken{UserID: u.ID, Name: "script", TokenHash: "hash", Scope: models.APITokenScopeRead})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uint(0), token.ID)
+	assert.Nil(t, token.LastUsedAt)
+
+	err = d.UpdateAPITokenLastUsed(ctx, token.ID)
+	assert.NoError(t, err)
+
+	fromDB, err := d.GetAPITokenByHash(ctx, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, "script", fromDB.Name)
+	assert.NotNil(t, fromDB.LastUsedAt)
+
+	revoked, err := d.RevokeAPIToken(ctx, uuid.New(), token.ID)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = d.RevokeAPIToken(ctx, u.ID, token.ID)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	tokens, err := d.GetAPITokens(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 0)
+}
+
+func testCountOnDemandSubscriptionStates(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, s, err := insertUserAndSubscription(d, ctx)
+	assert.NoError(t, err)
+
+	_, err = d.InsertSubscriptionState(ctx, models.SubscriptionState{SubscriptionID: s.ID, Status: "PREPARING"})
+	assert.NoError(t, err)
+
+	state, err := d.InsertSubscriptionState(ctx, models.SubscriptionState{SubscriptionID: s.ID, Status: "PREPARING", OnDemand: true})
+	assert.NoError(t, err)
+	assert.True(t, state.OnDemand)
+
+	count, err := d.CountOnDemandSubscriptionStates(ctx, u.ID, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), count)
+
+	count, err = d.CountOnDemandSubscriptionStates(ctx, u.ID, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), count)
+}
+
 func testGetSubscriptionUserTweets(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
 	ctx := context.WithValue(context.Background(), "Tx", tx)
 	_, s, err := insertUserAndSubscription(d, ctx)
@@ -391,20 +550,438 @@ func testInsertUserEmail(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
 	assert.Len(t, emails, 1)
 }
 
+func testSubscriptionListChanges(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	_, s, err := insertUserAndSubscription(d, ctx)
+	assert.NoError(t, err)
+
+	s.TwitterListID = "42"
+	s, err = d.UpdateSubscription(ctx, s)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", s.TwitterListID)
+
+	changes := []models.SubscriptionListChange{
+		models.SubscriptionListChange{SubscriptionID: s.ID, TwitterListID: "42", TwitterID: "1", ScreenName: "first", Kind: models.ListChangeAdded},
+		models.SubscriptionListChange{SubscriptionID: s.ID, TwitterListID: "42", TwitterID: "2", ScreenName: "second", Kind: models.ListChangeRemoved},
+	}
+	err = d.InsertSubscriptionListChanges(ctx, changes)
+	assert.NoError(t, err)
+
+	res, err := d.GetSubscriptionListChanges(ctx, s.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+
+	res, err = d.GetSubscriptionListChanges(ctx, s.ID, 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+}
+
+func testMagicLinks(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+
+	link := models.MagicLink{UserID: u.ID, Email: "test@example.com", NonceHash: "hash", ExpiresAt: time.Now().Add(time.Minute)}
+	res, err := d.InsertMagicLink(ctx, link)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uint(0), res.ID)
+
+	_, err = d.InsertMagicLink(ctx, models.MagicLink{UserID: u.ID, Email: link.Email, NonceHash: "expired", ExpiresAt: time.Now().Add(-time.Minute)})
+	assert.NoError(t, err)
+
+	err = d.InsertMagicLinkRequest(ctx, link.Email, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	err = d.InsertMagicLinkRequest(ctx, "Unknown@example.com", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+
+	count, err := d.CountMagicLinkRequests(ctx, link.Email, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), count)
+
+	count, err = d.CountMagicLinkRequests(ctx, "unknown@example.com", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), count)
+
+	used, err := d.UseMagicLink(ctx, "hash")
+	assert.NoError(t, err)
+	assert.NotNil(t, used.UsedAt)
+
+	_, err = d.UseMagicLink(ctx, "hash")
+	assert.True(t, err.(*DbError).HasNoRows())
+
+	_, err = d.UseMagicLink(ctx, "expired")
+	assert.True(t, err.(*DbError).HasNoRows())
+}
+
+func testLinkedTwitterUsers(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+
+	primary := models.TwitterUser{UserID: u.ID, TwitterID: "111", ScreenName: "first", Primary: true}
+	_, err = d.InsertTwitterUser(ctx, primary)
+	assert.NoError(t, err)
+
+	linked := models.TwitterUser{UserID: u.ID, TwitterID: "222", ScreenName: "second"}
+	_, err = d.InsertTwitterUser(ctx, linked)
+	assert.NoError(t, err)
+
+	accounts, err := d.GetTwitterUsers(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TwitterUser{primary, linked}, accounts)
+
+	fromDb, err := d.GetTwitterUser(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, primary, fromDb)
+
+	s, err := d.InsertSubscription(ctx, models.Subscription{
+		UserID:           u.ID,
+		Title:            "protected",
+		Email:            "test@example.com",
+		Day:              "monday",
+		TwitterAccountID: linked.TwitterID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, linked.TwitterID, s.TwitterAccountID)
+
+	unlinked, err := d.UnlinkTwitterUser(ctx, u.ID, primary.TwitterID)
+	assert.NoError(t, err)
+	assert.False(t, unlinked)
+
+	unlinked, err = d.UnlinkTwitterUser(ctx, u.ID, linked.TwitterID)
+	assert.NoError(t, err)
+	assert.True(t, unlinked)
+
+	s, err = d.GetSubscription(ctx, s.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "", s.TwitterAccountID)
+
+	accounts, err = d.GetTwitterUsers(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+}
+
+func testRotateTwitterUserKeys(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+
+	twitterUser := models.TwitterUser{UserID: u.ID, TwitterID: "111", AccessToken: "token", TokenSecret: "secret", Primary: true}
+	_, err = d.InsertTwitterUser(ctx, twitterUser)
+	assert.NoError(t, err)
+
+	keyring, err := NewTokenKeyring("k1:"+getTestKey('a'), "k1")
+	assert.NoError(t, err)
+	encrypted := NewUserDatastore(d.DB, keyring)
+
+	fromDb, err := encrypted.GetTwitterUser(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, twitterUser, fromDb)
+
+	n, err := encrypted.RotateTwitterUserKeys(ctx, 100)
+	assert.NoError(t, err)
+	assert.True(t, n > 0)
+
+	var accessToken string
+	err = tx.Get(&accessToken, "SELECT access_token FROM tw_account WHERE social_account_id = $1", twitterUser.TwitterID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, twitterUser.AccessToken, accessToken)
+
+	fromDb, err = encrypted.GetTwitterUser(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, twitterUser, fromDb)
+
+	n, err = encrypted.RotateTwitterUserKeys(ctx, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), n)
+
+	left, err := encrypted.CountTwitterUsersToRotate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), left)
+
+	// a row encrypted with a removed key is skipped
+	_, err = tx.Exec("UPDATE tw_account SET key_id = 'removed' WHERE social_account_id = $1", twitterUser.TwitterID)
+	assert.NoError(t, err)
+
+	rotated, err := NewTokenKeyring("k1:"+getTestKey('a')+",k2:"+getTestKey('b'), "k2")
+	assert.NoError(t, err)
+	n, err = NewUserDatastore(d.DB, rotated).RotateTwitterUserKeys(ctx, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), n)
+}
+
+func testUserSessions(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+
+	session, err := d.InsertUserSession(ctx, models.UserSession{UserID: u.ID, TokenHash: "hash", UserAgent: "Mozilla/5.0", IP: "127.0.0.1", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uint(0), session.ID)
+
+	_, err = d.InsertUserSession(ctx, models.UserSession{UserID: u.ID, TokenHash: "other", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+
+	_, err = d.InsertUserSession(ctx, models.UserSession{UserID: u.ID, TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Hour)})
+	assert.NoError(t, err)
+
+	fromDb, err := d.GetUserSessionByHash(ctx, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, fromDb.ID)
+	assert.Equal(t, "Mozilla/5.0", fromDb.UserAgent)
+
+	assert.NoError(t, d.UpdateUserSessionLastSeen(ctx, session.ID))
+
+	sessions, err := d.GetUserSessions(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	revoked, err := d.RevokeUserSession(ctx, u.ID, session.ID)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = d.RevokeUserSession(ctx, u.ID, session.ID)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	n, err := d.RevokeUserSessions(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), n)
+
+	sessions, err = d.GetUserSessions(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 0)
+}
+
+func testDataExports(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+
+	_, err = d.InsertUserEmail(ctx, models.UserEmail{UserID: u.ID, Email: "export@example.com", Status: models.EmailStatusConfirmed})
+	assert.NoError(t, err)
+
+	emails, err := d.GetUserEmailsByUserID(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, emails, 1)
+	assert.Equal(t, models.EmailStatusConfirmed, emails[0].Status)
+
+	export, err := d.InsertDataExport(ctx, models.DataExport{UserID: u.ID, Status: models.DataExportStatusPending})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uint(0), export.ID)
+
+	expiresAt := time.Now().Add(time.Hour)
+	export.Status = models.DataExportStatusReady
+	export.Archive = []byte("zip")
+	export.ExpiresAt = &expiresAt
+	_, err = d.UpdateDataExport(ctx, export)
+	assert.NoError(t, err)
+
+	fromDb, err := d.GetDataExport(ctx, export.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DataExportStatusReady, fromDb.Status)
+	assert.Equal(t, []byte("zip"), fromDb.Archive)
+
+	expired := time.Now().Add(-time.Hour)
+	_, err = d.UpdateDataExport(ctx, models.DataExport{ID: export.ID, Status: models.DataExportStatusReady, ExpiresAt: &expired})
+	assert.NoError(t, err)
+
+	exports, err := d.GetDataExports(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, exports, 1)
+	assert.Nil(t, exports[0].Archive)
+
+	n, err := d.RemoveExpiredDataExports(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), n)
+}
+
+func testAdmin(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, err := insertUser(d, ctx)
+	assert.NoError(t, err)
+
+	fromDb, err := d.GetUser(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserRoleUser, fromDb.Role)
+	assert.Nil(t, fromDb.DisabledAt)
+
+	err = d.UpdateUserRole(ctx, u.ID, models.UserRoleAdmin)
+	assert.NoError(t, err)
+	err = d.UpdateUserRole(ctx, uuid.New(), models.UserRoleAdmin)
+	assert.Error(t, err)
+
+	_, err = d.InsertUserEmail(ctx, models.UserEmail{UserID: u.ID, Email: "admin-search@example.com", Status: models.EmailStatusConfirmed})
+	assert.NoError(t, err)
+
+	users, err := d.SearchUsers(ctx, "ADMIN-SEARCH", 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, models.UserRoleAdmin, users[0].Role)
+
+	disabled, err := d.SetUserDisabled(ctx, u.ID, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, disabled.DisabledAt)
+
+	enabled, err := d.SetUserDisabled(ctx, u.ID, false)
+	assert.NoError(t, err)
+	assert.Nil(t, enabled.DisabledAt)
+
+	audit, err := d.InsertAdminAudit(ctx, models.AdminAudit{AdminID: u.ID, Action: "disable_user", Target: "user:" + u.ID.String()})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uint(0), audit.ID)
+
+	audits, err := d.GetAdminAudit(ctx, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, audit.ID, audits[0].ID)
+
+	_, err = d.InsertAPIToken(ctx, models.APIToken{UserID: u.ID, Name: "test", TokenHash: "admin-hash", Scope: models.APITokenScopeRead})
+	assert.NoError(t, err)
+	n, err := d.RevokeUserAPITokens(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), n)
+}
+
+func testManageUserEmails(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, s, err := insertUserAndSubscription(d, ctx)
+	assert.NoError(t, err)
+
+	_, err = d.InsertUserEmail(ctx, models.UserEmail{UserID: u.ID, Email: s.Email, Status: models.EmailStatusNew})
+	assert.NoError(t, err)
+	_, err = d.InsertUserEmail(ctx, models.UserEmail{UserID: u.ID, Email: "second@example.com", Status: models.EmailStatusConfirmed})
+	assert.NoError(t, err)
+
+	count, err := d.CountUserEmailsSince(ctx, u.ID, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), count)
+
+	err = d.SetEmailConfirmationSent(ctx, models.UserEmail{Email: s.Email, RemindersSent: 1})
+	assert.NoError(t, err)
+
+	email, err := d.GetUserEmail(ctx, models.UserEmail{Email: s.Email})
+	assert.NoError(t, err)
+	assert.Equal(t, models.EmailStatusSent, email.Status)
+	assert.NotNil(t, email.ConfirmationSentAt)
+	assert.Equal(t, uint(1), email.RemindersSent)
+
+	_, err = d.InsertUserEmail(ctx, models.UserEmail{UserID: u.ID, Email: "bounced@example.com", Status: models.EmailStatusBounced})
+	assert.NoError(t, err)
+	for _, e := range []string{"second@example.com", "bounced@example.com"} {
+		before, err := d.GetUserEmail(ctx, models.UserEmail{Email: e})
+		assert.NoError(t, err)
+
+		err = d.SetEmailConfirmationSent(ctx, models.UserEmail{Email: e})
+		assert.NoError(t, err)
+
+		after, err := d.GetUserEmail(ctx, models.UserEmail{Email: e})
+		assert.NoError(t, err)
+		assert.Equal(t, before.Status, after.Status)
+		assert.Nil(t, after.ConfirmationSentAt)
+	}
+
+	n, err := d.ReassignSubscriptionsEmail(ctx, u.ID, s.Email, "second@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), n)
+
+	subscription, err := d.GetSubscription(ctx, s.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "second@example.com", subscription.Email)
+
+	deleted, err := d.DeleteUserEmail(ctx, u.ID, s.Email)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = d.DeleteUserEmail(ctx, uuid.New(), "second@example.com")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func testWebhookToken(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+
+	err := d.InsertWebhookToken(ctx, "token")
+	assert.NoError(t, err)
+
+	err = d.InsertWebhookToken(ctx, "token")
+	assert.True(t, err.(*DbError).IsUniqueViolationError())
+}
+
+func testEmailConfirmation(t *testing.T, tx *sqlx.Tx, d *UserDatastore) {
+	ctx := context.WithValue(context.Background(), "Tx", tx)
+	u, s, err := insertUserAndSubscription(d, ctx)
+	assert.NoError(t, err)
+
+	_, err = d.InsertUserEmail(ctx, models.UserEmail{UserID: u.ID, Email: s.Email, Status: models.EmailStatusSent})
+	assert.NoError(t, err)
+
+	confirmation, err := d.InsertEmailConfirmation(ctx, models.EmailConfirmation{UserID: u.ID, Email: s.Email, NonceHash: "hash", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.NotZero(t, confirmation.ID)
+
+	used, err := d.UseEmailConfirmation(ctx, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, confirmation.ID, used.ID)
+	assert.NotNil(t, used.UsedAt)
+
+	_, err = d.UseEmailConfirmation(ctx, "hash")
+	assert.True(t, err.(*DbError).HasNoRows())
+
+	_, err = d.InsertEmailConfirmation(ctx, models.EmailConfirmation{UserID: u.ID, Email: s.Email, NonceHash: "expired", ExpiresAt: time.Now().Add(-time.Hour)})
+	assert.NoError(t, err)
+	_, err = d.UseEmailConfirmation(ctx, "expired")
+	assert.True(t, err.(*DbError).HasNoRows())
+
+	n, err := d.PauseEmailSubscriptions(ctx, u.ID, s.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), n)
+
+	subscription, err := d.GetSubscription(ctx, s.ID)
+	assert.NoError(t, err)
+	assert.True(t, subscription.Paused)
+
+	// the user's Twitter email is verified
+	_, err = d.InsertUserEmail(ctx, models.UserEmail{UserID: u.ID, Email: "FOO@bar.com", Status: models.EmailStatusAbandoned})
+	assert.NoError(t, err)
+
+	n, err = d.ConfirmVerifiedEmails(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), n)
+
+	email, err := d.GetUserEmail(ctx, models.UserEmail{Email: "FOO@bar.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.EmailStatusConfirmed, email.Status)
+}
+
 func TestUserDatastore(t *testing.T) {
 	tests := map[string]testFunc{
-		"TestInsertTwitterUser":          testInsertTwitterUser,
-		"TestInsertAndUpdateTwitterUser": testInsertAndUpdateTwitterUser,
-		"TestGetUser":                    testGetUser,
-		"TestUpdateUser":                 testUpdateUser,
-		"TestRemoveUser":                 testRemoveUser,
-		"TestInsertSubscription":         testInsertSubscription,
-		"TestUpdatetSubscription":        testUpdateSubscription,
-		"TestDeleteSubscription":         testDeleteSubscription,
-		"TestGetNewSubscriptionsUsers":   testGetNewSubscriptionsUsers,
-		"TestInsertSubscriptionState":    testInsertSubscriptionState,
-		"TestGetSubscriptionUserTweets":  testGetSubscriptionUserTweets,
-		"TestInsertUserEmail":            testInsertUserEmail,
+		"TestInsertTwitterUser":               testInsertTwitterUser,
+		"TestInsertAndUpdateTwitterUser":      testInsertAndUpdateTwitterUser,
+		"TestGetUser":                         testGetUser,
+		"TestUpdateUser":                      testUpdateUser,
+		"TestRemoveUser":                      testRemoveUser,
+		"TestRemoveUserGarbageCollects":       testRemoveUserGarbageCollects,
+		"TestInsertSubscription":              testInsertSubscription,
+		"TestUpdatetSubscription":             testUpdateSubscription,
+		"TestDeleteSubscription":              testDeleteSubscription,
+		"TestGetNewSubscriptionsUsers":        testGetNewSubscriptionsUsers,
+		"TestInsertSubscriptionState":         testInsertSubscriptionState,
+		"TestGetSubscriptionStates":           testGetSubscriptionStates,
+		"TestCountOnDemandSubscriptionStates": testCountOnDemandSubscriptionStates,
+		"TestUpdateUserVacation":              testUpdateUserVacation,
+		"TestAPITokens":                       testAPITokens,
+		"TestGetSubscriptionUserTweets":       testGetSubscriptionUserTweets,
+		"TestInsertUserEmail":                 testInsertUserEmail,
+		"TestSubscriptionListChanges":         testSubscriptionListChanges,
+		"TestMagicLinks":                      testMagicLinks,
+		"TestLinkedTwitterUsers":              testLinkedTwitterUsers,
+		"TestRotateTwitterUserKeys":           testRotateTwitterUserKeys,
+		"TestUserSessions":                    testUserSessions,
+		"TestDataExports":                     testDataExports,
+		"TestAdmin":                           testAdmin,
+		"TestManageUserEmails":                testManageUserEmails,
+		"TestEmailConfirmation":               testEmailConfirmation,
+		"TestWebhookToken":                    testWebhookToken,
 	}
 	runTests(tests, t)
 }
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+)
+
+const userEmailColumns = "user_id, email, status, created_at, confirmation_sent_at, reminders_sent"
+
+// CountUserEmailsSince returns the number of addresses the user added since the time
+func (d *UserDatastore) CountUserEmailsSince(ctx context.Context, userID uuid.UUID, since time.Time) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var count uint
+	err = t.tx.Get(&count, "SELECT count(*) FROM user_email_m2m WHERE user_id = $1 AND created_at >= $2", userID, since)
+	return count, t.getError()
+}
+
+// SetEmailConfirmationSent records that the confirmation email was sent to the address and the number of reminders
+func (d *UserDatastore) SetEmailConfirmationSent(ctx context.Context, email models.UserEmail) error {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	// confirmed and suppressed addresses keep their status, they could change while the email was being sent
+	_, err = t.tx.NamedExec("UPDATE user_email_m2m SET status = 'SENT', confirmation_sent_at = NOW(), reminders_sent = :reminders_sent "+
+		"WHERE email = :email AND status IN ('NEW', 'SENT', 'ABANDONED')", email)
+	return t.getError()
+}
+
+// ConfirmVerifiedEmails confirms not confirmed addresses which are the verified emails of their users' Twitter accounts,
+// returns their number
+func (d *UserDatastore) ConfirmVerifiedEmails(ctx context.Context) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("UPDATE user_email_m2m e SET status = 'CONFIRMED' FROM user_account u " +
+		"WHERE e.user_id = u.id AND u.email <> '' AND lower(e.email) = lower(u.email) AND e.status IN ('NEW', 'SENT', 'ABANDONED')")
+	if err != nil {
+		return 0, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return uint(n), t.getError()
+}
+
+// PauseEmailSubscriptions pauses user's subscriptions sent to the address, returns the number of paused ones
+func (d *UserDatastore) PauseEmailSubscriptions(ctx context.Context, userID uuid.UUID, email string) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("UPDATE subscription SET paused = TRUE WHERE user_id = $1 AND email = $2 AND NOT paused", userID, email)
+	if err != nil {
+		return 0, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return uint(n), t.getError()
+}
+
+func (d *UserDatastore) DeleteUserEmail(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("DELETE FROM user_email_m2m WHERE user_id = $1 AND email = $2", userID, email)
+	if err != nil {
+		return false, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, t.getError()
+}
+
+// ReassignSubscriptionsEmail moves user's subscriptions from one address to another, returns their number
+func (d *UserDatastore) ReassignSubscriptionsEmail(ctx context.Context, userID uuid.UUID, from, to string) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("UPDATE subscription SET email = $1 WHERE user_id = $2 AND email = $3", to, userID, from)
+	if err != nil {
+		return 0, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return uint(n), t.getError()
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+)
+
+const userSessionColumns = "id, user_id, token_hash, user_agent, ip, last_seen_at, expires_at, revoked_at, created_at"
+
+func (d *UserDatastore) InsertUserSession(ctx context.Context, session models.UserSession) (models.UserSession, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.UserSession
+	rows, err := t.tx.NamedQuery(
+		"INSERT INTO user_session (user_id, token_hash, user_agent, ip, expires_at) VALUES (:user_id, :token_hash, :user_agent, :ip, :expires_at) RETURNING "+userSessionColumns, session)
+	if err != nil {
+		return res, t.getError()
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.StructScan(&res)
+		if err != nil {
+			return res, t.getError()
+		}
+	}
+
+	return res, t.getError()
+}
+
+func (d *UserDatastore) GetUserSessionByHash(ctx context.Context, tokenHash string) (models.UserSession, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	var res models.UserSession
+	err = t.tx.Get(&res, "SELECT "+userSessionColumns+" FROM user_session WHERE token_hash = $1", tokenHash)
+	return res, t.getError()
+}
+
+// GetUserSessions returns active sessions, the recently used ones first
+func (d *UserDatastore) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]models.UserSession, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res := make([]models.UserSession, 0)
+	err = t.tx.Select(&res, "SELECT "+userSessionColumns+" FROM user_session WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW() ORDER BY last_seen_at DESC", userID)
+	return res, t.getError()
+}
+
+func (d *UserDatastore) UpdateUserSessionLastSeen(ctx context.Context, sessionID uint) error {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	_, err = t.tx.Exec("UPDATE user_session SET last_seen_at = NOW() WHERE id = $1", sessionID)
+	return t.getError()
+}
+
+func (d *UserDatastore) RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID uint) (bool, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("UPDATE user_session SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", sessionID, userID)
+	if err != nil {
+		return false, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, t.getError()
+}
+
+// RevokeUserSessions revokes all active sessions of the user and returns their number
+func (d *UserDatastore) RevokeUserSessions(ctx context.Context, userID uuid.UUID) (uint, error) {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	res, err := t.tx.Exec("UPDATE user_session SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()", userID)
+	if err != nil {
+		return 0, t.getError()
+	}
+
+	n, err := res.RowsAffected()
+	return uint(n), t.getError()
+}
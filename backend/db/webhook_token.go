@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+)
+
+// InsertWebhookToken records the token of a processed webhook, returns unique violation error if the token is used.
+// Tokens older than a day are removed, webhooks that old are rejected by their timestamp.
+func (d *UserDatastore) InsertWebhookToken(ctx context.Context, token string) error {
+	var err error
+	t := getTransaction(ctx, d.DB, &err)
+
+	defer func() {
+		t.commitOrRollback()
+	}()
+
+	_, err = t.tx.Exec("DELETE FROM webhook_token WHERE created_at < NOW() - INTERVAL '1 day'")
+	if err != nil {
+		return t.getError()
+	}
+
+	_, err = t.tx.Exec("INSERT INTO webhook_token (token) VALUES ($1)", token)
+	return t.getError()
+}
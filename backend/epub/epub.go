@@ -0,0 +1,237 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// MimeType - epub media type
+const MimeType = "application/epub+zip"
+
+const (
+	contentDir  = "OEBPS"
+	navFile     = "nav.xhtml"
+	ncxFile     = "toc.ncx"
+	packageFile = "content.opf"
+)
+
+// Chapter - book chapter, Body is a xhtml fragment
+type Chapter struct {
+	ID    string
+	Title string
+	File  string
+	Body  template.HTML
+}
+
+// Image - image embedded into the book
+type Image struct {
+	ID          string
+	File        string
+	ContentType string
+	Data        []byte
+}
+
+// Book - represents epub book
+type Book struct {
+	Identifier string
+	Title      string
+	Author     string
+	Language   string
+	Modified   time.Time
+	Chapters   []Chapter
+	Images     []Image
+}
+
+// NewBook returns new empty book
+func NewBook(identifier, title, author string) *Book {
+	return &Book{
+		Identifier: identifier,
+		Title:      title,
+		Author:     author,
+		Language:   "en",
+		Modified:   time.Now().UTC(),
+	}
+}
+
+// AddChapter adds chapter to the book
+func (b *Book) AddChapter(title string, body template.HTML) {
+	n := len(b.Chapters) + 1
+	b.Chapters = append(b.Chapters, Chapter{
+		ID:    fmt.Sprintf("chapter%d", n),
+		Title: title,
+		File:  fmt.Sprintf("chapter%d.xhtml", n),
+		Body:  body,
+	})
+}
+
+// AddImage adds image to the book and returns its path relative to chapters
+func (b *Book) AddImage(name, contentType string, data []byte) string {
+	img := Image{
+		ID:          fmt.Sprintf("image%d", len(b.Images)+1),
+		File:        "images/" + name,
+		ContentType: contentType,
+		Data:        data,
+	}
+	b.Images = append(b.Images, img)
+	return img.File
+}
+
+// Write writes book as epub container
+func (b *Book) Write(w io.Writer) error {
+	z := zip.NewWriter(w)
+
+	// mimetype must be the first entry and must not be compressed
+	f, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write([]byte(MimeType)); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		tmpl *template.Template
+		data interface{}
+	}{
+		{"META-INF/container.xml", containerTmpl, b},
+		{contentDir + "/" + packageFile, packageTmpl, b},
+		{contentDir + "/" + navFile, navTmpl, b},
+		{contentDir + "/" + ncxFile, ncxTmpl, b},
+	}
+
+	for _, file := range files {
+		if err = writeTemplate(z, file.name, file.tmpl, file.data); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range b.Chapters {
+		data := struct {
+			Book    *Book
+			Chapter Chapter
+		}{b, c}
+		if err = writeTemplate(z, contentDir+"/"+c.File, chapterTmpl, data); err != nil {
+			return err
+		}
+	}
+
+	for _, img := range b.Images {
+		f, err := z.Create(contentDir + "/" + img.File)
+		if err != nil {
+			return err
+		}
+		if _, err = f.Write(img.Data); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+// Bytes returns book as epub container
+func (b *Book) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	err := b.Write(&buf)
+	return buf.Bytes(), err
+}
+
+func writeTemplate(z *zip.Writer, name string, tmpl *template.Template, data interface{}) error {
+	f, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(f, data)
+}
+
+var funcMap = template.FuncMap{
+	"modified": func(t time.Time) string { return t.Format("2006-01-02T15:04:05Z") },
+	"inc":      func(i int) int { return i + 1 },
+	"xmlHeader": func() template.HTML {
+		return template.HTML(`<?xml version="1.0" encoding="UTF-8"?>`)
+	},
+}
+
+var containerTmpl = template.Must(template.New("container").Funcs(funcMap).Parse(`{{xmlHeader}}
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="` + contentDir + "/" + packageFile + `" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`))
+
+var packageTmpl = template.Must(template.New("package").Funcs(funcMap).Parse(`{{xmlHeader}}
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="bookid">{{.Identifier}}</dc:identifier>
+    <dc:title>{{.Title}}</dc:title>
+    <dc:creator>{{.Author}}</dc:creator>
+    <dc:language>{{.Language}}</dc:language>
+    <meta property="dcterms:modified">{{modified .Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="` + navFile + `" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="` + ncxFile + `" media-type="application/x-dtbncx+xml"/>
+    {{- range .Chapters}}
+    <item id="{{.ID}}" href="{{.File}}" media-type="application/xhtml+xml"/>
+    {{- end}}
+    {{- range .Images}}
+    <item id="{{.ID}}" href="{{.File}}" media-type="{{.ContentType}}"/>
+    {{- end}}
+  </manifest>
+  <spine toc="ncx">
+    {{- range .Chapters}}
+    <itemref idref="{{.ID}}"/>
+    {{- end}}
+  </spine>
+</package>
+`))
+
+var navTmpl = template.Must(template.New("nav").Funcs(funcMap).Parse(`{{xmlHeader}}
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>{{.Title}}</title></head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{.Title}}</h1>
+    <ol>
+      {{- range .Chapters}}
+      <li><a href="{{.File}}">{{.Title}}</a></li>
+      {{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+var ncxTmpl = template.Must(template.New("ncx").Funcs(funcMap).Parse(`{{xmlHeader}}
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="{{.Identifier}}"/>
+  </head>
+  <docTitle><text>{{.Title}}</text></docTitle>
+  <navMap>
+    {{- range $i, $c := .Chapters}}
+    <navPoint id="nav{{$c.ID}}" playOrder="{{inc $i}}">
+      <navLabel><text>{{$c.Title}}</text></navLabel>
+      <content src="{{$c.File}}"/>
+    </navPoint>
+    {{- end}}
+  </navMap>
+</ncx>
+`))
+
+var chapterTmpl = template.Must(template.New("chapter").Funcs(funcMap).Parse(`{{xmlHeader}}
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>{{.Chapter.Title}}</title></head>
+<body>
+  <h2>{{.Chapter.Title}}</h2>
+  {{.Chapter.Body}}
+</body>
+</html>
+`))
@@ -0,0 +1,59 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, r *zip.Reader, name string) string {
+	for _, f := range r.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			defer rc.Close()
+			b, err := ioutil.ReadAll(rc)
+			assert.NoError(t, err)
+			return string(b)
+		}
+	}
+	t.Fatalf("File %s not found", name)
+	return ""
+}
+
+func TestBookWrite(t *testing.T) {
+	book := NewBook("urn:uuid:123", "Issue <1>", "read-it-later.app")
+	book.AddChapter("Foo & Bar", template.HTML("<p>Hello</p>"))
+	book.AddChapter("Thread", template.HTML("<p>World</p>"))
+	img := book.AddImage("123.jpg", "image/jpeg", []byte{1, 2, 3})
+	assert.Equal(t, "images/123.jpg", img)
+
+	data, err := book.Bytes()
+	assert.NoError(t, err)
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "mimetype", r.File[0].Name)
+	assert.Equal(t, zip.Store, r.File[0].Method)
+	assert.Equal(t, MimeType, readFile(t, r, "mimetype"))
+
+	opf := readFile(t, r, "OEBPS/content.opf")
+	assert.True(t, strings.Contains(opf, `<item id="chapter2" href="chapter2.xhtml" media-type="application/xhtml+xml"/>`))
+	assert.True(t, strings.Contains(opf, `<item id="image1" href="images/123.jpg" media-type="image/jpeg"/>`))
+	assert.True(t, strings.Contains(opf, "Issue &lt;1&gt;"))
+
+	nav := readFile(t, r, "OEBPS/nav.xhtml")
+	assert.True(t, strings.Contains(nav, `<a href="chapter1.xhtml">Foo &amp; Bar</a>`))
+
+	chapter := readFile(t, r, "OEBPS/chapter1.xhtml")
+	assert.True(t, strings.HasPrefix(chapter, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.True(t, strings.Contains(chapter, "<p>Hello</p>"))
+
+	assert.Equal(t, "\x01\x02\x03", readFile(t, r, "OEBPS/images/123.jpg"))
+}
@@ -1,6 +1,10 @@
 package errors
 
-import "github.com/dmtr/mail_me_all/backend/db"
+import (
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/db"
+)
 
 type ErrorCode int
 
@@ -11,11 +15,100 @@ const (
 	DbError      ErrorCode = iota
 	NotFound     ErrorCode = iota
 	AuthRequired ErrorCode = iota
+	Conflict     ErrorCode = iota
+	RateLimited  ErrorCode = iota
+	Forbidden    ErrorCode = iota
+	Validation   ErrorCode = iota
+	Upstream     ErrorCode = iota
 )
 
+var errorNames = map[ErrorCode]string{
+	UnknownError: "unknown_error",
+	ServerError:  "server_error",
+	BadRequest:   "bad_request",
+	DbError:      "db_error",
+	NotFound:     "not_found",
+	AuthRequired: "auth_required",
+	Conflict:     "conflict",
+	RateLimited:  "rate_limited",
+	Forbidden:    "forbidden",
+	Validation:   "validation",
+	Upstream:     "upstream",
+}
+
+func (c ErrorCode) String() string {
+	if name, ok := errorNames[c]; ok {
+		return name
+	}
+	return errorNames[UnknownError]
+}
+
+// Error - domain error with the code and optional details
+type Error struct {
+	msg        string
+	code       ErrorCode
+	fields     map[string]string
+	retryAfter time.Duration
+}
+
+// New returns error with the code
+func New(msg string, code ErrorCode) *Error {
+	return &Error{msg: msg, code: code}
+}
+
+// NewNotFound returns error for missing entity
+func NewNotFound(msg string) *Error {
+	return New(msg, NotFound)
+}
+
+// NewForbidden returns error for the action not allowed to the user
+func NewForbidden(msg string) *Error {
+	return New(msg, Forbidden)
+}
+
+// NewConflict returns error for the action conflicting with the current state
+func NewConflict(msg string) *Error {
+	return New(msg, Conflict)
+}
+
+// NewUpstream returns error for failed calls to external services
+func NewUpstream(msg string) *Error {
+	return New(msg, Upstream)
+}
+
+// NewValidation returns error for invalid input, fields maps field names to problems
+func NewValidation(msg string, fields map[string]string) *Error {
+	return &Error{msg: msg, code: Validation, fields: fields}
+}
+
+// NewRateLimited returns error for exceeded limit, retryAfter is zero if unknown
+func NewRateLimited(msg string, retryAfter time.Duration) *Error {
+	return &Error{msg: msg, code: RateLimited, retryAfter: retryAfter}
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+func (e *Error) Code() ErrorCode {
+	return e.code
+}
+
+// Fields returns invalid fields of Validation error
+func (e *Error) Fields() map[string]string {
+	return e.fields
+}
+
+// RetryAfter returns time to wait before retry for RateLimited error
+func (e *Error) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
 func GetErrorCode(err error) ErrorCode {
 	var code ErrorCode
 	switch e := err.(type) {
+	case *Error:
+		code = e.Code()
 	case *db.DbError:
 		if e.HasNoRows() == true {
 			code = NotFound
@@ -0,0 +1,104 @@
+package errors
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var grpcCodes = map[ErrorCode]codes.Code{
+	UnknownError: codes.Unknown,
+	ServerError:  codes.Internal,
+	BadRequest:   codes.InvalidArgument,
+	DbError:      codes.Internal,
+	NotFound:     codes.NotFound,
+	AuthRequired: codes.Unauthenticated,
+	Conflict:     codes.Aborted,
+	RateLimited:  codes.ResourceExhausted,
+	Forbidden:    codes.PermissionDenied,
+	Validation:   codes.InvalidArgument,
+	Upstream:     codes.Unavailable,
+}
+
+var errorCodes = map[codes.Code]ErrorCode{
+	codes.Internal:          ServerError,
+	codes.InvalidArgument:   BadRequest,
+	codes.NotFound:          NotFound,
+	codes.Unauthenticated:   AuthRequired,
+	codes.Aborted:           Conflict,
+	codes.AlreadyExists:     Conflict,
+	codes.ResourceExhausted: RateLimited,
+	codes.PermissionDenied:  Forbidden,
+	codes.Unavailable:       Upstream,
+	codes.DeadlineExceeded:  Upstream,
+}
+
+// GRPCCode returns gRPC status code for the error code
+func GRPCCode(code ErrorCode) codes.Code {
+	if c, ok := grpcCodes[code]; ok {
+		return c
+	}
+	return codes.Unknown
+}
+
+// ToGRPCError converts error to gRPC status error keeping field details and retry delay
+func ToGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st := status.New(GRPCCode(GetErrorCode(err)), err.Error())
+	e, ok := err.(*Error)
+	if !ok {
+		return st.Err()
+	}
+
+	if len(e.fields) > 0 {
+		details := &errdetails.BadRequest{}
+		for field, description := range e.fields {
+			details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+		}
+		if s, err := st.WithDetails(details); err == nil {
+			st = s
+		}
+	}
+
+	if e.retryAfter > 0 {
+		if s, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(e.retryAfter)}); err == nil {
+			st = s
+		}
+	}
+
+	return st.Err()
+}
+
+// FromGRPCError converts error returned by gRPC client to the domain error
+func FromGRPCError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	st := status.Convert(err)
+	e := &Error{msg: st.Message(), code: UnknownError}
+	if code, ok := errorCodes[st.Code()]; ok {
+		e.code = code
+	}
+
+	for _, d := range st.Details() {
+		switch details := d.(type) {
+		case *errdetails.BadRequest:
+			e.code = Validation
+			e.fields = make(map[string]string, len(details.FieldViolations))
+			for _, v := range details.FieldViolations {
+				e.fields[v.Field] = v.Description
+			}
+		case *errdetails.RetryInfo:
+			if delay, err := ptypes.Duration(details.RetryDelay); err == nil {
+				e.retryAfter = delay
+			}
+		}
+	}
+
+	return e
+}
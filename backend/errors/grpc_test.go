@@ -0,0 +1,53 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCErrorRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		code codes.Code
+		want ErrorCode
+	}{
+		"NotFound":    {NewNotFound("no user"), codes.NotFound, NotFound},
+		"Forbidden":   {NewForbidden("not allowed"), codes.PermissionDenied, Forbidden},
+		"Conflict":    {NewConflict("in progress"), codes.Aborted, Conflict},
+		"Upstream":    {NewUpstream("twitter is down"), codes.Unavailable, Upstream},
+		"DbError":     {&db.DbError{Err: fmt.Errorf("no rows")}, codes.Internal, ServerError},
+		"Unknown":     {fmt.Errorf("unknown"), codes.Unknown, UnknownError},
+		"RateLimited": {NewRateLimited("slow down", time.Minute), codes.ResourceExhausted, RateLimited},
+		"Validation":  {NewValidation("invalid", map[string]string{"query": "required"}), codes.InvalidArgument, Validation},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			grpcErr := ToGRPCError(test.err)
+			assert.Equal(t, test.code, status.Code(grpcErr))
+
+			e := FromGRPCError(grpcErr)
+			assert.Equal(t, test.want, e.Code())
+			assert.Equal(t, test.err.Error(), e.Error())
+		})
+	}
+}
+
+func TestGRPCErrorDetails(t *testing.T) {
+	e := FromGRPCError(ToGRPCError(NewRateLimited("slow down", time.Minute)))
+	assert.Equal(t, time.Minute, e.RetryAfter())
+
+	fields := map[string]string{"query": "required"}
+	e = FromGRPCError(ToGRPCError(NewValidation("invalid", fields)))
+	assert.Equal(t, fields, e.Fields())
+
+	e = FromGRPCError(status.Error(codes.InvalidArgument, "bad"))
+	assert.Equal(t, BadRequest, e.Code())
+	assert.Nil(t, FromGRPCError(nil))
+}
@@ -1,32 +1,82 @@
 package mail
 
 import (
+	"bytes"
 	"context"
+	"io/ioutil"
 	"time"
 
 	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/models"
 	"github.com/mailgun/mailgun-go/v3"
 	log "github.com/sirupsen/logrus"
 )
 
 const timeout = time.Second * 20
 
-type EmailSender struct {
+const (
+	// MailgunSender - send emails with Mailgun API
+	MailgunSender string = "mailgun"
+
+	// SMTPSender - send emails with SMTP server
+	SMTPSender string = "smtp"
+
+	// FileSender - save emails to the outbox directory
+	FileSender string = "file"
+)
+
+// NewEmailSender returns EmailSender configured by conf.EmailSender
+func NewEmailSender(conf *config.Config) models.EmailSender {
+	switch conf.EmailSender {
+	case SMTPSender:
+		return NewSMTPEmailSender(conf)
+	case FileSender:
+		return NewFileEmailSender(conf)
+	case MailgunSender:
+		return NewMailgunEmailSender(conf)
+	default:
+		log.Warningf("Unknown email sender %s, using %s", conf.EmailSender, MailgunSender)
+		return NewMailgunEmailSender(conf)
+	}
+}
+
+// MailgunEmailSender sends emails with Mailgun API
+type MailgunEmailSender struct {
 	Conf *config.Config
 }
 
-func NewEmailSender(conf *config.Config) EmailSender {
-	return EmailSender{Conf: conf}
+// NewMailgunEmailSender returns new MailgunEmailSender
+func NewMailgunEmailSender(conf *config.Config) MailgunEmailSender {
+	return MailgunEmailSender{Conf: conf}
 }
 
-func (e EmailSender) Send(from, to, subject, body string) error {
+func (e MailgunEmailSender) Send(message models.EmailMessage) error {
 	var err error
 
 	mg := mailgun.NewMailgun(e.Conf.MgDomain, e.Conf.MgAPIKEY)
 	mg.SetAPIBase(mailgun.APIBaseEU)
 
-	m := mg.NewMessage(from, subject, "", to)
-	m.SetHtml(body)
+	m := mg.NewMessage(message.From, message.Subject, message.Text, message.To)
+	if message.HTML != "" {
+		m.SetHtml(message.HTML)
+	}
+
+	if message.ReplyTo != "" {
+		m.SetReplyTo(message.ReplyTo)
+	}
+
+	for k, v := range message.Headers {
+		m.AddHeader(k, v)
+	}
+
+	for _, a := range message.Attachments {
+		m.AddBufferAttachment(a.Filename, a.Data)
+	}
+
+	// Mailgun uses file name of inline attachment as its content id
+	for _, a := range message.Inline {
+		m.AddReaderInline(a.ContentID, ioutil.NopCloser(bytes.NewReader(a.Data)))
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
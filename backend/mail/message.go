@@ -0,0 +1,199 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+)
+
+const lineLength = 76
+
+func getMessageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndex(addr.Address, "@"); i != -1 {
+			domain = addr.Address[i+1:]
+		}
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("<%x.%d@%s>", b, time.Now().UnixNano(), domain)
+}
+
+func randomBoundary() string {
+	b := make([]byte, 30)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// createMultipart creates multipart part nested into parent
+func createMultipart(parent *multipart.Writer, subtype string) (*multipart.Writer, error) {
+	boundary := randomBoundary()
+	part, err := parent.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("multipart/%s; boundary=%s", subtype, boundary)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w := multipart.NewWriter(part)
+	return w, w.SetBoundary(boundary)
+}
+
+func writeTextPart(w *multipart.Writer, contentType, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err = qp.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func writeAttachment(w *multipart.Writer, a models.Attachment, disposition string) error {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": a.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+	}
+	if a.ContentID != "" {
+		h.Set("Content-ID", fmt.Sprintf("<%s>", a.ContentID))
+	}
+
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	for len(encoded) > lineLength {
+		if _, err = io.WriteString(part, encoded[:lineLength]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[lineLength:]
+	}
+	_, err = io.WriteString(part, encoded+"\r\n")
+	return err
+}
+
+// writeAlternative writes text and html versions of the message
+func writeAlternative(w *multipart.Writer, message models.EmailMessage) error {
+	var err error
+	if message.Text != "" && message.HTML != "" {
+		w, err = createMultipart(w, "alternative")
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+
+	if message.Text != "" {
+		if err = writeTextPart(w, "text/plain", message.Text); err != nil {
+			return err
+		}
+	}
+
+	if message.HTML != "" {
+		if err = writeTextPart(w, "text/html", message.HTML); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRelated writes message body together with inline attachments
+func writeRelated(w *multipart.Writer, message models.EmailMessage) error {
+	if len(message.Inline) == 0 {
+		return writeAlternative(w, message)
+	}
+
+	related, err := createMultipart(w, "related")
+	if err != nil {
+		return err
+	}
+
+	if err = writeAlternative(related, message); err != nil {
+		return err
+	}
+
+	for _, a := range message.Inline {
+		if err = writeAttachment(related, a, "inline"); err != nil {
+			return err
+		}
+	}
+	return related.Close()
+}
+
+// BuildMessage renders message as RFC 5322 message
+func BuildMessage(message models.EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+
+	header := textproto.MIMEHeader{}
+	for k, v := range message.Headers {
+		header.Set(k, v)
+	}
+	header.Set("From", message.From)
+	header.Set("To", message.To)
+	header.Set("Subject", mime.QEncoding.Encode("UTF-8", message.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-Id", getMessageID(message.From))
+	header.Set("Mime-Version", "1.0")
+	if message.ReplyTo != "" {
+		header.Set("Reply-To", message.ReplyTo)
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	header.Set("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%s", mixed.Boundary()))
+
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, header.Get(k))
+	}
+	buf.WriteString("\r\n")
+
+	err := writeRelated(mixed, message)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range message.Attachments {
+		if err = writeAttachment(mixed, a, "attachment"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
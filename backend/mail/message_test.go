@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildMessage(t *testing.T) {
+	message := models.NewEmailMessage("Read it later <noreply@example.com>", "test@example.com", "New Issue of тест", "<p>Hello <img src=\"cid:avatar-1.png\"></p>")
+	message.ReplyTo = "support@example.com"
+	message.Headers["List-Id"] = "<test.example.com>"
+	message.Inline = append(message.Inline, models.Attachment{Filename: "1.png", ContentType: "image/png", ContentID: "avatar-1.png", Data: []byte{1, 2, 3}})
+	message.Attachments = append(message.Attachments, models.Attachment{Filename: "issue.epub", ContentType: "application/epub+zip", Data: []byte("epub")})
+
+	data, err := BuildMessage(message)
+	assert.NoError(t, err)
+
+	m, err := netmail.ReadMessage(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	dec := new(mime.WordDecoder)
+	subject, err := dec.DecodeHeader(m.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, message.Subject, subject)
+	assert.Equal(t, message.ReplyTo, m.Header.Get("Reply-To"))
+	assert.Equal(t, "<test.example.com>", m.Header.Get("List-Id"))
+	assert.Contains(t, m.Header.Get("Message-Id"), "@example.com>")
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mixed := multipart.NewReader(m.Body, params["boundary"])
+
+	part, err := mixed.NextPart()
+	assert.NoError(t, err)
+	mediaType, params, err = mime.ParseMediaType(part.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/related", mediaType)
+
+	related := multipart.NewReader(part, params["boundary"])
+	html, err := related.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "text/html; charset=UTF-8", html.Header.Get("Content-Type"))
+	body, err := ioutil.ReadAll(html)
+	assert.NoError(t, err)
+	assert.Equal(t, message.HTML, string(body))
+
+	inline, err := related.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "<avatar-1.png>", inline.Header.Get("Content-Id"))
+
+	attachment, err := mixed.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "issue.epub", attachment.FileName())
+}
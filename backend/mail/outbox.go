@@ -0,0 +1,171 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	emlExt  = ".eml"
+	htmlExt = ".html"
+)
+
+// FileEmailSender writes emails into the outbox directory instead of sending them
+type FileEmailSender struct {
+	Conf *config.Config
+}
+
+// NewFileEmailSender returns new FileEmailSender
+func NewFileEmailSender(conf *config.Config) FileEmailSender {
+	return FileEmailSender{Conf: conf}
+}
+
+// inlineImages replaces cid: links with data urls, so html copy can be opened in a browser
+func inlineImages(message models.EmailMessage) string {
+	html := message.HTML
+	for _, a := range message.Inline {
+		dataURL := fmt.Sprintf("data:%s;base64,%s", a.ContentType, base64.StdEncoding.EncodeToString(a.Data))
+		html = strings.Replace(html, "cid:"+a.ContentID, dataURL, -1)
+	}
+	return html
+}
+
+func (e FileEmailSender) Send(message models.EmailMessage) error {
+	err := os.MkdirAll(e.Conf.OutboxPath, 0755)
+	if err != nil {
+		log.Errorf("Can't create outbox %s, got err %s", e.Conf.OutboxPath, err)
+		return err
+	}
+
+	msg, err := BuildMessage(message)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405.000"), uuid.New().String()[:8])
+
+	err = ioutil.WriteFile(filepath.Join(e.Conf.OutboxPath, name+emlExt), msg, 0644)
+	if err != nil {
+		log.Errorf("Can't write email, got err %s", err)
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(e.Conf.OutboxPath, name+htmlExt), []byte(inlineImages(message)), 0644)
+	if err != nil {
+		log.Errorf("Can't write email, got err %s", err)
+		return err
+	}
+
+	log.Infof("Email %s saved to %s", message, name)
+	return nil
+}
+
+// outboxMessage - captured message info
+type outboxMessage struct {
+	Name    string
+	To      string
+	Subject string
+	Date    string
+}
+
+func readOutbox(dir string) ([]outboxMessage, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+emlExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+
+	dec := new(mime.WordDecoder)
+	res := make([]outboxMessage, 0, len(files))
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			log.Errorf("Can't read %s, got err %s", f, err)
+			continue
+		}
+
+		m, err := netmail.ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			log.Errorf("Can't parse %s, got err %s", f, err)
+			continue
+		}
+
+		subject, err := dec.DecodeHeader(m.Header.Get("Subject"))
+		if err != nil {
+			subject = m.Header.Get("Subject")
+		}
+
+		res = append(res, outboxMessage{
+			Name:    strings.TrimSuffix(filepath.Base(f), emlExt),
+			To:      m.Header.Get("To"),
+			Subject: subject,
+			Date:    m.Header.Get("Date"),
+		})
+	}
+	return res, nil
+}
+
+var outboxTmpl = template.Must(template.New("outbox").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Outbox</title></head>
+<body>
+  <h1>Outbox</h1>
+  <table border="1" cellpadding="4" cellspacing="0">
+    <tr><th>Date</th><th>To</th><th>Subject</th><th></th></tr>
+    {{range .}}
+    <tr>
+      <td>{{.Date}}</td>
+      <td>{{.To}}</td>
+      <td><a href="{{.Name}}.html">{{.Subject}}</a></td>
+      <td><a href="{{.Name}}.eml">eml</a></td>
+    </tr>
+    {{else}}
+    <tr><td colspan="4">No messages</td></tr>
+    {{end}}
+  </table>
+</body>
+</html>
+`))
+
+// NewOutboxHandler returns handler showing messages captured by FileEmailSender
+func NewOutboxHandler(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Base(r.URL.Path)
+		ext := filepath.Ext(name)
+
+		if ext == emlExt || ext == htmlExt {
+			if ext == emlExt {
+				w.Header().Set("Content-Type", "message/rfc822")
+			}
+			http.ServeFile(w, r, filepath.Join(dir, name))
+			return
+		}
+
+		messages, err := readOutbox(dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err = outboxTmpl.Execute(w, messages); err != nil {
+			log.Errorf("Can't render outbox, got err %s", err)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileEmailSender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sender := NewFileEmailSender(&config.Config{OutboxPath: dir})
+	message := models.NewEmailMessage("noreply@example.com", "test@example.com", "New Issue", "<img src=\"cid:avatar-1.png\">")
+	message.Inline = append(message.Inline, models.Attachment{Filename: "1.png", ContentType: "image/png", ContentID: "avatar-1.png", Data: []byte{1, 2, 3}})
+	assert.NoError(t, sender.Send(message))
+
+	emls, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Equal(t, 1, len(emls))
+
+	html, err := ioutil.ReadFile(emls[0][:len(emls[0])-len(emlExt)] + htmlExt)
+	assert.NoError(t, err)
+	assert.Equal(t, "<img src=\"data:image/png;base64,AQID\">", string(html))
+
+	w := httptest.NewRecorder()
+	NewOutboxHandler(dir).ServeHTTP(w, httptest.NewRequest("GET", "/outbox/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "New Issue")
+	assert.Contains(t, w.Body.String(), "test@example.com")
+
+	w = httptest.NewRecorder()
+	NewOutboxHandler(dir).ServeHTTP(w, httptest.NewRequest("GET", "/outbox/"+filepath.Base(emls[0]), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "message/rfc822", w.Header().Get("Content-Type"))
+}
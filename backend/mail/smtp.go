@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"fmt"
+	"net/mail"
+	"net/smtp"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// SMTPEmailSender sends emails with SMTP server
+type SMTPEmailSender struct {
+	Conf *config.Config
+}
+
+// NewSMTPEmailSender returns new SMTPEmailSender
+func NewSMTPEmailSender(conf *config.Config) SMTPEmailSender {
+	return SMTPEmailSender{Conf: conf}
+}
+
+func (e SMTPEmailSender) Send(message models.EmailMessage) error {
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return err
+	}
+
+	msg, err := BuildMessage(message)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if e.Conf.SMTPUser != "" {
+		auth = smtp.PlainAuth("", e.Conf.SMTPUser, e.Conf.SMTPPassword, e.Conf.SMTPHost)
+	}
+
+	addr := fmt.Sprintf("%s:%d", e.Conf.SMTPHost, e.Conf.SMTPPort)
+	err = smtp.SendMail(addr, auth, from.Address, []string{to.Address}, msg)
+	if err != nil {
+		log.Errorf("Can't send email, got err %s", err)
+	}
+
+	return err
+}
@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/mailgun/mailgun-go/v3"
+	"github.com/mailgun/mailgun-go/v3/events"
+)
+
+// webhookMaxAge - older webhooks are rejected, so a captured webhook can't be replayed later
+const webhookMaxAge = 5 * time.Minute
+
+// ErrInvalidSignature - webhook payload is not signed by Mailgun
+var ErrInvalidSignature = errors.New("Invalid webhook signature")
+
+// ErrStaleWebhook - webhook timestamp is too old or in the future
+var ErrStaleWebhook = errors.New("Stale webhook")
+
+// EmailStatusChange - new status of the address reported by email provider,
+// Token is unique for every webhook and must be used once
+type EmailStatusChange struct {
+	Email  string
+	Status string
+	Token  string
+}
+
+// ParseMailgunWebhook verifies Mailgun webhook signature and timestamp and returns new status of the recipient address.
+// Status is empty if the event doesn't affect the address.
+func ParseMailgunWebhook(conf *config.Config, body []byte) (EmailStatusChange, error) {
+	return parseMailgunWebhook(conf, body, time.Now())
+}
+
+func parseMailgunWebhook(conf *config.Config, body []byte, now time.Time) (EmailStatusChange, error) {
+	var payload mailgun.WebhookPayload
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		return EmailStatusChange{}, err
+	}
+
+	// Mailgun signs webhooks with the webhook signing key, older accounts use API key
+	key := conf.MgWebhookKey
+	if key == "" {
+		key = conf.MgAPIKEY
+	}
+
+	mg := mailgun.NewMailgun(conf.MgDomain, key)
+	verified, err := mg.VerifyWebhookSignature(payload.Signature)
+	if err != nil || !verified {
+		return EmailStatusChange{}, ErrInvalidSignature
+	}
+
+	timestamp, err := strconv.ParseInt(payload.Signature.TimeStamp, 10, 64)
+	if err != nil {
+		return EmailStatusChange{}, ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > webhookMaxAge || age < -webhookMaxAge {
+		return EmailStatusChange{}, ErrStaleWebhook
+	}
+
+	event, err := mailgun.ParseEvent(payload.EventData)
+	if err != nil {
+		return EmailStatusChange{}, err
+	}
+
+	switch e := event.(type) {
+	case *events.Failed:
+		if e.Severity == "permanent" {
+			return EmailStatusChange{Email: e.Recipient, Status: models.EmailStatusBounced, Token: payload.Signature.Token}, nil
+		}
+	case *events.Complained:
+		return EmailStatusChange{Email: e.Recipient, Status: models.EmailStatusComplained, Token: payload.Signature.Token}, nil
+	case *events.Unsubscribed:
+		return EmailStatusChange{Email: e.Recipient, Status: models.EmailStatusUnsubscribed, Token: payload.Signature.Token}, nil
+	}
+
+	return EmailStatusChange{}, nil
+}
@@ -60,3 +60,25 @@ func removeOldTweets(a *app.App) {
 
 	log.Info("Command removeOldTweets finished")
 }
+
+func rotateTokenKeys(a *app.App) {
+	log.Info("Executing rotateTokenKeys command")
+
+	n, err := a.UseCases.RotateTokenKeys()
+	if err != nil {
+		log.Errorf("Got error executing command %s", err)
+	}
+
+	log.Infof("Command rotateTokenKeys finished, %d twitter accounts rotated", n)
+}
+
+func setUserRole(a *app.App, userID uuid.UUID, role string) {
+	log.Info("Executing setUserRole command")
+
+	err := a.UseCases.SetUserRole(userID, role)
+	if err != nil {
+		log.Errorf("Got error executing command %s", err)
+	}
+
+	log.Infof("Command setUserRole finished, user %s role %s", userID, role)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/dmtr/mail_me_all/backend/app"
 	"github.com/dmtr/mail_me_all/backend/mail"
+	"github.com/dmtr/mail_me_all/backend/models"
 	"github.com/dmtr/mail_me_all/backend/twapi"
 	"github.com/dmtr/mail_me_all/backend/twproxy"
 	"github.com/google/uuid"
@@ -33,6 +34,8 @@ const (
 	testEmail        string = "test-email"
 	sendConfirmation string = "send-confirmation"
 	removeTweets     string = "remove-old-tweets"
+	rotateKeys       string = "rotate-keys"
+	setRole          string = "set-role"
 )
 
 func handleSignals(server *http.Server) {
@@ -111,11 +114,14 @@ func main() {
 
 	flag.String("auth-key", "", "auth key")
 	flag.String("encrypt-key", "", "encryption key")
+	flag.String("smtp-password", "", "smtp password")
 
 	var subscriptionIDs *string = flag.String("subscription-ids", "", "subscription IDs")
 	var subject *string = flag.String("subject", "", "email subject")
 	var to *string = flag.String("to", "", "email to")
 	var body *string = flag.String("body", "", "email body")
+	var userID *string = flag.String("user-id", "", "user ID")
+	var role *string = flag.String("role", models.UserRoleAdmin, "user role")
 
 	flag.Parse()
 	viper.BindPFlags(flag.CommandLine)
@@ -156,13 +162,24 @@ func main() {
 	} else if cmd == testEmail {
 		a = app.GetApp(false, false, false, false)
 		sender := mail.NewEmailSender(a.Conf)
-		sender.Send(a.Conf.From, *to, *subject, *body)
+		sender.Send(models.NewEmailMessage(a.Conf.From, *to, *subject, *body))
 	} else if cmd == sendConfirmation {
 		a = app.GetApp(false, true, true, true)
 		sendConfirmationEmail(a)
 	} else if cmd == removeTweets {
 		a = app.GetApp(false, true, false, true)
 		removeOldTweets(a)
+	} else if cmd == rotateKeys {
+		a = app.GetApp(false, true, false, true)
+		rotateTokenKeys(a)
+	} else if cmd == setRole {
+		id, err := uuid.Parse(*userID)
+		if err != nil {
+			fmt.Printf("Invalid user id %s", *userID)
+			os.Exit(1)
+		}
+		a = app.GetApp(false, true, false, true)
+		setUserRole(a, id, *role)
 	} else {
 		fmt.Printf("Unknown command %s", cmd)
 		os.Exit(1)
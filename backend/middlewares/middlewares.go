@@ -1,38 +1,166 @@
 package middlewares
 
 import (
+	"context"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/gin-contrib/sessions"
 )
 
-func getUserID(c *gin.Context) string {
+// SessionTokenKey - cookie session key of the token identifying the server-side session
+const SessionTokenKey = "session"
+
+// SessionIDKey - context key of the server-side session id, set if the request is authenticated by the cookie
+const SessionIDKey = "SessionID"
+
+// GetSessionToken returns the session token from the cookie
+func GetSessionToken(c *gin.Context) string {
 	s := sessions.Default(c)
-	uid := s.Get("userid")
-	if uid == nil {
+	token := s.Get(SessionTokenKey)
+	if token == nil {
 		return ""
 	}
-	u, ok := uid.(string)
+	t, ok := token.(string)
 	if !ok {
-		log.Warningf("Can not convert userid to string %v", uid)
+		log.Warningf("Can not convert session token to string %v", token)
 		return ""
 	}
-	return u
+	return t
 }
 
-// SessionMiddleware adds user id to the context if session exists, otherwise returns 401
-func SessionMiddleware() gin.HandlerFunc {
+const bearerPrefix = "Bearer "
+
+// APITokenKey - context key of the personal access token id, set if the request is authenticated by the token
+const APITokenKey = "APITokenID"
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func authenticateAPIToken(c *gin.Context, usecases models.UserUseCase, header string) {
+	if !strings.HasPrefix(header, bearerPrefix) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": errors.AuthRequired})
+		return
+	}
+
+	token, err := usecases.AuthenticateAPIToken(context.Background(), strings.TrimPrefix(header, bearerPrefix))
+	if err != nil {
+		log.Warningf("Can not authenticate token, got error %s", err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": errors.AuthRequired})
+		return
+	}
+
+	if !token.CanWrite() && !isSafeMethod(c.Request.Method) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": errors.Forbidden})
+		return
+	}
+
+	c.Set("UserID", token.UserID.String())
+	c.Set(APITokenKey, token.ID)
+}
+
+// SessionMiddleware adds user id to the context if the cookie session is active or the request has
+// a valid "Authorization: Bearer" personal access token, otherwise returns 401.
+// Read scoped tokens are allowed only safe methods.
+func SessionMiddleware(usecases models.UserUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		uid := getUserID(c)
-		if uid != "" {
-			c.Set("UserID", uid)
-		} else {
+		if header := c.GetHeader("Authorization"); header != "" {
+			authenticateAPIToken(c, usecases, header)
+			return
+		}
+
+		session, err := usecases.AuthenticateSession(context.Background(), GetSessionToken(c))
+		if err != nil {
+			log.Debugf("Can not authenticate session, got error %s", err)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": errors.AuthRequired})
+			return
+		}
+
+		c.Set("UserID", session.UserID.String())
+		c.Set(SessionIDKey, session.ID)
+	}
+}
+
+// CookieSessionMiddleware must follow SessionMiddleware for routes that can't be used with personal access tokens
+func CookieSessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(APITokenKey); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": errors.Forbidden})
+		}
+	}
+}
+
+// isSameOrigin checks that the request comes from a page of the domain by its Origin header,
+// or by its Referer header if the browser didn't send Origin. The whole origin is compared,
+// pages are served over https, so another scheme or port is another origin.
+func isSameOrigin(r *http.Request, domain string) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+
+	if source == "" {
+		return false
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return false
+	}
+
+	origin := url.URL{Scheme: "https", Host: domain}
+	return strings.EqualFold(u.Scheme+"://"+u.Host, origin.String())
+}
+
+// CSRFMiddleware returns 403 for state-changing requests which don't come from pages of the domain.
+// It must follow SessionMiddleware on routes accepting personal access tokens: requests with a token
+// are exempt, browsers never add the Authorization header on their own, so a forged request can't have it.
+// The token endpoint POST /api/tokens accepts only the cookie session, so it's always checked.
+// Routes called by other servers, i.e. one-click unsubscribe and webhooks, must not use it.
+func CSRFMiddleware(domain string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) {
+			return
+		}
+
+		if _, ok := c.Get(APITokenKey); ok {
+			return
+		}
+
+		if !isSameOrigin(c.Request, domain) {
+			log.Warningf("Cross-site request %s %s, origin %q, referer %q", c.Request.Method, c.Request.URL.Path, c.GetHeader("Origin"), c.GetHeader("Referer"))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": errors.Forbidden, "message": "Cross-site request"})
+		}
+	}
+}
+
+// AdminMiddleware must follow SessionMiddleware for admin routes, returns 403 if the user isn't an admin
+func AdminMiddleware(usecases models.SystemUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("UserID"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": errors.AuthRequired})
+			return
+		}
+
+		_, err = usecases.AuthorizeAdmin(context.Background(), userID)
+		if err != nil {
+			log.Warningf("User %s can not use admin API, got error %s", userID, err)
+			code := errors.GetErrorCode(err)
+			if code == errors.AuthRequired {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": code})
+			} else {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": errors.Forbidden})
+			}
 		}
 	}
 }
@@ -0,0 +1,250 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/dmtr/mail_me_all/backend/usecases"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testToken = "mma_0123456789abcdef"
+
+const testSessionToken = "session-token"
+
+const testDomain = "localhost"
+
+func getRouter(datastoreMock *mocks.UserDatastore) *gin.Engine {
+	conf := config.GetConfig()
+	userUseCase := usecases.NewUserUseCase(datastoreMock, nil, &conf)
+
+	router := gin.New()
+	router.Use(sessions.Sessions("session", cookie.NewStore([]byte("secret"))))
+	router.GET("/signin", func(c *gin.Context) {
+		s := sessions.Default(c)
+		s.Set(SessionTokenKey, testSessionToken)
+		s.Save()
+	})
+	api := router.Group("/api", SessionMiddleware(userUseCase))
+	handler := func(c *gin.Context) {
+		uid, _ := c.Get("UserID")
+		c.String(http.StatusOK, uid.(string))
+	}
+	api.GET("/subscriptions", handler)
+	api.POST("/subscriptions", handler)
+	api.GET("/tokens", CookieSessionMiddleware(), handler)
+	checked := router.Group("/checked", SessionMiddleware(userUseCase), CSRFMiddleware(testDomain))
+	checked.GET("/subscriptions", handler)
+	checked.POST("/subscriptions", handler)
+	systemUseCase := usecases.NewSystemUseCase(datastoreMock, nil, &conf, nil)
+	router.GET("/admin/users", SessionMiddleware(userUseCase), AdminMiddleware(systemUseCase), handler)
+	return router
+}
+
+func mockToken(datastoreMock *mocks.UserDatastore, token models.APIToken) {
+	h := sha256.Sum256([]byte(testToken))
+	datastoreMock.On("GetAPITokenByHash", mock.Anything, hex.EncodeToString(h[:])).Return(token, nil)
+	datastoreMock.On("UpdateAPITokenLastUsed", mock.Anything, token.ID).Return(nil)
+}
+
+func request(router *gin.Engine, method, path, auth string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func mockSession(datastoreMock *mocks.UserDatastore, session models.UserSession) {
+	h := sha256.Sum256([]byte(testSessionToken))
+	datastoreMock.On("GetUserSessionByHash", mock.Anything, hex.EncodeToString(h[:])).Return(session, nil)
+	datastoreMock.On("UpdateUserSessionLastSeen", mock.Anything, session.ID).Return(nil)
+}
+
+func requestWithSession(router *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	return requestWithSessionHeaders(router, method, path, nil)
+}
+
+func requestWithSessionHeaders(router *gin.Engine, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	w := request(router, "GET", "/signin", "")
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Cookie", w.Header().Get("Set-Cookie"))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSessionMiddlewareCookie(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	session := models.UserSession{ID: 1, UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	mockSession(datastoreMock, session)
+	router := getRouter(datastoreMock)
+
+	w := requestWithSession(router, "POST", "/api/subscriptions")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, session.UserID.String(), w.Body.String())
+	datastoreMock.AssertCalled(t, "UpdateUserSessionLastSeen", mock.Anything, session.ID)
+}
+
+func TestSessionMiddlewareRevokedCookie(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	revokedAt := time.Now()
+	mockSession(datastoreMock, models.UserSession{ID: 1, UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt})
+	router := getRouter(datastoreMock)
+
+	w := requestWithSession(router, "GET", "/api/subscriptions")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSessionMiddlewareExpiredCookie(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	mockSession(datastoreMock, models.UserSession{ID: 1, UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)})
+	router := getRouter(datastoreMock)
+
+	w := requestWithSession(router, "GET", "/api/subscriptions")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserSessionLastSeen", 0)
+}
+
+func TestSessionMiddlewareNoAuth(t *testing.T) {
+	router := getRouter(new(mocks.UserDatastore))
+	w := request(router, "GET", "/api/subscriptions", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSessionMiddlewareWriteToken(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	token := models.APIToken{ID: 1, UserID: uuid.New(), Scope: models.APITokenScopeWrite}
+	mockToken(datastoreMock, token)
+	router := getRouter(datastoreMock)
+
+	w := request(router, "POST", "/api/subscriptions", "Bearer "+testToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, token.UserID.String(), w.Body.String())
+	datastoreMock.AssertCalled(t, "UpdateAPITokenLastUsed", mock.Anything, token.ID)
+}
+
+func TestSessionMiddlewareReadToken(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	mockToken(datastoreMock, models.APIToken{ID: 1, UserID: uuid.New(), Scope: models.APITokenScopeRead})
+	router := getRouter(datastoreMock)
+
+	w := request(router, "GET", "/api/subscriptions", "Bearer "+testToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = request(router, "POST", "/api/subscriptions", "Bearer "+testToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestSessionMiddlewareRevokedToken(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	revokedAt := time.Now()
+	mockToken(datastoreMock, models.APIToken{ID: 1, UserID: uuid.New(), Scope: models.APITokenScopeWrite, RevokedAt: &revokedAt})
+	router := getRouter(datastoreMock)
+
+	w := request(router, "GET", "/api/subscriptions", "Bearer "+testToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateAPITokenLastUsed", 0)
+}
+
+func TestSessionMiddlewareUnknownToken(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	datastoreMock.On("GetAPITokenByHash", mock.Anything, mock.Anything).Return(models.APIToken{}, &db.DbError{Err: sql.ErrNoRows})
+	router := getRouter(datastoreMock)
+
+	w := request(router, "GET", "/api/subscriptions", "Bearer "+testToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = request(router, "GET", "/api/subscriptions", "Basic dXNlcjpwYXNz")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestCookieSessionMiddleware(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	mockToken(datastoreMock, models.APIToken{ID: 1, UserID: uuid.New(), Scope: models.APITokenScopeWrite})
+	router := getRouter(datastoreMock)
+
+	w := request(router, "GET", "/api/tokens", "Bearer "+testToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	session := models.UserSession{ID: 1, UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	mockSession(datastoreMock, session)
+	datastoreMock.On("GetUser", mock.Anything, session.UserID).Return(models.User{ID: session.UserID, Role: models.UserRoleAdmin}, nil).Once()
+	router := getRouter(datastoreMock)
+
+	w := requestWithSession(router, "GET", "/admin/users")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	datastoreMock.On("GetUser", mock.Anything, session.UserID).Return(models.User{ID: session.UserID, Role: models.UserRoleUser}, nil).Once()
+	w = requestWithSession(router, "GET", "/admin/users")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	disabledAt := time.Now()
+	datastoreMock.On("GetUser", mock.Anything, session.UserID).Return(models.User{ID: session.UserID, Role: models.UserRoleAdmin, DisabledAt: &disabledAt}, nil).Once()
+	w = requestWithSession(router, "GET", "/admin/users")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCSRFMiddlewareCookie(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	mockSession(datastoreMock, models.UserSession{ID: 1, UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)})
+	router := getRouter(datastoreMock)
+
+	w := requestWithSessionHeaders(router, "POST", "/checked/subscriptions", map[string]string{"Origin": "https://localhost"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = requestWithSessionHeaders(router, "POST", "/checked/subscriptions", map[string]string{"Referer": "https://localhost/settings"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = requestWithSessionHeaders(router, "POST", "/checked/subscriptions", map[string]string{"Origin": "https://evil.example.com", "Referer": "https://localhost/settings"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = requestWithSessionHeaders(router, "POST", "/checked/subscriptions", map[string]string{"Origin": "null"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// another scheme or port is another origin
+	w = requestWithSessionHeaders(router, "POST", "/checked/subscriptions", map[string]string{"Origin": "http://localhost"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = requestWithSessionHeaders(router, "POST", "/checked/subscriptions", map[string]string{"Origin": "https://localhost:8443"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = requestWithSession(router, "POST", "/checked/subscriptions")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = requestWithSession(router, "GET", "/checked/subscriptions")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCSRFMiddlewareToken(t *testing.T) {
+	datastoreMock := new(mocks.UserDatastore)
+	token := models.APIToken{ID: 1, UserID: uuid.New(), Scope: models.APITokenScopeWrite}
+	mockToken(datastoreMock, token)
+	router := getRouter(datastoreMock)
+
+	w := request(router, "POST", "/checked/subscriptions", "Bearer "+testToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, token.UserID.String(), w.Body.String())
+}
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE subscription ADD COLUMN ereader_email VARCHAR NOT NULL DEFAULT '';
+
+COMMIT;
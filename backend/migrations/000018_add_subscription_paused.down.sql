@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE subscription DROP COLUMN paused;
+
+COMMIT;
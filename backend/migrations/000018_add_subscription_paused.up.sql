@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE subscription ADD COLUMN paused BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
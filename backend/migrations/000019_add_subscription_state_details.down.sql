@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS subscription_state_subscription_id_idx;
+
+ALTER TABLE subscription_state DROP COLUMN sent_at;
+ALTER TABLE subscription_state DROP COLUMN failure_reason;
+ALTER TABLE subscription_state DROP COLUMN tweet_count;
+
+COMMIT;
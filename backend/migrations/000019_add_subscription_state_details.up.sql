@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE subscription_state ADD COLUMN tweet_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE subscription_state ADD COLUMN failure_reason VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE subscription_state ADD COLUMN sent_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE subscription_state st SET tweet_count = (SELECT COUNT(*) FROM subscription_state_tweet_m2m m WHERE m.subscription_state_id = st.id);
+UPDATE subscription_state SET sent_at = updated_at WHERE status = 'SENT';
+
+CREATE INDEX subscription_state_subscription_id_idx ON subscription_state (subscription_id, created_at);
+
+COMMIT;
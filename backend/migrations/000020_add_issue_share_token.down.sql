@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS subscription_state_share_token_idx;
+
+ALTER TABLE subscription_state DROP COLUMN share_token;
+
+COMMIT;
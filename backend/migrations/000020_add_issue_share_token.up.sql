@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE subscription_state ADD COLUMN share_token VARCHAR NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX subscription_state_share_token_idx ON subscription_state (share_token) WHERE share_token <> '';
+
+COMMIT;
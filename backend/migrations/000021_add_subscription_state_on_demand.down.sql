@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE subscription_state DROP COLUMN on_demand;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE subscription_state ADD COLUMN on_demand BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE user_account DROP CONSTRAINT user_account_vacation_check;
+ALTER TABLE user_account DROP COLUMN vacation_end;
+ALTER TABLE user_account DROP COLUMN vacation_start;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE user_account ADD COLUMN vacation_start DATE;
+ALTER TABLE user_account ADD COLUMN vacation_end DATE;
+ALTER TABLE user_account ADD CONSTRAINT user_account_vacation_check
+    CHECK ((vacation_start IS NULL AND vacation_end IS NULL) OR vacation_start <= vacation_end);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS api_token;
+
+DROP TYPE IF EXISTS api_token_scope;
+
+COMMIT;
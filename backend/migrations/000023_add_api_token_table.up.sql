@@ -0,0 +1,27 @@
+BEGIN;
+
+CREATE TYPE api_token_scope AS ENUM ('READ', 'WRITE');
+
+CREATE TABLE api_token (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name VARCHAR NOT NULL,
+    token_hash VARCHAR NOT NULL UNIQUE,
+    scope api_token_scope NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT api_token_user_account_id_fk FOREIGN KEY (user_id) REFERENCES user_account (id) ON DELETE CASCADE
+);
+
+CREATE INDEX api_token_user_id_idx ON api_token (user_id);
+
+CREATE TRIGGER update_api_token
+      before update
+      on api_token
+      for each row
+      execute procedure update_timestamp()
+  ;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS subscription_list_change;
+
+DROP TYPE IF EXISTS subscription_list_change_kind;
+
+ALTER TABLE subscription DROP COLUMN twitter_list_id;
+
+COMMIT;
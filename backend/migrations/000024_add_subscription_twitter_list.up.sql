@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE subscription ADD COLUMN twitter_list_id VARCHAR NOT NULL DEFAULT '';
+
+CREATE TYPE subscription_list_change_kind AS ENUM ('ADDED', 'REMOVED');
+
+CREATE TABLE subscription_list_change (
+    id SERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    twitter_list_id VARCHAR NOT NULL,
+    user_twitter_id VARCHAR NOT NULL,
+    screen_name VARCHAR NOT NULL,
+    name VARCHAR NOT NULL,
+    kind subscription_list_change_kind NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT subscription_list_change_subscription_id_fk FOREIGN KEY (subscription_id) REFERENCES subscription (id) ON DELETE CASCADE
+);
+
+CREATE INDEX subscription_list_change_subscription_id_idx ON subscription_list_change (subscription_id, created_at);
+
+COMMIT;
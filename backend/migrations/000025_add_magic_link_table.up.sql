@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE magic_link (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    email VARCHAR NOT NULL,
+    nonce_hash VARCHAR NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT magic_link_user_account_id_fk FOREIGN KEY (user_id) REFERENCES user_account (id) ON DELETE CASCADE
+);
+
+CREATE INDEX magic_link_email_created_at_idx ON magic_link (email, created_at);
+
+COMMIT;
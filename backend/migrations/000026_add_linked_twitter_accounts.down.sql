@@ -0,0 +1,15 @@
+BEGIN;
+
+ALTER TABLE subscription DROP COLUMN twitter_account_id;
+
+DELETE FROM tw_account WHERE NOT is_primary;
+
+DROP INDEX IF EXISTS tw_account_user_id_primary_idx;
+
+DROP INDEX IF EXISTS tw_account_social_account_id_idx;
+
+ALTER TABLE tw_account DROP COLUMN is_primary;
+
+ALTER TABLE tw_account DROP COLUMN screen_name;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+ALTER TABLE tw_account ADD COLUMN screen_name VARCHAR NOT NULL DEFAULT '';
+
+ALTER TABLE tw_account ADD COLUMN is_primary BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE tw_account SET is_primary = TRUE;
+
+CREATE UNIQUE INDEX tw_account_social_account_id_idx ON tw_account (social_account_id);
+
+CREATE UNIQUE INDEX tw_account_user_id_primary_idx ON tw_account (user_id) WHERE is_primary;
+
+ALTER TABLE subscription ADD COLUMN twitter_account_id VARCHAR NOT NULL DEFAULT '';
+
+COMMIT;
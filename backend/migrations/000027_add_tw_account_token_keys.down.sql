@@ -0,0 +1,10 @@
+BEGIN;
+
+-- encrypted tokens can't be decrypted in SQL, users of these accounts have to sign in again
+UPDATE tw_account SET access_token = '', token_secret = '' WHERE key_id <> '';
+
+ALTER TABLE tw_account DROP COLUMN data_key;
+
+ALTER TABLE tw_account DROP COLUMN key_id;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE tw_account ADD COLUMN key_id VARCHAR NOT NULL DEFAULT '';
+
+ALTER TABLE tw_account ADD COLUMN data_key VARCHAR NOT NULL DEFAULT '';
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE user_session (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    token_hash VARCHAR NOT NULL UNIQUE,
+    user_agent VARCHAR NOT NULL DEFAULT '',
+    ip VARCHAR NOT NULL DEFAULT '',
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT user_session_user_account_id_fk FOREIGN KEY (user_id) REFERENCES user_account (id) ON DELETE CASCADE
+);
+
+CREATE INDEX user_session_user_id_idx ON user_session (user_id);
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE data_export (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    status VARCHAR NOT NULL DEFAULT 'PENDING',
+    archive BYTEA,
+    failure_reason VARCHAR NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT data_export_user_account_id_fk FOREIGN KEY (user_id) REFERENCES user_account (id) ON DELETE CASCADE
+);
+
+CREATE INDEX data_export_user_id_idx ON data_export (user_id);
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+-- audit of deleted accounts, no foreign key because the account is gone
+CREATE TABLE account_deletion (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    tokens_revoked INTEGER NOT NULL DEFAULT 0,
+    tokens_failed INTEGER NOT NULL DEFAULT 0,
+    tweets_removed INTEGER NOT NULL DEFAULT 0,
+    subscription_users_removed INTEGER NOT NULL DEFAULT 0,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS admin_audit;
+
+ALTER TABLE user_account DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE user_account DROP COLUMN IF EXISTS role;
+
+COMMIT;
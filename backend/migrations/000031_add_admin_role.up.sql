@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE user_account ADD COLUMN role VARCHAR NOT NULL DEFAULT 'USER';
+ALTER TABLE user_account ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE;
+
+-- actions of admins, target is the affected object e.g. user:<id>, no foreign keys so the audit outlives accounts
+CREATE TABLE admin_audit (
+    id SERIAL PRIMARY KEY,
+    admin_id UUID NOT NULL,
+    action VARCHAR NOT NULL,
+    target VARCHAR NOT NULL DEFAULT '',
+    details VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX admin_audit_created_at_idx ON admin_audit (created_at);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS user_email_m2m_user_id_idx;
+ALTER TABLE user_email_m2m DROP COLUMN IF EXISTS confirmation_sent_at;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- when the last confirmation email was sent, resending is throttled by it
+ALTER TABLE user_email_m2m ADD COLUMN confirmation_sent_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE user_email_m2m SET confirmation_sent_at = updated_at WHERE status = 'SENT';
+
+CREATE INDEX user_email_m2m_user_id_idx ON user_email_m2m (user_id);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TYPE email_status RENAME TO email_status_old;
+
+CREATE TYPE email_status AS ENUM ('NEW', 'SENT', 'CONFIRMED', 'BOUNCED', 'COMPLAINED', 'UNSUBSCRIBED');
+
+ALTER TABLE user_email_m2m ALTER COLUMN status TYPE email_status
+    USING (CASE WHEN status::text = 'ABANDONED' THEN 'NEW' ELSE status::text END)::email_status;
+
+DROP TYPE email_status_old;
+
+ALTER TABLE user_email_m2m DROP COLUMN IF EXISTS reminders_sent;
+
+DROP TABLE IF EXISTS email_confirmation;
+
+COMMIT;
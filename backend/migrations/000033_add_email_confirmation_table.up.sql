@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE email_confirmation (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    email VARCHAR NOT NULL,
+    nonce_hash VARCHAR NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT email_confirmation_user_account_id_fk FOREIGN KEY (user_id) REFERENCES user_account (id) ON DELETE CASCADE
+);
+
+-- reminders sent since the first confirmation email, the address is abandoned after the last one
+ALTER TABLE user_email_m2m ADD COLUMN reminders_sent INTEGER NOT NULL DEFAULT 0;
+
+ALTER TYPE email_status RENAME TO email_status_old;
+
+CREATE TYPE email_status AS ENUM ('NEW', 'SENT', 'CONFIRMED', 'BOUNCED', 'COMPLAINED', 'UNSUBSCRIBED', 'ABANDONED');
+
+ALTER TABLE user_email_m2m ALTER COLUMN status TYPE email_status USING status::text::email_status;
+
+DROP TYPE email_status_old;
+
+COMMIT;
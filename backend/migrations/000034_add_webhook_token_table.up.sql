@@ -0,0 +1,9 @@
+BEGIN;
+
+-- tokens of processed webhooks, a webhook with a used token is a replay
+CREATE TABLE webhook_token (
+    token VARCHAR PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- sign-in link requests are rate limited by the address whether it has an account or not
+CREATE TABLE magic_link_request (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX magic_link_request_email_created_at_idx ON magic_link_request (lower(email), created_at);
+
+COMMIT;
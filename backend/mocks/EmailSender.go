@@ -3,19 +3,20 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
+import models "github.com/dmtr/mail_me_all/backend/models"
 
 // EmailSender is an autogenerated mock type for the EmailSender type
 type EmailSender struct {
 	mock.Mock
 }
 
-// Send provides a mock function with given fields: from, to, subject, body
-func (_m *EmailSender) Send(from string, to string, subject string, body string) error {
-	ret := _m.Called(from, to, subject, body)
+// Send provides a mock function with given fields: message
+func (_m *EmailSender) Send(message models.EmailMessage) error {
+	ret := _m.Called(message)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, string, string) error); ok {
-		r0 = rf(from, to, subject, body)
+	if rf, ok := ret.Get(0).(func(models.EmailMessage) error); ok {
+		r0 = rf(message)
 	} else {
 		r0 = ret.Error(0)
 	}
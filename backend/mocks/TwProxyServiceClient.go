@@ -12,6 +12,96 @@ type TwProxyServiceClient struct {
 	mock.Mock
 }
 
+// GetFriends provides a mock function with given fields: ctx, in, opts
+func (_m *TwProxyServiceClient) GetFriends(ctx context.Context, in *rpc.FriendsRequest, opts ...grpc.CallOption) (*rpc.UsersPage, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *rpc.UsersPage
+	if rf, ok := ret.Get(0).(func(context.Context, *rpc.FriendsRequest, ...grpc.CallOption) *rpc.UsersPage); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rpc.UsersPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *rpc.FriendsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetListMembers provides a mock function with given fields: ctx, in, opts
+func (_m *TwProxyServiceClient) GetListMembers(ctx context.Context, in *rpc.ListMembersRequest, opts ...grpc.CallOption) (*rpc.UsersPage, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *rpc.UsersPage
+	if rf, ok := ret.Get(0).(func(context.Context, *rpc.ListMembersRequest, ...grpc.CallOption) *rpc.UsersPage); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rpc.UsersPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *rpc.ListMembersRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLists provides a mock function with given fields: ctx, in, opts
+func (_m *TwProxyServiceClient) GetLists(ctx context.Context, in *rpc.ListsRequest, opts ...grpc.CallOption) (*rpc.ListsResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *rpc.ListsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *rpc.ListsRequest, ...grpc.CallOption) *rpc.ListsResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rpc.ListsResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *rpc.ListsRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserInfo provides a mock function with given fields: ctx, in, opts
 func (_m *TwProxyServiceClient) GetUserInfo(ctx context.Context, in *rpc.UserInfoRequest, opts ...grpc.CallOption) (*rpc.UserInfo, error) {
 	_va := make([]interface{}, len(opts))
@@ -72,6 +162,36 @@ func (_m *TwProxyServiceClient) GetUserTimeline(ctx context.Context, in *rpc.Use
 	return r0, r1
 }
 
+// InvalidateToken provides a mock function with given fields: ctx, in, opts
+func (_m *TwProxyServiceClient) InvalidateToken(ctx context.Context, in *rpc.InvalidateTokenRequest, opts ...grpc.CallOption) (*rpc.InvalidateTokenResponse, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *rpc.InvalidateTokenResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *rpc.InvalidateTokenRequest, ...grpc.CallOption) *rpc.InvalidateTokenResponse); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rpc.InvalidateTokenResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *rpc.InvalidateTokenRequest, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SearchUsers provides a mock function with given fields: ctx, in, opts
 func (_m *TwProxyServiceClient) SearchUsers(ctx context.Context, in *rpc.UserSearchRequest, opts ...grpc.CallOption) (*rpc.UserSearchResult, error) {
 	_va := make([]interface{}, len(opts))
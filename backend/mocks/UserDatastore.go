@@ -5,6 +5,7 @@ package mocks
 import context "context"
 import mock "github.com/stretchr/testify/mock"
 import models "github.com/dmtr/mail_me_all/backend/models"
+import time "time"
 import uuid "github.com/google/uuid"
 
 // UserDatastore is an autogenerated mock type for the UserDatastore type
@@ -33,6 +34,132 @@ func (_m *UserDatastore) AcquireLock(ctx context.Context, key uint) (bool, error
 	return r0, r1
 }
 
+// ConfirmVerifiedEmails provides a mock function with given fields: ctx
+func (_m *UserDatastore) ConfirmVerifiedEmails(ctx context.Context) (uint, error) {
+	ret := _m.Called(ctx)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context) uint); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountMagicLinkRequests provides a mock function with given fields: ctx, email, since
+func (_m *UserDatastore) CountMagicLinkRequests(ctx context.Context, email string, since time.Time) (uint, error) {
+	ret := _m.Called(ctx, email, since)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) uint); ok {
+		r0 = rf(ctx, email, since)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, email, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountOnDemandSubscriptionStates provides a mock function with given fields: ctx, userID, since
+func (_m *UserDatastore) CountOnDemandSubscriptionStates(ctx context.Context, userID uuid.UUID, since time.Time) (uint, error) {
+	ret := _m.Called(ctx, userID, since)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) uint); ok {
+		r0 = rf(ctx, userID, since)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, userID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountSubscriptionStates provides a mock function with given fields: ctx, subscriptionID
+func (_m *UserDatastore) CountSubscriptionStates(ctx context.Context, subscriptionID uuid.UUID) (uint, error) {
+	ret := _m.Called(ctx, subscriptionID)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) uint); ok {
+		r0 = rf(ctx, subscriptionID)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, subscriptionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountTwitterUsersToRotate provides a mock function with given fields: ctx
+func (_m *UserDatastore) CountTwitterUsersToRotate(ctx context.Context) (uint, error) {
+	ret := _m.Called(ctx)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context) uint); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountUserEmailsSince provides a mock function with given fields: ctx, userID, since
+func (_m *UserDatastore) CountUserEmailsSince(ctx context.Context, userID uuid.UUID, since time.Time) (uint, error) {
+	ret := _m.Called(ctx, userID, since)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) uint); ok {
+		r0 = rf(ctx, userID, since)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, userID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteSubscription provides a mock function with given fields: ctx, subscription
 func (_m *UserDatastore) DeleteSubscription(ctx context.Context, subscription models.Subscription) error {
 	ret := _m.Called(ctx, subscription)
@@ -47,6 +174,161 @@ func (_m *UserDatastore) DeleteSubscription(ctx context.Context, subscription mo
 	return r0
 }
 
+// DeleteUserEmail provides a mock function with given fields: ctx, userID, email
+func (_m *UserDatastore) DeleteUserEmail(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	ret := _m.Called(ctx, userID, email)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) bool); ok {
+		r0 = rf(ctx, userID, email)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, userID, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPITokenByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *UserDatastore) GetAPITokenByHash(ctx context.Context, tokenHash string) (models.APIToken, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	var r0 models.APIToken
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.APIToken); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		r0 = ret.Get(0).(models.APIToken)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPITokens provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) GetAPITokens(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.APIToken
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.APIToken); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.APIToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAdminAudit provides a mock function with given fields: ctx, limit, offset
+func (_m *UserDatastore) GetAdminAudit(ctx context.Context, limit uint, offset uint) ([]models.AdminAudit, error) {
+	ret := _m.Called(ctx, limit, offset)
+
+	var r0 []models.AdminAudit
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []models.AdminAudit); ok {
+		r0 = rf(ctx, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AdminAudit)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDataExport provides a mock function with given fields: ctx, exportID
+func (_m *UserDatastore) GetDataExport(ctx context.Context, exportID uint) (models.DataExport, error) {
+	ret := _m.Called(ctx, exportID)
+
+	var r0 models.DataExport
+	if rf, ok := ret.Get(0).(func(context.Context, uint) models.DataExport); ok {
+		r0 = rf(ctx, exportID)
+	} else {
+		r0 = ret.Get(0).(models.DataExport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, exportID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDataExports provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) GetDataExports(ctx context.Context, userID uuid.UUID) ([]models.DataExport, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.DataExport
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.DataExport); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.DataExport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFailedSubscriptionStates provides a mock function with given fields: ctx, limit, offset
+func (_m *UserDatastore) GetFailedSubscriptionStates(ctx context.Context, limit uint, offset uint) ([]models.SubscriptionState, error) {
+	ret := _m.Called(ctx, limit, offset)
+
+	var r0 []models.SubscriptionState
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []models.SubscriptionState); ok {
+		r0 = rf(ctx, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.SubscriptionState)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(ctx, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNewSubscriptionsUsers provides a mock function with given fields: ctx, subscriptionIDs
 func (_m *UserDatastore) GetNewSubscriptionsUsers(ctx context.Context, subscriptionIDs ...uuid.UUID) (map[uuid.UUID][]string, error) {
 	_va := make([]interface{}, len(subscriptionIDs))
@@ -128,6 +410,94 @@ func (_m *UserDatastore) GetSubscription(ctx context.Context, subscriptionID uui
 	return r0, r1
 }
 
+// GetSubscriptionListChanges provides a mock function with given fields: ctx, subscriptionID, limit, offset
+func (_m *UserDatastore) GetSubscriptionListChanges(ctx context.Context, subscriptionID uuid.UUID, limit uint, offset uint) ([]models.SubscriptionListChange, error) {
+	ret := _m.Called(ctx, subscriptionID, limit, offset)
+
+	var r0 []models.SubscriptionListChange
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uint, uint) []models.SubscriptionListChange); ok {
+		r0 = rf(ctx, subscriptionID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.SubscriptionListChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uint, uint) error); ok {
+		r1 = rf(ctx, subscriptionID, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscriptionState provides a mock function with given fields: ctx, subscriptionStateID
+func (_m *UserDatastore) GetSubscriptionState(ctx context.Context, subscriptionStateID uint) (models.SubscriptionState, error) {
+	ret := _m.Called(ctx, subscriptionStateID)
+
+	var r0 models.SubscriptionState
+	if rf, ok := ret.Get(0).(func(context.Context, uint) models.SubscriptionState); ok {
+		r0 = rf(ctx, subscriptionStateID)
+	} else {
+		r0 = ret.Get(0).(models.SubscriptionState)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, subscriptionStateID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscriptionStateByShareToken provides a mock function with given fields: ctx, shareToken
+func (_m *UserDatastore) GetSubscriptionStateByShareToken(ctx context.Context, shareToken string) (models.SubscriptionState, error) {
+	ret := _m.Called(ctx, shareToken)
+
+	var r0 models.SubscriptionState
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.SubscriptionState); ok {
+		r0 = rf(ctx, shareToken)
+	} else {
+		r0 = ret.Get(0).(models.SubscriptionState)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, shareToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscriptionStates provides a mock function with given fields: ctx, subscriptionID, limit, offset
+func (_m *UserDatastore) GetSubscriptionStates(ctx context.Context, subscriptionID uuid.UUID, limit uint, offset uint) ([]models.SubscriptionState, error) {
+	ret := _m.Called(ctx, subscriptionID, limit, offset)
+
+	var r0 []models.SubscriptionState
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uint, uint) []models.SubscriptionState); ok {
+		r0 = rf(ctx, subscriptionID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.SubscriptionState)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uint, uint) error); ok {
+		r1 = rf(ctx, subscriptionID, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetSubscriptionTweets provides a mock function with given fields: ctx, subscriptionStateID
 func (_m *UserDatastore) GetSubscriptionTweets(ctx context.Context, subscriptionStateID uint) ([]models.Tweet, error) {
 	ret := _m.Called(ctx, subscriptionStateID)
@@ -151,20 +521,643 @@ func (_m *UserDatastore) GetSubscriptionTweets(ctx context.Context, subscription
 	return r0, r1
 }
 
-// GetSubscriptionUserTweets provides a mock function with given fields: ctx, subscriptionID
-func (_m *UserDatastore) GetSubscriptionUserTweets(ctx context.Context, subscriptionID uuid.UUID) (models.SubscriptionUserTweets, error) {
-	ret := _m.Called(ctx, subscriptionID)
+// GetSubscriptionUserTweets provides a mock function with given fields: ctx, subscriptionID
+func (_m *UserDatastore) GetSubscriptionUserTweets(ctx context.Context, subscriptionID uuid.UUID) (models.SubscriptionUserTweets, error) {
+	ret := _m.Called(ctx, subscriptionID)
+
+	var r0 models.SubscriptionUserTweets
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.SubscriptionUserTweets); ok {
+		r0 = rf(ctx, subscriptionID)
+	} else {
+		r0 = ret.Get(0).(models.SubscriptionUserTweets)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, subscriptionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscriptions provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]models.Subscription, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.Subscription
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.Subscription); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Subscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTodaySubscriptionsIDs provides a mock function with given fields: ctx
+func (_m *UserDatastore) GetTodaySubscriptionsIDs(ctx context.Context) ([]uuid.UUID, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []uuid.UUID
+	if rf, ok := ret.Get(0).(func(context.Context) []uuid.UUID); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTwitterUser provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) GetTwitterUser(ctx context.Context, userID uuid.UUID) (models.TwitterUser, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 models.TwitterUser
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.TwitterUser); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(models.TwitterUser)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTwitterUserByID provides a mock function with given fields: ctx, twitterUserID
+func (_m *UserDatastore) GetTwitterUserByID(ctx context.Context, twitterUserID string) (models.TwitterUser, error) {
+	ret := _m.Called(ctx, twitterUserID)
+
+	var r0 models.TwitterUser
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.TwitterUser); ok {
+		r0 = rf(ctx, twitterUserID)
+	} else {
+		r0 = ret.Get(0).(models.TwitterUser)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, twitterUserID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTwitterUsers provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) GetTwitterUsers(ctx context.Context, userID uuid.UUID) ([]models.TwitterUser, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.TwitterUser
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.TwitterUser); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TwitterUser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) GetUser(ctx context.Context, userID uuid.UUID) (models.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 models.User
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserEmail provides a mock function with given fields: ctx, userEmail
+func (_m *UserDatastore) GetUserEmail(ctx context.Context, userEmail models.UserEmail) (models.UserEmail, error) {
+	ret := _m.Called(ctx, userEmail)
+
+	var r0 models.UserEmail
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserEmail) models.UserEmail); ok {
+		r0 = rf(ctx, userEmail)
+	} else {
+		r0 = ret.Get(0).(models.UserEmail)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserEmail) error); ok {
+		r1 = rf(ctx, userEmail)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserEmails provides a mock function with given fields: ctx, status
+func (_m *UserDatastore) GetUserEmails(ctx context.Context, status string) ([]models.UserEmail, error) {
+	ret := _m.Called(ctx, status)
+
+	var r0 []models.UserEmail
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.UserEmail); ok {
+		r0 = rf(ctx, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UserEmail)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserEmailsByUserID provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) GetUserEmailsByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserEmail, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.UserEmail
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.UserEmail); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UserEmail)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserSessionByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *UserDatastore) GetUserSessionByHash(ctx context.Context, tokenHash string) (models.UserSession, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	var r0 models.UserSession
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.UserSession); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		r0 = ret.Get(0).(models.UserSession)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserSessions provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]models.UserSession, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []models.UserSession
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.UserSession); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UserSession)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HoldLock provides a mock function with given fields: ctx, key
+func (_m *UserDatastore) HoldLock(ctx context.Context, key uint) (func(), bool, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 func()
+	if rf, ok := ret.Get(0).(func(context.Context, uint) func()); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func())
+		}
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, uint) bool); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint) error); ok {
+		r2 = rf(ctx, key)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// InsertAPIToken provides a mock function with given fields: ctx, token
+func (_m *UserDatastore) InsertAPIToken(ctx context.Context, token models.APIToken) (models.APIToken, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 models.APIToken
+	if rf, ok := ret.Get(0).(func(context.Context, models.APIToken) models.APIToken); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(models.APIToken)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.APIToken) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertAdminAudit provides a mock function with given fields: ctx, audit
+func (_m *UserDatastore) InsertAdminAudit(ctx context.Context, audit models.AdminAudit) (models.AdminAudit, error) {
+	ret := _m.Called(ctx, audit)
+
+	var r0 models.AdminAudit
+	if rf, ok := ret.Get(0).(func(context.Context, models.AdminAudit) models.AdminAudit); ok {
+		r0 = rf(ctx, audit)
+	} else {
+		r0 = ret.Get(0).(models.AdminAudit)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.AdminAudit) error); ok {
+		r1 = rf(ctx, audit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertDataExport provides a mock function with given fields: ctx, export
+func (_m *UserDatastore) InsertDataExport(ctx context.Context, export models.DataExport) (models.DataExport, error) {
+	ret := _m.Called(ctx, export)
+
+	var r0 models.DataExport
+	if rf, ok := ret.Get(0).(func(context.Context, models.DataExport) models.DataExport); ok {
+		r0 = rf(ctx, export)
+	} else {
+		r0 = ret.Get(0).(models.DataExport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.DataExport) error); ok {
+		r1 = rf(ctx, export)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertEmailConfirmation provides a mock function with given fields: ctx, confirmation
+func (_m *UserDatastore) InsertEmailConfirmation(ctx context.Context, confirmation models.EmailConfirmation) (models.EmailConfirmation, error) {
+	ret := _m.Called(ctx, confirmation)
+
+	var r0 models.EmailConfirmation
+	if rf, ok := ret.Get(0).(func(context.Context, models.EmailConfirmation) models.EmailConfirmation); ok {
+		r0 = rf(ctx, confirmation)
+	} else {
+		r0 = ret.Get(0).(models.EmailConfirmation)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.EmailConfirmation) error); ok {
+		r1 = rf(ctx, confirmation)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertMagicLink provides a mock function with given fields: ctx, link
+func (_m *UserDatastore) InsertMagicLink(ctx context.Context, link models.MagicLink) (models.MagicLink, error) {
+	ret := _m.Called(ctx, link)
+
+	var r0 models.MagicLink
+	if rf, ok := ret.Get(0).(func(context.Context, models.MagicLink) models.MagicLink); ok {
+		r0 = rf(ctx, link)
+	} else {
+		r0 = ret.Get(0).(models.MagicLink)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.MagicLink) error); ok {
+		r1 = rf(ctx, link)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertMagicLinkRequest provides a mock function with given fields: ctx, email, removeBefore
+func (_m *UserDatastore) InsertMagicLinkRequest(ctx context.Context, email string, removeBefore time.Time) error {
+	ret := _m.Called(ctx, email, removeBefore)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, email, removeBefore)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertSubscription provides a mock function with given fields: ctx, subscription
+func (_m *UserDatastore) InsertSubscription(ctx context.Context, subscription models.Subscription) (models.Subscription, error) {
+	ret := _m.Called(ctx, subscription)
+
+	var r0 models.Subscription
+	if rf, ok := ret.Get(0).(func(context.Context, models.Subscription) models.Subscription); ok {
+		r0 = rf(ctx, subscription)
+	} else {
+		r0 = ret.Get(0).(models.Subscription)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.Subscription) error); ok {
+		r1 = rf(ctx, subscription)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertSubscriptionListChanges provides a mock function with given fields: ctx, changes
+func (_m *UserDatastore) InsertSubscriptionListChanges(ctx context.Context, changes []models.SubscriptionListChange) error {
+	ret := _m.Called(ctx, changes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.SubscriptionListChange) error); ok {
+		r0 = rf(ctx, changes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertSubscriptionState provides a mock function with given fields: ctx, state
+func (_m *UserDatastore) InsertSubscriptionState(ctx context.Context, state models.SubscriptionState) (models.SubscriptionState, error) {
+	ret := _m.Called(ctx, state)
+
+	var r0 models.SubscriptionState
+	if rf, ok := ret.Get(0).(func(context.Context, models.SubscriptionState) models.SubscriptionState); ok {
+		r0 = rf(ctx, state)
+	} else {
+		r0 = ret.Get(0).(models.SubscriptionState)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.SubscriptionState) error); ok {
+		r1 = rf(ctx, state)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertSubscriptionUserState provides a mock function with given fields: ctx, subscriptionID, userTwitterID, lastTweetID
+func (_m *UserDatastore) InsertSubscriptionUserState(ctx context.Context, subscriptionID uuid.UUID, userTwitterID string, lastTweetID string) error {
+	ret := _m.Called(ctx, subscriptionID, userTwitterID, lastTweetID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) error); ok {
+		r0 = rf(ctx, subscriptionID, userTwitterID, lastTweetID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertTweet provides a mock function with given fields: ctx, tweet, subscriptionStateID
+func (_m *UserDatastore) InsertTweet(ctx context.Context, tweet models.Tweet, subscriptionStateID uint) (models.Tweet, error) {
+	ret := _m.Called(ctx, tweet, subscriptionStateID)
+
+	var r0 models.Tweet
+	if rf, ok := ret.Get(0).(func(context.Context, models.Tweet, uint) models.Tweet); ok {
+		r0 = rf(ctx, tweet, subscriptionStateID)
+	} else {
+		r0 = ret.Get(0).(models.Tweet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.Tweet, uint) error); ok {
+		r1 = rf(ctx, tweet, subscriptionStateID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertTwitterUser provides a mock function with given fields: ctx, twitterUser
+func (_m *UserDatastore) InsertTwitterUser(ctx context.Context, twitterUser models.TwitterUser) (models.TwitterUser, error) {
+	ret := _m.Called(ctx, twitterUser)
+
+	var r0 models.TwitterUser
+	if rf, ok := ret.Get(0).(func(context.Context, models.TwitterUser) models.TwitterUser); ok {
+		r0 = rf(ctx, twitterUser)
+	} else {
+		r0 = ret.Get(0).(models.TwitterUser)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.TwitterUser) error); ok {
+		r1 = rf(ctx, twitterUser)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertUser provides a mock function with given fields: ctx, user
+func (_m *UserDatastore) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	ret := _m.Called(ctx, user)
+
+	var r0 models.User
+	if rf, ok := ret.Get(0).(func(context.Context, models.User) models.User); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertUserEmail provides a mock function with given fields: ctx, userEmail
+func (_m *UserDatastore) InsertUserEmail(ctx context.Context, userEmail models.UserEmail) (models.UserEmail, error) {
+	ret := _m.Called(ctx, userEmail)
+
+	var r0 models.UserEmail
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserEmail) models.UserEmail); ok {
+		r0 = rf(ctx, userEmail)
+	} else {
+		r0 = ret.Get(0).(models.UserEmail)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserEmail) error); ok {
+		r1 = rf(ctx, userEmail)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertUserSession provides a mock function with given fields: ctx, session
+func (_m *UserDatastore) InsertUserSession(ctx context.Context, session models.UserSession) (models.UserSession, error) {
+	ret := _m.Called(ctx, session)
+
+	var r0 models.UserSession
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserSession) models.UserSession); ok {
+		r0 = rf(ctx, session)
+	} else {
+		r0 = ret.Get(0).(models.UserSession)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserSession) error); ok {
+		r1 = rf(ctx, session)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertWebhookToken provides a mock function with given fields: ctx, token
+func (_m *UserDatastore) InsertWebhookToken(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PauseEmailSubscriptions provides a mock function with given fields: ctx, userID, email
+func (_m *UserDatastore) PauseEmailSubscriptions(ctx context.Context, userID uuid.UUID, email string) (uint, error) {
+	ret := _m.Called(ctx, userID, email)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) uint); ok {
+		r0 = rf(ctx, userID, email)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, userID, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReassignSubscriptionsEmail provides a mock function with given fields: ctx, userID, from, to
+func (_m *UserDatastore) ReassignSubscriptionsEmail(ctx context.Context, userID uuid.UUID, from string, to string) (uint, error) {
+	ret := _m.Called(ctx, userID, from, to)
 
-	var r0 models.SubscriptionUserTweets
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.SubscriptionUserTweets); ok {
-		r0 = rf(ctx, subscriptionID)
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) uint); ok {
+		r0 = rf(ctx, userID, from, to)
 	} else {
-		r0 = ret.Get(0).(models.SubscriptionUserTweets)
+		r0 = ret.Get(0).(uint)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, subscriptionID)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, string) error); ok {
+		r1 = rf(ctx, userID, from, to)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -172,22 +1165,20 @@ func (_m *UserDatastore) GetSubscriptionUserTweets(ctx context.Context, subscrip
 	return r0, r1
 }
 
-// GetSubscriptions provides a mock function with given fields: ctx, userID
-func (_m *UserDatastore) GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]models.Subscription, error) {
-	ret := _m.Called(ctx, userID)
+// ReleaseLock provides a mock function with given fields: ctx, key
+func (_m *UserDatastore) ReleaseLock(ctx context.Context, key uint) (bool, error) {
+	ret := _m.Called(ctx, key)
 
-	var r0 []models.Subscription
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.Subscription); ok {
-		r0 = rf(ctx, userID)
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uint) bool); ok {
+		r0 = rf(ctx, key)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Subscription)
-		}
+		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, key)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -195,17 +1186,15 @@ func (_m *UserDatastore) GetSubscriptions(ctx context.Context, userID uuid.UUID)
 	return r0, r1
 }
 
-// GetTodaySubscriptionsIDs provides a mock function with given fields: ctx
-func (_m *UserDatastore) GetTodaySubscriptionsIDs(ctx context.Context) ([]uuid.UUID, error) {
+// RemoveExpiredDataExports provides a mock function with given fields: ctx
+func (_m *UserDatastore) RemoveExpiredDataExports(ctx context.Context) (uint, error) {
 	ret := _m.Called(ctx)
 
-	var r0 []uuid.UUID
-	if rf, ok := ret.Get(0).(func(context.Context) []uuid.UUID); ok {
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context) uint); ok {
 		r0 = rf(ctx)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]uuid.UUID)
-		}
+		r0 = ret.Get(0).(uint)
 	}
 
 	var r1 error
@@ -218,20 +1207,34 @@ func (_m *UserDatastore) GetTodaySubscriptionsIDs(ctx context.Context) ([]uuid.U
 	return r0, r1
 }
 
-// GetTwitterUser provides a mock function with given fields: ctx, userID
-func (_m *UserDatastore) GetTwitterUser(ctx context.Context, userID uuid.UUID) (models.TwitterUser, error) {
-	ret := _m.Called(ctx, userID)
+// RemoveOldTweets provides a mock function with given fields: ctx, tweetTTL
+func (_m *UserDatastore) RemoveOldTweets(ctx context.Context, tweetTTL int) error {
+	ret := _m.Called(ctx, tweetTTL)
 
-	var r0 models.TwitterUser
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.TwitterUser); ok {
-		r0 = rf(ctx, userID)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, tweetTTL)
 	} else {
-		r0 = ret.Get(0).(models.TwitterUser)
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveUser provides a mock function with given fields: ctx, deletion
+func (_m *UserDatastore) RemoveUser(ctx context.Context, deletion models.AccountDeletion) (models.AccountDeletion, error) {
+	ret := _m.Called(ctx, deletion)
+
+	var r0 models.AccountDeletion
+	if rf, ok := ret.Get(0).(func(context.Context, models.AccountDeletion) models.AccountDeletion); ok {
+		r0 = rf(ctx, deletion)
+	} else {
+		r0 = ret.Get(0).(models.AccountDeletion)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, models.AccountDeletion) error); ok {
+		r1 = rf(ctx, deletion)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -239,20 +1242,20 @@ func (_m *UserDatastore) GetTwitterUser(ctx context.Context, userID uuid.UUID) (
 	return r0, r1
 }
 
-// GetTwitterUserByID provides a mock function with given fields: ctx, twitterUserID
-func (_m *UserDatastore) GetTwitterUserByID(ctx context.Context, twitterUserID string) (models.TwitterUser, error) {
-	ret := _m.Called(ctx, twitterUserID)
+// RevokeAPIToken provides a mock function with given fields: ctx, userID, tokenID
+func (_m *UserDatastore) RevokeAPIToken(ctx context.Context, userID uuid.UUID, tokenID uint) (bool, error) {
+	ret := _m.Called(ctx, userID, tokenID)
 
-	var r0 models.TwitterUser
-	if rf, ok := ret.Get(0).(func(context.Context, string) models.TwitterUser); ok {
-		r0 = rf(ctx, twitterUserID)
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uint) bool); ok {
+		r0 = rf(ctx, userID, tokenID)
 	} else {
-		r0 = ret.Get(0).(models.TwitterUser)
+		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, twitterUserID)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uint) error); ok {
+		r1 = rf(ctx, userID, tokenID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -260,15 +1263,15 @@ func (_m *UserDatastore) GetTwitterUserByID(ctx context.Context, twitterUserID s
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: ctx, userID
-func (_m *UserDatastore) GetUser(ctx context.Context, userID uuid.UUID) (models.User, error) {
+// RevokeUserAPITokens provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) RevokeUserAPITokens(ctx context.Context, userID uuid.UUID) (uint, error) {
 	ret := _m.Called(ctx, userID)
 
-	var r0 models.User
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.User); ok {
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) uint); ok {
 		r0 = rf(ctx, userID)
 	} else {
-		r0 = ret.Get(0).(models.User)
+		r0 = ret.Get(0).(uint)
 	}
 
 	var r1 error
@@ -281,20 +1284,20 @@ func (_m *UserDatastore) GetUser(ctx context.Context, userID uuid.UUID) (models.
 	return r0, r1
 }
 
-// GetUserEmail provides a mock function with given fields: ctx, userEmail
-func (_m *UserDatastore) GetUserEmail(ctx context.Context, userEmail models.UserEmail) (models.UserEmail, error) {
-	ret := _m.Called(ctx, userEmail)
+// RevokeUserSession provides a mock function with given fields: ctx, userID, sessionID
+func (_m *UserDatastore) RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID uint) (bool, error) {
+	ret := _m.Called(ctx, userID, sessionID)
 
-	var r0 models.UserEmail
-	if rf, ok := ret.Get(0).(func(context.Context, models.UserEmail) models.UserEmail); ok {
-		r0 = rf(ctx, userEmail)
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uint) bool); ok {
+		r0 = rf(ctx, userID, sessionID)
 	} else {
-		r0 = ret.Get(0).(models.UserEmail)
+		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, models.UserEmail) error); ok {
-		r1 = rf(ctx, userEmail)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uint) error); ok {
+		r1 = rf(ctx, userID, sessionID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -302,22 +1305,20 @@ func (_m *UserDatastore) GetUserEmail(ctx context.Context, userEmail models.User
 	return r0, r1
 }
 
-// GetUserEmails provides a mock function with given fields: ctx, status
-func (_m *UserDatastore) GetUserEmails(ctx context.Context, status string) ([]models.UserEmail, error) {
-	ret := _m.Called(ctx, status)
+// RevokeUserSessions provides a mock function with given fields: ctx, userID
+func (_m *UserDatastore) RevokeUserSessions(ctx context.Context, userID uuid.UUID) (uint, error) {
+	ret := _m.Called(ctx, userID)
 
-	var r0 []models.UserEmail
-	if rf, ok := ret.Get(0).(func(context.Context, string) []models.UserEmail); ok {
-		r0 = rf(ctx, status)
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) uint); ok {
+		r0 = rf(ctx, userID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.UserEmail)
-		}
+		r0 = ret.Get(0).(uint)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, status)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -325,20 +1326,20 @@ func (_m *UserDatastore) GetUserEmails(ctx context.Context, status string) ([]mo
 	return r0, r1
 }
 
-// InsertSubscription provides a mock function with given fields: ctx, subscription
-func (_m *UserDatastore) InsertSubscription(ctx context.Context, subscription models.Subscription) (models.Subscription, error) {
-	ret := _m.Called(ctx, subscription)
+// RotateTwitterUserKeys provides a mock function with given fields: ctx, limit
+func (_m *UserDatastore) RotateTwitterUserKeys(ctx context.Context, limit uint) (uint, error) {
+	ret := _m.Called(ctx, limit)
 
-	var r0 models.Subscription
-	if rf, ok := ret.Get(0).(func(context.Context, models.Subscription) models.Subscription); ok {
-		r0 = rf(ctx, subscription)
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint) uint); ok {
+		r0 = rf(ctx, limit)
 	} else {
-		r0 = ret.Get(0).(models.Subscription)
+		r0 = ret.Get(0).(uint)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, models.Subscription) error); ok {
-		r1 = rf(ctx, subscription)
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -346,20 +1347,22 @@ func (_m *UserDatastore) InsertSubscription(ctx context.Context, subscription mo
 	return r0, r1
 }
 
-// InsertSubscriptionState provides a mock function with given fields: ctx, state
-func (_m *UserDatastore) InsertSubscriptionState(ctx context.Context, state models.SubscriptionState) (models.SubscriptionState, error) {
-	ret := _m.Called(ctx, state)
+// SearchUsers provides a mock function with given fields: ctx, query, limit
+func (_m *UserDatastore) SearchUsers(ctx context.Context, query string, limit uint) ([]models.User, error) {
+	ret := _m.Called(ctx, query, limit)
 
-	var r0 models.SubscriptionState
-	if rf, ok := ret.Get(0).(func(context.Context, models.SubscriptionState) models.SubscriptionState); ok {
-		r0 = rf(ctx, state)
+	var r0 []models.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint) []models.User); ok {
+		r0 = rf(ctx, query, limit)
 	} else {
-		r0 = ret.Get(0).(models.SubscriptionState)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.User)
+		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, models.SubscriptionState) error); ok {
-		r1 = rf(ctx, state)
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint) error); ok {
+		r1 = rf(ctx, query, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -367,13 +1370,13 @@ func (_m *UserDatastore) InsertSubscriptionState(ctx context.Context, state mode
 	return r0, r1
 }
 
-// InsertSubscriptionUserState provides a mock function with given fields: ctx, subscriptionID, userTwitterID, lastTweetID
-func (_m *UserDatastore) InsertSubscriptionUserState(ctx context.Context, subscriptionID uuid.UUID, userTwitterID string, lastTweetID string) error {
-	ret := _m.Called(ctx, subscriptionID, userTwitterID, lastTweetID)
+// SetEmailConfirmationSent provides a mock function with given fields: ctx, email
+func (_m *UserDatastore) SetEmailConfirmationSent(ctx context.Context, email models.UserEmail) error {
+	ret := _m.Called(ctx, email)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) error); ok {
-		r0 = rf(ctx, subscriptionID, userTwitterID, lastTweetID)
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserEmail) error); ok {
+		r0 = rf(ctx, email)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -381,20 +1384,20 @@ func (_m *UserDatastore) InsertSubscriptionUserState(ctx context.Context, subscr
 	return r0
 }
 
-// InsertTweet provides a mock function with given fields: ctx, tweet, subscriptionStateID
-func (_m *UserDatastore) InsertTweet(ctx context.Context, tweet models.Tweet, subscriptionStateID uint) (models.Tweet, error) {
-	ret := _m.Called(ctx, tweet, subscriptionStateID)
+// SetUserDisabled provides a mock function with given fields: ctx, userID, disabled
+func (_m *UserDatastore) SetUserDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (models.User, error) {
+	ret := _m.Called(ctx, userID, disabled)
 
-	var r0 models.Tweet
-	if rf, ok := ret.Get(0).(func(context.Context, models.Tweet, uint) models.Tweet); ok {
-		r0 = rf(ctx, tweet, subscriptionStateID)
+	var r0 models.User
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, bool) models.User); ok {
+		r0 = rf(ctx, userID, disabled)
 	} else {
-		r0 = ret.Get(0).(models.Tweet)
+		r0 = ret.Get(0).(models.User)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, models.Tweet, uint) error); ok {
-		r1 = rf(ctx, tweet, subscriptionStateID)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, bool) error); ok {
+		r1 = rf(ctx, userID, disabled)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -402,20 +1405,20 @@ func (_m *UserDatastore) InsertTweet(ctx context.Context, tweet models.Tweet, su
 	return r0, r1
 }
 
-// InsertTwitterUser provides a mock function with given fields: ctx, twitterUser
-func (_m *UserDatastore) InsertTwitterUser(ctx context.Context, twitterUser models.TwitterUser) (models.TwitterUser, error) {
-	ret := _m.Called(ctx, twitterUser)
+// UnlinkTwitterUser provides a mock function with given fields: ctx, userID, twitterID
+func (_m *UserDatastore) UnlinkTwitterUser(ctx context.Context, userID uuid.UUID, twitterID string) (bool, error) {
+	ret := _m.Called(ctx, userID, twitterID)
 
-	var r0 models.TwitterUser
-	if rf, ok := ret.Get(0).(func(context.Context, models.TwitterUser) models.TwitterUser); ok {
-		r0 = rf(ctx, twitterUser)
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) bool); ok {
+		r0 = rf(ctx, userID, twitterID)
 	} else {
-		r0 = ret.Get(0).(models.TwitterUser)
+		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, models.TwitterUser) error); ok {
-		r1 = rf(ctx, twitterUser)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, userID, twitterID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -423,41 +1426,34 @@ func (_m *UserDatastore) InsertTwitterUser(ctx context.Context, twitterUser mode
 	return r0, r1
 }
 
-// InsertUser provides a mock function with given fields: ctx, user
-func (_m *UserDatastore) InsertUser(ctx context.Context, user models.User) (models.User, error) {
-	ret := _m.Called(ctx, user)
-
-	var r0 models.User
-	if rf, ok := ret.Get(0).(func(context.Context, models.User) models.User); ok {
-		r0 = rf(ctx, user)
-	} else {
-		r0 = ret.Get(0).(models.User)
-	}
+// UpdateAPITokenLastUsed provides a mock function with given fields: ctx, tokenID
+func (_m *UserDatastore) UpdateAPITokenLastUsed(ctx context.Context, tokenID uint) error {
+	ret := _m.Called(ctx, tokenID)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, models.User) error); ok {
-		r1 = rf(ctx, user)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, tokenID)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// InsertUserEmail provides a mock function with given fields: ctx, userEmail
-func (_m *UserDatastore) InsertUserEmail(ctx context.Context, userEmail models.UserEmail) (models.UserEmail, error) {
-	ret := _m.Called(ctx, userEmail)
+// UpdateAccountDeletion provides a mock function with given fields: ctx, deletion
+func (_m *UserDatastore) UpdateAccountDeletion(ctx context.Context, deletion models.AccountDeletion) (models.AccountDeletion, error) {
+	ret := _m.Called(ctx, deletion)
 
-	var r0 models.UserEmail
-	if rf, ok := ret.Get(0).(func(context.Context, models.UserEmail) models.UserEmail); ok {
-		r0 = rf(ctx, userEmail)
+	var r0 models.AccountDeletion
+	if rf, ok := ret.Get(0).(func(context.Context, models.AccountDeletion) models.AccountDeletion); ok {
+		r0 = rf(ctx, deletion)
 	} else {
-		r0 = ret.Get(0).(models.UserEmail)
+		r0 = ret.Get(0).(models.AccountDeletion)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, models.UserEmail) error); ok {
-		r1 = rf(ctx, userEmail)
+	if rf, ok := ret.Get(1).(func(context.Context, models.AccountDeletion) error); ok {
+		r1 = rf(ctx, deletion)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -465,20 +1461,20 @@ func (_m *UserDatastore) InsertUserEmail(ctx context.Context, userEmail models.U
 	return r0, r1
 }
 
-// ReleaseLock provides a mock function with given fields: ctx, key
-func (_m *UserDatastore) ReleaseLock(ctx context.Context, key uint) (bool, error) {
-	ret := _m.Called(ctx, key)
+// UpdateDataExport provides a mock function with given fields: ctx, export
+func (_m *UserDatastore) UpdateDataExport(ctx context.Context, export models.DataExport) (models.DataExport, error) {
+	ret := _m.Called(ctx, export)
 
-	var r0 bool
-	if rf, ok := ret.Get(0).(func(context.Context, uint) bool); ok {
-		r0 = rf(ctx, key)
+	var r0 models.DataExport
+	if rf, ok := ret.Get(0).(func(context.Context, models.DataExport) models.DataExport); ok {
+		r0 = rf(ctx, export)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Get(0).(models.DataExport)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
-		r1 = rf(ctx, key)
+	if rf, ok := ret.Get(1).(func(context.Context, models.DataExport) error); ok {
+		r1 = rf(ctx, export)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -486,27 +1482,13 @@ func (_m *UserDatastore) ReleaseLock(ctx context.Context, key uint) (bool, error
 	return r0, r1
 }
 
-// RemoveOldTweets provides a mock function with given fields: ctx, tweetTTL
-func (_m *UserDatastore) RemoveOldTweets(ctx context.Context, tweetTTL int) error {
-	ret := _m.Called(ctx, tweetTTL)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
-		r0 = rf(ctx, tweetTTL)
-	} else {
-		r0 = ret.Error(0)
-	}
-
-	return r0
-}
-
-// RemoveUser provides a mock function with given fields: ctx, userID
-func (_m *UserDatastore) RemoveUser(ctx context.Context, userID uuid.UUID) error {
-	ret := _m.Called(ctx, userID)
+// UpdateIssueUserStateTweets provides a mock function with given fields: ctx, subscriptionStateID
+func (_m *UserDatastore) UpdateIssueUserStateTweets(ctx context.Context, subscriptionStateID uint) error {
+	ret := _m.Called(ctx, subscriptionStateID)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
-		r0 = rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, subscriptionStateID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -646,3 +1628,87 @@ func (_m *UserDatastore) UpdateUserEmail(ctx context.Context, userEmail models.U
 
 	return r0, r1
 }
+
+// UpdateUserRole provides a mock function with given fields: ctx, userID, role
+func (_m *UserDatastore) UpdateUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	ret := _m.Called(ctx, userID, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, userID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUserSessionLastSeen provides a mock function with given fields: ctx, sessionID
+func (_m *UserDatastore) UpdateUserSessionLastSeen(ctx context.Context, sessionID uint) error {
+	ret := _m.Called(ctx, sessionID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUserVacation provides a mock function with given fields: ctx, userID, start, end
+func (_m *UserDatastore) UpdateUserVacation(ctx context.Context, userID uuid.UUID, start *time.Time, end *time.Time) error {
+	ret := _m.Called(ctx, userID, start, end)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *time.Time, *time.Time) error); ok {
+		r0 = rf(ctx, userID, start, end)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseEmailConfirmation provides a mock function with given fields: ctx, nonceHash
+func (_m *UserDatastore) UseEmailConfirmation(ctx context.Context, nonceHash string) (models.EmailConfirmation, error) {
+	ret := _m.Called(ctx, nonceHash)
+
+	var r0 models.EmailConfirmation
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.EmailConfirmation); ok {
+		r0 = rf(ctx, nonceHash)
+	} else {
+		r0 = ret.Get(0).(models.EmailConfirmation)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, nonceHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UseMagicLink provides a mock function with given fields: ctx, nonceHash
+func (_m *UserDatastore) UseMagicLink(ctx context.Context, nonceHash string) (models.MagicLink, error) {
+	ret := _m.Called(ctx, nonceHash)
+
+	var r0 models.MagicLink
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.MagicLink); ok {
+		r0 = rf(ctx, nonceHash)
+	} else {
+		r0 = ret.Get(0).(models.MagicLink)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, nonceHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -36,6 +36,39 @@ const (
 
 	//EmailStatusConfirmed - Email status Confirmed
 	EmailStatusConfirmed string = "CONFIRMED"
+
+	//EmailStatusBounced - Email hard-bounced
+	EmailStatusBounced string = "BOUNCED"
+
+	//EmailStatusComplained - recipient marked email as spam
+	EmailStatusComplained string = "COMPLAINED"
+
+	//EmailStatusUnsubscribed - recipient unsubscribed using provider's link
+	EmailStatusUnsubscribed string = "UNSUBSCRIBED"
+
+	//EmailStatusAbandoned - address wasn't confirmed after all reminders
+	EmailStatusAbandoned string = "ABANDONED"
+
+	//APITokenScopeRead - token can only read data
+	APITokenScopeRead string = "READ"
+
+	//APITokenScopeWrite - token can read and change data
+	APITokenScopeWrite string = "WRITE"
+
+	//UserRoleUser - regular user
+	UserRoleUser string = "USER"
+
+	//UserRoleAdmin - operator who can use the admin API
+	UserRoleAdmin string = "ADMIN"
+
+	//DataExportStatusPending - archive is being built
+	DataExportStatusPending string = "PENDING"
+
+	//DataExportStatusReady - archive can be downloaded till it expires
+	DataExportStatusReady string = "READY"
+
+	//DataExportStatusFailed - archive could not be built
+	DataExportStatusFailed string = "FAILED"
 )
 
 // Model interface
@@ -43,14 +76,16 @@ type Model interface {
 	String() string
 }
 
-// TwitterUser - represents twitter account
+// TwitterUser - represents twitter account linked to the user, the primary account is the one the user signed up with,
+// its tokens fetch timelines of subscriptions that don't choose another account
 type TwitterUser struct {
 	UserID        uuid.UUID `db:"user_id"`
 	TwitterID     string    `db:"social_account_id"`
 	AccessToken   string    `db:"access_token"`
 	TokenSecret   string    `db:"token_secret"`
 	ProfileIMGURL string    `db:"profile_image_url"`
-	ScreenName    string
+	ScreenName    string    `db:"screen_name"`
+	Primary       bool      `db:"is_primary"`
 }
 
 func (t TwitterUser) String() string {
@@ -59,26 +94,181 @@ func (t TwitterUser) String() string {
 
 // User - represents user
 type User struct {
-	ID    uuid.UUID `db:"id"`
-	Name  string    `db:"name"`
-	Email string    `db:"email"`
+	ID            uuid.UUID  `db:"id"`
+	Name          string     `db:"name"`
+	Email         string     `db:"email"`
+	VacationStart *time.Time `db:"vacation_start"`
+	VacationEnd   *time.Time `db:"vacation_end"`
+	Role          string     `db:"role"`
+	DisabledAt    *time.Time `db:"disabled_at"`
 }
 
 func (u User) String() string {
 	return fmt.Sprintf("User: Name %s, ID %s", u.Name, u.ID)
 }
 
+// IsAdmin - true if the user can use the admin API
+func (u User) IsAdmin() bool {
+	return u.Role == UserRoleAdmin && u.DisabledAt == nil
+}
+
+// AdminAudit - action of an admin, target is the affected object e.g. user:<id>
+type AdminAudit struct {
+	ID        uint      `db:"id"`
+	AdminID   uuid.UUID `db:"admin_id"`
+	Action    string    `db:"action"`
+	Target    string    `db:"target"`
+	Details   string    `db:"details"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (a AdminAudit) String() string {
+	return fmt.Sprintf("AdminAudit: AdminID %s, Action %s, Target %s", a.AdminID, a.Action, a.Target)
+}
+
+// AdminUserDetails - user with everything support needs to inspect, twitter tokens are not included
+type AdminUserDetails struct {
+	User            User
+	Emails          []UserEmail
+	TwitterAccounts []TwitterUser
+	Subscriptions   []Subscription
+}
+
+// APIToken - personal access token, only its hash is stored
+type APIToken struct {
+	ID         uint       `db:"id"`
+	UserID     uuid.UUID  `db:"user_id"`
+	Name       string     `db:"name"`
+	TokenHash  string     `db:"token_hash"`
+	Scope      string     `db:"scope"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+func (t APIToken) String() string {
+	return fmt.Sprintf("APIToken: ID %d, UserID %s, Name %s, Scope %s", t.ID, t.UserID, t.Name, t.Scope)
+}
+
+// CanWrite - true if the token may change data
+func (t APIToken) CanWrite() bool {
+	return t.Scope == APITokenScopeWrite
+}
+
+// MagicLink - single-use sign-in link sent to a confirmed email, only the hash of its nonce is stored
+type MagicLink struct {
+	ID        uint       `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	Email     string     `db:"email"`
+	NonceHash string     `db:"nonce_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+func (l MagicLink) String() string {
+	return fmt.Sprintf("MagicLink: ID %d, UserID %s, Email %s", l.ID, l.UserID, l.Email)
+}
+
+// EmailConfirmation - single-use link confirming the address, only the hash of its nonce is stored
+type EmailConfirmation struct {
+	ID        uint       `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	Email     string     `db:"email"`
+	NonceHash string     `db:"nonce_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+func (c EmailConfirmation) String() string {
+	return fmt.Sprintf("EmailConfirmation: ID %d, UserID %s, Email %s", c.ID, c.UserID, c.Email)
+}
+
+// UserSession - signed in browser session, the cookie keeps the session token and only its hash is stored
+type UserSession struct {
+	ID         uint       `db:"id"`
+	UserID     uuid.UUID  `db:"user_id"`
+	TokenHash  string     `db:"token_hash"`
+	UserAgent  string     `db:"user_agent"`
+	IP         string     `db:"ip"`
+	LastSeenAt time.Time  `db:"last_seen_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+func (s UserSession) String() string {
+	return fmt.Sprintf("UserSession: ID %d, UserID %s", s.ID, s.UserID)
+}
+
+// IsActive - true if the session is neither revoked nor expired
+func (s UserSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// DataExport - background export of all user data as a ZIP archive, the archive is removed when it expires
+type DataExport struct {
+	ID            uint       `db:"id"`
+	UserID        uuid.UUID  `db:"user_id"`
+	Status        string     `db:"status"`
+	Archive       []byte     `db:"archive"`
+	FailureReason string     `db:"failure_reason"`
+	ExpiresAt     *time.Time `db:"expires_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	// DownloadLink - signed link to the ready archive, it isn't stored
+	DownloadLink string `db:"-"`
+}
+
+func (e DataExport) String() string {
+	return fmt.Sprintf("DataExport: ID %d, UserID %s, Status %s", e.ID, e.UserID, e.Status)
+}
+
+// IsReady - true if the archive can be downloaded
+func (e DataExport) IsReady(now time.Time) bool {
+	return e.Status == DataExportStatusReady && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
+
+// AccountDeletion - audit record of a purged account, it keeps no personal data
+type AccountDeletion struct {
+	ID                       uint      `db:"id"`
+	UserID                   uuid.UUID `db:"user_id"`
+	TokensRevoked            uint      `db:"tokens_revoked"`
+	TokensFailed             uint      `db:"tokens_failed"`
+	TweetsRemoved            uint      `db:"tweets_removed"`
+	SubscriptionUsersRemoved uint      `db:"subscription_users_removed"`
+	DeletedAt                time.Time `db:"deleted_at"`
+}
+
+func (d AccountDeletion) String() string {
+	return fmt.Sprintf("AccountDeletion: ID %d, UserID %s, tweets %d, subscription users %d", d.ID, d.UserID, d.TweetsRemoved, d.SubscriptionUsersRemoved)
+}
+
 // UserEmail - confirmed user email address
 type UserEmail struct {
-	UserID uuid.UUID `db:"user_id"`
-	Email  string    `db:"email"`
-	Status string    `db:"status"`
+	UserID             uuid.UUID  `db:"user_id"`
+	Email              string     `db:"email"`
+	Status             string     `db:"status"`
+	CreatedAt          time.Time  `db:"created_at"`
+	ConfirmationSentAt *time.Time `db:"confirmation_sent_at"`
+	RemindersSent      uint       `db:"reminders_sent"`
 }
 
 func (u UserEmail) String() string {
 	return fmt.Sprintf("User: ID %s, email %s", u.UserID, u.Email)
 }
 
+// IsSuppressed checks if emails must not be sent to the address
+func (u UserEmail) IsSuppressed() bool {
+	return IsEmailSuppressed(u.Status)
+}
+
+// IsEmailSuppressed checks if email status means that emails must not be sent to the address
+func IsEmailSuppressed(status string) bool {
+	return status == EmailStatusBounced || status == EmailStatusComplained || status == EmailStatusUnsubscribed
+}
+
 // TwitterUserSearchResult - twitter user info
 type TwitterUserSearchResult struct {
 	Name          string `db:"name"`
@@ -118,6 +308,66 @@ func (u UserList) Diff(another UserList) UserList {
 	return res
 }
 
+// TwitterList represents a Twitter List the user owns or subscribes to
+type TwitterList struct {
+	ID              string
+	Name            string
+	Description     string
+	OwnerScreenName string
+	MemberCount     int64
+	Private         bool
+}
+
+// Twitter accounts sources to import into a subscription
+const (
+	ImportSourceList      = "list"
+	ImportSourceFollowing = "following"
+)
+
+// ImportSource describes Twitter accounts to import into a subscription,
+// UserIDs and Query select some of the user's followings, all of them are imported otherwise
+type ImportSource struct {
+	Kind    string
+	ListID  string
+	UserIDs []string
+	Query   string
+	// Sync keeps the subscription synced with the list after the import
+	Sync bool
+}
+
+// SubscriptionDays - delivery days, the spelling follows the weekday database enum
+var SubscriptionDays = []string{"monday", "tuesday", "wensday", "thursday", "friday", "saturday", "sunday"}
+
+// SubscriptionImportResult - outcome of importing one subscription of the file,
+// Errors are keyed by the field name, Unresolved lists handles Twitter doesn't know
+type SubscriptionImportResult struct {
+	Subscription Subscription
+	Resolved     int
+	Unresolved   []string
+	Errors       map[string]string
+}
+
+// Valid returns true if the subscription can be imported
+func (r SubscriptionImportResult) Valid() bool {
+	return len(r.Errors) == 0 && len(r.Unresolved) == 0
+}
+
+// SubscriptionsImportReport - outcome of importing the file, nothing is created on the dry run
+type SubscriptionsImportReport struct {
+	DryRun  bool
+	Results []SubscriptionImportResult
+}
+
+// Valid returns true if all subscriptions can be imported
+func (r SubscriptionsImportReport) Valid() bool {
+	for _, res := range r.Results {
+		if !res.Valid() {
+			return false
+		}
+	}
+	return true
+}
+
 // Subscription represents user subscription
 type Subscription struct {
 	ID            uuid.UUID `db:"id"`
@@ -127,7 +377,13 @@ type Subscription struct {
 	Day           string    `db:"day"`
 	IgnoreRT      bool      `db:"ignore_rt"`
 	IgnoreReplies bool      `db:"ignore_replies"`
-	UserList      UserList
+	EreaderEmail  string    `db:"ereader_email"`
+	Paused        bool      `db:"paused"`
+	EmailStatus   string    `db:"email_status"`
+	TwitterListID string    `db:"twitter_list_id"`
+	// TwitterAccountID - id of the linked twitter account fetching timelines, empty for the primary account
+	TwitterAccountID string `db:"twitter_account_id"`
+	UserList         UserList
 }
 
 func (s Subscription) String() string {
@@ -168,6 +424,22 @@ func (s Subscription) Equal(another Subscription) bool {
 		return false
 	}
 
+	if s.EreaderEmail != another.EreaderEmail {
+		return false
+	}
+
+	if s.Paused != another.Paused {
+		return false
+	}
+
+	if s.TwitterListID != another.TwitterListID {
+		return false
+	}
+
+	if s.TwitterAccountID != another.TwitterAccountID {
+		return false
+	}
+
 	if len(s.UserList) != len(another.UserList) {
 		return false
 	}
@@ -180,13 +452,40 @@ func (s Subscription) Equal(another Subscription) bool {
 	return true
 }
 
-// SubscriptionState - subscription status
-type SubscriptionState struct {
+// Changes of twitter list members
+const (
+	ListChangeAdded   = "ADDED"
+	ListChangeRemoved = "REMOVED"
+)
+
+// SubscriptionListChange - account added to or removed from the subscription when its twitter list was synced
+type SubscriptionListChange struct {
 	ID             uint      `db:"id"`
 	SubscriptionID uuid.UUID `db:"subscription_id"`
-	Status         string    `db:"status"`
+	TwitterListID  string    `db:"twitter_list_id"`
+	TwitterID      string    `db:"user_twitter_id"`
+	ScreenName     string    `db:"screen_name"`
+	Name           string    `db:"name"`
+	Kind           string    `db:"kind"`
 	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (c SubscriptionListChange) String() string {
+	return fmt.Sprintf("SubscriptionListChange: SubscriptionID %s, TwitterID %s, Kind %s", c.SubscriptionID, c.TwitterID, c.Kind)
+}
+
+// SubscriptionState - subscription status
+type SubscriptionState struct {
+	ID             uint       `db:"id"`
+	SubscriptionID uuid.UUID  `db:"subscription_id"`
+	Status         string     `db:"status"`
+	TweetCount     uint       `db:"tweet_count"`
+	FailureReason  string     `db:"failure_reason"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	SentAt         *time.Time `db:"sent_at"`
+	ShareToken     string     `db:"share_token"`
+	OnDemand       bool       `db:"on_demand"`
 }
 
 func (s *SubscriptionState) String() string {
@@ -194,6 +493,35 @@ func (s *SubscriptionState) String() string {
 		"SubscriptionState: id %d, subscription_id %s, status %s, updated at %s", s.ID, s.SubscriptionID, s.Status, s.UpdatedAt)
 }
 
+// Stages of sending an issue on demand
+const (
+	SendStageFetching  string = "fetching"
+	SendStageStored    string = "stored"
+	SendStageRendering string = "rendering"
+	SendStageSent      string = "sent"
+	SendStageFailed    string = "failed"
+)
+
+// SendProgress - progress of sending an issue on demand
+type SendProgress struct {
+	Stage      string `json:"stage"`
+	IssueID    uint   `json:"issue_id"`
+	TweetCount uint   `json:"tweet_count"`
+	Message    string `json:"message,omitempty"`
+}
+
+// Issue - subscription issue with its tweets
+type Issue struct {
+	State        SubscriptionState
+	Subscription Subscription
+	Tweets       []Tweet
+	ShareLink    string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("Issue: id %d, subscription %s, tweets %d", i.State.ID, i.Subscription.ID, len(i.Tweets))
+}
+
 // UserLastTweet - last read tweet of a user
 type UserLastTweet struct {
 	ScreenName  string
@@ -262,6 +590,46 @@ type UserUseCase interface {
 	DeleteSubscription(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID) error
 	DeleteAccount(ctx context.Context, userID uuid.UUID) error
 	ConfirmEmail(ctx context.Context, token string) error
+	UpdateEmailStatus(ctx context.Context, email, status string) error
+	UseWebhookToken(ctx context.Context, token string) error
+	Unsubscribe(ctx context.Context, token string, remove bool) (Subscription, error)
+	GetIssueEpub(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (Attachment, error)
+	GetSubscriptionIssues(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID, limit, offset uint) ([]SubscriptionState, uint, error)
+	GetIssue(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (Issue, error)
+	GetIssueHTML(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (string, error)
+	GetSharedIssueHTML(ctx context.Context, shareToken string) (string, error)
+	ShareIssue(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (string, error)
+	UnshareIssue(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) error
+	PreviewSubscription(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID, settings *Subscription) (Issue, string, error)
+	PauseSubscription(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID) (Subscription, error)
+	ResumeSubscription(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID, catchUp bool) (Subscription, error)
+	SetVacation(ctx context.Context, userID uuid.UUID, start, end *time.Time) (User, error)
+	CreateAPIToken(ctx context.Context, userID uuid.UUID, name, scope string) (APIToken, string, error)
+	GetAPITokens(ctx context.Context, userID uuid.UUID) ([]APIToken, error)
+	RevokeAPIToken(ctx context.Context, userID uuid.UUID, tokenID uint) error
+	AuthenticateAPIToken(ctx context.Context, token string) (APIToken, error)
+	GetTwitterLists(ctx context.Context, userID uuid.UUID) ([]TwitterList, error)
+	GetTwitterListMembers(ctx context.Context, userID uuid.UUID, listID string, cursor int64) ([]TwitterUserSearchResult, int64, error)
+	GetTwitterFriends(ctx context.Context, userID uuid.UUID, cursor int64) ([]TwitterUserSearchResult, int64, error)
+	ImportTwitterUsers(ctx context.Context, userID uuid.UUID, source ImportSource, subscription Subscription) (Subscription, int, error)
+	GetSubscriptionListChanges(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID, limit, offset uint) ([]SubscriptionListChange, error)
+	ImportSubscriptions(ctx context.Context, userID uuid.UUID, subscriptions []Subscription, dryRun bool) (SubscriptionsImportReport, error)
+	SignInWithMagicLink(ctx context.Context, token string) (User, error)
+	LinkTwitterAccount(ctx context.Context, userID uuid.UUID, twitterID, screenName, accessToken, tokenSecret string) (TwitterUser, error)
+	GetTwitterAccounts(ctx context.Context, userID uuid.UUID) ([]TwitterUser, error)
+	GetTwitterAccountSubscriptions(ctx context.Context, userID uuid.UUID, twitterID string) ([]Subscription, error)
+	UnlinkTwitterAccount(ctx context.Context, userID uuid.UUID, twitterID string, force bool) ([]Subscription, error)
+	StartSession(ctx context.Context, userID uuid.UUID, userAgent, ip string) (string, error)
+	AuthenticateSession(ctx context.Context, token string) (UserSession, error)
+	GetSessions(ctx context.Context, userID uuid.UUID) ([]UserSession, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uint) error
+	RevokeSessions(ctx context.Context, userID uuid.UUID) (uint, error)
+	GetDataExports(ctx context.Context, userID uuid.UUID) ([]DataExport, error)
+	DownloadDataExport(ctx context.Context, token string) (Attachment, error)
+	GetEmails(ctx context.Context, userID uuid.UUID) ([]UserEmail, error)
+	AddEmail(ctx context.Context, userID uuid.UUID, email string) (UserEmail, error)
+	DeleteEmail(ctx context.Context, userID uuid.UUID, email, reassignTo string) ([]Subscription, error)
+	ReactivateEmail(ctx context.Context, userID uuid.UUID, email string) (UserEmail, error)
 }
 
 // UserDatastore - represents all user related database methods
@@ -269,12 +637,41 @@ type UserDatastore interface {
 	InsertUser(ctx context.Context, user User) (User, error)
 	UpdateUser(ctx context.Context, user User) (User, error)
 	GetUser(ctx context.Context, userID uuid.UUID) (User, error)
-	RemoveUser(ctx context.Context, userID uuid.UUID) error
+	RemoveUser(ctx context.Context, deletion AccountDeletion) (AccountDeletion, error)
+	UpdateAccountDeletion(ctx context.Context, deletion AccountDeletion) (AccountDeletion, error)
+	UpdateUserVacation(ctx context.Context, userID uuid.UUID, start, end *time.Time) error
+	UpdateUserRole(ctx context.Context, userID uuid.UUID, role string) error
+	SetUserDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (User, error)
+	SearchUsers(ctx context.Context, query string, limit uint) ([]User, error)
+	InsertAdminAudit(ctx context.Context, audit AdminAudit) (AdminAudit, error)
+	GetAdminAudit(ctx context.Context, limit, offset uint) ([]AdminAudit, error)
+
+	InsertAPIToken(ctx context.Context, token APIToken) (APIToken, error)
+	GetAPITokens(ctx context.Context, userID uuid.UUID) ([]APIToken, error)
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (APIToken, error)
+	RevokeAPIToken(ctx context.Context, userID uuid.UUID, tokenID uint) (bool, error)
+	UpdateAPITokenLastUsed(ctx context.Context, tokenID uint) error
+	RevokeUserAPITokens(ctx context.Context, userID uuid.UUID) (uint, error)
+
+	InsertUserSession(ctx context.Context, session UserSession) (UserSession, error)
+	GetUserSessionByHash(ctx context.Context, tokenHash string) (UserSession, error)
+	GetUserSessions(ctx context.Context, userID uuid.UUID) ([]UserSession, error)
+	UpdateUserSessionLastSeen(ctx context.Context, sessionID uint) error
+	RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID uint) (bool, error)
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) (uint, error)
+	InsertMagicLink(ctx context.Context, link MagicLink) (MagicLink, error)
+	InsertMagicLinkRequest(ctx context.Context, email string, removeBefore time.Time) error
+	CountMagicLinkRequests(ctx context.Context, email string, since time.Time) (uint, error)
+	UseMagicLink(ctx context.Context, nonceHash string) (MagicLink, error)
 
 	InsertTwitterUser(ctx context.Context, twitterUser TwitterUser) (TwitterUser, error)
 	UpdateTwitterUser(ctx context.Context, twitterUser TwitterUser) (TwitterUser, error)
 	GetTwitterUserByID(ctx context.Context, twitterUserID string) (TwitterUser, error)
 	GetTwitterUser(ctx context.Context, userID uuid.UUID) (TwitterUser, error)
+	GetTwitterUsers(ctx context.Context, userID uuid.UUID) ([]TwitterUser, error)
+	UnlinkTwitterUser(ctx context.Context, userID uuid.UUID, twitterID string) (bool, error)
+	RotateTwitterUserKeys(ctx context.Context, limit uint) (uint, error)
+	CountTwitterUsersToRotate(ctx context.Context) (uint, error)
 
 	InsertSubscription(ctx context.Context, subscription Subscription) (Subscription, error)
 	GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]Subscription, error)
@@ -289,8 +686,17 @@ type UserDatastore interface {
 	GetTodaySubscriptionsIDs(ctx context.Context) ([]uuid.UUID, error)
 	InsertSubscriptionState(ctx context.Context, state SubscriptionState) (SubscriptionState, error)
 	UpdateSubscriptionState(ctx context.Context, state SubscriptionState) (SubscriptionState, error)
+	GetSubscriptionState(ctx context.Context, subscriptionStateID uint) (SubscriptionState, error)
 	GetReadySubscriptionsStates(ctx context.Context, subscriptionIDs ...uuid.UUID) ([]SubscriptionState, error)
+	GetSubscriptionStates(ctx context.Context, subscriptionID uuid.UUID, limit, offset uint) ([]SubscriptionState, error)
+	CountSubscriptionStates(ctx context.Context, subscriptionID uuid.UUID) (uint, error)
+	CountOnDemandSubscriptionStates(ctx context.Context, userID uuid.UUID, since time.Time) (uint, error)
+	GetSubscriptionStateByShareToken(ctx context.Context, shareToken string) (SubscriptionState, error)
+	GetFailedSubscriptionStates(ctx context.Context, limit, offset uint) ([]SubscriptionState, error)
 	UpdateSubscriptionUserStateTweets(ctx context.Context) error
+	UpdateIssueUserStateTweets(ctx context.Context, subscriptionStateID uint) error
+	InsertSubscriptionListChanges(ctx context.Context, changes []SubscriptionListChange) error
+	GetSubscriptionListChanges(ctx context.Context, subscriptionID uuid.UUID, limit, offset uint) ([]SubscriptionListChange, error)
 
 	GetSubscriptionUserTweets(ctx context.Context, subscriptionID uuid.UUID) (SubscriptionUserTweets, error)
 	GetSubscriptionTweets(ctx context.Context, subscriptionStateID uint) ([]Tweet, error)
@@ -299,11 +705,28 @@ type UserDatastore interface {
 
 	AcquireLock(ctx context.Context, key uint) (bool, error)
 	ReleaseLock(ctx context.Context, key uint) (bool, error)
+	HoldLock(ctx context.Context, key uint) (func(), bool, error)
 
 	InsertUserEmail(ctx context.Context, userEmail UserEmail) (UserEmail, error)
 	GetUserEmail(ctx context.Context, userEmail UserEmail) (UserEmail, error)
 	UpdateUserEmail(ctx context.Context, userEmail UserEmail) (UserEmail, error)
 	GetUserEmails(ctx context.Context, status string) ([]UserEmail, error)
+	GetUserEmailsByUserID(ctx context.Context, userID uuid.UUID) ([]UserEmail, error)
+	CountUserEmailsSince(ctx context.Context, userID uuid.UUID, since time.Time) (uint, error)
+	SetEmailConfirmationSent(ctx context.Context, email UserEmail) error
+	InsertEmailConfirmation(ctx context.Context, confirmation EmailConfirmation) (EmailConfirmation, error)
+	UseEmailConfirmation(ctx context.Context, nonceHash string) (EmailConfirmation, error)
+	ConfirmVerifiedEmails(ctx context.Context) (uint, error)
+	PauseEmailSubscriptions(ctx context.Context, userID uuid.UUID, email string) (uint, error)
+	InsertWebhookToken(ctx context.Context, token string) error
+	DeleteUserEmail(ctx context.Context, userID uuid.UUID, email string) (bool, error)
+	ReassignSubscriptionsEmail(ctx context.Context, userID uuid.UUID, from, to string) (uint, error)
+
+	InsertDataExport(ctx context.Context, export DataExport) (DataExport, error)
+	UpdateDataExport(ctx context.Context, export DataExport) (DataExport, error)
+	GetDataExport(ctx context.Context, exportID uint) (DataExport, error)
+	GetDataExports(ctx context.Context, userID uuid.UUID) ([]DataExport, error)
+	RemoveExpiredDataExports(ctx context.Context) (uint, error)
 
 	RemoveOldTweets(ctx context.Context, tweetTTL int) error
 }
@@ -314,8 +737,22 @@ type SystemUseCase interface {
 	PrepareSubscriptions(ids ...uuid.UUID) error
 	SendSubscriptions(ids ...uuid.UUID) error
 	SendConfirmationEmail() error
-	GetToken(email, userID string) (string, error)
 	RemoveOldTweets() error
+	SendSubscriptionNow(userID, subscriptionID uuid.UUID) (<-chan SendProgress, error)
+	SendMagicLink(ctx context.Context, email string) error
+	RotateTokenKeys() (uint, error)
+	ExportUserData(userID uuid.UUID) (DataExport, error)
+	ResendConfirmationEmail(ctx context.Context, userID uuid.UUID, email string) (UserEmail, error)
+	SetUserRole(userID uuid.UUID, role string) error
+	AuthorizeAdmin(ctx context.Context, userID uuid.UUID) (User, error)
+	AdminSearchUsers(ctx context.Context, adminID uuid.UUID, query string) ([]User, error)
+	AdminGetUser(ctx context.Context, adminID, userID uuid.UUID) (AdminUserDetails, error)
+	AdminGetSubscriptionIssues(ctx context.Context, adminID, subscriptionID uuid.UUID, limit, offset uint) ([]SubscriptionState, uint, error)
+	AdminGetFailedIssues(ctx context.Context, adminID uuid.UUID, limit, offset uint) ([]SubscriptionState, error)
+	AdminResendIssue(ctx context.Context, adminID uuid.UUID, subscriptionStateID uint) (SubscriptionState, error)
+	AdminForceReconfirmation(ctx context.Context, adminID uuid.UUID, email string) (UserEmail, error)
+	AdminSetUserDisabled(ctx context.Context, adminID, userID uuid.UUID, disabled bool) (User, error)
+	AdminGetAudit(ctx context.Context, adminID uuid.UUID, limit, offset uint) ([]AdminAudit, error)
 }
 
 // UseCases - represents all use cases
@@ -329,7 +766,47 @@ func NewUseCases(user UserUseCase, system SystemUseCase) *UseCases {
 	return &UseCases{user, system}
 }
 
+// Attachment - email attachment, inline attachments are referenced from html body by cid:ContentID
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+func (a Attachment) String() string {
+	return fmt.Sprintf("Attachment: Filename %s, size %d", a.Filename, len(a.Data))
+}
+
+// EmailMessage - email message
+type EmailMessage struct {
+	From        string
+	To          string
+	ReplyTo     string
+	Subject     string
+	HTML        string
+	Text        string
+	Headers     map[string]string
+	Attachments []Attachment
+	Inline      []Attachment
+}
+
+// NewEmailMessage returns html email message
+func NewEmailMessage(from, to, subject, html string) EmailMessage {
+	return EmailMessage{
+		From:    from,
+		To:      to,
+		Subject: subject,
+		HTML:    html,
+		Headers: make(map[string]string),
+	}
+}
+
+func (m EmailMessage) String() string {
+	return fmt.Sprintf("EmailMessage: To %s, Subject %s, attachments %d, inline %d", m.To, m.Subject, len(m.Attachments), len(m.Inline))
+}
+
 //EmailSender - send emails
 type EmailSender interface {
-	Send(from, to, subject, body string) error
+	Send(message EmailMessage) error
 }
@@ -497,6 +497,462 @@ func (m *UserTimelineResponse) GetTweets() []*Tweet {
 	return nil
 }
 
+type ListsRequest struct {
+	AccessToken          string   `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	AccessSecret         string   `protobuf:"bytes,2,opt,name=access_secret,json=accessSecret,proto3" json:"access_secret,omitempty"`
+	TwitterId            string   `protobuf:"bytes,3,opt,name=twitter_id,json=twitterId,proto3" json:"twitter_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListsRequest) Reset()         { *m = ListsRequest{} }
+func (m *ListsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListsRequest) ProtoMessage()    {}
+func (*ListsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d18216394e4bf04e, []int{7}
+}
+
+func (m *ListsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListsRequest.Unmarshal(m, b)
+}
+func (m *ListsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListsRequest.Merge(m, src)
+}
+func (m *ListsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListsRequest.Size(m)
+}
+func (m *ListsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListsRequest proto.InternalMessageInfo
+
+func (m *ListsRequest) GetAccessToken() string {
+	if m != nil {
+		return m.AccessToken
+	}
+	return ""
+}
+
+func (m *ListsRequest) GetAccessSecret() string {
+	if m != nil {
+		return m.AccessSecret
+	}
+	return ""
+}
+
+func (m *ListsRequest) GetTwitterId() string {
+	if m != nil {
+		return m.TwitterId
+	}
+	return ""
+}
+
+type TwitterList struct {
+	IdStr                string   `protobuf:"bytes,1,opt,name=id_str,json=idStr,proto3" json:"id_str,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description          string   `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	OwnerScreenName      string   `protobuf:"bytes,4,opt,name=owner_screen_name,json=ownerScreenName,proto3" json:"owner_screen_name,omitempty"`
+	MemberCount          int64    `protobuf:"varint,5,opt,name=member_count,json=memberCount,proto3" json:"member_count,omitempty"`
+	Private              bool     `protobuf:"varint,6,opt,name=private,proto3" json:"private,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TwitterList) Reset()         { *m = TwitterList{} }
+func (m *TwitterList) String() string { return proto.CompactTextString(m) }
+func (*TwitterList) ProtoMessage()    {}
+func (*TwitterList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d18216394e4bf04e, []int{8}
+}
+
+func (m *TwitterList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TwitterList.Unmarshal(m, b)
+}
+func (m *TwitterList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TwitterList.Marshal(b, m, deterministic)
+}
+func (m *TwitterList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TwitterList.Merge(m, src)
+}
+func (m *TwitterList) XXX_Size() int {
+	return xxx_messageInfo_TwitterList.Size(m)
+}
+func (m *TwitterList) XXX_DiscardUnknown() {
+	xxx_messageInfo_TwitterList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TwitterList proto.InternalMessageInfo
+
+func (m *TwitterList) GetIdStr() string {
+	if m != nil {
+		return m.IdStr
+	}
+	return ""
+}
+
+func (m *TwitterList) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TwitterList) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *TwitterList) GetOwnerScreenName() string {
+	if m != nil {
+		return m.OwnerScreenName
+	}
+	return ""
+}
+
+func (m *TwitterList) GetMemberCount() int64 {
+	if m != nil {
+		return m.MemberCount
+	}
+	return 0
+}
+
+func (m *TwitterList) GetPrivate() bool {
+	if m != nil {
+		return m.Private
+	}
+	return false
+}
+
+type ListsResponse struct {
+	Lists                []*TwitterList `protobuf:"bytes,1,rep,name=lists,proto3" json:"lists,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *ListsResponse) Reset()         { *m = ListsResponse{} }
+func (m *ListsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListsResponse) ProtoMessage()    {}
+func (*ListsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d18216394e4bf04e, []int{9}
+}
+
+func (m *ListsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListsResponse.Unmarshal(m, b)
+}
+func (m *ListsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListsResponse.Merge(m, src)
+}
+func (m *ListsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListsResponse.Size(m)
+}
+func (m *ListsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListsResponse proto.InternalMessageInfo
+
+func (m *ListsResponse) GetLists() []*TwitterList {
+	if m != nil {
+		return m.Lists
+	}
+	return nil
+}
+
+type ListMembersRequest struct {
+	AccessToken          string   `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	AccessSecret         string   `protobuf:"bytes,2,opt,name=access_secret,json=accessSecret,proto3" json:"access_secret,omitempty"`
+	TwitterId            string   `protobuf:"bytes,3,opt,name=twitter_id,json=twitterId,proto3" json:"twitter_id,omitempty"`
+	ListId               string   `protobuf:"bytes,4,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+	Cursor               int64    `protobuf:"varint,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Count                int64    `protobuf:"varint,6,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListMembersRequest) Reset()         { *m = ListMembersRequest{} }
+func (m *ListMembersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListMembersRequest) ProtoMessage()    {}
+func (*ListMembersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d18216394e4bf04e, []int{10}
+}
+
+func (m *ListMembersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListMembersRequest.Unmarshal(m, b)
+}
+func (m *ListMembersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListMembersRequest.Marshal(b, m, deterministic)
+}
+func (m *ListMembersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListMembersRequest.Merge(m, src)
+}
+func (m *ListMembersRequest) XXX_Size() int {
+	return xxx_messageInfo_ListMembersRequest.Size(m)
+}
+func (m *ListMembersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListMembersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListMembersRequest proto.InternalMessageInfo
+
+func (m *ListMembersRequest) GetAccessToken() string {
+	if m != nil {
+		return m.AccessToken
+	}
+	return ""
+}
+
+func (m *ListMembersRequest) GetAccessSecret() string {
+	if m != nil {
+		return m.AccessSecret
+	}
+	return ""
+}
+
+func (m *ListMembersRequest) GetTwitterId() string {
+	if m != nil {
+		return m.TwitterId
+	}
+	return ""
+}
+
+func (m *ListMembersRequest) GetListId() string {
+	if m != nil {
+		return m.ListId
+	}
+	return ""
+}
+
+func (m *ListMembersRequest) GetCursor() int64 {
+	if m != nil {
+		return m.Cursor
+	}
+	return 0
+}
+
+func (m *ListMembersRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type FriendsRequest struct {
+	AccessToken          string   `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	AccessSecret         string   `protobuf:"bytes,2,opt,name=access_secret,json=accessSecret,proto3" json:"access_secret,omitempty"`
+	TwitterId            string   `protobuf:"bytes,3,opt,name=twitter_id,json=twitterId,proto3" json:"twitter_id,omitempty"`
+	Cursor               int64    `protobuf:"varint,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Count                int64    `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FriendsRequest) Reset()         { *m = FriendsRequest{} }
+func (m *FriendsRequest) String() string { return proto.CompactTextString(m) }
+func (*FriendsRequest) ProtoMessage()    {}
+func (*FriendsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d18216394e4bf04e, []int{11}
+}
+
+func (m *FriendsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FriendsRequest.Unmarshal(m, b)
+}
+func (m *FriendsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FriendsRequest.Marshal(b, m, deterministic)
+}
+func (m *FriendsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FriendsRequest.Merge(m, src)
+}
+func (m *FriendsRequest) XXX_Size() int {
+	return xxx_messageInfo_FriendsRequest.Size(m)
+}
+func (m *FriendsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FriendsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FriendsRequest proto.InternalMessageInfo
+
+func (m *FriendsRequest) GetAccessToken() string {
+	if m != nil {
+		return m.AccessToken
+	}
+	return ""
+}
+
+func (m *FriendsRequest) GetAccessSecret() string {
+	if m != nil {
+		return m.AccessSecret
+	}
+	return ""
+}
+
+func (m *FriendsRequest) GetTwitterId() string {
+	if m != nil {
+		return m.TwitterId
+	}
+	return ""
+}
+
+func (m *FriendsRequest) GetCursor() int64 {
+	if m != nil {
+		return m.Cursor
+	}
+	return 0
+}
+
+func (m *FriendsRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type UsersPage struct {
+	Users                []*UserInfo `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	NextCursor           int64       `protobuf:"varint,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *UsersPage) Reset()         { *m = UsersPage{} }
+func (m *UsersPage) String() string { return proto.CompactTextString(m) }
+func (*UsersPage) ProtoMessage()    {}
+func (*UsersPage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d18216394e4bf04e, []int{12}
+}
+
+func (m *UsersPage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UsersPage.Unmarshal(m, b)
+}
+func (m *UsersPage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UsersPage.Marshal(b, m, deterministic)
+}
+func (m *UsersPage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UsersPage.Merge(m, src)
+}
+func (m *UsersPage) XXX_Size() int {
+	return xxx_messageInfo_UsersPage.Size(m)
+}
+func (m *UsersPage) XXX_DiscardUnknown() {
+	xxx_messageInfo_UsersPage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UsersPage proto.InternalMessageInfo
+
+func (m *UsersPage) GetUsers() []*UserInfo {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
+func (m *UsersPage) GetNextCursor() int64 {
+	if m != nil {
+		return m.NextCursor
+	}
+	return 0
+}
+
+type InvalidateTokenRequest struct {
+	AccessToken          string   `protobuf:"bytes,1,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	AccessSecret         string   `protobuf:"bytes,2,opt,name=access_secret,json=accessSecret,proto3" json:"access_secret,omitempty"`
+	TwitterId            string   `protobuf:"bytes,3,opt,name=twitter_id,json=twitterId,proto3" json:"twitter_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvalidateTokenRequest) Reset()         { *m = InvalidateTokenRequest{} }
+func (m *InvalidateTokenRequest) String() string { return proto.CompactTextString(m) }
+func (*InvalidateTokenRequest) ProtoMessage()    {}
+func (*InvalidateTokenRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d18216394e4bf04e, []int{13}
+}
+
+func (m *InvalidateTokenRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvalidateTokenRequest.Unmarshal(m, b)
+}
+func (m *InvalidateTokenRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvalidateTokenRequest.Marshal(b, m, deterministic)
+}
+func (m *InvalidateTokenRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvalidateTokenRequest.Merge(m, src)
+}
+func (m *InvalidateTokenRequest) XXX_Size() int {
+	return xxx_messageInfo_InvalidateTokenRequest.Size(m)
+}
+func (m *InvalidateTokenRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvalidateTokenRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvalidateTokenRequest proto.InternalMessageInfo
+
+func (m *InvalidateTokenRequest) GetAccessToken() string {
+	if m != nil {
+		return m.AccessToken
+	}
+	return ""
+}
+
+func (m *InvalidateTokenRequest) GetAccessSecret() string {
+	if m != nil {
+		return m.AccessSecret
+	}
+	return ""
+}
+
+func (m *InvalidateTokenRequest) GetTwitterId() string {
+	if m != nil {
+		return m.TwitterId
+	}
+	return ""
+}
+
+type InvalidateTokenResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvalidateTokenResponse) Reset()         { *m = InvalidateTokenResponse{} }
+func (m *InvalidateTokenResponse) String() string { return proto.CompactTextString(m) }
+func (*InvalidateTokenResponse) ProtoMessage()    {}
+func (*InvalidateTokenResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d18216394e4bf04e, []int{14}
+}
+
+func (m *InvalidateTokenResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvalidateTokenResponse.Unmarshal(m, b)
+}
+func (m *InvalidateTokenResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvalidateTokenResponse.Marshal(b, m, deterministic)
+}
+func (m *InvalidateTokenResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvalidateTokenResponse.Merge(m, src)
+}
+func (m *InvalidateTokenResponse) XXX_Size() int {
+	return xxx_messageInfo_InvalidateTokenResponse.Size(m)
+}
+func (m *InvalidateTokenResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvalidateTokenResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvalidateTokenResponse proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*UserInfoRequest)(nil), "rpc.UserInfoRequest")
 	proto.RegisterType((*UserInfo)(nil), "rpc.UserInfo")
@@ -505,51 +961,77 @@ func init() {
 	proto.RegisterType((*UserTimelineRequest)(nil), "rpc.UserTimelineRequest")
 	proto.RegisterType((*Tweet)(nil), "rpc.Tweet")
 	proto.RegisterType((*UserTimelineResponse)(nil), "rpc.UserTimelineResponse")
+	proto.RegisterType((*ListsRequest)(nil), "rpc.ListsRequest")
+	proto.RegisterType((*TwitterList)(nil), "rpc.TwitterList")
+	proto.RegisterType((*ListsResponse)(nil), "rpc.ListsResponse")
+	proto.RegisterType((*ListMembersRequest)(nil), "rpc.ListMembersRequest")
+	proto.RegisterType((*FriendsRequest)(nil), "rpc.FriendsRequest")
+	proto.RegisterType((*UsersPage)(nil), "rpc.UsersPage")
+	proto.RegisterType((*InvalidateTokenRequest)(nil), "rpc.InvalidateTokenRequest")
+	proto.RegisterType((*InvalidateTokenResponse)(nil), "rpc.InvalidateTokenResponse")
 }
 
 func init() { proto.RegisterFile("twproxy.proto", fileDescriptor_d18216394e4bf04e) }
 
 var fileDescriptor_d18216394e4bf04e = []byte{
-	// 614 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x54, 0xcd, 0x6e, 0xd3, 0x4c,
-	0x14, 0xad, 0x9b, 0x3a, 0x71, 0x6e, 0xbe, 0xf4, 0x67, 0x9a, 0xb6, 0x6e, 0x3f, 0x21, 0x8a, 0x2b,
-	0xa4, 0x88, 0x45, 0x17, 0x0d, 0x52, 0x25, 0x16, 0x6c, 0x21, 0x1b, 0x54, 0xd9, 0xee, 0xda, 0x32,
-	0xce, 0x4d, 0x19, 0xe1, 0xd8, 0xee, 0xcc, 0x98, 0x34, 0x6f, 0x81, 0xd8, 0xb1, 0xe3, 0x91, 0x10,
-	0x4f, 0x84, 0xe6, 0xce, 0x38, 0x24, 0x29, 0x12, 0x3b, 0xc4, 0x6e, 0xe6, 0x9c, 0x73, 0xe7, 0xfe,
-	0x0f, 0xf4, 0xd5, 0xbc, 0x12, 0xe5, 0xc3, 0xe2, 0xb2, 0x12, 0xa5, 0x2a, 0x59, 0x4b, 0x54, 0x59,
-	0xf0, 0xd5, 0x81, 0xbd, 0x5b, 0x89, 0x62, 0x5c, 0x4c, 0xcb, 0x10, 0xef, 0x6b, 0x94, 0x8a, 0x3d,
-	0x83, 0xff, 0xd2, 0x2c, 0x43, 0x29, 0x13, 0x55, 0x7e, 0xc4, 0xc2, 0x77, 0xce, 0x9d, 0x61, 0x37,
-	0xec, 0x19, 0x2c, 0xd6, 0x10, 0xbb, 0x80, 0xbe, 0x95, 0x48, 0xcc, 0x04, 0x2a, 0x7f, 0x9b, 0x34,
-	0xd6, 0x2e, 0x22, 0x8c, 0x3d, 0x01, 0x50, 0x73, 0xae, 0x14, 0x8a, 0x84, 0x4f, 0xfc, 0x16, 0x29,
-	0xba, 0x16, 0x19, 0x4f, 0xd8, 0x53, 0xe8, 0xc9, 0x4c, 0x20, 0x16, 0x49, 0x91, 0xce, 0xd0, 0xdf,
-	0x21, 0x1e, 0x0c, 0xf4, 0x2e, 0x9d, 0x61, 0xf0, 0xcd, 0x01, 0xaf, 0x89, 0x6d, 0xe3, 0x31, 0x67,
-	0xf3, 0x31, 0x06, 0x3b, 0xf4, 0x8a, 0x89, 0x83, 0xce, 0x6c, 0x00, 0x2e, 0xce, 0x52, 0x9e, 0x5b,
-	0xd7, 0xe6, 0xf2, 0x47, 0xb7, 0xec, 0x05, 0x1c, 0x54, 0xa2, 0x9c, 0xf2, 0x1c, 0x13, 0x3e, 0x4b,
-	0xef, 0x30, 0xa9, 0x45, 0xee, 0xbb, 0x24, 0xdb, 0xb3, 0xc4, 0x58, 0xe3, 0xb7, 0x22, 0x0f, 0x3e,
-	0x3b, 0x70, 0xa0, 0x43, 0x8c, 0x30, 0x15, 0xd9, 0x87, 0xbf, 0x5c, 0xc0, 0x01, 0xb8, 0xf7, 0x35,
-	0x8a, 0x85, 0xcd, 0xc1, 0x5c, 0x82, 0x6b, 0xd8, 0x5f, 0x8d, 0x48, 0xd6, 0xb9, 0x62, 0x17, 0xe0,
-	0xd6, 0x12, 0x85, 0xf4, 0x9d, 0xf3, 0xd6, 0xb0, 0x77, 0xd5, 0xbf, 0x14, 0x55, 0x76, 0xb9, 0x6c,
-	0xbb, 0xe1, 0x82, 0x2f, 0xdb, 0x70, 0xa8, 0xb1, 0x98, 0xcf, 0x30, 0xe7, 0x05, 0xfe, 0x63, 0xe3,
-	0xc0, 0x4e, 0xc1, 0x93, 0xbc, 0xc8, 0x50, 0x5b, 0xeb, 0x76, 0xb4, 0xc2, 0x0e, 0xdd, 0x4d, 0x25,
-	0xb2, 0xb2, 0x2e, 0x94, 0xdf, 0x26, 0xdc, 0x5c, 0xd8, 0xff, 0xd0, 0xe5, 0x77, 0x45, 0x29, 0x30,
-	0x11, 0xca, 0xef, 0x9c, 0x3b, 0x43, 0x2f, 0xf4, 0x0c, 0x10, 0x2a, 0xf6, 0x1c, 0x76, 0x1b, 0x12,
-	0xab, 0x9c, 0xa3, 0xf4, 0x3d, 0x52, 0xf4, 0xad, 0xc2, 0x80, 0xc1, 0x8f, 0x6d, 0x70, 0xe3, 0x39,
-	0xa2, 0x62, 0x47, 0xd0, 0xe6, 0x93, 0x44, 0x2a, 0x61, 0x0b, 0xe0, 0xf2, 0x49, 0xa4, 0x84, 0x1e,
-	0x3c, 0x85, 0x0f, 0x4d, 0xc6, 0x74, 0xd6, 0x8e, 0xa7, 0x75, 0x9e, 0x27, 0x44, 0x98, 0x44, 0x3d,
-	0x0d, 0xc4, 0x9a, 0xbc, 0x86, 0x53, 0x5e, 0x90, 0xd3, 0x45, 0xa2, 0xca, 0x44, 0xaa, 0x54, 0xd5,
-	0x32, 0xb1, 0x4f, 0x9b, 0xac, 0x07, 0xbc, 0xd0, 0xfe, 0x17, 0x71, 0x19, 0x11, 0x3b, 0x26, 0x4f,
-	0x23, 0x38, 0x59, 0x35, 0xd4, 0x4d, 0x6b, 0xcc, 0xcc, 0x74, 0xb2, 0xa5, 0x19, 0xb5, 0x96, 0x8c,
-	0x4e, 0xa0, 0x63, 0x85, 0x54, 0x9b, 0x6e, 0xd8, 0xae, 0x89, 0xd3, 0x31, 0x12, 0x41, 0xc5, 0xee,
-	0x98, 0x18, 0x35, 0x40, 0xa5, 0x1e, 0xc2, 0x3e, 0x91, 0xab, 0x0d, 0xf1, 0x48, 0xb3, 0xab, 0xf1,
-	0xe8, 0x57, 0x53, 0x46, 0x70, 0x4c, 0xca, 0xc7, 0x1b, 0xd3, 0x25, 0xfd, 0xa1, 0x66, 0x6f, 0x36,
-	0xb6, 0xe6, 0x15, 0x0c, 0xd6, 0x07, 0x4d, 0x56, 0x65, 0x21, 0x91, 0x05, 0xd0, 0x56, 0xba, 0xd6,
-	0xcd, 0x9c, 0x02, 0xcd, 0x29, 0x95, 0x3f, 0xb4, 0xcc, 0xd5, 0x77, 0x07, 0x76, 0xe3, 0xf9, 0x8d,
-	0xfe, 0xc7, 0x22, 0x14, 0x9f, 0x78, 0x86, 0xec, 0x25, 0xf4, 0xde, 0xa0, 0x5a, 0xfe, 0x14, 0x83,
-	0xf5, 0xe9, 0x36, 0x53, 0x7c, 0xb6, 0x3e, 0xf3, 0xc1, 0x16, 0x7b, 0x0d, 0x3d, 0xb3, 0x23, 0x1a,
-	0x93, 0xec, 0x78, 0xc9, 0xaf, 0xed, 0xf2, 0xd9, 0xd1, 0x23, 0x5c, 0x6f, 0x54, 0xb0, 0xc5, 0xde,
-	0xc2, 0x9e, 0xf5, 0xda, 0xe4, 0xc1, 0xfc, 0xa5, 0x76, 0x63, 0x87, 0xce, 0x4e, 0x7f, 0xc3, 0x98,
-	0xa4, 0x83, 0xad, 0xf7, 0x6d, 0xfa, 0x8f, 0x47, 0x3f, 0x03, 0x00, 0x00, 0xff, 0xff, 0xc1, 0xe5,
-	0x65, 0x91, 0xa0, 0x05, 0x00, 0x00,
+	// 901 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x56, 0xcd, 0x6e, 0x23, 0x45,
+	0x10, 0xce, 0xc4, 0xb1, 0x3d, 0x2e, 0xc7, 0x76, 0xd2, 0xf1, 0x26, 0x13, 0x2f, 0x88, 0xec, 0xac,
+	0x40, 0x11, 0x87, 0x1c, 0x36, 0x48, 0x91, 0x40, 0xe2, 0xb2, 0x12, 0xc1, 0x12, 0xac, 0xc2, 0xd8,
+	0x39, 0x8f, 0x66, 0xc7, 0x95, 0xd0, 0x62, 0xdc, 0x33, 0xdb, 0xdd, 0x13, 0x27, 0x27, 0x5e, 0x01,
+	0x71, 0xe3, 0xc6, 0x85, 0x17, 0x81, 0x03, 0x77, 0x9e, 0x08, 0x75, 0x75, 0x7b, 0xd6, 0x7f, 0x02,
+	0x4e, 0x16, 0xb7, 0xa9, 0xef, 0xab, 0xea, 0xaa, 0xfa, 0xba, 0xba, 0x7b, 0xa0, 0xa3, 0x67, 0x85,
+	0xcc, 0x1f, 0x9f, 0x2e, 0x0a, 0x99, 0xeb, 0x9c, 0xd5, 0x64, 0x91, 0x86, 0xbf, 0x78, 0xd0, 0xbb,
+	0x55, 0x28, 0x87, 0xe2, 0x2e, 0x8f, 0xf0, 0x5d, 0x89, 0x4a, 0xb3, 0x17, 0xb0, 0x9f, 0xa4, 0x29,
+	0x2a, 0x15, 0xeb, 0xfc, 0x07, 0x14, 0x81, 0x77, 0xe6, 0x9d, 0xb7, 0xa2, 0xb6, 0xc5, 0xc6, 0x06,
+	0x62, 0x2f, 0xa1, 0xe3, 0x5c, 0x14, 0xa6, 0x12, 0x75, 0xb0, 0x4b, 0x3e, 0x2e, 0x6e, 0x44, 0x18,
+	0xfb, 0x10, 0x40, 0xcf, 0xb8, 0xd6, 0x28, 0x63, 0x3e, 0x09, 0x6a, 0xe4, 0xd1, 0x72, 0xc8, 0x70,
+	0xc2, 0x3e, 0x82, 0xb6, 0x4a, 0x25, 0xa2, 0x88, 0x45, 0x32, 0xc5, 0x60, 0x8f, 0x78, 0xb0, 0xd0,
+	0x9b, 0x64, 0x8a, 0xe1, 0xaf, 0x1e, 0xf8, 0xf3, 0xda, 0x56, 0x16, 0xf3, 0x56, 0x17, 0x63, 0xb0,
+	0x47, 0xab, 0xd8, 0x3a, 0xe8, 0x9b, 0xf5, 0xa1, 0x8e, 0xd3, 0x84, 0x67, 0x2e, 0xb5, 0x35, 0xfe,
+	0x35, 0x2d, 0xfb, 0x14, 0x0e, 0x0b, 0x99, 0xdf, 0xf1, 0x0c, 0x63, 0x3e, 0x4d, 0xee, 0x31, 0x2e,
+	0x65, 0x16, 0xd4, 0xc9, 0xad, 0xe7, 0x88, 0xa1, 0xc1, 0x6f, 0x65, 0x16, 0xfe, 0xe4, 0xc1, 0xa1,
+	0x29, 0x71, 0x84, 0x89, 0x4c, 0xbf, 0xdf, 0xb2, 0x80, 0x7d, 0xa8, 0xbf, 0x2b, 0x51, 0x3e, 0xb9,
+	0x1e, 0xac, 0x11, 0x5e, 0xc1, 0xc1, 0x62, 0x45, 0xaa, 0xcc, 0x34, 0x7b, 0x09, 0xf5, 0x52, 0xa1,
+	0x54, 0x81, 0x77, 0x56, 0x3b, 0x6f, 0xbf, 0xea, 0x5c, 0xc8, 0x22, 0xbd, 0xa8, 0xb6, 0xdd, 0x72,
+	0xe1, 0xcf, 0xbb, 0x70, 0x64, 0xb0, 0x31, 0x9f, 0x62, 0xc6, 0x05, 0xfe, 0xcf, 0xc6, 0x81, 0x9d,
+	0x82, 0xaf, 0xb8, 0x48, 0xd1, 0x44, 0x9b, 0xed, 0xa8, 0x45, 0x4d, 0xb2, 0xad, 0x12, 0x69, 0x5e,
+	0x0a, 0x1d, 0x34, 0x08, 0xb7, 0x06, 0x7b, 0x0e, 0x2d, 0x7e, 0x2f, 0x72, 0x89, 0xb1, 0xd4, 0x41,
+	0xf3, 0xcc, 0x3b, 0xf7, 0x23, 0xdf, 0x02, 0x91, 0x66, 0x1f, 0x43, 0x77, 0x4e, 0x62, 0x91, 0x71,
+	0x54, 0x81, 0x4f, 0x1e, 0x1d, 0xe7, 0x61, 0xc1, 0xf0, 0xaf, 0x5d, 0xa8, 0x8f, 0x67, 0x88, 0x9a,
+	0x3d, 0x83, 0x06, 0x9f, 0xc4, 0x4a, 0x4b, 0x27, 0x40, 0x9d, 0x4f, 0x46, 0x5a, 0x9a, 0xc1, 0xd3,
+	0xf8, 0x38, 0xef, 0x98, 0xbe, 0x4d, 0xe2, 0xbb, 0x32, 0xcb, 0x62, 0x22, 0x6c, 0xa3, 0xbe, 0x01,
+	0xc6, 0x86, 0xbc, 0x82, 0x53, 0x2e, 0x28, 0xe9, 0x53, 0xac, 0xf3, 0x58, 0xe9, 0x44, 0x97, 0x2a,
+	0x76, 0x4b, 0xdb, 0xae, 0xfb, 0x5c, 0x98, 0xfc, 0x4f, 0xe3, 0x7c, 0x44, 0xec, 0x90, 0x32, 0x5d,
+	0xc2, 0xc9, 0x62, 0xa0, 0xd9, 0xb4, 0x79, 0x98, 0x9d, 0x4e, 0x56, 0x85, 0xd1, 0xd6, 0x52, 0xd0,
+	0x09, 0x34, 0x9d, 0x23, 0x69, 0xd3, 0x8a, 0x1a, 0x25, 0x71, 0xa6, 0x46, 0x22, 0x48, 0xec, 0xa6,
+	0xad, 0xd1, 0x00, 0x24, 0xf5, 0x39, 0x1c, 0x10, 0xb9, 0xb8, 0x21, 0x3e, 0xf9, 0x74, 0x0d, 0x3e,
+	0x7a, 0xbf, 0x29, 0x97, 0x70, 0x4c, 0x9e, 0xeb, 0x27, 0xa6, 0x45, 0xfe, 0x47, 0x86, 0xbd, 0x59,
+	0x39, 0x35, 0x9f, 0x43, 0x7f, 0x79, 0xd0, 0x54, 0x91, 0x0b, 0x85, 0x2c, 0x84, 0x86, 0x36, 0x5a,
+	0xcf, 0xe7, 0x14, 0x68, 0x4e, 0x49, 0xfe, 0xc8, 0x31, 0x61, 0x09, 0xfb, 0xdf, 0x70, 0xa5, 0xd5,
+	0x76, 0xa7, 0x33, 0xfc, 0xc3, 0x83, 0xf6, 0xd8, 0x5a, 0x26, 0xfd, 0x3f, 0x4c, 0xc3, 0xda, 0x35,
+	0x74, 0x06, 0xed, 0x09, 0xaa, 0x54, 0xf2, 0x42, 0xf3, 0x5c, 0xb8, 0xa5, 0x17, 0x21, 0x73, 0xe3,
+	0xe4, 0x33, 0xb1, 0xa2, 0xb7, 0x1d, 0x85, 0x1e, 0x11, 0x0b, 0x82, 0xbf, 0x80, 0xfd, 0x29, 0x4e,
+	0xdf, 0xa2, 0x8c, 0xed, 0xc4, 0xdb, 0x93, 0xd0, 0xb6, 0xd8, 0x6b, 0x03, 0xb1, 0x00, 0x9a, 0x85,
+	0xe4, 0x0f, 0x89, 0x46, 0xda, 0x73, 0x3f, 0x9a, 0x9b, 0xe1, 0x15, 0x74, 0x9c, 0x78, 0x4e, 0xf1,
+	0x4f, 0xa0, 0x9e, 0x71, 0x55, 0x09, 0x7e, 0xe0, 0x04, 0xaf, 0xfa, 0x8c, 0x2c, 0x1d, 0xfe, 0xee,
+	0x01, 0x33, 0xf6, 0xb7, 0x94, 0x66, 0xcb, 0xe2, 0x9b, 0x21, 0x36, 0x65, 0x18, 0xce, 0xaa, 0xd2,
+	0x30, 0xe6, 0x70, 0xc2, 0x8e, 0xa1, 0x91, 0x96, 0x52, 0xe5, 0xd2, 0xc9, 0xe0, 0xac, 0xcd, 0xf7,
+	0x41, 0xf8, 0x9b, 0x07, 0xdd, 0xaf, 0x24, 0x47, 0x31, 0xd9, 0x76, 0x03, 0xef, 0xeb, 0xdc, 0xdb,
+	0x5c, 0x67, 0x7d, 0xb1, 0xce, 0xef, 0xa0, 0x65, 0x8e, 0x87, 0xba, 0x49, 0xee, 0xf1, 0x3f, 0x5d,
+	0xdd, 0xe6, 0xee, 0x14, 0xf8, 0xa8, 0x63, 0x97, 0x64, 0x97, 0x56, 0x03, 0x03, 0xbd, 0x26, 0x24,
+	0xfc, 0x11, 0x8e, 0x87, 0xe2, 0x21, 0xc9, 0xf8, 0x24, 0xd1, 0x48, 0x7d, 0x6d, 0xf9, 0xfc, 0x9c,
+	0xc2, 0xc9, 0x5a, 0x01, 0x76, 0x06, 0x5f, 0xfd, 0x59, 0x83, 0xee, 0x78, 0x76, 0x63, 0xfe, 0x4c,
+	0x46, 0x28, 0x1f, 0x78, 0x8a, 0xec, 0x33, 0x68, 0x5f, 0xa3, 0xae, 0xde, 0xfe, 0xfe, 0x72, 0xd3,
+	0xb6, 0xf2, 0xc1, 0xb2, 0x14, 0xe1, 0x0e, 0xfb, 0x12, 0xda, 0xf6, 0xd5, 0xbb, 0x25, 0x51, 0x8e,
+	0x2b, 0x7e, 0xe9, 0x75, 0x1e, 0x3c, 0x5b, 0xc3, 0xcd, 0x1b, 0x19, 0xee, 0xb0, 0xaf, 0xa1, 0xe7,
+	0xb2, 0xce, 0x6f, 0x26, 0x16, 0x54, 0xbe, 0x2b, 0xaf, 0xe2, 0xe0, 0x74, 0x03, 0x63, 0x1b, 0x0a,
+	0x77, 0xd8, 0x25, 0xf8, 0xd7, 0xa8, 0xe9, 0xa8, 0xb1, 0x43, 0x72, 0x5c, 0xbc, 0xb3, 0x06, 0x6c,
+	0x11, 0xaa, 0x82, 0xbe, 0x80, 0xae, 0x0b, 0x72, 0xa7, 0x8c, 0x9d, 0x54, 0x7e, 0xcb, 0xe7, 0x6e,
+	0xd0, 0xad, 0x92, 0xd3, 0x90, 0x50, 0x46, 0xb8, 0x46, 0xed, 0xa6, 0x9b, 0x1d, 0x11, 0xbf, 0x3c,
+	0xeb, 0x1b, 0x82, 0xde, 0x40, 0x6f, 0x65, 0x53, 0xd8, 0x73, 0x72, 0xda, 0x3c, 0x2b, 0x83, 0x0f,
+	0x36, 0x93, 0xf3, 0x0e, 0xde, 0x36, 0xe8, 0xc7, 0xf2, 0xf2, 0xef, 0x01, 0x00, 0x38, 0x6f, 0xb3,
+	0x3c, 0x69, 0x0a, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -567,6 +1049,10 @@ type TwProxyServiceClient interface {
 	GetUserInfo(ctx context.Context, in *UserInfoRequest, opts ...grpc.CallOption) (*UserInfo, error)
 	SearchUsers(ctx context.Context, in *UserSearchRequest, opts ...grpc.CallOption) (*UserSearchResult, error)
 	GetUserTimeline(ctx context.Context, in *UserTimelineRequest, opts ...grpc.CallOption) (*UserTimelineResponse, error)
+	GetLists(ctx context.Context, in *ListsRequest, opts ...grpc.CallOption) (*ListsResponse, error)
+	GetListMembers(ctx context.Context, in *ListMembersRequest, opts ...grpc.CallOption) (*UsersPage, error)
+	GetFriends(ctx context.Context, in *FriendsRequest, opts ...grpc.CallOption) (*UsersPage, error)
+	InvalidateToken(ctx context.Context, in *InvalidateTokenRequest, opts ...grpc.CallOption) (*InvalidateTokenResponse, error)
 }
 
 type twProxyServiceClient struct {
@@ -604,11 +1090,51 @@ func (c *twProxyServiceClient) GetUserTimeline(ctx context.Context, in *UserTime
 	return out, nil
 }
 
+func (c *twProxyServiceClient) GetLists(ctx context.Context, in *ListsRequest, opts ...grpc.CallOption) (*ListsResponse, error) {
+	out := new(ListsResponse)
+	err := c.cc.Invoke(ctx, "/rpc.TwProxyService/GetLists", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *twProxyServiceClient) GetListMembers(ctx context.Context, in *ListMembersRequest, opts ...grpc.CallOption) (*UsersPage, error) {
+	out := new(UsersPage)
+	err := c.cc.Invoke(ctx, "/rpc.TwProxyService/GetListMembers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *twProxyServiceClient) GetFriends(ctx context.Context, in *FriendsRequest, opts ...grpc.CallOption) (*UsersPage, error) {
+	out := new(UsersPage)
+	err := c.cc.Invoke(ctx, "/rpc.TwProxyService/GetFriends", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *twProxyServiceClient) InvalidateToken(ctx context.Context, in *InvalidateTokenRequest, opts ...grpc.CallOption) (*InvalidateTokenResponse, error) {
+	out := new(InvalidateTokenResponse)
+	err := c.cc.Invoke(ctx, "/rpc.TwProxyService/InvalidateToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TwProxyServiceServer is the server API for TwProxyService service.
 type TwProxyServiceServer interface {
 	GetUserInfo(context.Context, *UserInfoRequest) (*UserInfo, error)
 	SearchUsers(context.Context, *UserSearchRequest) (*UserSearchResult, error)
 	GetUserTimeline(context.Context, *UserTimelineRequest) (*UserTimelineResponse, error)
+	GetLists(context.Context, *ListsRequest) (*ListsResponse, error)
+	GetListMembers(context.Context, *ListMembersRequest) (*UsersPage, error)
+	GetFriends(context.Context, *FriendsRequest) (*UsersPage, error)
+	InvalidateToken(context.Context, *InvalidateTokenRequest) (*InvalidateTokenResponse, error)
 }
 
 // UnimplementedTwProxyServiceServer can be embedded to have forward compatible implementations.
@@ -624,6 +1150,18 @@ func (*UnimplementedTwProxyServiceServer) SearchUsers(ctx context.Context, req *
 func (*UnimplementedTwProxyServiceServer) GetUserTimeline(ctx context.Context, req *UserTimelineRequest) (*UserTimelineResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserTimeline not implemented")
 }
+func (*UnimplementedTwProxyServiceServer) GetLists(ctx context.Context, req *ListsRequest) (*ListsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLists not implemented")
+}
+func (*UnimplementedTwProxyServiceServer) GetListMembers(ctx context.Context, req *ListMembersRequest) (*UsersPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetListMembers not implemented")
+}
+func (*UnimplementedTwProxyServiceServer) GetFriends(ctx context.Context, req *FriendsRequest) (*UsersPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFriends not implemented")
+}
+func (*UnimplementedTwProxyServiceServer) InvalidateToken(ctx context.Context, req *InvalidateTokenRequest) (*InvalidateTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InvalidateToken not implemented")
+}
 
 func RegisterTwProxyServiceServer(s *grpc.Server, srv TwProxyServiceServer) {
 	s.RegisterService(&_TwProxyService_serviceDesc, srv)
@@ -683,6 +1221,78 @@ func _TwProxyService_GetUserTimeline_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TwProxyService_GetLists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TwProxyServiceServer).GetLists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.TwProxyService/GetLists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TwProxyServiceServer).GetLists(ctx, req.(*ListsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TwProxyService_GetListMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TwProxyServiceServer).GetListMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.TwProxyService/GetListMembers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TwProxyServiceServer).GetListMembers(ctx, req.(*ListMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TwProxyService_GetFriends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TwProxyServiceServer).GetFriends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.TwProxyService/GetFriends",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TwProxyServiceServer).GetFriends(ctx, req.(*FriendsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TwProxyService_InvalidateToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvalidateTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TwProxyServiceServer).InvalidateToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.TwProxyService/InvalidateToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TwProxyServiceServer).InvalidateToken(ctx, req.(*InvalidateTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _TwProxyService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "rpc.TwProxyService",
 	HandlerType: (*TwProxyServiceServer)(nil),
@@ -699,6 +1309,22 @@ var _TwProxyService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetUserTimeline",
 			Handler:    _TwProxyService_GetUserTimeline_Handler,
 		},
+		{
+			MethodName: "GetLists",
+			Handler:    _TwProxyService_GetLists_Handler,
+		},
+		{
+			MethodName: "GetListMembers",
+			Handler:    _TwProxyService_GetListMembers_Handler,
+		},
+		{
+			MethodName: "GetFriends",
+			Handler:    _TwProxyService_GetFriends_Handler,
+		},
+		{
+			MethodName: "InvalidateToken",
+			Handler:    _TwProxyService_InvalidateToken_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "twproxy.proto",
@@ -0,0 +1,74 @@
+package twapi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	tw "github.com/dghubble/go-twitter/twitter"
+	"github.com/dmtr/mail_me_all/backend/errors"
+)
+
+// Twitter API error codes, https://developer.twitter.com/en/docs/basics/response-codes
+const (
+	codeCouldNotAuthenticate = 32
+	codePageNotExist         = 34
+	codeUserNotFound         = 50
+	codeUserSuspended        = 63
+	codeRateLimitExceeded    = 88
+	codeInvalidToken         = 89
+	codeNotAuthorized        = 179
+)
+
+const rateLimitResetHeader = "x-rate-limit-reset"
+
+// getRetryAfter returns time left till the rate limit window reset
+func getRetryAfter(resp *http.Response, now time.Time) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	reset, err := strconv.ParseInt(resp.Header.Get(rateLimitResetHeader), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	retryAfter := time.Unix(reset, 0).Sub(now)
+	if retryAfter < 0 {
+		return 0
+	}
+	return retryAfter.Round(time.Second)
+}
+
+// convertError converts error returned by Twitter API client to the domain error
+func convertError(resp *http.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if apiErr, ok := err.(tw.APIError); ok && !apiErr.Empty() {
+		switch apiErr.Errors[0].Code {
+		case codeRateLimitExceeded:
+			return errors.NewRateLimited(err.Error(), getRetryAfter(resp, time.Now()))
+		case codePageNotExist, codeUserNotFound, codeUserSuspended:
+			return errors.NewNotFound(err.Error())
+		case codeCouldNotAuthenticate, codeInvalidToken, codeNotAuthorized:
+			return errors.NewForbidden(err.Error())
+		}
+	}
+
+	if resp == nil {
+		return errors.NewUpstream(err.Error())
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return errors.NewRateLimited(err.Error(), getRetryAfter(resp, time.Now()))
+	case http.StatusNotFound:
+		return errors.NewNotFound(err.Error())
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errors.NewForbidden(err.Error())
+	}
+
+	return errors.NewUpstream(err.Error())
+}
@@ -0,0 +1,57 @@
+package twapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	tw "github.com/dghubble/go-twitter/twitter"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func getResponse(status int) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{}}
+}
+
+func TestConvertError(t *testing.T) {
+	apiError := func(code int) error {
+		return tw.APIError{Errors: []tw.ErrorDetail{tw.ErrorDetail{Code: code, Message: "test"}}}
+	}
+
+	tests := map[string]struct {
+		resp *http.Response
+		err  error
+		want errors.ErrorCode
+	}{
+		"RateLimitCode":   {getResponse(http.StatusTooManyRequests), apiError(codeRateLimitExceeded), errors.RateLimited},
+		"UserNotFound":    {getResponse(http.StatusNotFound), apiError(codeUserNotFound), errors.NotFound},
+		"InvalidToken":    {getResponse(http.StatusUnauthorized), apiError(codeInvalidToken), errors.Forbidden},
+		"NotFoundStatus":  {getResponse(http.StatusNotFound), fmt.Errorf("not found"), errors.NotFound},
+		"ServerError":     {getResponse(http.StatusServiceUnavailable), apiError(130), errors.Upstream},
+		"NetworkError":    {nil, fmt.Errorf("connection refused"), errors.Upstream},
+		"TooManyRequests": {getResponse(http.StatusTooManyRequests), fmt.Errorf("too many"), errors.RateLimited},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := convertError(test.resp, test.err)
+			assert.Equal(t, test.want, errors.GetErrorCode(err))
+		})
+	}
+
+	assert.Nil(t, convertError(nil, nil))
+}
+
+func TestGetRetryAfter(t *testing.T) {
+	now := time.Now()
+	resp := getResponse(http.StatusTooManyRequests)
+	resp.Header.Set(rateLimitResetHeader, strconv.FormatInt(now.Add(90*time.Second).Unix(), 10))
+	assert.InDelta(t, 90, getRetryAfter(resp, now).Seconds(), 1)
+
+	resp.Header.Set(rateLimitResetHeader, strconv.FormatInt(now.Add(-time.Minute).Unix(), 10))
+	assert.Equal(t, time.Duration(0), getRetryAfter(resp, now))
+	assert.Equal(t, time.Duration(0), getRetryAfter(nil, now))
+}
@@ -1,15 +1,21 @@
 package twapi
 
 import (
+	"fmt"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	tw "github.com/dghubble/go-twitter/twitter"
 	"github.com/dghubble/oauth1"
+	"github.com/dmtr/mail_me_all/backend/errors"
 
 	log "github.com/sirupsen/logrus"
 )
 
+const invalidateTokenURL = "https://api.twitter.com/1.1/oauth/invalidate_token"
+
 const (
 	sessionExpiresIn = 60 * 10
 	page             = 1
@@ -37,6 +43,16 @@ type Tweet struct {
 	UserProfileImageUrl  string
 }
 
+// List represents twitter list
+type List struct {
+	IDStr           string
+	Name            string
+	Description     string
+	OwnerScreenName string
+	MemberCount     int64
+	Private         bool
+}
+
 type Twitter struct {
 	oauth1Config *oauth1.Config
 	sessions     map[string]*tw.Client
@@ -94,14 +110,27 @@ func (t Twitter) getSession(accessToken, accessSecret, twitterID string) *tw.Cli
 	return client
 }
 
+// GetUserInfo returns info of the user with the screen name. Without the screen name it returns
+// the authenticated user with its email, Twitter returns the email only if it's verified.
 func (t Twitter) GetUserInfo(accessToken, accessSecret, twitterID, screenName string) (UserInfo, error) {
 	client := t.getSession(accessToken, accessSecret, twitterID)
-	user, _, err := client.Users.Show(&tw.UserShowParams{
-		ScreenName: screenName,
-	})
+
+	var user *tw.User
+	var resp *http.Response
+	var err error
+	if screenName == "" {
+		user, resp, err = client.Accounts.VerifyCredentials(&tw.AccountVerifyParams{
+			SkipStatus:   tw.Bool(true),
+			IncludeEmail: tw.Bool(true),
+		})
+	} else {
+		user, resp, err = client.Users.Show(&tw.UserShowParams{
+			ScreenName: screenName,
+		})
+	}
 	if err != nil {
 		log.Errorf("Got error calling twitter api: %s", err)
-		return UserInfo{}, err
+		return UserInfo{}, convertError(resp, err)
 	}
 
 	u := UserInfo{
@@ -117,11 +146,11 @@ func (t Twitter) GetUserInfo(accessToken, accessSecret, twitterID, screenName st
 func (t Twitter) SearchUsers(accessToken, accessSecret, twitterID, query string) ([]UserInfo, error) {
 	client := t.getSession(accessToken, accessSecret, twitterID)
 	includeEntities := false
-	users, _, err := client.Users.Search(query, &tw.UserSearchParams{Page: page, Count: count, IncludeEntities: &includeEntities})
+	users, resp, err := client.Users.Search(query, &tw.UserSearchParams{Page: page, Count: count, IncludeEntities: &includeEntities})
 
 	if err != nil {
 		log.Errorf("Got error calling twitter api: %s", err)
-		return make([]UserInfo, 0, 0), err
+		return make([]UserInfo, 0, 0), convertError(resp, err)
 	}
 
 	res := make([]UserInfo, 0, len(users))
@@ -165,11 +194,11 @@ func (t Twitter) GetUserTimeline(accessToken, accessSecret, twitterID, screenNam
 		params.Count = int(count)
 	}
 
-	tweets, _, err := client.Timelines.UserTimeline(&params)
+	tweets, resp, err := client.Timelines.UserTimeline(&params)
 
 	if err != nil {
 		log.Errorf("Got error calling twitter api: %s", err)
-		return []Tweet{}, err
+		return []Tweet{}, convertError(resp, err)
 	}
 
 	res := make([]Tweet, 0, len(tweets))
@@ -190,3 +219,103 @@ func (t Twitter) GetUserTimeline(accessToken, accessSecret, twitterID, screenNam
 
 	return res, err
 }
+
+func adaptUsers(users []tw.User) []UserInfo {
+	res := make([]UserInfo, 0, len(users))
+	for _, user := range users {
+		res = append(res, UserInfo{
+			TwitterID:     user.IDStr,
+			Name:          user.Name,
+			Email:         user.Email,
+			ScreenName:    user.ScreenName,
+			ProfileIMGURL: user.ProfileImageURLHttps,
+		})
+	}
+	return res
+}
+
+// GetLists returns lists the user owns or subscribes to
+func (t Twitter) GetLists(accessToken, accessSecret, twitterID string) ([]List, error) {
+	client := t.getSession(accessToken, accessSecret, twitterID)
+	userID, err := strconv.ParseInt(twitterID, 10, 64)
+	if err != nil {
+		return []List{}, err
+	}
+
+	lists, resp, err := client.Lists.List(&tw.ListsListParams{UserID: userID})
+	if err != nil {
+		log.Errorf("Got error calling twitter api: %s", err)
+		return []List{}, convertError(resp, err)
+	}
+
+	res := make([]List, 0, len(lists))
+	for _, list := range lists {
+		l := List{
+			IDStr:       list.IDStr,
+			Name:        list.Name,
+			Description: list.Description,
+			MemberCount: int64(list.MemberCount),
+			Private:     list.Mode == "private",
+		}
+		if list.User != nil {
+			l.OwnerScreenName = list.User.ScreenName
+		}
+		res = append(res, l)
+	}
+
+	return res, nil
+}
+
+// GetListMembers returns a page of list members and the cursor of the next page, zero cursor means the last page
+func (t Twitter) GetListMembers(accessToken, accessSecret, twitterID, listID string, cursor, count int64) ([]UserInfo, int64, error) {
+	client := t.getSession(accessToken, accessSecret, twitterID)
+	id, err := strconv.ParseInt(listID, 10, 64)
+	if err != nil {
+		return []UserInfo{}, 0, errors.NewValidation(err.Error(), map[string]string{"list_id": "must be a number"})
+	}
+
+	skipStatus := true
+	members, resp, err := client.Lists.Members(&tw.ListsMembersParams{ListID: id, Cursor: cursor, Count: int(count), SkipStatus: &skipStatus})
+	if err != nil {
+		log.Errorf("Got error calling twitter api: %s", err)
+		return []UserInfo{}, 0, convertError(resp, err)
+	}
+
+	return adaptUsers(members.Users), members.NextCursor, nil
+}
+
+// GetFriends returns a page of accounts the user follows and the cursor of the next page, zero cursor means the last page
+func (t Twitter) GetFriends(accessToken, accessSecret, twitterID string, cursor, count int64) ([]UserInfo, int64, error) {
+	client := t.getSession(accessToken, accessSecret, twitterID)
+	userID, err := strconv.ParseInt(twitterID, 10, 64)
+	if err != nil {
+		return []UserInfo{}, 0, err
+	}
+
+	skipStatus := true
+	friends, resp, err := client.Friends.List(&tw.FriendListParams{UserID: userID, Cursor: cursor, Count: int(count), SkipStatus: &skipStatus})
+	if err != nil {
+		log.Errorf("Got error calling twitter api: %s", err)
+		return []UserInfo{}, 0, convertError(resp, err)
+	}
+
+	return adaptUsers(friends.Users), friends.NextCursor, nil
+}
+
+// InvalidateToken revokes the OAuth grant, the token can't be used after that
+func (t Twitter) InvalidateToken(accessToken, accessSecret, twitterID string) error {
+	t.deleteSession(twitterID)
+
+	token := oauth1.NewToken(accessToken, accessSecret)
+	resp, err := t.oauth1Config.Client(oauth1.NoContext, token).Post(invalidateTokenURL, "application/x-www-form-urlencoded", nil)
+	if err != nil {
+		log.Errorf("Got error calling twitter api: %s", err)
+		return convertError(resp, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp, fmt.Errorf("Can not invalidate token of %s, got status %d", twitterID, resp.StatusCode))
+	}
+	return nil
+}
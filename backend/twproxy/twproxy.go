@@ -3,6 +3,7 @@ package twproxy
 import (
 	"context"
 
+	"github.com/dmtr/mail_me_all/backend/errors"
 	pb "github.com/dmtr/mail_me_all/backend/rpc"
 	"github.com/dmtr/mail_me_all/backend/twapi"
 )
@@ -21,7 +22,7 @@ func NewServiceServer(t twapi.Twitter) *ServiceServer {
 func (s *ServiceServer) GetUserInfo(ctx context.Context, request *pb.UserInfoRequest) (*pb.UserInfo, error) {
 	res, err := s.twitter.GetUserInfo(request.AccessToken, request.AccessSecret, request.TwitterId, request.ScreenName)
 	if err != nil {
-		return nil, err
+		return nil, errors.ToGRPCError(err)
 	}
 
 	u := pb.UserInfo{
@@ -39,7 +40,7 @@ func (s *ServiceServer) GetUserInfo(ctx context.Context, request *pb.UserInfoReq
 func (s *ServiceServer) SearchUsers(ctx context.Context, request *pb.UserSearchRequest) (*pb.UserSearchResult, error) {
 	users, err := s.twitter.SearchUsers(request.AccessToken, request.AccessSecret, request.TwitterId, request.Query)
 	if err != nil {
-		return nil, err
+		return nil, errors.ToGRPCError(err)
 	}
 
 	res := pb.UserSearchResult{
@@ -57,7 +58,7 @@ func (s *ServiceServer) SearchUsers(ctx context.Context, request *pb.UserSearchR
 		res.Users = append(res.Users, &u)
 	}
 
-	return &res, err
+	return &res, nil
 }
 
 //
@@ -65,7 +66,7 @@ func (s *ServiceServer) GetUserTimeline(ctx context.Context, request *pb.UserTim
 	tweets, err := s.twitter.GetUserTimeline(
 		request.AccessToken, request.AccessSecret, request.TwitterId, request.ScreenName, request.SinceId, request.Count, request.IgnoreRt, request.IgnoreReplies)
 	if err != nil {
-		return nil, err
+		return nil, errors.ToGRPCError(err)
 	}
 
 	res := pb.UserTimelineResponse{
@@ -87,5 +88,78 @@ func (s *ServiceServer) GetUserTimeline(ctx context.Context, request *pb.UserTim
 		res.Tweets = append(res.Tweets, &t)
 	}
 
-	return &res, err
+	return &res, nil
+}
+
+func adaptUsersPage(users []twapi.UserInfo, nextCursor int64) *pb.UsersPage {
+	res := pb.UsersPage{
+		Users:      make([]*pb.UserInfo, 0, len(users)),
+		NextCursor: nextCursor,
+	}
+
+	for _, user := range users {
+		res.Users = append(res.Users, &pb.UserInfo{
+			TwitterId:       user.TwitterID,
+			Name:            user.Name,
+			ScreenName:      user.ScreenName,
+			ProfileImageUrl: user.ProfileIMGURL,
+		})
+	}
+	return &res
+}
+
+//GetLists - returns lists of the user
+func (s *ServiceServer) GetLists(ctx context.Context, request *pb.ListsRequest) (*pb.ListsResponse, error) {
+	lists, err := s.twitter.GetLists(request.AccessToken, request.AccessSecret, request.TwitterId)
+	if err != nil {
+		return nil, errors.ToGRPCError(err)
+	}
+
+	res := pb.ListsResponse{
+		Lists: make([]*pb.TwitterList, 0, len(lists)),
+	}
+
+	for _, list := range lists {
+		res.Lists = append(res.Lists, &pb.TwitterList{
+			IdStr:           list.IDStr,
+			Name:            list.Name,
+			Description:     list.Description,
+			OwnerScreenName: list.OwnerScreenName,
+			MemberCount:     list.MemberCount,
+			Private:         list.Private,
+		})
+	}
+
+	return &res, nil
+}
+
+//GetListMembers - returns a page of list members
+func (s *ServiceServer) GetListMembers(ctx context.Context, request *pb.ListMembersRequest) (*pb.UsersPage, error) {
+	users, nextCursor, err := s.twitter.GetListMembers(
+		request.AccessToken, request.AccessSecret, request.TwitterId, request.ListId, request.Cursor, request.Count)
+	if err != nil {
+		return nil, errors.ToGRPCError(err)
+	}
+
+	return adaptUsersPage(users, nextCursor), nil
+}
+
+//GetFriends - returns a page of accounts the user follows
+func (s *ServiceServer) GetFriends(ctx context.Context, request *pb.FriendsRequest) (*pb.UsersPage, error) {
+	users, nextCursor, err := s.twitter.GetFriends(request.AccessToken, request.AccessSecret, request.TwitterId, request.Cursor, request.Count)
+	if err != nil {
+		return nil, errors.ToGRPCError(err)
+	}
+
+	return adaptUsersPage(users, nextCursor), nil
+}
+
+//InvalidateToken - revokes the user's token
+func (s *ServiceServer) InvalidateToken(ctx context.Context, request *pb.InvalidateTokenRequest) (*pb.InvalidateTokenResponse, error) {
+	err := s.twitter.InvalidateToken(request.AccessToken, request.AccessSecret, request.TwitterId)
+	if err != nil {
+		return nil, errors.ToGRPCError(err)
+	}
+
+	return &pb.InvalidateTokenResponse{}, nil
 }
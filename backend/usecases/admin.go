@@ -0,0 +1,302 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	adminSearchLimit = 50
+
+	adminActionSearchUsers     = "search_users"
+	adminActionGetUser         = "get_user"
+	adminActionGetIssues       = "get_issues"
+	adminActionGetFailedIssues = "get_failed_issues"
+	adminActionResendIssue     = "resend_issue"
+	adminActionForceReconfirm  = "force_reconfirmation"
+	adminActionDisableUser     = "disable_user"
+	adminActionEnableUser      = "enable_user"
+	adminActionGetAudit        = "get_audit"
+	adminActionSetRole         = "set_role"
+)
+
+// audit records the admin action, the action must not be done if it can't be recorded
+func (s SystemUseCase) audit(ctx context.Context, adminID uuid.UUID, action, target, details string) error {
+	a, err := s.UserDatastore.InsertAdminAudit(ctx, models.AdminAudit{AdminID: adminID, Action: action, Target: target, Details: details})
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	log.Infof("Recorded %s", a)
+	return nil
+}
+
+// SetUserRole sets the role of the user, it's used from the command line to appoint the first admin
+func (s SystemUseCase) SetUserRole(userID uuid.UUID, role string) error {
+	if role != models.UserRoleUser && role != models.UserRoleAdmin {
+		return NewUseCaseError(fmt.Sprintf("Unknown role %s", role), errors.BadRequest)
+	}
+
+	ctx := context.Background()
+	err := s.UserDatastore.UpdateUserRole(ctx, userID, role)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	// nobody signed in does it, so the user is recorded as the actor
+	return s.audit(ctx, userID, adminActionSetRole, "user:"+userID.String(), role)
+}
+
+// AuthorizeAdmin returns the user if it has the admin role and isn't disabled
+func (s SystemUseCase) AuthorizeAdmin(ctx context.Context, userID uuid.UUID) (models.User, error) {
+	user, err := s.UserDatastore.GetUser(ctx, userID)
+	if err != nil {
+		code := errors.GetErrorCode(err)
+		if code == errors.NotFound {
+			code = errors.AuthRequired
+		}
+		return user, NewUseCaseError(err.Error(), code)
+	}
+
+	if !user.IsAdmin() {
+		return user, errors.NewForbidden(fmt.Sprintf("%s is not an admin", user))
+	}
+	return user, nil
+}
+
+// AdminSearchUsers finds users by id, name or email
+func (s SystemUseCase) AdminSearchUsers(ctx context.Context, adminID uuid.UUID, query string) ([]models.User, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []models.User{}, errors.NewValidation("Query is empty", map[string]string{"q": "required"})
+	}
+
+	err := s.audit(ctx, adminID, adminActionSearchUsers, "", query)
+	if err != nil {
+		return []models.User{}, err
+	}
+
+	users, err := s.UserDatastore.SearchUsers(ctx, query, adminSearchLimit)
+	if err != nil {
+		return users, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return users, nil
+}
+
+// AdminGetUser returns the user with emails, twitter accounts and subscriptions
+func (s SystemUseCase) AdminGetUser(ctx context.Context, adminID, userID uuid.UUID) (models.AdminUserDetails, error) {
+	var res models.AdminUserDetails
+
+	err := s.audit(ctx, adminID, adminActionGetUser, "user:"+userID.String(), "")
+	if err != nil {
+		return res, err
+	}
+
+	res.User, err = s.UserDatastore.GetUser(ctx, userID)
+	if err != nil {
+		return res, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	res.Emails, err = s.UserDatastore.GetUserEmailsByUserID(ctx, userID)
+	if err != nil {
+		return res, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	accounts, err := s.UserDatastore.GetTwitterUsers(ctx, userID)
+	if err != nil {
+		return res, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	res.TwitterAccounts = make([]models.TwitterUser, 0, len(accounts))
+	for _, a := range accounts {
+		a.AccessToken = ""
+		a.TokenSecret = ""
+		res.TwitterAccounts = append(res.TwitterAccounts, a)
+	}
+
+	res.Subscriptions, err = s.UserDatastore.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return res, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return res, nil
+}
+
+// AdminGetSubscriptionIssues returns a page of the subscription issues and their total number
+func (s SystemUseCase) AdminGetSubscriptionIssues(ctx context.Context, adminID, subscriptionID uuid.UUID, limit, offset uint) ([]models.SubscriptionState, uint, error) {
+	err := s.audit(ctx, adminID, adminActionGetIssues, "subscription:"+subscriptionID.String(), "")
+	if err != nil {
+		return []models.SubscriptionState{}, 0, err
+	}
+
+	_, err = s.UserDatastore.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return []models.SubscriptionState{}, 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	states, err := s.UserDatastore.GetSubscriptionStates(ctx, subscriptionID, limit, offset)
+	if err != nil {
+		return states, 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	total, err := s.UserDatastore.CountSubscriptionStates(ctx, subscriptionID)
+	if err != nil {
+		return states, 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return states, total, nil
+}
+
+// AdminGetFailedIssues returns failed issues of all users, latest first
+func (s SystemUseCase) AdminGetFailedIssues(ctx context.Context, adminID uuid.UUID, limit, offset uint) ([]models.SubscriptionState, error) {
+	err := s.audit(ctx, adminID, adminActionGetFailedIssues, "", "")
+	if err != nil {
+		return []models.SubscriptionState{}, err
+	}
+
+	states, err := s.UserDatastore.GetFailedSubscriptionStates(ctx, limit, offset)
+	if err != nil {
+		return states, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return states, nil
+}
+
+// AdminResendIssue sends a prepared issue again, e.g. after a failure fixed on our side.
+// The issue is sent synchronously holding the send lock, so the scheduled send can't send it at the same time,
+// and its new state is returned.
+func (s SystemUseCase) AdminResendIssue(ctx context.Context, adminID uuid.UUID, subscriptionStateID uint) (models.SubscriptionState, error) {
+	unlock, locked, err := s.UserDatastore.HoldLock(ctx, sendKey)
+	if err != nil {
+		return models.SubscriptionState{}, NewUseCaseError(err.Error(), errors.DbError)
+	}
+
+	if !locked {
+		return models.SubscriptionState{}, errors.NewConflict("Issues are being sent, try again later")
+	}
+	defer unlock()
+
+	state, err := s.UserDatastore.GetSubscriptionState(ctx, subscriptionStateID)
+	if err != nil {
+		return state, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if state.Status == models.Preparing || state.Status == models.Sending {
+		return state, errors.NewConflict(fmt.Sprintf("Issue %d is %s", state.ID, strings.ToLower(state.Status)))
+	}
+
+	subscription, err := s.UserDatastore.GetSubscription(ctx, state.SubscriptionID)
+	if err != nil {
+		return state, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	email, err := s.UserDatastore.GetUserEmail(ctx, models.UserEmail{UserID: subscription.UserID, Email: subscription.Email})
+	if err != nil {
+		return state, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if email.Status != models.EmailStatusConfirmed {
+		return state, NewUseCaseError(fmt.Sprintf("Email %s is %s", email.Email, email.Status), errors.BadRequest)
+	}
+
+	tweets, err := s.UserDatastore.GetSubscriptionTweets(ctx, state.ID)
+	if err != nil {
+		return state, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if len(tweets) == 0 {
+		return state, NewUseCaseError(fmt.Sprintf("Issue %d has no tweets, they may have been removed", state.ID), errors.BadRequest)
+	}
+
+	tmpl, err := getIssueTemplate(s.Conf.TemplatePath)
+	if err != nil {
+		return state, NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	err = s.audit(ctx, adminID, adminActionResendIssue, fmt.Sprintf("issue:%d", state.ID), fmt.Sprintf("previous status %s", state.Status))
+	if err != nil {
+		return state, err
+	}
+
+	// a failure is reported in the returned state with its reason
+	state.FailureReason = ""
+	return s.sendIssue(subscription, state, tmpl, noProgress), nil
+}
+
+// AdminForceReconfirmation resets the email to new, issues aren't sent to it till the user confirms it again.
+// The confirmation email is sent by the confirmation job.
+func (s SystemUseCase) AdminForceReconfirmation(ctx context.Context, adminID uuid.UUID, email string) (models.UserEmail, error) {
+	userEmail, err := s.UserDatastore.GetUserEmail(ctx, models.UserEmail{Email: email})
+	if err != nil {
+		return userEmail, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	err = s.audit(ctx, adminID, adminActionForceReconfirm, "email:"+userEmail.Email, fmt.Sprintf("previous status %s", userEmail.Status))
+	if err != nil {
+		return userEmail, err
+	}
+
+	userEmail.Status = models.EmailStatusNew
+	userEmail, err = s.UserDatastore.UpdateUserEmail(ctx, userEmail)
+	if err != nil {
+		return userEmail, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return userEmail, nil
+}
+
+// AdminSetUserDisabled disables or enables the user. A disabled user can't sign in, its sessions and
+// API tokens are revoked and its subscriptions aren't sent.
+func (s SystemUseCase) AdminSetUserDisabled(ctx context.Context, adminID, userID uuid.UUID, disabled bool) (models.User, error) {
+	if disabled && adminID == userID {
+		return models.User{}, NewUseCaseError("Admin can not disable itself", errors.BadRequest)
+	}
+
+	action := adminActionEnableUser
+	if disabled {
+		action = adminActionDisableUser
+	}
+
+	err := s.audit(ctx, adminID, action, "user:"+userID.String(), "")
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user, err := s.UserDatastore.SetUserDisabled(ctx, userID, disabled)
+	if err != nil {
+		return user, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if !disabled {
+		return user, nil
+	}
+
+	sessions, err := s.UserDatastore.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return user, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	tokens, err := s.UserDatastore.RevokeUserAPITokens(ctx, userID)
+	if err != nil {
+		return user, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Disabled %s, revoked %d sessions and %d API tokens", user, sessions, tokens)
+	return user, nil
+}
+
+// AdminGetAudit returns a page of admin actions, latest first
+func (s SystemUseCase) AdminGetAudit(ctx context.Context, adminID uuid.UUID, limit, offset uint) ([]models.AdminAudit, error) {
+	err := s.audit(ctx, adminID, adminActionGetAudit, "", "")
+	if err != nil {
+		return []models.AdminAudit{}, err
+	}
+
+	res, err := s.UserDatastore.GetAdminAudit(ctx, limit, offset)
+	if err != nil {
+		return res, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return res, nil
+}
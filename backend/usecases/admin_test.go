@@ -0,0 +1,186 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func auditAction(action string) interface{} {
+	return mock.MatchedBy(func(a models.AdminAudit) bool { return a.Action == action })
+}
+
+func TestAuthorizeAdmin(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	admin := models.User{ID: uuid.New(), Role: models.UserRoleAdmin}
+	user := models.User{ID: uuid.New(), Role: models.UserRoleUser}
+	datastoreMock.On("GetUser", mock.Anything, admin.ID).Return(admin, nil)
+	datastoreMock.On("GetUser", mock.Anything, user.ID).Return(user, nil)
+
+	_, err := s.AuthorizeAdmin(context.Background(), admin.ID)
+	assert.NoError(t, err)
+
+	_, err = s.AuthorizeAdmin(context.Background(), user.ID)
+	assert.Equal(t, errors.Forbidden, errors.GetErrorCode(err))
+}
+
+func TestSetUserRole(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	userID := uuid.New()
+	datastoreMock.On("UpdateUserRole", mock.Anything, userID, models.UserRoleAdmin).Return(nil)
+	datastoreMock.On("InsertAdminAudit", mock.Anything, auditAction(adminActionSetRole)).Return(models.AdminAudit{ID: 1}, nil)
+
+	assert.NoError(t, s.SetUserRole(userID, models.UserRoleAdmin))
+
+	err := s.SetUserRole(userID, "ROOT")
+	assert.Equal(t, errors.BadRequest, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserRole", 1)
+}
+
+func TestAdminGetUserHidesTokens(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	adminID := uuid.New()
+	user := models.User{ID: uuid.New(), Name: "Test"}
+	datastoreMock.On("InsertAdminAudit", mock.Anything, mock.MatchedBy(func(a models.AdminAudit) bool {
+		return a.AdminID == adminID && a.Action == adminActionGetUser && a.Target == "user:"+user.ID.String()
+	})).Return(models.AdminAudit{ID: 1}, nil)
+	datastoreMock.On("GetUser", mock.Anything, user.ID).Return(user, nil)
+	datastoreMock.On("GetUserEmailsByUserID", mock.Anything, user.ID).Return(
+		[]models.UserEmail{{UserID: user.ID, Email: "test@example.com", Status: models.EmailStatusBounced}}, nil)
+	datastoreMock.On("GetTwitterUsers", mock.Anything, user.ID).Return(
+		[]models.TwitterUser{{UserID: user.ID, TwitterID: "111", AccessToken: "access-token", TokenSecret: "token-secret"}}, nil)
+	datastoreMock.On("GetSubscriptions", mock.Anything, user.ID).Return([]models.Subscription{}, nil)
+
+	details, err := s.AdminGetUser(context.Background(), adminID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.EmailStatusBounced, details.Emails[0].Status)
+	assert.Equal(t, "111", details.TwitterAccounts[0].TwitterID)
+	assert.Empty(t, details.TwitterAccounts[0].AccessToken)
+	assert.Empty(t, details.TwitterAccounts[0].TokenSecret)
+}
+
+func TestAdminActionNotDoneWithoutAudit(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	datastoreMock.On("InsertAdminAudit", mock.Anything, mock.Anything).Return(models.AdminAudit{}, errors.New("db is down", errors.DbError))
+
+	_, err := s.AdminSetUserDisabled(context.Background(), uuid.New(), uuid.New(), true)
+	assert.Error(t, err)
+	datastoreMock.AssertNotCalled(t, "SetUserDisabled", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	adminID := uuid.New()
+	user := models.User{ID: uuid.New()}
+	datastoreMock.On("InsertAdminAudit", mock.Anything, auditAction(adminActionDisableUser)).Return(models.AdminAudit{ID: 1}, nil)
+	datastoreMock.On("SetUserDisabled", mock.Anything, user.ID, true).Return(user, nil)
+	datastoreMock.On("RevokeUserSessions", mock.Anything, user.ID).Return(uint(2), nil)
+	datastoreMock.On("RevokeUserAPITokens", mock.Anything, user.ID).Return(uint(1), nil)
+
+	_, err := s.AdminSetUserDisabled(context.Background(), adminID, user.ID, true)
+	assert.NoError(t, err)
+	datastoreMock.AssertNumberOfCalls(t, "RevokeUserSessions", 1)
+	datastoreMock.AssertNumberOfCalls(t, "RevokeUserAPITokens", 1)
+
+	_, err = s.AdminSetUserDisabled(context.Background(), adminID, adminID, true)
+	assert.Equal(t, errors.BadRequest, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "SetUserDisabled", 1)
+}
+
+func TestAdminForceReconfirmation(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	email := models.UserEmail{UserID: uuid.New(), Email: "test@example.com", Status: models.EmailStatusConfirmed}
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: email.Email}).Return(email, nil)
+	datastoreMock.On("InsertAdminAudit", mock.Anything, auditAction(adminActionForceReconfirm)).Return(models.AdminAudit{ID: 1}, nil)
+	datastoreMock.On("UpdateUserEmail", mock.Anything, mock.MatchedBy(func(e models.UserEmail) bool {
+		return e.Email == email.Email && e.Status == models.EmailStatusNew
+	})).Return(func(ctx context.Context, e models.UserEmail) models.UserEmail { return e }, nil)
+
+	res, err := s.AdminForceReconfirmation(context.Background(), uuid.New(), email.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, models.EmailStatusNew, res.Status)
+}
+
+func TestAdminResendIssue(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	s.Conf.TemplatePath = "../templates"
+	s.Conf.InlineImages = false
+	s.Conf.EncryptKey = "secret"
+	subscription := models.Subscription{ID: uuid.New(), UserID: uuid.New(), Email: "test@example.com", Title: "News"}
+	state := models.SubscriptionState{ID: 7, SubscriptionID: subscription.ID, Status: models.Failed, FailureReason: "Can not send email"}
+	unlocked := false
+	datastoreMock.On("HoldLock", mock.Anything, uint(sendKey)).Return(func() { unlocked = true }, true, nil)
+	datastoreMock.On("GetSubscriptionState", mock.Anything, state.ID).Return(state, nil)
+	datastoreMock.On("GetSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{UserID: subscription.UserID, Email: subscription.Email}).Return(
+		models.UserEmail{UserID: subscription.UserID, Email: subscription.Email, Status: models.EmailStatusConfirmed}, nil)
+	datastoreMock.On("GetSubscriptionTweets", mock.Anything, state.ID).Return(
+		[]models.Tweet{{TweetID: "42", Tweet: models.TweetAttrs{IdStr: "42", FullText: "Hello"}}}, nil)
+	datastoreMock.On("InsertAdminAudit", mock.Anything, auditAction(adminActionResendIssue)).Return(models.AdminAudit{ID: 1}, nil)
+	datastoreMock.On("UpdateSubscriptionState", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, s models.SubscriptionState) models.SubscriptionState { return s }, nil)
+
+	emailSender := s.EmailSender.(*mocks.EmailSender)
+	emailSender.On("Send", mock.MatchedBy(func(m models.EmailMessage) bool { return m.To == subscription.Email })).Return(nil)
+
+	res, err := s.AdminResendIssue(context.Background(), uuid.New(), state.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Sent, res.Status)
+	assert.Empty(t, res.FailureReason)
+	assert.True(t, unlocked)
+	emailSender.AssertNumberOfCalls(t, "Send", 1)
+}
+
+func TestAdminResendIssueSendFailed(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	s.Conf.TemplatePath = "../templates"
+	s.Conf.InlineImages = false
+	s.Conf.EncryptKey = "secret"
+	subscription := models.Subscription{ID: uuid.New(), UserID: uuid.New(), Email: "test@example.com", Title: "News"}
+	state := models.SubscriptionState{ID: 7, SubscriptionID: subscription.ID, Status: models.Failed}
+	datastoreMock.On("HoldLock", mock.Anything, uint(sendKey)).Return(func() {}, true, nil)
+	datastoreMock.On("GetSubscriptionState", mock.Anything, state.ID).Return(state, nil)
+	datastoreMock.On("GetSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{UserID: subscription.UserID, Email: subscription.Email}).Return(
+		models.UserEmail{UserID: subscription.UserID, Email: subscription.Email, Status: models.EmailStatusConfirmed}, nil)
+	datastoreMock.On("GetSubscriptionTweets", mock.Anything, state.ID).Return(
+		[]models.Tweet{{TweetID: "42", Tweet: models.TweetAttrs{IdStr: "42", FullText: "Hello"}}}, nil)
+	datastoreMock.On("InsertAdminAudit", mock.Anything, auditAction(adminActionResendIssue)).Return(models.AdminAudit{ID: 1}, nil)
+	datastoreMock.On("UpdateSubscriptionState", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, s models.SubscriptionState) models.SubscriptionState { return s }, nil)
+
+	emailSender := s.EmailSender.(*mocks.EmailSender)
+	emailSender.On("Send", mock.Anything).Return(fmt.Errorf("Forbidden: domain mg.example.com is disabled"))
+
+	// provider errors are logged, the user sees a generic reason
+	res, err := s.AdminResendIssue(context.Background(), uuid.New(), state.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Failed, res.Status)
+	assert.Equal(t, "Can not send email", res.FailureReason)
+}
+
+func TestAdminResendIssueInProgress(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	datastoreMock.On("HoldLock", mock.Anything, uint(sendKey)).Return(func() {}, true, nil)
+	datastoreMock.On("GetSubscriptionState", mock.Anything, uint(7)).Return(models.SubscriptionState{ID: 7, Status: models.Sending}, nil)
+
+	_, err := s.AdminResendIssue(context.Background(), uuid.New(), 7)
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	datastoreMock.AssertNotCalled(t, "InsertAdminAudit", mock.Anything, mock.Anything)
+}
+
+func TestAdminResendIssueScheduledSend(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	datastoreMock.On("HoldLock", mock.Anything, uint(sendKey)).Return(nil, false, nil)
+
+	_, err := s.AdminResendIssue(context.Background(), uuid.New(), 7)
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	datastoreMock.AssertNotCalled(t, "GetSubscriptionState", mock.Anything, mock.Anything)
+}
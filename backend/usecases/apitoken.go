@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	apiTokenPrefix     = "mma_"
+	apiTokenSize       = 32
+	maxAPITokenNameLen = 100
+)
+
+// hashToken - tokens are stored as hashes, so a leaked database can not be used to sign in
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// CreateAPIToken creates a personal access token, the token itself is returned only once
+func (u UserUseCase) CreateAPIToken(ctx context.Context, userID uuid.UUID, name, scope string) (models.APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPITokenNameLen {
+		return models.APIToken{}, "", errors.NewValidation("Invalid token name", map[string]string{"name": "required, up to " + strconv.Itoa(maxAPITokenNameLen) + " characters"})
+	}
+
+	scope = strings.ToUpper(scope)
+	if scope != models.APITokenScopeRead && scope != models.APITokenScopeWrite {
+		return models.APIToken{}, "", errors.NewValidation(fmt.Sprintf("Unknown token scope %s", scope), map[string]string{"scope": "must be read or write"})
+	}
+
+	random, err := getRandomToken(apiTokenSize)
+	if err != nil {
+		return models.APIToken{}, "", NewUseCaseError(err.Error(), errors.ServerError)
+	}
+	token := apiTokenPrefix + random
+
+	apiToken, err := u.UserDatastore.InsertAPIToken(ctx, models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scope:     scope,
+	})
+	if err != nil {
+		return apiToken, "", NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Created %s", apiToken)
+	return apiToken, token, nil
+}
+
+// GetAPITokens returns user's tokens that aren't revoked
+func (u UserUseCase) GetAPITokens(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	tokens, err := u.UserDatastore.GetAPITokens(ctx, userID)
+	if err != nil {
+		return tokens, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes user's token, the token can't be used after that
+func (u UserUseCase) RevokeAPIToken(ctx context.Context, userID uuid.UUID, tokenID uint) error {
+	revoked, err := u.UserDatastore.RevokeAPIToken(ctx, userID, tokenID)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if !revoked {
+		return NewUseCaseError(fmt.Sprintf("User %s has no token %d", userID, tokenID), errors.NotFound)
+	}
+
+	return nil
+}
+
+// AuthenticateAPIToken finds an active token and records its usage
+func (u UserUseCase) AuthenticateAPIToken(ctx context.Context, token string) (models.APIToken, error) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return models.APIToken{}, NewUseCaseError("Invalid token", errors.AuthRequired)
+	}
+
+	apiToken, err := u.UserDatastore.GetAPITokenByHash(ctx, hashToken(token))
+	if err != nil {
+		code := errors.GetErrorCode(err)
+		if code == errors.NotFound {
+			code = errors.AuthRequired
+		}
+		return apiToken, NewUseCaseError(err.Error(), code)
+	}
+
+	if apiToken.RevokedAt != nil {
+		return apiToken, NewUseCaseError(fmt.Sprintf("%s is revoked", apiToken), errors.AuthRequired)
+	}
+
+	err = u.UserDatastore.UpdateAPITokenLastUsed(ctx, apiToken.ID)
+	if err != nil {
+		log.Errorf("Can not update last usage of %s, got error %s", apiToken, err)
+	}
+
+	return apiToken, nil
+}
@@ -0,0 +1,264 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ConfirmationEmailSubj - subject of the email with the confirmation link
+	ConfirmationEmailSubj = "Email address confirmation"
+	// ConfirmationReminderSubj - subject of the reminder with a new confirmation link
+	ConfirmationReminderSubj = "Reminder: email address confirmation"
+	// emailConfirmationSubject - subject of confirmation tokens, so they can't be used as other tokens
+	emailConfirmationSubject   = "email_confirmation"
+	emailConfirmationNonceSize = 32
+)
+
+// confirmationReminderDelays - delays of the reminders after the previous confirmation email,
+// so they are sent on days 1, 3 and 7 after the first one
+var confirmationReminderDelays = []time.Duration{24 * time.Hour, 48 * time.Hour, 96 * time.Hour}
+
+// EmailConfirmationClaims - claims of the token from the confirmation link, the nonce makes the token single-use
+type EmailConfirmationClaims struct {
+	Nonce string `json:"nonce"`
+	jwt.StandardClaims
+}
+
+func (s SystemUseCase) getEmailConfirmationToken(nonce string, expiresAt time.Time) (string, error) {
+	claims := EmailConfirmationClaims{
+		nonce,
+		jwt.StandardClaims{
+			Subject:   emailConfirmationSubject,
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	return getSignedToken(s.Conf.EncryptKey, claims)
+}
+
+func (s SystemUseCase) getEmailConfirmationLink(nonce string, expiresAt time.Time) (string, error) {
+	token, err := s.getEmailConfirmationToken(nonce, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	link := &url.URL{
+		Scheme:   "https",
+		Host:     s.Conf.Domain,
+		Path:     "confirm/email",
+		RawQuery: fmt.Sprintf("token=%s", token),
+	}
+	return link.String(), nil
+}
+
+func getConfirmationTemplate(templatePath string) (*template.Template, error) {
+	return template.New("confirm.html").ParseFiles(filepath.Join(templatePath, "confirm.html"))
+}
+
+// sendConfirmationEmail sends a new confirmation link to the address and records when it was sent
+// and how many reminders were sent
+func (s SystemUseCase) sendConfirmationEmail(ctx context.Context, tmpl *template.Template, email models.UserEmail, reminder bool) error {
+	nonce, err := getRandomToken(emailConfirmationNonceSize)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.Conf.EmailConfirmTTL) * time.Hour)
+	confirmation, err := s.UserDatastore.InsertEmailConfirmation(ctx, models.EmailConfirmation{
+		UserID:    email.UserID,
+		Email:     email.Email,
+		NonceHash: hashToken(nonce),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Errorf("Can not insert confirmation for %s: %s", email, err)
+		return err
+	}
+
+	link, err := s.getEmailConfirmationLink(nonce, expiresAt)
+	if err != nil {
+		log.Errorf("Can not get confirmation link: %s", err)
+		return err
+	}
+
+	type TemplateData struct {
+		ConfirmationLink string
+		TTL              int
+		Reminder         bool
+	}
+
+	var buf strings.Builder
+	err = tmpl.Execute(&buf, TemplateData{ConfirmationLink: link, TTL: s.Conf.EmailConfirmTTL, Reminder: reminder})
+	if err != nil {
+		log.Errorf("Can not execute template: %s", err)
+		return err
+	}
+
+	subject := ConfirmationEmailSubj
+	if reminder {
+		subject = ConfirmationReminderSubj
+	}
+
+	err = s.EmailSender.Send(models.NewEmailMessage(s.Conf.From, email.Email, subject, buf.String()))
+	if err != nil {
+		log.Errorf("Can not send email: %s", err)
+		return err
+	}
+
+	err = s.UserDatastore.SetEmailConfirmationSent(ctx, email)
+	if err != nil {
+		log.Errorf("Can not update user email: %s", err)
+		return err
+	}
+
+	log.Infof("Sent %s, reminder %t", confirmation, reminder)
+	return nil
+}
+
+// remindOrAbandon sends the next reminder when its delay has passed. After the last reminder
+// expires the address is abandoned and subscriptions sent to it are paused.
+func (s SystemUseCase) remindOrAbandon(ctx context.Context, tmpl *template.Template, email models.UserEmail, now time.Time) error {
+	sentAt := email.CreatedAt
+	if email.ConfirmationSentAt != nil {
+		sentAt = *email.ConfirmationSentAt
+	}
+
+	if int(email.RemindersSent) < len(confirmationReminderDelays) {
+		if now.Sub(sentAt) < confirmationReminderDelays[email.RemindersSent] {
+			return nil
+		}
+
+		email.RemindersSent++
+		return s.sendConfirmationEmail(ctx, tmpl, email, true)
+	}
+
+	if now.Sub(sentAt) < time.Duration(s.Conf.EmailConfirmTTL)*time.Hour {
+		return nil
+	}
+
+	email.Status = models.EmailStatusAbandoned
+	_, err := s.UserDatastore.UpdateUserEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.UserDatastore.PauseEmailSubscriptions(ctx, email.UserID, email.Email)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Email %s is abandoned, paused %d subscriptions", email, n)
+	return nil
+}
+
+// SendConfirmationEmail confirms addresses verified by Twitter, sends confirmation links to new addresses
+// and reminders to not confirmed ones
+func (s SystemUseCase) SendConfirmationEmail() error {
+	ctx := context.Background()
+	n, err := s.UserDatastore.ConfirmVerifiedEmails(ctx)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Infof("Confirmed %d emails verified by Twitter", n)
+	}
+
+	emails, err := s.UserDatastore.GetUserEmails(ctx, models.EmailStatusNew)
+	if err != nil {
+		return err
+	}
+
+	tmpl := template.Must(getConfirmationTemplate(s.Conf.TemplatePath))
+
+	for _, email := range emails {
+		// the address may be new again, e.g. after it bounced, so reminders start over
+		email.RemindersSent = 0
+		if e := s.sendConfirmationEmail(ctx, tmpl, email, false); e != nil {
+			err = e
+		}
+	}
+
+	sent, e := s.UserDatastore.GetUserEmails(ctx, models.EmailStatusSent)
+	if e != nil {
+		return e
+	}
+
+	now := time.Now()
+	for _, email := range sent {
+		if e := s.remindOrAbandon(ctx, tmpl, email, now); e != nil {
+			log.Errorf("Can not remind %s, got error %s", email, e)
+			err = e
+		}
+	}
+	return err
+}
+
+func (u UserUseCase) parseEmailConfirmationToken(token string) (string, error) {
+	var claims EmailConfirmationClaims
+	t, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(u.Conf.EncryptKey), nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if !t.Valid || claims.Subject != emailConfirmationSubject || claims.Nonce == "" {
+		return "", fmt.Errorf("Invalid confirmation token")
+	}
+
+	return claims.Nonce, nil
+}
+
+// ConfirmEmail uses the confirmation link and confirms its address. The link can be used once,
+// before it expires and while the address still belongs to the user.
+func (u UserUseCase) ConfirmEmail(ctx context.Context, token string) error {
+	nonce, err := u.parseEmailConfirmationToken(token)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	confirmation, err := u.UserDatastore.UseEmailConfirmation(ctx, hashToken(nonce))
+	if err != nil {
+		code := errors.GetErrorCode(err)
+		if code == errors.NotFound {
+			return NewUseCaseError("Confirmation link is used or expired", errors.AuthRequired)
+		}
+		return NewUseCaseError(err.Error(), code)
+	}
+
+	email, err := u.UserDatastore.GetUserEmail(ctx, models.UserEmail{Email: confirmation.Email})
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if email.UserID != confirmation.UserID {
+		log.Errorf("%s doesn't match %s", confirmation, email)
+		return NewUseCaseError("Can't confirm email", errors.AuthRequired)
+	}
+
+	email.Status = models.EmailStatusConfirmed
+	_, err = u.UserDatastore.UpdateUserEmail(ctx, email)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Confirmed %s with %s", email, confirmation)
+	return nil
+}
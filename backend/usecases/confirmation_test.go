@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSendConfirmationEmailReminders(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	s.Conf.TemplatePath = "../templates"
+	s.Conf.EncryptKey = "secret"
+	s.Conf.EmailConfirmTTL = 24
+	userID := uuid.New()
+	dayAgo := time.Now().Add(-25 * time.Hour)
+	hourAgo := time.Now().Add(-time.Hour)
+
+	datastoreMock.On("ConfirmVerifiedEmails", mock.Anything).Return(uint(1), nil)
+	// the address bounced and was saved again, so reminders start over
+	datastoreMock.On("GetUserEmails", mock.Anything, models.EmailStatusNew).Return([]models.UserEmail{
+		{UserID: userID, Email: "new@example.com", Status: models.EmailStatusNew, RemindersSent: 3},
+	}, nil)
+	datastoreMock.On("GetUserEmails", mock.Anything, models.EmailStatusSent).Return([]models.UserEmail{
+		{UserID: userID, Email: "due@example.com", Status: models.EmailStatusSent, ConfirmationSentAt: &dayAgo},
+		{UserID: userID, Email: "waits@example.com", Status: models.EmailStatusSent, ConfirmationSentAt: &dayAgo, RemindersSent: 1},
+		{UserID: userID, Email: "expired@example.com", Status: models.EmailStatusSent, ConfirmationSentAt: &dayAgo, RemindersSent: 3},
+		{UserID: userID, Email: "last@example.com", Status: models.EmailStatusSent, ConfirmationSentAt: &hourAgo, RemindersSent: 3},
+	}, nil)
+	datastoreMock.On("InsertEmailConfirmation", mock.Anything, mock.Anything).Return(models.EmailConfirmation{ID: 1, UserID: userID}, nil)
+	datastoreMock.On("SetEmailConfirmationSent", mock.Anything, mock.MatchedBy(func(e models.UserEmail) bool {
+		return e.Email == "new@example.com" && e.RemindersSent == 0
+	})).Return(nil)
+	datastoreMock.On("SetEmailConfirmationSent", mock.Anything, mock.MatchedBy(func(e models.UserEmail) bool {
+		return e.Email == "due@example.com" && e.RemindersSent == 1
+	})).Return(nil)
+	datastoreMock.On("UpdateUserEmail", mock.Anything, mock.MatchedBy(func(e models.UserEmail) bool {
+		return e.Email == "expired@example.com" && e.Status == models.EmailStatusAbandoned
+	})).Return(models.UserEmail{}, nil)
+	datastoreMock.On("PauseEmailSubscriptions", mock.Anything, userID, "expired@example.com").Return(uint(2), nil)
+
+	emailSender := s.EmailSender.(*mocks.EmailSender)
+	emailSender.On("Send", mock.MatchedBy(func(m models.EmailMessage) bool {
+		return m.To == "new@example.com" && m.Subject == ConfirmationEmailSubj
+	})).Return(nil)
+	emailSender.On("Send", mock.MatchedBy(func(m models.EmailMessage) bool {
+		return m.To == "due@example.com" && m.Subject == ConfirmationReminderSubj
+	})).Return(nil)
+
+	err := s.SendConfirmationEmail()
+	assert.NoError(t, err)
+	emailSender.AssertNumberOfCalls(t, "Send", 2)
+	datastoreMock.AssertNumberOfCalls(t, "InsertEmailConfirmation", 2)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 1)
+	datastoreMock.AssertNumberOfCalls(t, "PauseEmailSubscriptions", 1)
+}
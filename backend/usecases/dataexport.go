@@ -0,0 +1,439 @@
+package usecases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DataExportEmailSubj - subject of the email with the download link
+	DataExportEmailSubj = "Your Read-it-later.app data export is ready"
+	// dataExportSubject - subject of download tokens, so they can't be used as other tokens
+	dataExportSubject = "data_export"
+	// dataExportStaleAfter - pending export is considered lost after that, e.g. the server was restarted while building it
+	dataExportStaleAfter = time.Hour
+	dataExportIssuesPage = 100
+)
+
+// DataExportClaims - claims of the token from the download link
+type DataExportClaims struct {
+	ExportID uint `json:"export_id"`
+	jwt.StandardClaims
+}
+
+type exportedUser struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	VacationStart *time.Time `json:"vacation_start"`
+	VacationEnd   *time.Time `json:"vacation_end"`
+}
+
+// exportedTwitterAccount - linked twitter identity, tokens are never exported
+type exportedTwitterAccount struct {
+	TwitterID     string `json:"twitter_id"`
+	ScreenName    string `json:"screen_name"`
+	ProfileIMGURL string `json:"profile_image_url"`
+	Primary       bool   `json:"primary"`
+}
+
+type exportedEmail struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+}
+
+type exportedAccount struct {
+	TwitterID  string `json:"twitter_id"`
+	Name       string `json:"name"`
+	ScreenName string `json:"screen_name"`
+}
+
+type exportedSubscription struct {
+	ID               string            `json:"id"`
+	Title            string            `json:"title"`
+	Email            string            `json:"email"`
+	Day              string            `json:"day"`
+	IgnoreRT         bool              `json:"ignore_rt"`
+	IgnoreReplies    bool              `json:"ignore_replies"`
+	EreaderEmail     string            `json:"ereader_email"`
+	Paused           bool              `json:"paused"`
+	TwitterListID    string            `json:"twitter_list_id"`
+	TwitterAccountID string            `json:"twitter_account_id"`
+	Accounts         []exportedAccount `json:"accounts"`
+}
+
+type exportedIssue struct {
+	ID             uint       `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	Status         string     `json:"status"`
+	TweetCount     uint       `json:"tweet_count"`
+	FailureReason  string     `json:"failure_reason"`
+	OnDemand       bool       `json:"on_demand"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	SentAt         *time.Time `json:"sent_at"`
+}
+
+func writeJSONFile(w *zip.Writer, name string, v interface{}) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// getAllSubscriptionStates returns all issues of the subscription, the latest first
+func getAllSubscriptionStates(ctx context.Context, datastore models.UserDatastore, subscriptionID uuid.UUID) ([]models.SubscriptionState, error) {
+	var res []models.SubscriptionState
+	for offset := uint(0); ; offset += dataExportIssuesPage {
+		states, err := datastore.GetSubscriptionStates(ctx, subscriptionID, dataExportIssuesPage, offset)
+		if err != nil {
+			return res, err
+		}
+
+		res = append(res, states...)
+		if len(states) < dataExportIssuesPage {
+			return res, nil
+		}
+	}
+}
+
+// buildDataExportArchive returns ZIP archive with all data of the user, tweets of every issue are in tweets/<issue id>.json
+func buildDataExportArchive(ctx context.Context, datastore models.UserDatastore, user models.User) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	err := writeJSONFile(w, "user.json", exportedUser{
+		ID:            user.ID.String(),
+		Name:          user.Name,
+		Email:         user.Email,
+		VacationStart: user.VacationStart,
+		VacationEnd:   user.VacationEnd,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	twitterUsers, err := datastore.GetTwitterUsers(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]exportedTwitterAccount, 0, len(twitterUsers))
+	for _, t := range twitterUsers {
+		accounts = append(accounts, exportedTwitterAccount{
+			TwitterID:     t.TwitterID,
+			ScreenName:    t.ScreenName,
+			ProfileIMGURL: t.ProfileIMGURL,
+			Primary:       t.Primary,
+		})
+	}
+
+	if err = writeJSONFile(w, "twitter_accounts.json", accounts); err != nil {
+		return nil, err
+	}
+
+	userEmails, err := datastore.GetUserEmailsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]exportedEmail, 0, len(userEmails))
+	for _, e := range userEmails {
+		emails = append(emails, exportedEmail{Email: e.Email, Status: e.Status})
+	}
+
+	if err = writeJSONFile(w, "emails.json", emails); err != nil {
+		return nil, err
+	}
+
+	userSubscriptions, err := datastore.GetSubscriptions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]exportedSubscription, 0, len(userSubscriptions))
+	issues := make([]exportedIssue, 0)
+	for _, s := range userSubscriptions {
+		e := exportedSubscription{
+			ID:               s.ID.String(),
+			Title:            s.Title,
+			Email:            s.Email,
+			Day:              s.Day,
+			IgnoreRT:         s.IgnoreRT,
+			IgnoreReplies:    s.IgnoreReplies,
+			EreaderEmail:     s.EreaderEmail,
+			Paused:           s.Paused,
+			TwitterListID:    s.TwitterListID,
+			TwitterAccountID: s.TwitterAccountID,
+			Accounts:         make([]exportedAccount, 0, len(s.UserList)),
+		}
+		for _, u := range s.UserList {
+			e.Accounts = append(e.Accounts, exportedAccount{TwitterID: u.TwitterID, Name: u.Name, ScreenName: u.ScreenName})
+		}
+		subscriptions = append(subscriptions, e)
+
+		states, err := getAllSubscriptionStates(ctx, datastore, s.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, state := range states {
+			issues = append(issues, exportedIssue{
+				ID:             state.ID,
+				SubscriptionID: s.ID.String(),
+				Status:         state.Status,
+				TweetCount:     state.TweetCount,
+				FailureReason:  state.FailureReason,
+				OnDemand:       state.OnDemand,
+				CreatedAt:      state.CreatedAt,
+				UpdatedAt:      state.UpdatedAt,
+				SentAt:         state.SentAt,
+			})
+
+			tweets, err := datastore.GetSubscriptionTweets(ctx, state.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			// old tweets are removed after TweetTTL days
+			if len(tweets) == 0 {
+				continue
+			}
+
+			archived := make([]models.TweetAttrs, 0, len(tweets))
+			for _, t := range tweets {
+				archived = append(archived, t.Tweet)
+			}
+
+			if err = writeJSONFile(w, fmt.Sprintf("tweets/%d.json", state.ID), archived); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = writeJSONFile(w, "subscriptions.json", subscriptions); err != nil {
+		return nil, err
+	}
+
+	if err = writeJSONFile(w, "issues.json", issues); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func getDataExportLink(conf *config.Config, export models.DataExport) (string, error) {
+	claims := DataExportClaims{
+		export.ID,
+		jwt.StandardClaims{
+			Subject:   dataExportSubject,
+			ExpiresAt: export.ExpiresAt.Unix(),
+		},
+	}
+
+	token, err := getSignedToken(conf.EncryptKey, claims)
+	if err != nil {
+		return "", err
+	}
+
+	link := &url.URL{
+		Scheme: "https",
+		Host:   conf.Domain,
+		Path:   "exports/" + token,
+	}
+	return link.String(), nil
+}
+
+// ExportUserData starts building the archive with all user data. The archive is built in background,
+// the download link is emailed to a confirmed email of the user when it's ready.
+func (s SystemUseCase) ExportUserData(userID uuid.UUID) (models.DataExport, error) {
+	ctx := context.Background()
+
+	exports, err := s.UserDatastore.GetDataExports(ctx, userID)
+	if err != nil {
+		return models.DataExport{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	for _, e := range exports {
+		if e.Status == models.DataExportStatusPending && time.Since(e.CreatedAt) < dataExportStaleAfter {
+			return e, errors.NewConflict("Data export is in progress")
+		}
+	}
+
+	user, err := s.UserDatastore.GetUser(ctx, userID)
+	if err != nil {
+		return models.DataExport{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	export, err := s.UserDatastore.InsertDataExport(ctx, models.DataExport{UserID: userID, Status: models.DataExportStatusPending})
+	if err != nil {
+		return export, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Started %s", export)
+	go s.exportUserData(user, export)
+	return export, nil
+}
+
+func (s SystemUseCase) exportUserData(user models.User, export models.DataExport) {
+	ctx := context.Background()
+
+	archive, err := buildDataExportArchive(ctx, s.UserDatastore, user)
+	if err != nil {
+		log.Errorf("Can not build archive of %s, got error %s", export, err)
+		export.Status = models.DataExportStatusFailed
+		export.FailureReason = "Can not build the archive"
+		if _, err = s.UserDatastore.UpdateDataExport(ctx, export); err != nil {
+			log.Errorf("Can not update %s, got error %s", export, err)
+		}
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.Conf.DataExportTTL) * time.Hour)
+	export.Status = models.DataExportStatusReady
+	export.Archive = archive
+	export.ExpiresAt = &expiresAt
+	export, err = s.UserDatastore.UpdateDataExport(ctx, export)
+	if err != nil {
+		log.Errorf("Can not update %s, got error %s", export, err)
+		return
+	}
+
+	log.Infof("%s is ready, archive size %d", export, len(archive))
+	s.sendDataExportLink(ctx, user, export)
+}
+
+// sendDataExportLink emails the download link, user's own email is preferred if it's confirmed
+func (s SystemUseCase) sendDataExportLink(ctx context.Context, user models.User, export models.DataExport) {
+	emails, err := s.UserDatastore.GetUserEmailsByUserID(ctx, user.ID)
+	if err != nil {
+		log.Errorf("Can not get emails of %s, got error %s", user, err)
+		return
+	}
+
+	var to string
+	for _, e := range emails {
+		if e.Status == models.EmailStatusConfirmed && (to == "" || e.Email == user.Email) {
+			to = e.Email
+		}
+	}
+
+	if to == "" {
+		log.Infof("%s has no confirmed email, %s can be downloaded from settings", user, export)
+		return
+	}
+
+	link, err := getDataExportLink(s.Conf, export)
+	if err != nil {
+		log.Errorf("Can not get download link of %s, got error %s", export, err)
+		return
+	}
+
+	tmpl, err := template.ParseFiles(filepath.Join(s.Conf.TemplatePath, "data_export.html"))
+	if err != nil {
+		log.Errorf("Can not parse template, got error %s", err)
+		return
+	}
+
+	var buf strings.Builder
+	err = tmpl.Execute(&buf, struct {
+		DownloadLink string
+		TTL          int
+	}{link, s.Conf.DataExportTTL})
+	if err != nil {
+		log.Errorf("Can not execute template, got error %s", err)
+		return
+	}
+
+	err = s.EmailSender.Send(models.NewEmailMessage(s.Conf.From, to, DataExportEmailSubj, buf.String()))
+	if err != nil {
+		log.Errorf("Can not send download link of %s, got error %s", export, err)
+	}
+}
+
+// GetDataExports returns exports of the user, ready ones have download links
+func (u UserUseCase) GetDataExports(ctx context.Context, userID uuid.UUID) ([]models.DataExport, error) {
+	exports, err := u.UserDatastore.GetDataExports(ctx, userID)
+	if err != nil {
+		return exports, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	now := time.Now()
+	for i, e := range exports {
+		if !e.IsReady(now) {
+			continue
+		}
+
+		exports[i].DownloadLink, err = getDataExportLink(u.Conf, e)
+		if err != nil {
+			return exports, NewUseCaseError(err.Error(), errors.ServerError)
+		}
+	}
+	return exports, nil
+}
+
+func (u UserUseCase) parseDataExportToken(token string) (uint, error) {
+	var claims DataExportClaims
+	t, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(u.Conf.EncryptKey), nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !t.Valid || claims.Subject != dataExportSubject || claims.ExportID == 0 {
+		return 0, fmt.Errorf("Invalid download token")
+	}
+
+	return claims.ExportID, nil
+}
+
+// DownloadDataExport returns the archive by the signed download link
+func (u UserUseCase) DownloadDataExport(ctx context.Context, token string) (models.Attachment, error) {
+	exportID, err := u.parseDataExportToken(token)
+	if err != nil {
+		return models.Attachment{}, NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	export, err := u.UserDatastore.GetDataExport(ctx, exportID)
+	if err != nil {
+		return models.Attachment{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if !export.IsReady(time.Now()) {
+		return models.Attachment{}, errors.NewNotFound(fmt.Sprintf("%s is expired", export))
+	}
+
+	return models.Attachment{
+		Filename:    fmt.Sprintf("read-it-later-export-%s.zip", export.CreatedAt.Format("2006-01-02")),
+		ContentType: "application/zip",
+		Data:        export.Archive,
+	}, nil
+}
@@ -0,0 +1,133 @@
+package usecases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func readArchive(t *testing.T, data []byte) map[string]string {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(rc)
+		assert.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestBuildDataExportArchive(t *testing.T) {
+	_, datastoreMock, _ := getSyncUseCase()
+	user := models.User{ID: uuid.New(), Name: "Test", Email: "test@example.com"}
+	subscription := models.Subscription{
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		Title:    "News",
+		UserList: models.UserList{{TwitterID: "1", Name: "Foo", ScreenName: "foo"}},
+	}
+	datastoreMock.On("GetTwitterUsers", mock.Anything, user.ID).Return(
+		[]models.TwitterUser{{UserID: user.ID, TwitterID: "111", ScreenName: "test", AccessToken: "access-token", TokenSecret: "token-secret", Primary: true}}, nil)
+	datastoreMock.On("GetUserEmailsByUserID", mock.Anything, user.ID).Return(
+		[]models.UserEmail{{UserID: user.ID, Email: "test@example.com", Status: models.EmailStatusConfirmed}}, nil)
+	datastoreMock.On("GetSubscriptions", mock.Anything, user.ID).Return([]models.Subscription{subscription}, nil)
+	datastoreMock.On("GetSubscriptionStates", mock.Anything, subscription.ID, uint(dataExportIssuesPage), uint(0)).Return(
+		[]models.SubscriptionState{{ID: 7, SubscriptionID: subscription.ID, Status: models.Sent}, {ID: 6, SubscriptionID: subscription.ID, Status: models.Sent}}, nil)
+	datastoreMock.On("GetSubscriptionTweets", mock.Anything, uint(7)).Return(
+		[]models.Tweet{{TweetID: "42", Tweet: models.TweetAttrs{IdStr: "42", FullText: "Hello"}}}, nil)
+	datastoreMock.On("GetSubscriptionTweets", mock.Anything, uint(6)).Return([]models.Tweet{}, nil)
+
+	data, err := buildDataExportArchive(context.Background(), datastoreMock, user)
+	assert.NoError(t, err)
+
+	files := readArchive(t, data)
+	assert.Len(t, files, 6)
+	assert.Contains(t, files["user.json"], "test@example.com")
+	assert.Contains(t, files["twitter_accounts.json"], `"screen_name": "test"`)
+	assert.Contains(t, files["emails.json"], models.EmailStatusConfirmed)
+	assert.Contains(t, files["subscriptions.json"], `"screen_name": "foo"`)
+	assert.Contains(t, files["issues.json"], `"id": 6`)
+	assert.Contains(t, files["tweets/7.json"], "Hello")
+	for name, content := range files {
+		assert.NotContains(t, content, "access-token", name)
+		assert.NotContains(t, content, "token-secret", name)
+	}
+}
+
+func TestExportUserDataInProgress(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	userID := uuid.New()
+	datastoreMock.On("GetDataExports", mock.Anything, userID).Return(
+		[]models.DataExport{{ID: 1, UserID: userID, Status: models.DataExportStatusPending, CreatedAt: time.Now()}}, nil)
+
+	_, err := s.ExportUserData(userID)
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	datastoreMock.AssertNotCalled(t, "InsertDataExport", mock.Anything, mock.Anything)
+}
+
+func TestExportUserDataSendsLink(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	s.Conf.TemplatePath = "../templates"
+	s.Conf.EncryptKey = "secret"
+	user := models.User{ID: uuid.New(), Email: "test@example.com"}
+	datastoreMock.On("GetTwitterUsers", mock.Anything, user.ID).Return([]models.TwitterUser{}, nil)
+	datastoreMock.On("GetUserEmailsByUserID", mock.Anything, user.ID).Return([]models.UserEmail{
+		{UserID: user.ID, Email: "other@example.com", Status: models.EmailStatusConfirmed},
+		{UserID: user.ID, Email: "test@example.com", Status: models.EmailStatusConfirmed},
+	}, nil)
+	datastoreMock.On("GetSubscriptions", mock.Anything, user.ID).Return([]models.Subscription{}, nil)
+	datastoreMock.On("UpdateDataExport", mock.Anything, mock.MatchedBy(func(e models.DataExport) bool {
+		return e.Status == models.DataExportStatusReady && len(e.Archive) > 0 && e.ExpiresAt != nil
+	})).Return(func(ctx context.Context, e models.DataExport) models.DataExport { return e }, nil)
+
+	emailSender := s.EmailSender.(*mocks.EmailSender)
+	emailSender.On("Send", mock.MatchedBy(func(m models.EmailMessage) bool {
+		return m.To == "test@example.com" && strings.Contains(m.HTML, "/exports/")
+	})).Return(nil)
+
+	s.exportUserData(user, models.DataExport{ID: 1, UserID: user.ID, Status: models.DataExportStatusPending})
+	datastoreMock.AssertNumberOfCalls(t, "UpdateDataExport", 1)
+	emailSender.AssertNumberOfCalls(t, "Send", 1)
+}
+
+func TestDownloadDataExport(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	u.Conf.EncryptKey = "secret"
+	expiresAt := time.Now().Add(time.Hour)
+	export := models.DataExport{ID: 1, UserID: uuid.New(), Status: models.DataExportStatusReady, Archive: []byte("zip"), ExpiresAt: &expiresAt}
+	datastoreMock.On("GetDataExport", mock.Anything, export.ID).Return(export, nil).Once()
+
+	link, err := getDataExportLink(u.Conf, export)
+	assert.NoError(t, err)
+	token := link[strings.LastIndex(link, "/")+1:]
+
+	archive, err := u.DownloadDataExport(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/zip", archive.ContentType)
+	assert.Equal(t, []byte("zip"), archive.Data)
+
+	expired := time.Now().Add(-time.Minute)
+	export.ExpiresAt = &expired
+	datastoreMock.On("GetDataExport", mock.Anything, export.ID).Return(export, nil).Once()
+	_, err = u.DownloadDataExport(context.Background(), token)
+	assert.Equal(t, errors.NotFound, errors.GetErrorCode(err))
+
+	_, err = u.DownloadDataExport(context.Background(), "invalid")
+	assert.Equal(t, errors.AuthRequired, errors.GetErrorCode(err))
+}
@@ -0,0 +1,193 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// emailAddLimitWindow - EmailAddLimit addresses can be added during the window
+const emailAddLimitWindow = time.Hour
+
+// getOwnEmail returns the user's address, addresses of other users are reported as not found
+func getOwnEmail(ctx context.Context, datastore models.UserDatastore, userID uuid.UUID, email string) (models.UserEmail, error) {
+	userEmail, err := datastore.GetUserEmail(ctx, models.UserEmail{Email: email})
+	if err != nil {
+		return userEmail, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if userEmail.UserID != userID {
+		return models.UserEmail{}, errors.NewNotFound(fmt.Sprintf("User %s has no email %s", userID, email))
+	}
+	return userEmail, nil
+}
+
+// GetEmails returns user's addresses with their confirmation statuses
+func (u UserUseCase) GetEmails(ctx context.Context, userID uuid.UUID) ([]models.UserEmail, error) {
+	emails, err := u.UserDatastore.GetUserEmailsByUserID(ctx, userID)
+	if err != nil {
+		return emails, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return emails, nil
+}
+
+// AddEmail adds a new address, the confirmation email is sent by the confirmation job
+func (u UserUseCase) AddEmail(ctx context.Context, userID uuid.UUID, email string) (models.UserEmail, error) {
+	email = strings.TrimSpace(email)
+
+	existing, err := u.UserDatastore.GetUserEmail(ctx, models.UserEmail{Email: email})
+	if err == nil {
+		if existing.UserID != userID {
+			log.Warningf("Email %s belongs to another user %s", email, existing)
+			return models.UserEmail{}, errors.NewForbidden("Email belongs to another user")
+		}
+		return existing, errors.NewConflict(fmt.Sprintf("Email %s is already added", email))
+	} else if errors.GetErrorCode(err) != errors.NotFound {
+		return existing, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	count, err := u.UserDatastore.CountUserEmailsSince(ctx, userID, time.Now().Add(-emailAddLimitWindow))
+	if err != nil {
+		return models.UserEmail{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if count >= uint(u.Conf.EmailAddLimit) {
+		return models.UserEmail{}, errors.NewRateLimited(fmt.Sprintf("Up to %d emails can be added per hour", u.Conf.EmailAddLimit), emailAddLimitWindow)
+	}
+
+	userEmail, err := u.UserDatastore.InsertUserEmail(ctx, models.UserEmail{UserID: userID, Email: email, Status: models.EmailStatusNew})
+	if err != nil {
+		return userEmail, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Added %s", userEmail)
+	return userEmail, nil
+}
+
+// DeleteEmail removes the address. If subscriptions use it they are returned with Conflict error,
+// unless reassignTo is another confirmed address of the user the subscriptions are moved to.
+func (u UserUseCase) DeleteEmail(ctx context.Context, userID uuid.UUID, email, reassignTo string) ([]models.Subscription, error) {
+	userEmail, err := getOwnEmail(ctx, u.UserDatastore, userID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := u.UserDatastore.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	subscriptions := make([]models.Subscription, 0)
+	for _, s := range all {
+		if s.Email == userEmail.Email {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+
+	if len(subscriptions) > 0 {
+		if reassignTo == "" {
+			msg := fmt.Sprintf("%d subscriptions use the email", len(subscriptions))
+			return subscriptions, errors.NewConflict(msg)
+		}
+
+		if reassignTo == userEmail.Email {
+			return subscriptions, NewUseCaseError("Subscriptions can not be reassigned to the deleted email", errors.BadRequest)
+		}
+
+		target, err := getOwnEmail(ctx, u.UserDatastore, userID, reassignTo)
+		if err != nil {
+			return subscriptions, err
+		}
+
+		if target.Status != models.EmailStatusConfirmed {
+			return subscriptions, NewUseCaseError(fmt.Sprintf("Email %s is not confirmed", target.Email), errors.BadRequest)
+		}
+
+		n, err := u.UserDatastore.ReassignSubscriptionsEmail(ctx, userID, userEmail.Email, target.Email)
+		if err != nil {
+			return subscriptions, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+		}
+		log.Infof("Reassigned %d subscriptions from %s to %s", n, userEmail.Email, target.Email)
+	}
+
+	deleted, err := u.UserDatastore.DeleteUserEmail(ctx, userID, userEmail.Email)
+	if err != nil {
+		return subscriptions, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if !deleted {
+		return subscriptions, errors.NewNotFound(fmt.Sprintf("User %s has no email %s", userID, email))
+	}
+
+	log.Infof("Deleted %s", userEmail)
+	return subscriptions, nil
+}
+
+// ReactivateEmail makes a bounced or abandoned address new again, so the confirmation job asks to confirm it.
+// Addresses whose recipient complained or unsubscribed aren't reactivated, emails to them need the recipient's consent.
+func (u UserUseCase) ReactivateEmail(ctx context.Context, userID uuid.UUID, email string) (models.UserEmail, error) {
+	userEmail, err := getOwnEmail(ctx, u.UserDatastore, userID, email)
+	if err != nil {
+		return userEmail, err
+	}
+
+	switch userEmail.Status {
+	case models.EmailStatusBounced, models.EmailStatusAbandoned:
+	case models.EmailStatusComplained, models.EmailStatusUnsubscribed:
+		return userEmail, errors.NewConflict(fmt.Sprintf("Email %s is %s, it can't be reactivated", userEmail.Email, strings.ToLower(userEmail.Status)))
+	default:
+		return userEmail, errors.NewConflict(fmt.Sprintf("Email %s is %s, it doesn't need reactivation", userEmail.Email, strings.ToLower(userEmail.Status)))
+	}
+
+	log.Infof("Reactivating %s", userEmail)
+	userEmail.Status = models.EmailStatusNew
+	userEmail, err = u.UserDatastore.UpdateUserEmail(ctx, userEmail)
+	if err != nil {
+		return userEmail, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return userEmail, nil
+}
+
+// ResendConfirmationEmail sends the confirmation link again, at most once per EmailResendDelay minutes
+func (s SystemUseCase) ResendConfirmationEmail(ctx context.Context, userID uuid.UUID, email string) (models.UserEmail, error) {
+	userEmail, err := getOwnEmail(ctx, s.UserDatastore, userID, email)
+	if err != nil {
+		return userEmail, err
+	}
+
+	if userEmail.Status == models.EmailStatusConfirmed {
+		return userEmail, errors.NewConflict(fmt.Sprintf("Email %s is already confirmed", userEmail.Email))
+	}
+
+	// suppressed addresses get emails again only after they are reactivated
+	if userEmail.IsSuppressed() {
+		return userEmail, errors.NewConflict(fmt.Sprintf("Email %s is %s", userEmail.Email, strings.ToLower(userEmail.Status)))
+	}
+
+	now := time.Now()
+	delay := time.Duration(s.Conf.EmailResendDelay) * time.Minute
+	if userEmail.ConfirmationSentAt != nil && now.Sub(*userEmail.ConfirmationSentAt) < delay {
+		retryAfter := userEmail.ConfirmationSentAt.Add(delay).Sub(now)
+		return userEmail, errors.NewRateLimited(fmt.Sprintf("Confirmation email was sent to %s recently", userEmail.Email), retryAfter)
+	}
+
+	tmpl, err := getConfirmationTemplate(s.Conf.TemplatePath)
+	if err != nil {
+		return userEmail, NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	err = s.sendConfirmationEmail(ctx, tmpl, userEmail, false)
+	if err != nil {
+		return userEmail, NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	userEmail.Status = models.EmailStatusSent
+	userEmail.ConfirmationSentAt = &now
+	return userEmail, nil
+}
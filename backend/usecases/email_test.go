@@ -0,0 +1,143 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAddEmail(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	u.Conf.EmailAddLimit = 2
+	userID := uuid.New()
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "new@example.com"}).Return(models.UserEmail{}, &db.DbError{Err: sql.ErrNoRows})
+	datastoreMock.On("CountUserEmailsSince", mock.Anything, userID, mock.Anything).Return(uint(1), nil).Once()
+	datastoreMock.On("InsertUserEmail", mock.Anything, models.UserEmail{UserID: userID, Email: "new@example.com", Status: models.EmailStatusNew}).Return(
+		func(ctx context.Context, e models.UserEmail) models.UserEmail { return e }, nil)
+
+	email, err := u.AddEmail(context.Background(), userID, " new@example.com ")
+	assert.NoError(t, err)
+	assert.Equal(t, models.EmailStatusNew, email.Status)
+
+	datastoreMock.On("CountUserEmailsSince", mock.Anything, userID, mock.Anything).Return(uint(2), nil).Once()
+	_, err = u.AddEmail(context.Background(), userID, "new@example.com")
+	assert.Equal(t, errors.RateLimited, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "InsertUserEmail", 1)
+}
+
+func TestAddEmailExisting(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "own@example.com"}).Return(
+		models.UserEmail{UserID: userID, Email: "own@example.com"}, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "other@example.com"}).Return(
+		models.UserEmail{UserID: uuid.New(), Email: "other@example.com"}, nil)
+
+	_, err := u.AddEmail(context.Background(), userID, "own@example.com")
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+
+	_, err = u.AddEmail(context.Background(), userID, "other@example.com")
+	assert.Equal(t, errors.Forbidden, errors.GetErrorCode(err))
+	datastoreMock.AssertNotCalled(t, "InsertUserEmail", mock.Anything, mock.Anything)
+}
+
+func TestDeleteEmailUsedBySubscriptions(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	used := models.Subscription{ID: uuid.New(), UserID: userID, Email: "old@example.com"}
+	other := models.Subscription{ID: uuid.New(), UserID: userID, Email: "new@example.com"}
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "old@example.com"}).Return(
+		models.UserEmail{UserID: userID, Email: "old@example.com", Status: models.EmailStatusConfirmed}, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "new@example.com"}).Return(
+		models.UserEmail{UserID: userID, Email: "new@example.com", Status: models.EmailStatusConfirmed}, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "pending@example.com"}).Return(
+		models.UserEmail{UserID: userID, Email: "pending@example.com", Status: models.EmailStatusSent}, nil)
+	datastoreMock.On("GetSubscriptions", mock.Anything, userID).Return([]models.Subscription{used, other}, nil)
+
+	subscriptions, err := u.DeleteEmail(context.Background(), userID, "old@example.com", "")
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	assert.Equal(t, []models.Subscription{used}, subscriptions)
+
+	_, err = u.DeleteEmail(context.Background(), userID, "old@example.com", "pending@example.com")
+	assert.Equal(t, errors.BadRequest, errors.GetErrorCode(err))
+	datastoreMock.AssertNotCalled(t, "DeleteUserEmail", mock.Anything, mock.Anything, mock.Anything)
+
+	datastoreMock.On("ReassignSubscriptionsEmail", mock.Anything, userID, "old@example.com", "new@example.com").Return(uint(1), nil)
+	datastoreMock.On("DeleteUserEmail", mock.Anything, userID, "old@example.com").Return(true, nil)
+	subscriptions, err = u.DeleteEmail(context.Background(), userID, "old@example.com", "new@example.com")
+	assert.NoError(t, err)
+	assert.Len(t, subscriptions, 1)
+	datastoreMock.AssertNumberOfCalls(t, "ReassignSubscriptionsEmail", 1)
+}
+
+func TestDeleteEmailOfAnotherUser(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "other@example.com"}).Return(
+		models.UserEmail{UserID: uuid.New(), Email: "other@example.com"}, nil)
+
+	_, err := u.DeleteEmail(context.Background(), uuid.New(), "other@example.com", "")
+	assert.Equal(t, errors.NotFound, errors.GetErrorCode(err))
+	datastoreMock.AssertNotCalled(t, "DeleteUserEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResendConfirmationEmail(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	s.Conf.TemplatePath = "../templates"
+	s.Conf.EncryptKey = "secret"
+	s.Conf.EmailResendDelay = 10
+	userID := uuid.New()
+	sentAt := time.Now().Add(-time.Hour)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "test@example.com"}).Return(
+		models.UserEmail{UserID: userID, Email: "test@example.com", Status: models.EmailStatusSent, ConfirmationSentAt: &sentAt}, nil).Once()
+	datastoreMock.On("InsertEmailConfirmation", mock.Anything, mock.MatchedBy(func(c models.EmailConfirmation) bool {
+		return c.UserID == userID && c.Email == "test@example.com" && len(c.NonceHash) == 64
+	})).Return(models.EmailConfirmation{ID: 1, UserID: userID, Email: "test@example.com"}, nil)
+	datastoreMock.On("SetEmailConfirmationSent", mock.Anything, mock.MatchedBy(func(e models.UserEmail) bool {
+		return e.Email == "test@example.com"
+	})).Return(nil)
+
+	emailSender := s.EmailSender.(*mocks.EmailSender)
+	emailSender.On("Send", mock.MatchedBy(func(m models.EmailMessage) bool {
+		return m.To == "test@example.com" && m.Subject == ConfirmationEmailSubj
+	})).Return(nil)
+
+	email, err := s.ResendConfirmationEmail(context.Background(), userID, "test@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, models.EmailStatusSent, email.Status)
+	emailSender.AssertNumberOfCalls(t, "Send", 1)
+
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "test@example.com"}).Return(email, nil).Once()
+	_, err = s.ResendConfirmationEmail(context.Background(), userID, "test@example.com")
+	assert.Equal(t, errors.RateLimited, errors.GetErrorCode(err))
+	emailSender.AssertNumberOfCalls(t, "Send", 1)
+}
+
+func TestResendConfirmationEmailConfirmed(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	userID := uuid.New()
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "test@example.com"}).Return(
+		models.UserEmail{UserID: userID, Email: "test@example.com", Status: models.EmailStatusConfirmed}, nil)
+
+	_, err := s.ResendConfirmationEmail(context.Background(), userID, "test@example.com")
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+}
+
+func TestResendConfirmationEmailSuppressed(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	userID := uuid.New()
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "test@example.com"}).Return(
+		models.UserEmail{UserID: userID, Email: "test@example.com", Status: models.EmailStatusComplained}, nil)
+
+	_, err := s.ResendConfirmationEmail(context.Background(), userID, "test@example.com")
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	s.EmailSender.(*mocks.EmailSender).AssertNotCalled(t, "Send", mock.Anything)
+}
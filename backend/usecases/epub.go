@@ -0,0 +1,126 @@
+package usecases
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmtr/mail_me_all/backend/epub"
+	"github.com/dmtr/mail_me_all/backend/models"
+)
+
+// epubChapter - tweets of one author or one thread
+type epubChapter struct {
+	Title  string
+	Tweets []models.Tweet
+}
+
+// groupTweets splits tweets into chapters: every thread found in the issue gets its own chapter,
+// the rest of the tweets are grouped by author. Tweets must be sorted by id.
+func groupTweets(tweets []models.Tweet) []epubChapter {
+	byID := make(map[string]models.Tweet, len(tweets))
+	for _, t := range tweets {
+		byID[t.TweetID] = t
+	}
+
+	root := func(t models.Tweet) string {
+		id := t.TweetID
+		for {
+			parent, ok := byID[byID[id].Tweet.InReplyToStatusIdStr]
+			if !ok {
+				return id
+			}
+			id = parent.TweetID
+		}
+	}
+
+	threads := make(map[string][]models.Tweet)
+	for _, t := range tweets {
+		r := root(t)
+		threads[r] = append(threads[r], t)
+	}
+
+	chapters := make([]epubChapter, 0)
+	authors := make(map[string]int)
+	for _, t := range tweets {
+		thread, ok := threads[t.TweetID]
+		if !ok {
+			continue
+		}
+
+		if len(thread) > 1 {
+			chapters = append(chapters, epubChapter{
+				Title:  fmt.Sprintf("Thread by %s (@%s)", t.Tweet.UserName, t.Tweet.UserScreenName),
+				Tweets: thread,
+			})
+			continue
+		}
+
+		i, ok := authors[t.Tweet.UserId]
+		if !ok {
+			chapters = append(chapters, epubChapter{Title: fmt.Sprintf("%s (@%s)", t.Tweet.UserName, t.Tweet.UserScreenName)})
+			i = len(chapters) - 1
+			authors[t.Tweet.UserId] = i
+		}
+		chapters[i].Tweets = append(chapters[i].Tweets, t)
+	}
+
+	return chapters
+}
+
+func getEpubTemplate(templatePath string) (*template.Template, error) {
+	return template.New("chapter.xhtml").Funcs(template.FuncMap{
+		"shortener": shortener,
+	}).ParseFiles(filepath.Join(templatePath, "chapter.xhtml"))
+}
+
+func getEpubFilename(subscription models.Subscription, state models.SubscriptionState) string {
+	title := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '"' {
+			return '_'
+		}
+		return r
+	}, subscription.Title)
+	return fmt.Sprintf("%s %s.epub", title, state.CreatedAt.Format("2006-01-02"))
+}
+
+func renderEpub(templatePath string, subscription models.Subscription, state models.SubscriptionState, tweets []models.Tweet, fetch imageFetcher) (models.Attachment, error) {
+	tmpl, err := getEpubTemplate(templatePath)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	title := fmt.Sprintf("%s, %s", subscription.Title, state.CreatedAt.Format("January 2, 2006"))
+	book := epub.NewBook(fmt.Sprintf("urn:read-it-later:%s:%d", subscription.ID, state.ID), title, "read-it-later.app")
+
+	images := make(map[string]string)
+	for url, img := range getImageAttachments(tweets, fetch) {
+		images[url] = book.AddImage(img.Filename, img.ContentType, img.Data)
+	}
+
+	type TemplateData struct {
+		Tweets []models.Tweet
+		Images map[string]string
+	}
+
+	for _, c := range groupTweets(tweets) {
+		var buf strings.Builder
+		err = tmpl.Execute(&buf, TemplateData{Tweets: c.Tweets, Images: images})
+		if err != nil {
+			return models.Attachment{}, err
+		}
+		book.AddChapter(c.Title, template.HTML(buf.String()))
+	}
+
+	data, err := book.Bytes()
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	return models.Attachment{
+		Filename:    getEpubFilename(subscription, state),
+		ContentType: epub.MimeType,
+		Data:        data,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTweet(id, userID, replyTo string) models.Tweet {
+	return models.Tweet{
+		TweetID: id,
+		Tweet: models.TweetAttrs{
+			IdStr:                id,
+			UserId:               userID,
+			UserName:             "user" + userID,
+			UserScreenName:       "user" + userID,
+			InReplyToStatusIdStr: replyTo,
+		},
+	}
+}
+
+func TestGroupTweets(t *testing.T) {
+	tweets := []models.Tweet{
+		newTweet("1", "10", ""),
+		newTweet("2", "20", ""),
+		newTweet("3", "10", "1"),
+		newTweet("4", "10", ""),
+		newTweet("5", "30", "100"),
+		newTweet("6", "20", "3"),
+	}
+
+	chapters := groupTweets(tweets)
+	assert.Equal(t, 4, len(chapters))
+
+	assert.Equal(t, "Thread by user10 (@user10)", chapters[0].Title)
+	assert.Equal(t, []models.Tweet{tweets[0], tweets[2], tweets[5]}, chapters[0].Tweets)
+
+	assert.Equal(t, "user20 (@user20)", chapters[1].Title)
+	assert.Equal(t, []models.Tweet{tweets[1]}, chapters[1].Tweets)
+
+	assert.Equal(t, "user10 (@user10)", chapters[2].Title)
+	assert.Equal(t, []models.Tweet{tweets[3]}, chapters[2].Tweets)
+
+	assert.Equal(t, "user30 (@user30)", chapters[3].Title)
+	assert.Equal(t, []models.Tweet{tweets[4]}, chapters[3].Tweets)
+}
+
+func TestSendEpubUnconfirmedEmail(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	subscription := models.Subscription{ID: uuid.New(), UserID: uuid.New(), EreaderEmail: "test@kindle.com"}
+	userEmail := models.UserEmail{UserID: subscription.UserID, Email: subscription.EreaderEmail, Status: models.EmailStatusSent}
+	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(userEmail, nil)
+
+	s.sendEpub(subscription, models.SubscriptionState{ID: 1}, []models.Tweet{newTweet("1", "1", "")})
+
+	datastoreMock.AssertNumberOfCalls(t, "GetUserEmail", 1)
+	s.EmailSender.(*mocks.EmailSender).AssertNotCalled(t, "Send", mock.Anything)
+}
@@ -2,19 +2,9 @@ package usecases
 
 import "github.com/dmtr/mail_me_all/backend/errors"
 
-type UseCaseError struct {
-	msg  string
-	code errors.ErrorCode
-}
+// UseCaseError - error returned by use cases, see errors.Error for the typed details
+type UseCaseError = errors.Error
 
 func NewUseCaseError(msg string, code errors.ErrorCode) *UseCaseError {
-	return &UseCaseError{msg: msg, code: code}
-}
-
-func (e *UseCaseError) Error() string {
-	return e.msg
-}
-
-func (e *UseCaseError) Code() errors.ErrorCode {
-	return e.code
+	return errors.New(msg, code)
 }
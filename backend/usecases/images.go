@@ -0,0 +1,101 @@
+package usecases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	imageFetchTimeout = time.Second * 10
+	maxImageSize      = 1 << 20
+)
+
+// imageExtensions - extensions of the types DetectContentType recognizes, mime lists several for some types
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type imageFetcher func(url string) ([]byte, string, error)
+
+// imageFilename names the image after its url, so every url gets its own file
+func imageFilename(url, contentType string) string {
+	h := sha256.Sum256([]byte(url))
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return hex.EncodeToString(h[:8]) + ext
+}
+
+func fetchImage(url string) ([]byte, string, error) {
+	client := http.Client{Timeout: imageFetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("Got status %d fetching %s", resp.StatusCode, url)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(data) > maxImageSize {
+		return nil, "", fmt.Errorf("Image %s is larger than %d bytes", url, maxImageSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("Got %s fetching %s", contentType, url)
+	}
+	return data, contentType, nil
+}
+
+// getImageAttachments fetches profile images of tweets authors, returns map image url to attachment
+func getImageAttachments(tweets []models.Tweet, fetch imageFetcher) map[string]models.Attachment {
+	images := make(map[string]models.Attachment)
+	failed := make(map[string]bool)
+
+	for _, t := range tweets {
+		url := t.Tweet.UserProfileImageUrl
+		if _, ok := images[url]; ok || failed[url] || url == "" {
+			continue
+		}
+
+		data, contentType, err := fetch(url)
+		if err != nil {
+			log.Warnf("Can not fetch image %s, got error %s", url, err)
+			failed[url] = true
+			continue
+		}
+
+		filename := imageFilename(url, contentType)
+		images[url] = models.Attachment{
+			Filename:    filename,
+			ContentType: contentType,
+			ContentID:   "avatar-" + filename,
+			Data:        data,
+		}
+	}
+
+	return images
+}
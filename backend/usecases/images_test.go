@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchImage(t *testing.T) {
+	var img bytes.Buffer
+	err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/avatar.png":
+			w.Write(img.Bytes())
+		case "/large.png":
+			w.Write(append(img.Bytes(), make([]byte, maxImageSize)...))
+		default:
+			w.Write([]byte("<html></html>"))
+		}
+	}))
+	defer server.Close()
+
+	data, contentType, err := fetchImage(server.URL + "/avatar.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, img.Bytes(), data)
+
+	_, _, err = fetchImage(server.URL + "/large.png")
+	assert.Error(t, err)
+
+	_, _, err = fetchImage(server.URL + "/page.html")
+	assert.Error(t, err)
+}
+
+func TestGetImageAttachments(t *testing.T) {
+	tweets := []models.Tweet{
+		models.Tweet{Tweet: models.TweetAttrs{UserId: "1", UserProfileImageUrl: "https://pbs.twimg.com/old.jpg"}},
+		models.Tweet{Tweet: models.TweetAttrs{UserId: "1", UserProfileImageUrl: "https://pbs.twimg.com/new?format=jpg"}},
+	}
+	fetch := func(url string) ([]byte, string, error) { return []byte{1}, "image/jpeg", nil }
+
+	images := getImageAttachments(tweets, fetch)
+	assert.Len(t, images, 2)
+
+	old := images["https://pbs.twimg.com/old.jpg"]
+	updated := images["https://pbs.twimg.com/new?format=jpg"]
+	assert.NotEqual(t, old.Filename, updated.Filename)
+	assert.NotEqual(t, old.ContentID, updated.ContentID)
+	assert.True(t, strings.HasSuffix(updated.Filename, ".jpg"))
+}
@@ -0,0 +1,295 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// importPageSize - max page size of Twitter friends/list API
+	importPageSize = 200
+	// maxImportPages - friends/list allows 15 requests per 15 minutes
+	maxImportPages = 15
+	// maxSubscriptionUsers - the issue of bigger subscription takes too long to prepare
+	maxSubscriptionUsers = 500
+)
+
+// usersPageFunc fetches a page of Twitter accounts starting from the cursor
+type usersPageFunc func(cursor int64) ([]models.TwitterUserSearchResult, int64, error)
+
+func adaptUsersPage(page *pb.UsersPage) ([]models.TwitterUserSearchResult, int64) {
+	users := make([]models.TwitterUserSearchResult, 0, len(page.Users))
+	for _, user := range page.Users {
+		users = append(users, models.TwitterUserSearchResult{
+			TwitterID:     user.TwitterId,
+			Name:          user.Name,
+			ScreenName:    user.ScreenName,
+			ProfileIMGURL: user.ProfileImageUrl,
+		})
+	}
+	return users, page.NextCursor
+}
+
+// GetTwitterLists returns Twitter Lists the user owns or subscribes to
+func (u UserUseCase) GetTwitterLists(ctx context.Context, userID uuid.UUID) ([]models.TwitterList, error) {
+	twitterUser, err := u.UserDatastore.GetTwitterUser(ctx, userID)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	req := pb.ListsRequest{
+		TwitterId:    twitterUser.TwitterID,
+		AccessToken:  twitterUser.AccessToken,
+		AccessSecret: twitterUser.TokenSecret,
+	}
+
+	res, err := u.RpcClient.GetLists(ctx, &req)
+	if err != nil {
+		log.Errorf("Can not get lists of user %s: %s", userID, err)
+		return nil, errors.FromGRPCError(err)
+	}
+
+	lists := make([]models.TwitterList, 0, len(res.Lists))
+	for _, l := range res.Lists {
+		lists = append(lists, models.TwitterList{
+			ID:              l.IdStr,
+			Name:            l.Name,
+			Description:     l.Description,
+			OwnerScreenName: l.OwnerScreenName,
+			MemberCount:     l.MemberCount,
+			Private:         l.Private,
+		})
+	}
+	return lists, nil
+}
+
+func getListMembersPage(ctx context.Context, client pb.TwProxyServiceClient, twitterUser models.TwitterUser, listID string) usersPageFunc {
+	return func(cursor int64) ([]models.TwitterUserSearchResult, int64, error) {
+		req := pb.ListMembersRequest{
+			TwitterId:    twitterUser.TwitterID,
+			AccessToken:  twitterUser.AccessToken,
+			AccessSecret: twitterUser.TokenSecret,
+			ListId:       listID,
+			Cursor:       cursor,
+			Count:        importPageSize,
+		}
+
+		res, err := client.GetListMembers(ctx, &req)
+		if err != nil {
+			log.Errorf("Can not get members of list %s: %s", listID, err)
+			return nil, 0, errors.FromGRPCError(err)
+		}
+
+		users, next := adaptUsersPage(res)
+		return users, next, nil
+	}
+}
+
+func getFriendsPage(ctx context.Context, client pb.TwProxyServiceClient, twitterUser models.TwitterUser) usersPageFunc {
+	return func(cursor int64) ([]models.TwitterUserSearchResult, int64, error) {
+		req := pb.FriendsRequest{
+			TwitterId:    twitterUser.TwitterID,
+			AccessToken:  twitterUser.AccessToken,
+			AccessSecret: twitterUser.TokenSecret,
+			Cursor:       cursor,
+			Count:        importPageSize,
+		}
+
+		res, err := client.GetFriends(ctx, &req)
+		if err != nil {
+			log.Errorf("Can not get friends of user %s: %s", twitterUser.TwitterID, err)
+			return nil, 0, errors.FromGRPCError(err)
+		}
+
+		users, next := adaptUsersPage(res)
+		return users, next, nil
+	}
+}
+
+// GetTwitterListMembers returns a page of the list members and the cursor of the next page, zero cursor means the last page
+func (u UserUseCase) GetTwitterListMembers(ctx context.Context, userID uuid.UUID, listID string, cursor int64) ([]models.TwitterUserSearchResult, int64, error) {
+	twitterUser, err := u.UserDatastore.GetTwitterUser(ctx, userID)
+	if err != nil {
+		return nil, 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return getListMembersPage(ctx, u.RpcClient, twitterUser, listID)(cursor)
+}
+
+// GetTwitterFriends returns a page of accounts the user follows and the cursor of the next page, zero cursor means the last page
+func (u UserUseCase) GetTwitterFriends(ctx context.Context, userID uuid.UUID, cursor int64) ([]models.TwitterUserSearchResult, int64, error) {
+	twitterUser, err := u.UserDatastore.GetTwitterUser(ctx, userID)
+	if err != nil {
+		return nil, 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return getFriendsPage(ctx, u.RpcClient, twitterUser)(cursor)
+}
+
+// getAllUsers fetches pages until the last one or the pages limit, returns false if the last page wasn't reached
+func getAllUsers(getPage usersPageFunc) (models.UserList, bool, error) {
+	var users models.UserList
+	var cursor int64
+	for i := 0; i < maxImportPages; i++ {
+		page, next, err := getPage(cursor)
+		if err != nil {
+			return nil, false, err
+		}
+
+		users = append(users, page...)
+		if next == 0 {
+			return users, true, nil
+		}
+		cursor = next
+	}
+
+	log.Warningf("Stopped fetching accounts after %d pages", maxImportPages)
+	return users, false, nil
+}
+
+// checkSelectedUsers returns a validation error if some of the selected accounts weren't fetched
+func checkSelectedUsers(users models.UserList, ids []string) error {
+	fetched := make(map[string]bool, len(users))
+	for _, user := range users {
+		fetched[user.TwitterID] = true
+	}
+
+	missing := 0
+	for _, id := range ids {
+		if !fetched[id] {
+			missing++
+		}
+	}
+
+	if missing > 0 {
+		err := fmt.Sprintf("%d selected accounts aren't among the first %d followings", missing, maxImportPages*importPageSize)
+		return errors.NewValidation(err, map[string]string{"user_ids": "not found"})
+	}
+	return nil
+}
+
+// filterUsers returns users selected by ids and matching the query
+func filterUsers(users models.UserList, ids []string, query string) models.UserList {
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	res := make(models.UserList, 0, len(users))
+	for _, user := range users {
+		if len(selected) > 0 && !selected[user.TwitterID] {
+			continue
+		}
+
+		if query != "" && !strings.Contains(strings.ToLower(user.Name), query) && !strings.Contains(strings.ToLower(user.ScreenName), query) {
+			continue
+		}
+		res = append(res, user)
+	}
+	return res
+}
+
+func (u UserUseCase) getImportedUsers(ctx context.Context, userID uuid.UUID, source models.ImportSource) (models.UserList, error) {
+	twitterUser, err := u.UserDatastore.GetTwitterUser(ctx, userID)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	switch source.Kind {
+	case models.ImportSourceList:
+		if source.ListID == "" {
+			return nil, errors.NewValidation("List is required", map[string]string{"list_id": "required"})
+		}
+		users, complete, err := getAllUsers(getListMembersPage(ctx, u.RpcClient, twitterUser, source.ListID))
+		if err != nil {
+			return nil, err
+		}
+
+		if !complete {
+			err := fmt.Sprintf("List has more than %d members", maxImportPages*importPageSize)
+			return nil, errors.NewValidation(err, map[string]string{"list_id": "too many members"})
+		}
+		return users, nil
+	case models.ImportSourceFollowing:
+		users, complete, err := getAllUsers(getFriendsPage(ctx, u.RpcClient, twitterUser))
+		if err != nil {
+			return nil, err
+		}
+
+		if !complete {
+			// accounts after the pages limit can't be imported, so the import fails rather than imports a part
+			if len(source.UserIDs) == 0 {
+				err := fmt.Sprintf("You follow more than %d accounts, select the accounts to import", maxImportPages*importPageSize)
+				return nil, errors.NewValidation(err, map[string]string{"user_ids": "required"})
+			}
+
+			if err := checkSelectedUsers(users, source.UserIDs); err != nil {
+				return nil, err
+			}
+		}
+		return filterUsers(users, source.UserIDs, source.Query), nil
+	}
+
+	return nil, errors.NewValidation(fmt.Sprintf("Unknown import source %s", source.Kind), map[string]string{"source": "must be list or following"})
+}
+
+// ImportTwitterUsers adds accounts of a Twitter List or the user's followings to the subscription.
+// A new subscription is created if the subscription has no id. Returns the subscription and the number of added accounts.
+func (u UserUseCase) ImportTwitterUsers(ctx context.Context, userID uuid.UUID, source models.ImportSource, subscription models.Subscription) (models.Subscription, int, error) {
+	imported, err := u.getImportedUsers(ctx, userID, source)
+	if err != nil {
+		return subscription, 0, err
+	}
+
+	if subscription.ID != uuid.Nil {
+		existing, err := u.getOwnSubscription(ctx, userID, subscription.ID)
+		if err != nil {
+			return subscription, 0, err
+		}
+		subscription = existing
+	}
+
+	if source.Kind == models.ImportSourceList && source.Sync {
+		// list syncs replace the subscription accounts with the list members, so other accounts would be dropped
+		if extra := subscription.UserList.Diff(imported); len(extra) > 0 {
+			err := fmt.Sprintf("Subscription has %d accounts which aren't on the list, only a subscription with list members can be synced", len(extra))
+			return subscription, 0, errors.NewValidation(err, map[string]string{"sync": "subscription has accounts not on the list"})
+		}
+		subscription.TwitterListID = source.ListID
+	}
+
+	added := imported.Diff(subscription.UserList)
+	if len(added) == 0 {
+		return subscription, 0, errors.NewValidation("No new accounts to import", map[string]string{"source": "has no new accounts"})
+	}
+
+	if len(subscription.UserList)+len(added) > maxSubscriptionUsers {
+		err := fmt.Sprintf("Subscription can have up to %d accounts", maxSubscriptionUsers)
+		return subscription, 0, errors.NewValidation(err, map[string]string{"source": "too many accounts"})
+	}
+
+	subscription.UserList = append(subscription.UserList, added...)
+	if subscription.ID == uuid.Nil {
+		subscription.UserID = userID
+		subscription, err = u.AddSubscription(ctx, subscription)
+	} else {
+		subscription, err = u.UserDatastore.UpdateSubscription(ctx, subscription)
+		if err != nil {
+			err = NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+		}
+	}
+	if err != nil {
+		return subscription, 0, err
+	}
+
+	log.Infof("Imported %d accounts from %s into %s", len(added), source.Kind, subscription)
+	return subscription, len(added), nil
+}
@@ -0,0 +1,209 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const shareTokenSize = 24
+
+// issueTemplateData - data for the issue template, used for emails and web pages
+type issueTemplateData struct {
+	Tweets          []models.Tweet
+	Images          map[string]models.Attachment
+	ViewLink        string
+	UnsubscribeLink string
+}
+
+// Avatar returns link to the inline image if the image was attached, otherwise image url.
+// Only the cid link is trusted, image url is a plain string so the template escapes it.
+func (d issueTemplateData) Avatar(url string) interface{} {
+	if img, ok := d.Images[url]; ok {
+		return template.URL("cid:" + img.ContentID)
+	}
+	return url
+}
+
+func getIssueTemplate(templatePath string) (*template.Template, error) {
+	return template.New("mail.html").Funcs(template.FuncMap{
+		"shortener": shortener,
+	}).ParseFiles(filepath.Join(templatePath, "mail.html"))
+}
+
+func renderIssue(tmpl *template.Template, data issueTemplateData) (string, error) {
+	var buf strings.Builder
+	err := tmpl.Execute(&buf, data)
+	return buf.String(), err
+}
+
+func getLink(domain, path string) string {
+	link := &url.URL{
+		Scheme: "https",
+		Host:   domain,
+		Path:   path,
+	}
+	return link.String()
+}
+
+// getIssueLink returns link to the issue page available to the owner
+func getIssueLink(domain string, subscriptionStateID uint) string {
+	return getLink(domain, fmt.Sprintf("issues/%d", subscriptionStateID))
+}
+
+// getSharedIssueLink returns public link to the issue
+func getSharedIssueLink(domain, shareToken string) string {
+	return getLink(domain, fmt.Sprintf("shared/%s", shareToken))
+}
+
+func getRandomToken(size int) (string, error) {
+	b := make([]byte, size)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func getShareToken() (string, error) {
+	return getRandomToken(shareTokenSize)
+}
+
+func (u UserUseCase) getIssue(ctx context.Context, state models.SubscriptionState) (models.Issue, error) {
+	subscription, err := u.UserDatastore.GetSubscription(ctx, state.SubscriptionID)
+	if err != nil {
+		return models.Issue{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	tweets, err := u.UserDatastore.GetSubscriptionTweets(ctx, state.ID)
+	if err != nil {
+		return models.Issue{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	issue := models.Issue{State: state, Subscription: subscription, Tweets: tweets}
+	if state.ShareToken != "" {
+		issue.ShareLink = getSharedIssueLink(u.Conf.Domain, state.ShareToken)
+	}
+
+	return issue, nil
+}
+
+// getOwnSubscriptionState returns subscription state if the subscription belongs to the user
+func (u UserUseCase) getOwnSubscriptionState(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (models.SubscriptionState, error) {
+	state, err := u.UserDatastore.GetSubscriptionState(ctx, subscriptionStateID)
+	if err != nil {
+		return state, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	subscription, err := u.UserDatastore.GetSubscription(ctx, state.SubscriptionID)
+	if err != nil {
+		return state, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if userID != subscription.UserID {
+		err := fmt.Errorf("User %s can not read subscription %s", userID, subscription)
+		return state, NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	return state, nil
+}
+
+func (u UserUseCase) GetIssue(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (models.Issue, error) {
+	state, err := u.getOwnSubscriptionState(ctx, userID, subscriptionStateID)
+	if err != nil {
+		return models.Issue{}, err
+	}
+
+	return u.getIssue(ctx, state)
+}
+
+func (u UserUseCase) renderIssueHTML(issue models.Issue) (string, error) {
+	tmpl, err := getIssueTemplate(u.Conf.TemplatePath)
+	if err != nil {
+		log.Errorf("Can not parse issue template, got error %s", err)
+		return "", NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	html, err := renderIssue(tmpl, issueTemplateData{Tweets: issue.Tweets})
+	if err != nil {
+		log.Errorf("Can not render %s, got error %s", issue, err)
+		return "", NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	return html, nil
+}
+
+func (u UserUseCase) GetIssueHTML(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (string, error) {
+	issue, err := u.GetIssue(ctx, userID, subscriptionStateID)
+	if err != nil {
+		return "", err
+	}
+
+	return u.renderIssueHTML(issue)
+}
+
+func (u UserUseCase) GetSharedIssueHTML(ctx context.Context, shareToken string) (string, error) {
+	state, err := u.UserDatastore.GetSubscriptionStateByShareToken(ctx, shareToken)
+	if err != nil {
+		return "", NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	issue, err := u.getIssue(ctx, state)
+	if err != nil {
+		return "", err
+	}
+
+	return u.renderIssueHTML(issue)
+}
+
+func (u UserUseCase) ShareIssue(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (string, error) {
+	state, err := u.getOwnSubscriptionState(ctx, userID, subscriptionStateID)
+	if err != nil {
+		return "", err
+	}
+
+	if state.ShareToken != "" {
+		return getSharedIssueLink(u.Conf.Domain, state.ShareToken), nil
+	}
+
+	state.ShareToken, err = getShareToken()
+	if err != nil {
+		return "", NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	_, err = u.UserDatastore.UpdateSubscriptionState(ctx, state)
+	if err != nil {
+		return "", NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return getSharedIssueLink(u.Conf.Domain, state.ShareToken), nil
+}
+
+func (u UserUseCase) UnshareIssue(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) error {
+	state, err := u.getOwnSubscriptionState(ctx, userID, subscriptionStateID)
+	if err != nil {
+		return err
+	}
+
+	if state.ShareToken == "" {
+		return nil
+	}
+
+	state.ShareToken = ""
+	_, err = u.UserDatastore.UpdateSubscriptionState(ctx, state)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package usecases
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueAvatar(t *testing.T) {
+	tmpl := template.Must(template.New("avatar").Parse(`<img src="{{.Avatar "https://pbs.twimg.com/a.jpg"}}"><img src="{{.Avatar "javascript:alert(1)"}}">`))
+	data := issueTemplateData{Images: map[string]models.Attachment{
+		"https://pbs.twimg.com/a.jpg": models.Attachment{ContentID: "avatar-1.jpg"},
+	}}
+
+	html, err := renderIssue(tmpl, data)
+	assert.NoError(t, err)
+	assert.Contains(t, html, `src="cid:avatar-1.jpg"`)
+	assert.NotContains(t, html, "javascript")
+
+	html, err = renderIssue(tmpl, issueTemplateData{})
+	assert.NoError(t, err)
+	assert.Contains(t, html, `src="https://pbs.twimg.com/a.jpg"`)
+}
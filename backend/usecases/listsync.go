@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// initSubscriptionUser saves the user's last tweet, so only tweets published later get into issues
+func (s SystemUseCase) initSubscriptionUser(ctx context.Context, user models.TwitterUser, subscriptionID uuid.UUID, u models.TwitterUserSearchResult) error {
+	req := pb.UserTimelineRequest{
+		AccessToken:  user.AccessToken,
+		AccessSecret: user.TokenSecret,
+		TwitterId:    user.TwitterID,
+		ScreenName:   u.ScreenName,
+		SinceId:      0,
+		Count:        1}
+
+	tweets, err := s.RpcClient.GetUserTimeline(ctx, &req)
+	if err != nil {
+		return errors.FromGRPCError(err)
+	}
+
+	log.Debugf("tweets: %v", tweets)
+
+	if len(tweets.Tweets) == 0 {
+		return fmt.Errorf("User %s has no tweets", u)
+	}
+
+	return s.UserDatastore.InsertSubscriptionUserState(ctx, subscriptionID, u.TwitterID, tweets.Tweets[0].IdStr)
+}
+
+func newListChange(subscription models.Subscription, u models.TwitterUserSearchResult, kind string) models.SubscriptionListChange {
+	return models.SubscriptionListChange{
+		SubscriptionID: subscription.ID,
+		TwitterListID:  subscription.TwitterListID,
+		TwitterID:      u.TwitterID,
+		ScreenName:     u.ScreenName,
+		Name:           u.Name,
+		Kind:           kind,
+	}
+}
+
+// syncSubscriptionList replaces the subscription users with the current members of its twitter list and records the changes.
+// The subscription is returned unchanged if it has no list or the list can't be fetched.
+func (s SystemUseCase) syncSubscriptionList(ctx context.Context, subscription models.Subscription, user models.TwitterUser) models.Subscription {
+	if subscription.TwitterListID == "" {
+		return subscription
+	}
+
+	members, complete, err := getAllUsers(getListMembersPage(ctx, s.RpcClient, user, subscription.TwitterListID))
+	if err != nil {
+		log.Errorf("Can not sync list %s of %s, got error %s", subscription.TwitterListID, subscription, err)
+		return subscription
+	}
+
+	// members after the pages limit would be recorded as removed
+	if !complete {
+		log.Errorf("Can not sync list %s of %s, got only %d members", subscription.TwitterListID, subscription, len(members))
+		return subscription
+	}
+
+	if len(members) > maxSubscriptionUsers {
+		log.Warningf("List %s has %d members, only %d are kept", subscription.TwitterListID, len(members), maxSubscriptionUsers)
+		members = members[:maxSubscriptionUsers]
+	}
+
+	added := members.Diff(subscription.UserList)
+	removed := subscription.UserList.Diff(members)
+	if len(added) == 0 && len(removed) == 0 {
+		return subscription
+	}
+
+	synced := subscription
+	synced.UserList = members
+	synced, err = s.UserDatastore.UpdateSubscription(ctx, synced)
+	if err != nil {
+		log.Errorf("Can not update users of %s, got error %s", subscription, err)
+		return subscription
+	}
+
+	changes := make([]models.SubscriptionListChange, 0, len(added)+len(removed))
+	for _, u := range added {
+		err = s.initSubscriptionUser(ctx, user, subscription.ID, u)
+		if err != nil {
+			log.Errorf("Can not init subscription user %s, got error %s", u, err)
+		}
+		changes = append(changes, newListChange(subscription, u, models.ListChangeAdded))
+	}
+
+	for _, u := range removed {
+		changes = append(changes, newListChange(subscription, u, models.ListChangeRemoved))
+	}
+
+	err = s.UserDatastore.InsertSubscriptionListChanges(ctx, changes)
+	if err != nil {
+		log.Errorf("Can not save list changes of %s, got error %s", subscription, err)
+	}
+
+	log.Infof("Synced list %s of %s, %d added, %d removed", subscription.TwitterListID, subscription, len(added), len(removed))
+	return synced
+}
+
+// GetSubscriptionListChanges returns accounts added to and removed from the subscription by list syncs, latest first
+func (u UserUseCase) GetSubscriptionListChanges(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID, limit, offset uint) ([]models.SubscriptionListChange, error) {
+	_, err := u.getOwnSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := u.UserDatastore.GetSubscriptionListChanges(ctx, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func getSyncUseCase() (*SystemUseCase, *mocks.UserDatastore, *mocks.TwProxyServiceClient) {
+	conf := config.GetConfig()
+	datastoreMock := new(mocks.UserDatastore)
+	clientMock := new(mocks.TwProxyServiceClient)
+	return NewSystemUseCase(datastoreMock, clientMock, &conf, new(mocks.EmailSender)), datastoreMock, clientMock
+}
+
+func TestSyncSubscriptionList(t *testing.T) {
+	s, datastoreMock, clientMock := getSyncUseCase()
+	user := models.TwitterUser{UserID: uuid.New(), TwitterID: "111", AccessToken: "token", TokenSecret: "secret"}
+	subscription := models.Subscription{
+		ID:            uuid.New(),
+		UserID:        user.UserID,
+		TwitterListID: "42",
+		UserList: models.UserList{
+			models.TwitterUserSearchResult{TwitterID: "1", ScreenName: "stays"},
+			models.TwitterUserSearchResult{TwitterID: "2", ScreenName: "leaves"},
+		},
+	}
+
+	members := pb.UsersPage{Users: []*pb.UserInfo{
+		&pb.UserInfo{TwitterId: "1", ScreenName: "stays"},
+		&pb.UserInfo{TwitterId: "3", ScreenName: "joins"},
+	}}
+	clientMock.On("GetListMembers", mock.Anything, mock.Anything).Return(&members, nil)
+	clientMock.On("GetUserTimeline", mock.Anything, mock.Anything).Return(&pb.UserTimelineResponse{Tweets: []*pb.Tweet{&pb.Tweet{IdStr: "100"}}}, nil)
+
+	synced := subscription
+	synced.UserList = models.UserList{
+		models.TwitterUserSearchResult{TwitterID: "1", ScreenName: "stays"},
+		models.TwitterUserSearchResult{TwitterID: "3", ScreenName: "joins"},
+	}
+	datastoreMock.On("UpdateSubscription", mock.Anything, synced).Return(synced, nil)
+	datastoreMock.On("InsertSubscriptionUserState", mock.Anything, subscription.ID, "3", "100").Return(nil)
+	datastoreMock.On("InsertSubscriptionListChanges", mock.Anything, []models.SubscriptionListChange{
+		models.SubscriptionListChange{SubscriptionID: subscription.ID, TwitterListID: "42", TwitterID: "3", ScreenName: "joins", Kind: models.ListChangeAdded},
+		models.SubscriptionListChange{SubscriptionID: subscription.ID, TwitterListID: "42", TwitterID: "2", ScreenName: "leaves", Kind: models.ListChangeRemoved},
+	}).Return(nil)
+
+	res := s.syncSubscriptionList(context.Background(), subscription, user)
+	assert.Equal(t, synced, res)
+	datastoreMock.AssertExpectations(t)
+}
+
+func TestSyncSubscriptionListUnchanged(t *testing.T) {
+	s, datastoreMock, clientMock := getSyncUseCase()
+	subscription := models.Subscription{ID: uuid.New(), TwitterListID: "42", UserList: models.UserList{models.TwitterUserSearchResult{TwitterID: "1"}}}
+	clientMock.On("GetListMembers", mock.Anything, mock.Anything).Return(&pb.UsersPage{Users: []*pb.UserInfo{&pb.UserInfo{TwitterId: "1"}}}, nil)
+
+	res := s.syncSubscriptionList(context.Background(), subscription, models.TwitterUser{})
+	assert.Equal(t, subscription, res)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 0)
+}
+
+func TestSyncSubscriptionListFailed(t *testing.T) {
+	s, datastoreMock, clientMock := getSyncUseCase()
+	subscription := models.Subscription{ID: uuid.New(), TwitterListID: "42", UserList: models.UserList{models.TwitterUserSearchResult{TwitterID: "1"}}}
+	clientMock.On("GetListMembers", mock.Anything, mock.Anything).Return(nil, status.Error(codes.NotFound, "twitter: 34 Sorry, that page does not exist"))
+
+	res := s.syncSubscriptionList(context.Background(), subscription, models.TwitterUser{})
+	assert.Equal(t, subscription, res)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 0)
+
+	subscription.TwitterListID = ""
+	res = s.syncSubscriptionList(context.Background(), subscription, models.TwitterUser{})
+	assert.Equal(t, subscription, res)
+	clientMock.AssertNumberOfCalls(t, "GetListMembers", 1)
+}
@@ -0,0 +1,192 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MagicLinkEmailSubj - subject of the email with the sign-in link
+	MagicLinkEmailSubj = "Sign in to Read-it-later.app"
+	// magicLinkSubject - subject of sign-in tokens, so they can't be used as other tokens
+	magicLinkSubject   = "magic_link"
+	magicLinkNonceSize = 32
+	// magicLinkLimitWindow - MagicLinkLimit links can be requested for an email during the window
+	magicLinkLimitWindow = time.Hour
+)
+
+// MagicLinkClaims - claims of the token from the sign-in link, the nonce makes the token single-use
+type MagicLinkClaims struct {
+	Nonce string `json:"nonce"`
+	jwt.StandardClaims
+}
+
+func (s SystemUseCase) getMagicLink(nonce string, expiresAt time.Time) (string, error) {
+	claims := MagicLinkClaims{
+		nonce,
+		jwt.StandardClaims{
+			Subject:   magicLinkSubject,
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	token, err := getSignedToken(s.Conf.EncryptKey, claims)
+	if err != nil {
+		return "", err
+	}
+
+	link := &url.URL{
+		Scheme:   "https",
+		Host:     s.Conf.Domain,
+		Path:     "signin/email",
+		RawQuery: fmt.Sprintf("token=%s", token),
+	}
+	return link.String(), nil
+}
+
+// SendMagicLink emails a sign-in link if the email is confirmed. Requests are rate limited by the email
+// and unknown emails are ignored, so the response doesn't tell whether the email has an account.
+func (s SystemUseCase) SendMagicLink(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+
+	now := time.Now()
+	count, err := s.UserDatastore.CountMagicLinkRequests(ctx, email, now.Add(-magicLinkLimitWindow))
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if count >= uint(s.Conf.MagicLinkLimit) {
+		return errors.NewRateLimited(fmt.Sprintf("Up to %d sign-in links can be requested per hour", s.Conf.MagicLinkLimit), magicLinkLimitWindow)
+	}
+
+	err = s.UserDatastore.InsertMagicLinkRequest(ctx, email, now.Add(-magicLinkLimitWindow))
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	userEmail, err := s.UserDatastore.GetUserEmail(ctx, models.UserEmail{Email: email})
+	if err != nil {
+		if errors.GetErrorCode(err) == errors.NotFound {
+			log.Infof("Sign-in link requested for unknown email %s", email)
+			return nil
+		}
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if userEmail.Status != models.EmailStatusConfirmed {
+		log.Infof("Sign-in link requested for not confirmed %s", userEmail)
+		return nil
+	}
+
+	nonce, err := getRandomToken(magicLinkNonceSize)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	expiresAt := now.Add(time.Duration(s.Conf.MagicLinkTTL) * time.Minute)
+	link, err := s.UserDatastore.InsertMagicLink(ctx, models.MagicLink{
+		UserID:    userEmail.UserID,
+		Email:     email,
+		NonceHash: hashToken(nonce),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	signInLink, err := s.getMagicLink(nonce, expiresAt)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	tmpl, err := template.ParseFiles(filepath.Join(s.Conf.TemplatePath, "magic_link.html"))
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	var buf strings.Builder
+	err = tmpl.Execute(&buf, struct {
+		SignInLink string
+		TTL        int
+	}{signInLink, s.Conf.MagicLinkTTL})
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	// sending errors aren't returned, otherwise the response would tell that the email has an account
+	err = s.EmailSender.Send(models.NewEmailMessage(s.Conf.From, email, MagicLinkEmailSubj, buf.String()))
+	if err != nil {
+		log.Errorf("Can not send %s, got error %s", link, err)
+		return nil
+	}
+
+	log.Infof("Sent %s", link)
+	return nil
+}
+
+func (u UserUseCase) parseMagicLinkToken(token string) (string, error) {
+	var claims MagicLinkClaims
+	t, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(u.Conf.EncryptKey), nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if !t.Valid || claims.Subject != magicLinkSubject || claims.Nonce == "" {
+		return "", fmt.Errorf("Invalid sign-in token")
+	}
+
+	return claims.Nonce, nil
+}
+
+// SignInWithMagicLink uses the sign-in link and returns its user. The link can be used once,
+// before it expires and while its email still belongs to the user.
+func (u UserUseCase) SignInWithMagicLink(ctx context.Context, token string) (models.User, error) {
+	nonce, err := u.parseMagicLinkToken(token)
+	if err != nil {
+		return models.User{}, NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	link, err := u.UserDatastore.UseMagicLink(ctx, hashToken(nonce))
+	if err != nil {
+		code := errors.GetErrorCode(err)
+		if code == errors.NotFound {
+			return models.User{}, NewUseCaseError("Sign-in link is used or expired", errors.AuthRequired)
+		}
+		return models.User{}, NewUseCaseError(err.Error(), code)
+	}
+
+	email, err := u.UserDatastore.GetUserEmail(ctx, models.UserEmail{Email: link.Email})
+	if err != nil {
+		return models.User{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if email.UserID != link.UserID || email.Status != models.EmailStatusConfirmed {
+		log.Warningf("%s doesn't match %s", link, email)
+		return models.User{}, NewUseCaseError("Email is not confirmed", errors.AuthRequired)
+	}
+
+	user, err := u.UserDatastore.GetUser(ctx, link.UserID)
+	if err != nil {
+		return user, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("%s signed in with %s", user, link)
+	return user, nil
+}
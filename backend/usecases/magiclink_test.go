@@ -0,0 +1,117 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var signInLinkRe = regexp.MustCompile(`href="([^"]+)"`)
+
+func getMagicLinkUseCases() (*SystemUseCase, *UserUseCase, *mocks.UserDatastore, *mocks.EmailSender) {
+	conf := config.GetConfig()
+	conf.TemplatePath = "../templates"
+	conf.EncryptKey = "test key"
+	datastoreMock := new(mocks.UserDatastore)
+	emailMock := new(mocks.EmailSender)
+	return NewSystemUseCase(datastoreMock, nil, &conf, emailMock), NewUserUseCase(datastoreMock, nil, &conf), datastoreMock, emailMock
+}
+
+func TestMagicLinkSignIn(t *testing.T) {
+	s, u, datastoreMock, emailMock := getMagicLinkUseCases()
+	email := models.UserEmail{UserID: uuid.New(), Email: "test@example.com", Status: models.EmailStatusConfirmed}
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: email.Email}).Return(email, nil)
+	datastoreMock.On("CountMagicLinkRequests", mock.Anything, email.Email, mock.Anything).Return(uint(0), nil)
+	datastoreMock.On("InsertMagicLinkRequest", mock.Anything, email.Email, mock.Anything).Return(nil)
+
+	var link models.MagicLink
+	datastoreMock.On("InsertMagicLink", mock.Anything, mock.Anything).Return(func(ctx context.Context, l models.MagicLink) models.MagicLink {
+		link = l
+		return l
+	}, nil)
+
+	var message models.EmailMessage
+	emailMock.On("Send", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		message = args.Get(0).(models.EmailMessage)
+	})
+
+	err := s.SendMagicLink(context.Background(), " test@example.com ")
+	assert.NoError(t, err)
+	assert.Equal(t, email.UserID, link.UserID)
+	assert.True(t, link.ExpiresAt.After(time.Now()))
+	assert.Equal(t, email.Email, message.To)
+
+	m := signInLinkRe.FindStringSubmatch(message.HTML)
+	assert.Len(t, m, 2)
+	signInLink, err := url.Parse(m[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "/signin/email", signInLink.Path)
+	token := signInLink.Query().Get("token")
+
+	user := models.User{ID: email.UserID, Name: "test"}
+	datastoreMock.On("UseMagicLink", mock.Anything, link.NonceHash).Return(link, nil).Once()
+	datastoreMock.On("GetUser", mock.Anything, email.UserID).Return(user, nil)
+
+	res, err := u.SignInWithMagicLink(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, user, res)
+
+	datastoreMock.On("UseMagicLink", mock.Anything, link.NonceHash).Return(models.MagicLink{}, &db.DbError{Err: sql.ErrNoRows})
+	_, err = u.SignInWithMagicLink(context.Background(), token)
+	assert.Equal(t, errors.AuthRequired, errors.GetErrorCode(err))
+}
+
+func TestMagicLinkNotSent(t *testing.T) {
+	s, _, datastoreMock, emailMock := getMagicLinkUseCases()
+	datastoreMock.On("CountMagicLinkRequests", mock.Anything, mock.Anything, mock.Anything).Return(uint(0), nil)
+	datastoreMock.On("InsertMagicLinkRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "unknown@example.com"}).Return(models.UserEmail{}, &db.DbError{Err: sql.ErrNoRows})
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: "new@example.com"}).Return(models.UserEmail{Email: "new@example.com", Status: models.EmailStatusNew}, nil)
+
+	err := s.SendMagicLink(context.Background(), "unknown@example.com")
+	assert.NoError(t, err)
+
+	err = s.SendMagicLink(context.Background(), "new@example.com")
+	assert.NoError(t, err)
+
+	datastoreMock.AssertNumberOfCalls(t, "InsertMagicLink", 0)
+	emailMock.AssertNumberOfCalls(t, "Send", 0)
+}
+
+func TestMagicLinkRateLimited(t *testing.T) {
+	s, _, datastoreMock, emailMock := getMagicLinkUseCases()
+	datastoreMock.On("CountMagicLinkRequests", mock.Anything, mock.Anything, mock.Anything).Return(uint(s.Conf.MagicLinkLimit), nil)
+
+	// unknown emails are limited the same way, so the limit doesn't tell whether the email has an account
+	for _, email := range []string{"test@example.com", "unknown@example.com"} {
+		err := s.SendMagicLink(context.Background(), email)
+		assert.Equal(t, errors.RateLimited, errors.GetErrorCode(err))
+		assert.Equal(t, magicLinkLimitWindow, err.(*errors.Error).RetryAfter())
+	}
+
+	datastoreMock.AssertNumberOfCalls(t, "GetUserEmail", 0)
+	datastoreMock.AssertNumberOfCalls(t, "InsertMagicLinkRequest", 0)
+	emailMock.AssertNumberOfCalls(t, "Send", 0)
+}
+
+func TestMagicLinkOtherToken(t *testing.T) {
+	s, u, datastoreMock, _ := getMagicLinkUseCases()
+	token, err := s.getUnsubscribeToken(uuid.New())
+	assert.NoError(t, err)
+
+	_, err = u.SignInWithMagicLink(context.Background(), token)
+	assert.Equal(t, errors.AuthRequired, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "UseMagicLink", 0)
+}
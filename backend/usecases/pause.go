@@ -0,0 +1,134 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+func (u UserUseCase) getOwnSubscription(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID) (models.Subscription, error) {
+	subscription, err := u.UserDatastore.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if userID != subscription.UserID {
+		err := fmt.Errorf("User %s can not edit subscription %s", userID, subscription)
+		return subscription, NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	return subscription, nil
+}
+
+// PauseSubscription stops sending issues, subscription users and read tweets are kept
+func (u UserUseCase) PauseSubscription(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID) (models.Subscription, error) {
+	subscription, err := u.getOwnSubscription(ctx, userID, subscriptionID)
+	if err != nil || subscription.Paused {
+		return subscription, err
+	}
+
+	subscription.Paused = true
+	subscription, err = u.UserDatastore.UpdateSubscription(ctx, subscription)
+	if err != nil {
+		return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Subscription %s paused", subscription)
+	return subscription, nil
+}
+
+// ResumeSubscription starts sending issues again. With catchUp the first issue contains all tweets
+// published since the last issue, otherwise tweets published while the subscription was paused are skipped.
+func (u UserUseCase) ResumeSubscription(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID, catchUp bool) (models.Subscription, error) {
+	subscription, err := u.getOwnSubscription(ctx, userID, subscriptionID)
+	if err != nil || !subscription.Paused {
+		return subscription, err
+	}
+
+	if !catchUp {
+		err = u.skipReadTweets(ctx, subscription)
+		if err != nil {
+			return subscription, err
+		}
+	}
+
+	subscription.Paused = false
+	subscription, err = u.UserDatastore.UpdateSubscription(ctx, subscription)
+	if err != nil {
+		return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Subscription %s resumed, catch up %t", subscription, catchUp)
+	return subscription, nil
+}
+
+// skipReadTweets moves subscription users' last read tweet to their latest tweet,
+// users whose timeline can't be fetched keep their last read tweet
+func (u UserUseCase) skipReadTweets(ctx context.Context, subscription models.Subscription) error {
+	user, err := getSubscriptionTwitterUser(ctx, u.UserDatastore, subscription)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	for _, su := range subscription.UserList {
+		req := pb.UserTimelineRequest{
+			AccessToken:  user.AccessToken,
+			AccessSecret: user.TokenSecret,
+			TwitterId:    user.TwitterID,
+			ScreenName:   su.ScreenName,
+			SinceId:      0,
+			Count:        1}
+
+		tweets, err := u.RpcClient.GetUserTimeline(ctx, &req)
+		if err != nil {
+			log.Errorf("Can not get timeline for user %s, got error %s", su, err)
+			continue
+		}
+
+		if len(tweets.Tweets) == 0 {
+			continue
+		}
+
+		err = u.UserDatastore.UpdateSubscriptionUserState(ctx, subscription.ID, su.TwitterID, tweets.Tweets[0].IdStr)
+		if err != nil {
+			return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+		}
+	}
+
+	return nil
+}
+
+// SetVacation sets dates when no issues are sent to the user, nil dates remove the vacation.
+// Tweets published during the vacation are sent in the first issue after it.
+func (u UserUseCase) SetVacation(ctx context.Context, userID uuid.UUID, start, end *time.Time) (models.User, error) {
+	if (start == nil) != (end == nil) {
+		return models.User{}, errors.NewValidation("Both vacation start and end are required", map[string]string{
+			"vacation_start": "required with vacation_end",
+			"vacation_end":   "required with vacation_start",
+		})
+	}
+
+	if start != nil && end.Before(*start) {
+		return models.User{}, errors.NewValidation("Vacation ends before it starts", map[string]string{
+			"vacation_end": "must not be before vacation_start",
+		})
+	}
+
+	err := u.UserDatastore.UpdateUserVacation(ctx, userID, start, end)
+	if err != nil {
+		return models.User{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	user, err := u.UserDatastore.GetUser(ctx, userID)
+	if err != nil {
+		return user, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return user, nil
+}
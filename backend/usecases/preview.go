@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/google/uuid"
+)
+
+// previewRecentTweets - number of recent tweets shown for users that have no read tweets yet
+const previewRecentTweets = 5
+
+// tweetsByID sorts tweets in the order they were published
+type tweetsByID []models.Tweet
+
+func (t tweetsByID) Len() int      { return len(t) }
+func (t tweetsByID) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t tweetsByID) Less(i, j int) bool {
+	a, _ := strconv.ParseInt(t[i].TweetID, 10, 64)
+	b, _ := strconv.ParseInt(t[j].TweetID, 10, 64)
+	return a < b
+}
+
+// PreviewSubscription renders the next issue of the subscription. Settings, if given, replace saved filters and user list.
+// Nothing is written to the database, users that aren't saved in the subscription yet have no read tweets, so their recent tweets are shown.
+func (u UserUseCase) PreviewSubscription(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID, settings *models.Subscription) (models.Issue, string, error) {
+	subscription, err := u.UserDatastore.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return models.Issue{}, "", NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if userID != subscription.UserID {
+		err := fmt.Errorf("User %s can not read subscription %s", userID, subscription)
+		return models.Issue{}, "", NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	if settings != nil {
+		subscription.IgnoreRT = settings.IgnoreRT
+		subscription.IgnoreReplies = settings.IgnoreReplies
+		if len(settings.UserList) > 0 {
+			subscription.UserList = settings.UserList
+		}
+	}
+
+	user, err := getSubscriptionTwitterUser(ctx, u.UserDatastore, subscription)
+	if err != nil {
+		return models.Issue{}, "", NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	subscriptionUserTweets, err := u.UserDatastore.GetSubscriptionUserTweets(ctx, subscription.ID)
+	if err != nil {
+		return models.Issue{}, "", NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	channels := make([]<-chan models.Tweet, 0, len(subscription.UserList))
+	for _, su := range subscription.UserList {
+		if _, ok := subscriptionUserTweets.Tweets[su.TwitterID]; !ok {
+			req := pb.UserTimelineRequest{
+				AccessToken:   user.AccessToken,
+				AccessSecret:  user.TokenSecret,
+				TwitterId:     user.TwitterID,
+				ScreenName:    su.ScreenName,
+				Count:         previewRecentTweets,
+				IgnoreRt:      subscription.IgnoreRT,
+				IgnoreReplies: subscription.IgnoreReplies,
+			}
+			channels = append(channels, getTimeline(u.RpcClient, req, su))
+			continue
+		}
+
+		ch := getTweets(u.RpcClient, subscriptionUserTweets, su, user.AccessToken, user.TokenSecret, user.TwitterID, subscription.IgnoreRT, subscription.IgnoreReplies)
+		channels = append(channels, ch)
+	}
+
+	tweets := make([]models.Tweet, 0)
+	for t := range merge(channels) {
+		tweets = append(tweets, t)
+	}
+	sort.Sort(tweetsByID(tweets))
+
+	issue := models.Issue{
+		State:        models.SubscriptionState{SubscriptionID: subscription.ID, TweetCount: uint(len(tweets))},
+		Subscription: subscription,
+		Tweets:       tweets,
+	}
+
+	html, err := u.renderIssueHTML(issue)
+	if err != nil {
+		return issue, "", err
+	}
+
+	return issue, html, nil
+}
@@ -0,0 +1,174 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// sendProgressBuffer - enough room for all stages, so sending doesn't block if nobody reads the progress
+const sendProgressBuffer = 8
+
+// progressFunc reports a stage of sending an issue
+type progressFunc func(models.SendProgress)
+
+func noProgress(models.SendProgress) {}
+
+// tillTomorrow returns time left till the daily send now limit is reset
+func tillTomorrow(now time.Time) time.Duration {
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
+
+// lockKey returns an advisory lock key of the object, keys of different kinds don't clash
+func lockKey(kind string, id uuid.UUID) uint {
+	h := fnv.New64a()
+	h.Write([]byte(kind))
+	h.Write(id[:])
+	// the key is passed as a signed bigint
+	return uint(h.Sum64() >> 1)
+}
+
+// SendSubscriptionNow prepares and sends the next issue of the subscription right away.
+// Checks are done before returning, progress is reported to the channel which is closed when the issue is sent or failed.
+func (s SystemUseCase) SendSubscriptionNow(userID, subscriptionID uuid.UUID) (<-chan models.SendProgress, error) {
+	ctx := context.Background()
+
+	subscription, err := s.UserDatastore.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if userID != subscription.UserID {
+		err := fmt.Errorf("User %s can not send subscription %s", userID, subscription)
+		return nil, NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	if subscription.Paused {
+		return nil, errors.NewConflict("Subscription is paused")
+	}
+
+	// the subscription lock is held till the issue is sent, so the subscription isn't sent twice at once
+	unlock, err := s.holdLock(ctx, lockKey("subscription", subscription.ID), "The subscription is being sent, try again later")
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := s.sendSubscriptionNow(ctx, userID, subscription, unlock)
+	if err != nil {
+		unlock()
+	}
+	return progress, err
+}
+
+// holdLock takes the lock, a conflict with the message is returned if the lock is taken already
+func (s SystemUseCase) holdLock(ctx context.Context, key uint, message string) (func(), error) {
+	unlock, locked, err := s.UserDatastore.HoldLock(ctx, key)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.DbError)
+	}
+
+	if !locked {
+		return nil, errors.NewConflict(message)
+	}
+	return unlock, nil
+}
+
+// sendSubscriptionNow checks the limits and starts sending, unlock is called when sending is done
+func (s SystemUseCase) sendSubscriptionNow(ctx context.Context, userID uuid.UUID, subscription models.Subscription, unlock func()) (<-chan models.SendProgress, error) {
+	// the user lock is held till the issue is stored, so the daily limit can't be exceeded by concurrent requests
+	unlockUser, err := s.holdLock(ctx, lockKey("user", userID), "Another issue is being sent, try again later")
+	if err != nil {
+		return nil, err
+	}
+	defer unlockUser()
+
+	// the day starts in UTC both for the count and for the retry time
+	now := time.Now().UTC()
+	count, err := s.UserDatastore.CountOnDemandSubscriptionStates(ctx, userID, now.Truncate(24*time.Hour))
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if count >= uint(s.Conf.SendNowLimit) {
+		err := fmt.Errorf("User %s has already sent %d issues today", userID, count)
+		return nil, errors.NewRateLimited(err.Error(), tillTomorrow(now))
+	}
+
+	email, err := s.UserDatastore.GetUserEmail(ctx, models.UserEmail{UserID: userID, Email: subscription.Email})
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if email.Status != models.EmailStatusConfirmed {
+		err := fmt.Errorf("Email %s is %s", email.Email, email.Status)
+		return nil, NewUseCaseError(err.Error(), errors.BadRequest)
+	}
+
+	user, err := getSubscriptionTwitterUser(ctx, s.UserDatastore, subscription)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	tmpl, err := getIssueTemplate(s.Conf.TemplatePath)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	state, err := s.UserDatastore.InsertSubscriptionState(
+		ctx, models.SubscriptionState{SubscriptionID: subscription.ID, Status: models.Preparing, OnDemand: true})
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	progress := make(chan models.SendProgress, sendProgressBuffer)
+	report := func(p models.SendProgress) {
+		p.IssueID = state.ID
+		progress <- p
+	}
+
+	go func() {
+		defer close(progress)
+		defer unlock()
+
+		state, err := s.storeTweets(subscription, user, state, report)
+		if err != nil {
+			log.Errorf("Can't get subscription user' tweets %s", err)
+			state.Status = models.Failed
+			state.FailureReason = "Can not get tweets"
+			_, err = s.UserDatastore.UpdateSubscriptionState(ctx, state)
+			if err != nil {
+				log.Errorf("Can not update subscription state got error %s", err)
+			}
+			report(models.SendProgress{Stage: models.SendStageFailed, Message: state.FailureReason})
+			return
+		}
+
+		state.Status = models.Sending
+		state, err = s.UserDatastore.UpdateSubscriptionState(ctx, state)
+		if err != nil {
+			log.Errorf("Can not update subscription state got error %s", err)
+			report(models.SendProgress{Stage: models.SendStageFailed, Message: "Can not update the issue"})
+			return
+		}
+
+		state = s.sendIssue(subscription, state, tmpl, report)
+		if state.Status != models.Sent {
+			report(models.SendProgress{Stage: models.SendStageFailed, TweetCount: state.TweetCount, Message: state.FailureReason})
+			return
+		}
+
+		err = s.UserDatastore.UpdateIssueUserStateTweets(ctx, state.ID)
+		if err != nil {
+			log.Errorf("Can not update subscription user state got error %s", err)
+		}
+		report(models.SendProgress{Stage: models.SendStageSent, TweetCount: state.TweetCount})
+	}()
+
+	return progress, nil
+}
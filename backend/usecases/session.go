@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	sessionTokenSize = 32
+	// sessionLastSeenInterval - last seen time isn't updated more often, so requests don't write to the database
+	sessionLastSeenInterval = time.Minute
+	maxUserAgentLen         = 512
+)
+
+// StartSession records a new session of the signed in user and returns its token for the cookie,
+// the session expires with the cookie, disabled users can't sign in
+func (u UserUseCase) StartSession(ctx context.Context, userID uuid.UUID, userAgent, ip string) (string, error) {
+	user, err := u.UserDatastore.GetUser(ctx, userID)
+	if err != nil {
+		return "", NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if user.DisabledAt != nil {
+		return "", errors.NewForbidden(fmt.Sprintf("%s is disabled", user))
+	}
+
+	token, err := getRandomToken(sessionTokenSize)
+	if err != nil {
+		return "", NewUseCaseError(err.Error(), errors.ServerError)
+	}
+
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = userAgent[:maxUserAgentLen]
+	}
+
+	session, err := u.UserDatastore.InsertUserSession(ctx, models.UserSession{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(time.Duration(u.Conf.MaxAge) * time.Second),
+	})
+	if err != nil {
+		return "", NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Started %s", session)
+	return token, nil
+}
+
+// AuthenticateSession finds an active session and records its usage
+func (u UserUseCase) AuthenticateSession(ctx context.Context, token string) (models.UserSession, error) {
+	if token == "" {
+		return models.UserSession{}, NewUseCaseError("No session", errors.AuthRequired)
+	}
+
+	session, err := u.UserDatastore.GetUserSessionByHash(ctx, hashToken(token))
+	if err != nil {
+		code := errors.GetErrorCode(err)
+		if code == errors.NotFound {
+			code = errors.AuthRequired
+		}
+		return session, NewUseCaseError(err.Error(), code)
+	}
+
+	now := time.Now()
+	if !session.IsActive(now) {
+		return session, NewUseCaseError(fmt.Sprintf("%s is revoked or expired", session), errors.AuthRequired)
+	}
+
+	if now.Sub(session.LastSeenAt) > sessionLastSeenInterval {
+		err = u.UserDatastore.UpdateUserSessionLastSeen(ctx, session.ID)
+		if err != nil {
+			log.Errorf("Can not update last seen time of %s, got error %s", session, err)
+		}
+	}
+
+	return session, nil
+}
+
+// GetSessions returns user's active sessions
+func (u UserUseCase) GetSessions(ctx context.Context, userID uuid.UUID) ([]models.UserSession, error) {
+	sessions, err := u.UserDatastore.GetUserSessions(ctx, userID)
+	if err != nil {
+		return sessions, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs out the session, its cookie can't be used after that
+func (u UserUseCase) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uint) error {
+	revoked, err := u.UserDatastore.RevokeUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if !revoked {
+		return NewUseCaseError(fmt.Sprintf("User %s has no session %d", userID, sessionID), errors.NotFound)
+	}
+
+	log.Infof("User %s revoked session %d", userID, sessionID)
+	return nil
+}
+
+// RevokeSessions signs out all sessions of the user
+func (u UserUseCase) RevokeSessions(ctx context.Context, userID uuid.UUID) (uint, error) {
+	n, err := u.UserDatastore.RevokeUserSessions(ctx, userID)
+	if err != nil {
+		return 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("User %s revoked %d sessions", userID, n)
+	return n, nil
+}
@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStartSessionDisabledUser(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	disabledAt := time.Now()
+	datastoreMock.On("GetUser", mock.Anything, userID).Return(models.User{ID: userID, DisabledAt: &disabledAt}, nil)
+
+	_, err := u.StartSession(context.Background(), userID, "Mozilla/5.0", "127.0.0.1")
+	assert.Equal(t, errors.Forbidden, errors.GetErrorCode(err))
+	datastoreMock.AssertNotCalled(t, "InsertUserSession", mock.Anything, mock.Anything)
+}
+
+func TestStartAndAuthenticateSession(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	datastoreMock.On("GetUser", mock.Anything, userID).Return(models.User{ID: userID}, nil)
+
+	var session models.UserSession
+	datastoreMock.On("InsertUserSession", mock.Anything, mock.Anything).Return(func(ctx context.Context, s models.UserSession) models.UserSession {
+		s.ID = 1
+		s.LastSeenAt = time.Now()
+		session = s
+		return s
+	}, nil)
+
+	token, err := u.StartSession(context.Background(), userID, "Mozilla/5.0", "127.0.0.1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, session.TokenHash)
+	assert.Equal(t, hashToken(token), session.TokenHash)
+	assert.Equal(t, "Mozilla/5.0", session.UserAgent)
+	assert.True(t, session.ExpiresAt.After(time.Now()))
+
+	datastoreMock.On("GetUserSessionByHash", mock.Anything, hashToken(token)).Return(session, nil).Once()
+	res, err := u.AuthenticateSession(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, res.UserID)
+	// last seen time was just recorded
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserSessionLastSeen", 0)
+
+	session.LastSeenAt = time.Now().Add(-time.Hour)
+	datastoreMock.On("GetUserSessionByHash", mock.Anything, hashToken(token)).Return(session, nil).Once()
+	datastoreMock.On("UpdateUserSessionLastSeen", mock.Anything, session.ID).Return(nil)
+	_, err = u.AuthenticateSession(context.Background(), token)
+	assert.NoError(t, err)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserSessionLastSeen", 1)
+}
+
+func TestAuthenticateRevokedSession(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	revokedAt := time.Now()
+	session := models.UserSession{ID: 1, UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}
+	datastoreMock.On("GetUserSessionByHash", mock.Anything, hashToken("token")).Return(session, nil)
+
+	_, err := u.AuthenticateSession(context.Background(), "token")
+	assert.Equal(t, errors.AuthRequired, errors.GetErrorCode(err))
+
+	_, err = u.AuthenticateSession(context.Background(), "")
+	assert.Equal(t, errors.AuthRequired, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "GetUserSessionByHash", 1)
+}
@@ -0,0 +1,270 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxImportedSubscriptions - every handle of the file is looked up on Twitter, so files are limited
+const maxImportedSubscriptions = 50
+
+// handleResolver looks up Twitter accounts by handles, every handle is looked up once per import
+type handleResolver struct {
+	ctx         context.Context
+	client      pb.TwProxyServiceClient
+	twitterUser models.TwitterUser
+	accounts    map[string]*models.TwitterUserSearchResult
+}
+
+func newHandleResolver(ctx context.Context, client pb.TwProxyServiceClient, twitterUser models.TwitterUser) *handleResolver {
+	return &handleResolver{
+		ctx:         ctx,
+		client:      client,
+		twitterUser: twitterUser,
+		accounts:    make(map[string]*models.TwitterUserSearchResult),
+	}
+}
+
+// resolve returns nil if Twitter doesn't know the handle
+func (r *handleResolver) resolve(screenName string) (*models.TwitterUserSearchResult, error) {
+	key := strings.ToLower(screenName)
+	if account, ok := r.accounts[key]; ok {
+		return account, nil
+	}
+
+	req := pb.UserInfoRequest{
+		TwitterId:    r.twitterUser.TwitterID,
+		AccessToken:  r.twitterUser.AccessToken,
+		AccessSecret: r.twitterUser.TokenSecret,
+		ScreenName:   screenName,
+	}
+
+	res, err := r.client.GetUserInfo(r.ctx, &req)
+	if err != nil {
+		e := errors.FromGRPCError(err)
+		if e.Code() != errors.NotFound {
+			log.Errorf("Can not resolve handle %s: %s", screenName, err)
+			return nil, e
+		}
+		r.accounts[key] = nil
+		return nil, nil
+	}
+
+	account := &models.TwitterUserSearchResult{
+		TwitterID:     res.TwitterId,
+		Name:          res.Name,
+		ScreenName:    res.ScreenName,
+		ProfileIMGURL: res.ProfileImageUrl,
+	}
+	r.accounts[key] = account
+	return account, nil
+}
+
+// resolveUsers fills in ids of accounts known by handles only, duplicated accounts are dropped
+func (r *handleResolver) resolveUsers(result *models.SubscriptionImportResult) error {
+	seen := make(map[string]bool)
+	users := make(models.UserList, 0, len(result.Subscription.UserList))
+	for _, user := range result.Subscription.UserList {
+		if user.TwitterID == "" {
+			account, err := r.resolve(user.ScreenName)
+			if err != nil {
+				return err
+			}
+
+			if account == nil {
+				result.Unresolved = append(result.Unresolved, user.ScreenName)
+				continue
+			}
+			result.Resolved++
+			user = *account
+		}
+
+		if !seen[user.TwitterID] {
+			seen[user.TwitterID] = true
+			users = append(users, user)
+		}
+	}
+
+	result.Subscription.UserList = users
+	return nil
+}
+
+func isEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+func isSubscriptionDay(day string) bool {
+	for _, d := range models.SubscriptionDays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// validateImportedSubscription checks settings of the subscription read from the file
+func validateImportedSubscription(s models.Subscription) map[string]string {
+	fields := make(map[string]string)
+	if strings.TrimSpace(s.Title) == "" {
+		fields["title"] = "required"
+	}
+
+	if !isEmail(s.Email) {
+		fields["email"] = "must be email"
+	}
+
+	if s.EreaderEmail != "" && !isEmail(s.EreaderEmail) {
+		fields["ereader_email"] = "must be email"
+	}
+
+	if !isSubscriptionDay(s.Day) {
+		fields["day"] = "must be one of " + strings.Join(models.SubscriptionDays, ", ")
+	}
+
+	if len(s.UserList) == 0 {
+		fields["accounts"] = "required"
+	} else if len(s.UserList) > maxSubscriptionUsers {
+		fields["accounts"] = fmt.Sprintf("up to %d accounts", maxSubscriptionUsers)
+	}
+
+	for _, user := range s.UserList {
+		if user.TwitterID == "" && user.ScreenName == "" {
+			fields["accounts"] = "every account requires screen_name or twitter_id"
+			break
+		}
+	}
+	return fields
+}
+
+// checkImportedEmail returns true if the email has to be added to the user's emails
+func (u UserUseCase) checkImportedEmail(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	userEmail, err := u.UserDatastore.GetUserEmail(ctx, models.UserEmail{UserID: userID, Email: email})
+	if err != nil {
+		if errors.GetErrorCode(err) == errors.NotFound {
+			return true, nil
+		}
+		return false, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if userEmail.UserID != userID {
+		log.Warningf("Imported email %s belongs to another user %s", email, userEmail)
+		return false, errors.NewForbidden("Email belongs to another user")
+	}
+	return false, nil
+}
+
+// getImportErrors returns errors of the report keyed by the subscription index and the field
+func getImportErrors(report models.SubscriptionsImportReport) map[string]string {
+	fields := make(map[string]string)
+	for i, res := range report.Results {
+		for field, msg := range res.Errors {
+			fields[fmt.Sprintf("subscriptions[%d].%s", i, field)] = msg
+		}
+
+		if len(res.Unresolved) > 0 {
+			fields[fmt.Sprintf("subscriptions[%d].accounts", i)] = "unknown handles " + strings.Join(res.Unresolved, ", ")
+		}
+	}
+	return fields
+}
+
+// ImportSubscriptions validates subscriptions read from the file, resolves handles and creates the subscriptions.
+// Either all subscriptions are created or none, the caller provides the transaction. Nothing is created on the dry run.
+func (u UserUseCase) ImportSubscriptions(ctx context.Context, userID uuid.UUID, subscriptions []models.Subscription, dryRun bool) (models.SubscriptionsImportReport, error) {
+	report := models.SubscriptionsImportReport{DryRun: dryRun}
+	if len(subscriptions) == 0 {
+		return report, errors.NewValidation("File has no subscriptions", map[string]string{"file": "has no subscriptions"})
+	}
+
+	if len(subscriptions) > maxImportedSubscriptions {
+		err := fmt.Sprintf("File can have up to %d subscriptions", maxImportedSubscriptions)
+		return report, errors.NewValidation(err, map[string]string{"file": "too many subscriptions"})
+	}
+
+	twitterUser, err := u.UserDatastore.GetTwitterUser(ctx, userID)
+	if err != nil {
+		return report, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	resolver := newHandleResolver(ctx, u.RpcClient, twitterUser)
+	newEmails := make(map[string]bool)
+	for _, s := range subscriptions {
+		s.ID = uuid.Nil
+		s.UserID = userID
+		s.Day = strings.ToLower(s.Day)
+		s.EmailStatus = ""
+
+		res := models.SubscriptionImportResult{Subscription: s, Errors: validateImportedSubscription(s)}
+		if _, ok := res.Errors["email"]; !ok {
+			if _, checked := newEmails[s.Email]; !checked {
+				add, err := u.checkImportedEmail(ctx, userID, s.Email)
+				if errors.GetErrorCode(err) == errors.Forbidden {
+					res.Errors["email"] = "belongs to another user"
+				} else if err != nil {
+					return report, err
+				} else {
+					newEmails[s.Email] = add
+				}
+			}
+		}
+
+		if _, ok := res.Errors["ereader_email"]; !ok && s.EreaderEmail != "" {
+			if _, checked := newEmails[s.EreaderEmail]; !checked {
+				add, err := u.checkImportedEmail(ctx, userID, s.EreaderEmail)
+				if errors.GetErrorCode(err) == errors.Forbidden {
+					res.Errors["ereader_email"] = "belongs to another user"
+				} else if err != nil {
+					return report, err
+				} else {
+					newEmails[s.EreaderEmail] = add
+				}
+			}
+		}
+
+		if len(res.Errors) == 0 {
+			if err := resolver.resolveUsers(&res); err != nil {
+				return report, err
+			}
+		}
+		report.Results = append(report.Results, res)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if !report.Valid() {
+		return report, errors.NewValidation("File has invalid subscriptions", getImportErrors(report))
+	}
+
+	for email, add := range newEmails {
+		if !add {
+			continue
+		}
+
+		_, err := u.UserDatastore.InsertUserEmail(ctx, models.UserEmail{UserID: userID, Email: email, Status: models.EmailStatusNew})
+		if err != nil {
+			return report, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+		}
+	}
+
+	for i, res := range report.Results {
+		s, err := u.UserDatastore.InsertSubscription(ctx, res.Subscription)
+		if err != nil {
+			return report, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+		}
+		report.Results[i].Subscription = s
+	}
+
+	log.Infof("User %s imported %d subscriptions", userID, len(report.Results))
+	return report, nil
+}
@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	pb "github.com/dmtr/mail_me_all/backend/rpc"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func getImportedSubscriptions() []models.Subscription {
+	return []models.Subscription{
+		models.Subscription{
+			Title: "golang",
+			Email: "test@example.com",
+			Day:   "Monday",
+			UserList: models.UserList{
+				models.TwitterUserSearchResult{TwitterID: "1", ScreenName: "known"},
+				models.TwitterUserSearchResult{ScreenName: "gopher"},
+			},
+		},
+		models.Subscription{
+			Title:    "news",
+			Email:    "test@example.com",
+			Day:      "sunday",
+			UserList: models.UserList{models.TwitterUserSearchResult{ScreenName: "Gopher"}},
+		},
+	}
+}
+
+func mockImportDatastore(datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient, userID uuid.UUID) {
+	datastoreMock.On("GetTwitterUser", mock.Anything, userID).Return(models.TwitterUser{UserID: userID, TwitterID: "111"}, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(models.UserEmail{}, &db.DbError{Err: sql.ErrNoRows})
+	clientMock.On("GetUserInfo", mock.Anything, mock.MatchedBy(func(req *pb.UserInfoRequest) bool {
+		return strings.EqualFold(req.ScreenName, "gopher")
+	})).Return(&pb.UserInfo{TwitterId: "2", ScreenName: "gopher", Name: "Gopher"}, nil)
+	clientMock.On("GetUserInfo", mock.Anything, mock.Anything).Return(nil, status.Error(codes.NotFound, "twitter: 50 User not found"))
+}
+
+func testImportSubscriptionsDryRun(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	userID := uuid.New()
+	mockImportDatastore(datastoreMock, clientMock, userID)
+
+	report, err := usecases.ImportSubscriptions(context.Background(), userID, getImportedSubscriptions(), true)
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.True(t, report.Valid())
+	assert.Len(t, report.Results, 2)
+	assert.Equal(t, "monday", report.Results[0].Subscription.Day)
+	assert.Equal(t, 1, report.Results[0].Resolved)
+	assert.Equal(t, "2", report.Results[0].Subscription.UserList[1].TwitterID)
+	assert.Equal(t, "2", report.Results[1].Subscription.UserList[0].TwitterID)
+
+	clientMock.AssertNumberOfCalls(t, "GetUserInfo", 1)
+	datastoreMock.AssertNumberOfCalls(t, "GetUserEmail", 1)
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscription", 0)
+}
+
+func testImportSubscriptionsOk(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	userID := uuid.New()
+	mockImportDatastore(datastoreMock, clientMock, userID)
+	datastoreMock.On("InsertUserEmail", mock.Anything, models.UserEmail{UserID: userID, Email: "test@example.com", Status: models.EmailStatusNew}).Return(models.UserEmail{}, nil)
+	datastoreMock.On("InsertSubscription", mock.Anything, mock.Anything).Return(func(ctx context.Context, s models.Subscription) models.Subscription {
+		s.ID = uuid.New()
+		return s
+	}, nil)
+
+	report, err := usecases.ImportSubscriptions(context.Background(), userID, getImportedSubscriptions(), false)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, report.Results[0].Subscription.ID)
+	assert.Equal(t, userID, report.Results[1].Subscription.UserID)
+	datastoreMock.AssertNumberOfCalls(t, "InsertUserEmail", 1)
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscription", 2)
+}
+
+func testImportSubscriptionsInvalid(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	userID := uuid.New()
+	mockImportDatastore(datastoreMock, clientMock, userID)
+
+	subscriptions := getImportedSubscriptions()
+	subscriptions[0].Day = "someday"
+	subscriptions[1].UserList = append(subscriptions[1].UserList, models.TwitterUserSearchResult{ScreenName: "nobody"})
+
+	report, err := usecases.ImportSubscriptions(context.Background(), userID, subscriptions, false)
+	assert.Error(t, err)
+	assert.Equal(t, errors.Validation, errors.GetErrorCode(err))
+	fields := err.(*errors.Error).Fields()
+	assert.Contains(t, fields, "subscriptions[0].day")
+	assert.Contains(t, fields, "subscriptions[1].accounts")
+	assert.Equal(t, []string{"nobody"}, report.Results[1].Unresolved)
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscription", 0)
+}
+
+func testImportSubscriptionsRateLimited(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	userID := uuid.New()
+	datastoreMock.On("GetTwitterUser", mock.Anything, userID).Return(models.TwitterUser{UserID: userID, TwitterID: "111"}, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(models.UserEmail{UserID: userID}, nil)
+	clientMock.On("GetUserInfo", mock.Anything, mock.Anything).Return(nil, status.Error(codes.ResourceExhausted, "twitter: 88 Rate limit exceeded"))
+
+	_, err := usecases.ImportSubscriptions(context.Background(), userID, getImportedSubscriptions(), true)
+	assert.Equal(t, errors.RateLimited, errors.GetErrorCode(err))
+}
+
+func TestImportSubscriptions(t *testing.T) {
+	tests := map[string]testFunc{
+		"TestImportSubscriptionsDryRun":      testImportSubscriptionsDryRun,
+		"TestImportSubscriptionsOk":          testImportSubscriptionsOk,
+		"TestImportSubscriptionsInvalid":     testImportSubscriptionsInvalid,
+		"TestImportSubscriptionsRateLimited": testImportSubscriptionsRateLimited,
+	}
+	runTests(tests, t)
+}
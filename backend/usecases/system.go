@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"html/template"
 	"net/url"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -22,6 +21,8 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const epubEmailBody = "<p>The new issue is attached.</p>"
+
 const (
 	initKey    = 1
 	prepareKey = 2
@@ -43,9 +44,17 @@ func getShortenerRegexp() *regexp.Regexp {
 	return shortenerRegex
 }
 
-type JWTClaims struct {
-	Email  string `json:"email"`
-	UserID string `json:"user_id"`
+func shortener(s string) template.HTML {
+	r := getShortenerRegexp()
+	return template.HTML(r.ReplaceAllStringFunc(s, func(t string) string { return fmt.Sprintf("<a href=\"%s\">%s</a>", t, t) }))
+}
+
+// unsubscribeSubject - subject of unsubscribe tokens, so they can't be used as other tokens
+const unsubscribeSubject = "unsubscribe"
+
+// UnsubscribeClaims - claims of the token from unsubscribe link
+type UnsubscribeClaims struct {
+	SubscriptionID string `json:"subscription_id"`
 	jwt.StandardClaims
 }
 
@@ -109,7 +118,7 @@ func (s SystemUseCase) initSubscription(subscriptionID uuid.UUID, users []string
 	}
 	log.Infof("Got subscription %s", subscription)
 
-	user, err := s.UserDatastore.GetTwitterUser(context.Background(), subscription.UserID)
+	user, err := getSubscriptionTwitterUser(context.Background(), s.UserDatastore, subscription)
 	if err != nil {
 		log.Errorf("Can not get user %s, got error %s", subscription.UserID, err)
 		return
@@ -124,24 +133,9 @@ func (s SystemUseCase) initSubscription(subscriptionID uuid.UUID, users []string
 			continue
 		}
 
-		req := pb.UserTimelineRequest{
-			AccessToken:  user.AccessToken,
-			AccessSecret: user.TokenSecret,
-			TwitterId:    user.TwitterID,
-			ScreenName:   u.ScreenName,
-			SinceId:      0,
-			Count:        1}
-
-		tweets, err := s.RpcClient.GetUserTimeline(context.Background(), &req)
-		if err != nil {
-			log.Errorf("Can not get timeline for user %s", u)
-		}
-
-		log.Debugf("tweets: %v", tweets)
-
-		err = s.UserDatastore.InsertSubscriptionUserState(context.Background(), subscriptionID, u.TwitterID, tweets.Tweets[0].IdStr)
+		err = s.initSubscriptionUser(context.Background(), user, subscriptionID, u)
 		if err != nil {
-			log.Errorf("Can not insert subscription_user_state, got error %s", err)
+			log.Errorf("Can not init subscription user %s, got error %s", u, err)
 		}
 	}
 }
@@ -226,7 +220,7 @@ func (s SystemUseCase) PrepareSubscriptions(ids ...uuid.UUID) error {
 		}
 		log.Infof("Got subscription %s", subscription)
 
-		user, err := s.UserDatastore.GetTwitterUser(context.Background(), subscription.UserID)
+		user, err := getSubscriptionTwitterUser(context.Background(), s.UserDatastore, subscription)
 		if err != nil {
 			log.Errorf("Can not get user %s, got error %s", subscription.UserID, err)
 			continue
@@ -246,15 +240,33 @@ func (s SystemUseCase) PrepareSubscriptions(ids ...uuid.UUID) error {
 func (s SystemUseCase) prepareSubscription(subscription models.Subscription, user models.TwitterUser, subscriptionState models.SubscriptionState, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	subscriptionUserTweets, err := s.UserDatastore.GetSubscriptionUserTweets(context.Background(), subscription.ID)
+	subscriptionState, err := s.storeTweets(subscription, user, subscriptionState, noProgress)
 	if err != nil {
 		log.Errorf("Can't get subscription user' tweets %s", err)
 		return
 	}
 
+	subscriptionState.Status = models.Ready
+	_, err = s.UserDatastore.UpdateSubscriptionState(context.Background(), subscriptionState)
+	if err != nil {
+		log.Errorf("Can't update subscription state %s  %s", subscriptionState.String(), err)
+	}
+}
+
+// storeTweets fetches new tweets of the subscription users and stores them in the issue
+func (s SystemUseCase) storeTweets(subscription models.Subscription, user models.TwitterUser, subscriptionState models.SubscriptionState, progress progressFunc) (models.SubscriptionState, error) {
+	subscription = s.syncSubscriptionList(context.Background(), subscription, user)
+
+	subscriptionUserTweets, err := s.UserDatastore.GetSubscriptionUserTweets(context.Background(), subscription.ID)
+	if err != nil {
+		return subscriptionState, err
+	}
+
+	progress(models.SendProgress{Stage: models.SendStageFetching})
+
 	channels := make([]<-chan models.Tweet, 0)
 	for _, u := range subscription.UserList {
-		ch := s.getTweets(subscriptionUserTweets, u, user.AccessToken, user.TokenSecret, user.TwitterID, subscription.IgnoreRT, subscription.IgnoreReplies)
+		ch := getTweets(s.RpcClient, subscriptionUserTweets, u, user.AccessToken, user.TokenSecret, user.TwitterID, subscription.IgnoreRT, subscription.IgnoreReplies)
 		channels = append(channels, ch)
 	}
 
@@ -263,17 +275,17 @@ func (s SystemUseCase) prepareSubscription(subscription models.Subscription, use
 		_, err := s.UserDatastore.InsertTweet(context.Background(), t, subscriptionState.ID)
 		if err != nil {
 			log.Errorf("Can't insert tweet %s", err)
+			continue
 		}
+		subscriptionState.TweetCount++
 	}
 
-	subscriptionState.Status = models.Ready
-	_, err = s.UserDatastore.UpdateSubscriptionState(context.Background(), subscriptionState)
-	if err != nil {
-		log.Errorf("Can't update subscription state %s  %s", subscriptionState.String(), err)
-	}
+	progress(models.SendProgress{Stage: models.SendStageStored, TweetCount: subscriptionState.TweetCount})
+	return subscriptionState, nil
 }
 
-func (s SystemUseCase) getTweets(subscriptionUserTweets models.SubscriptionUserTweets, user models.TwitterUserSearchResult, accessToken, tokenSecret, twitterID string, ignoreRT, ignoreReplies bool) <-chan models.Tweet {
+// getTweets fetches user's tweets published after the last tweet read by the subscription
+func getTweets(client pb.TwProxyServiceClient, subscriptionUserTweets models.SubscriptionUserTweets, user models.TwitterUserSearchResult, accessToken, tokenSecret, twitterID string, ignoreRT, ignoreReplies bool) <-chan models.Tweet {
 	ch := make(chan models.Tweet)
 
 	lastTweet, ok := subscriptionUserTweets.Tweets[user.TwitterID]
@@ -290,20 +302,28 @@ func (s SystemUseCase) getTweets(subscriptionUserTweets models.SubscriptionUserT
 		return ch
 	}
 
-	go func() {
-		req := pb.UserTimelineRequest{
-			AccessToken:   accessToken,
-			AccessSecret:  tokenSecret,
-			TwitterId:     twitterID,
-			ScreenName:    user.ScreenName,
-			SinceId:       sinceID,
-			IgnoreRt:      ignoreRT,
-			IgnoreReplies: ignoreReplies,
-		}
+	req := pb.UserTimelineRequest{
+		AccessToken:   accessToken,
+		AccessSecret:  tokenSecret,
+		TwitterId:     twitterID,
+		ScreenName:    user.ScreenName,
+		SinceId:       sinceID,
+		IgnoreRt:      ignoreRT,
+		IgnoreReplies: ignoreReplies,
+	}
+	return getTimeline(client, req, user)
+}
+
+// getTimeline fetches user's tweets matching the request
+func getTimeline(client pb.TwProxyServiceClient, req pb.UserTimelineRequest, user models.TwitterUserSearchResult) <-chan models.Tweet {
+	ch := make(chan models.Tweet)
 
-		tweets, err := s.RpcClient.GetUserTimeline(context.Background(), &req)
+	go func() {
+		tweets, err := client.GetUserTimeline(context.Background(), &req)
 		if err != nil {
 			log.Errorf("Can not get timeline for user %s", user)
+			close(ch)
+			return
 		}
 
 		for _, t := range tweets.Tweets {
@@ -352,17 +372,7 @@ func (s SystemUseCase) SendSubscriptions(ids ...uuid.UUID) error {
 
 	log.Infof("Got subscriptions %v", states)
 
-	r := getShortenerRegexp()
-	shortener := func(s string) template.HTML {
-		return template.HTML(r.ReplaceAllStringFunc(s, func(t string) string { return fmt.Sprintf("<a href=\"%s\">%s</a>", t, t) }))
-	}
-
-	funcMap := template.FuncMap{
-		"shortener": shortener,
-	}
-
-	templatePath := s.Conf.TemplatePath
-	tmpl := template.Must(template.New("mail.html").Funcs(funcMap).ParseFiles(filepath.Join(templatePath, "mail.html")))
+	tmpl := template.Must(getIssueTemplate(s.Conf.TemplatePath))
 
 	var wg sync.WaitGroup
 
@@ -381,6 +391,17 @@ func (s SystemUseCase) SendSubscriptions(ids ...uuid.UUID) error {
 		}
 		log.Infof("Got subscription %s", subscription)
 
+		if subscription.Paused {
+			log.Warningf("Subscription %s is paused", subscription)
+			state.Status = models.Failed
+			state.FailureReason = "Subscription is paused"
+			_, err = s.UserDatastore.UpdateSubscriptionState(context.Background(), state)
+			if err != nil {
+				log.Errorf("Can not update subscription state got error %s", err)
+			}
+			continue
+		}
+
 		userEmail := models.UserEmail{
 			UserID: subscription.UserID,
 			Email:  subscription.Email,
@@ -392,12 +413,23 @@ func (s SystemUseCase) SendSubscriptions(ids ...uuid.UUID) error {
 			continue
 		}
 
+		if email.IsSuppressed() {
+			log.Warningf("Email %s is suppressed, status %s", email, email.Status)
+			state.Status = models.Failed
+			state.FailureReason = fmt.Sprintf("Email address %s is %s", email.Email, strings.ToLower(email.Status))
+			_, err = s.UserDatastore.UpdateSubscriptionState(context.Background(), state)
+			if err != nil {
+				log.Errorf("Can not update subscription state got error %s", err)
+			}
+			continue
+		}
+
 		if email.Status != models.EmailStatusConfirmed {
 			log.Errorf("Email is not confirmed %s", email)
 			continue
 		}
 
-		user, err := s.UserDatastore.GetTwitterUser(context.Background(), subscription.UserID)
+		user, err := getSubscriptionTwitterUser(context.Background(), s.UserDatastore, subscription)
 		if err != nil {
 			log.Errorf("Can not get user %s, got error %s", subscription.UserID, err)
 			continue
@@ -418,18 +450,23 @@ func (s SystemUseCase) SendSubscriptions(ids ...uuid.UUID) error {
 
 func (s SystemUseCase) sendSubscription(subscription models.Subscription, subscriptionState models.SubscriptionState, tmpl *template.Template, wg *sync.WaitGroup) {
 	defer wg.Done()
+	s.sendIssue(subscription, subscriptionState, tmpl, noProgress)
+}
 
+// sendIssue renders the issue and sends it, returns the issue state after sending
+func (s SystemUseCase) sendIssue(subscription models.Subscription, subscriptionState models.SubscriptionState, tmpl *template.Template, progress progressFunc) models.SubscriptionState {
 	log.Infof("SubscriptionState %+v", subscriptionState)
 
 	tweets, err := s.UserDatastore.GetSubscriptionTweets(context.Background(), subscriptionState.ID)
 	if err != nil {
 		log.Errorf("Can not get tweets for subscription %s, got error %s", subscription, err)
 		subscriptionState.Status = models.Failed
+		subscriptionState.FailureReason = "Can not get tweets"
 		_, err = s.UserDatastore.UpdateSubscriptionState(context.Background(), subscriptionState)
 		if err != nil {
 			log.Errorf("Can not update subscription state got error %s", err)
 		}
-		return
+		return subscriptionState
 	}
 
 	if len(tweets) == 0 {
@@ -439,53 +476,103 @@ func (s SystemUseCase) sendSubscription(subscription models.Subscription, subscr
 		if err != nil {
 			log.Errorf("Can not update subscription state got error %s", err)
 		}
-		return
+		return subscriptionState
 	}
 
-	type TemplateData struct {
-		Tweets []models.Tweet
+	data := issueTemplateData{Tweets: tweets}
+	if s.Conf.InlineImages {
+		data.Images = getImageAttachments(tweets, fetchImage)
 	}
 
-	var buf strings.Builder
-	err = tmpl.Execute(&buf, TemplateData{Tweets: tweets})
+	data.ViewLink = getIssueLink(s.Conf.Domain, subscriptionState.ID)
+	data.UnsubscribeLink, err = s.getUnsubscribeLink(subscription.ID)
+	if err != nil {
+		log.Errorf("Can not get unsubscribe link for subscription %s, got error %s", subscription, err)
+	}
+
+	progress(models.SendProgress{Stage: models.SendStageRendering, TweetCount: uint(len(tweets))})
+	html, err := renderIssue(tmpl, data)
 	if err != nil {
 		log.Errorf("err %s", err)
 		subscriptionState.Status = models.Failed
+		subscriptionState.FailureReason = "Can not render the issue"
 		_, err = s.UserDatastore.UpdateSubscriptionState(context.Background(), subscriptionState)
 		if err != nil {
 			log.Errorf("Can not update subscription state got error %s", err)
 		}
-		return
+		return subscriptionState
 	}
 
-	log.Debugf("html %s", buf.String())
+	log.Debugf("html %s", html)
+
+	message := models.NewEmailMessage(s.Conf.From, subscription.Email, subscription.GetSubject(), html)
+	for _, img := range data.Images {
+		message.Inline = append(message.Inline, img)
+	}
+
+	if data.UnsubscribeLink != "" {
+		message.Headers["List-Unsubscribe"] = fmt.Sprintf("<%s>", data.UnsubscribeLink)
+		message.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	}
 
-	err = s.EmailSender.Send(s.Conf.From, subscription.Email, subscription.GetSubject(), buf.String())
+	err = s.EmailSender.Send(message)
 
 	if err != nil {
+		// the reason is shown to the user, provider errors can have details of our account
+		log.Errorf("Can not send issue %d of subscription %s, got error %s", subscriptionState.ID, subscription, err)
 		subscriptionState.Status = models.Failed
+		subscriptionState.FailureReason = "Can not send email"
 	} else {
+		now := time.Now()
 		subscriptionState.Status = models.Sent
+		subscriptionState.SentAt = &now
+		if subscription.EreaderEmail != "" {
+			s.sendEpub(subscription, subscriptionState, tweets)
+		}
 	}
 	_, err = s.UserDatastore.UpdateSubscriptionState(context.Background(), subscriptionState)
 	if err != nil {
 		log.Errorf("Can not update subscription state got error %s", err)
-		return
 	}
+	return subscriptionState
 }
 
-func (s SystemUseCase) GetToken(email, userID string) (string, error) {
-	exp := time.Now().Unix() + 24*60*60
-	claims := JWTClaims{
-		email,
-		userID,
-		jwt.StandardClaims{
-			ExpiresAt: exp,
-		},
+// sendEpub sends the issue as an e-book to the e-reader address, the address must be confirmed like any other
+func (s SystemUseCase) sendEpub(subscription models.Subscription, subscriptionState models.SubscriptionState, tweets []models.Tweet) {
+	if len(tweets) == 0 {
+		return
+	}
+
+	userEmail := models.UserEmail{UserID: subscription.UserID, Email: subscription.EreaderEmail}
+	email, err := s.UserDatastore.GetUserEmail(context.Background(), userEmail)
+	if err != nil {
+		log.Errorf("Can not get UserEmail %s, got error %s", userEmail, err)
+		return
+	}
+
+	if email.UserID != subscription.UserID || email.IsSuppressed() || email.Status != models.EmailStatusConfirmed {
+		log.Warningf("E-reader email %s can not be used, status %s", email, email.Status)
+		return
+	}
+
+	book, err := renderEpub(s.Conf.TemplatePath, subscription, subscriptionState, tweets, fetchImage)
+	if err != nil {
+		log.Errorf("Can not render epub for subscription %s, got error %s", subscription, err)
+		return
 	}
 
+	message := models.NewEmailMessage(s.Conf.From, subscription.EreaderEmail, subscription.GetSubject(), epubEmailBody)
+	message.Attachments = append(message.Attachments, book)
+
+	err = s.EmailSender.Send(message)
+	if err != nil {
+		log.Errorf("Can not send epub for subscription %s, got error %s", subscription, err)
+	}
+}
+
+func getSignedToken(key string, claims jwt.Claims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, err := token.SignedString([]byte(s.Conf.EncryptKey))
+	ss, err := token.SignedString([]byte(key))
 	if err != nil {
 		return "", err
 	}
@@ -493,8 +580,21 @@ func (s SystemUseCase) GetToken(email, userID string) (string, error) {
 	return ss, err
 }
 
-func (s SystemUseCase) getEmailConfirmationLink(email, userID string) (string, error) {
-	token, err := s.GetToken(email, userID)
+// getUnsubscribeToken returns token for unsubscribe link, the token doesn't expire
+// because links from old issues must keep working
+func (s SystemUseCase) getUnsubscribeToken(subscriptionID uuid.UUID) (string, error) {
+	claims := UnsubscribeClaims{
+		subscriptionID.String(),
+		jwt.StandardClaims{
+			Subject: unsubscribeSubject,
+		},
+	}
+
+	return getSignedToken(s.Conf.EncryptKey, claims)
+}
+
+func (s SystemUseCase) getUnsubscribeLink(subscriptionID uuid.UUID) (string, error) {
+	token, err := s.getUnsubscribeToken(subscriptionID)
 	if err != nil {
 		return "", err
 	}
@@ -502,48 +602,24 @@ func (s SystemUseCase) getEmailConfirmationLink(email, userID string) (string, e
 	link := &url.URL{
 		Scheme:   "https",
 		Host:     s.Conf.Domain,
-		Path:     "confirm/email",
+		Path:     "unsubscribe",
 		RawQuery: fmt.Sprintf("token=%s", token),
 	}
 
 	return link.String(), err
 }
 
-func (s SystemUseCase) SendConfirmationEmail() error {
-	emails, err := s.UserDatastore.GetUserEmails(context.Background(), models.EmailStatusNew)
-	tmpl := template.Must(template.New("confirm.html").ParseFiles(filepath.Join(s.Conf.TemplatePath, "confirm.html")))
-
-	for _, email := range emails {
-		var buf strings.Builder
-		link, err := s.getEmailConfirmationLink(email.Email, email.UserID.String())
-		if err != nil {
-			log.Errorf("Can not get confirmation link: %s", err)
-		}
-
-		type TemplateData struct {
-			ConfirmationLink string
-		}
-
-		err = tmpl.Execute(&buf, TemplateData{ConfirmationLink: link})
-		if err != nil {
-			log.Errorf("Can not execute template: %s", err)
-		}
-
-		err = s.EmailSender.Send(s.Conf.From, email.Email, ConfirmationEmailSubj, buf.String())
-		if err == nil {
-			email.Status = models.EmailStatusSent
-			_, err = s.UserDatastore.UpdateUserEmail(context.Background(), email)
-			if err != nil {
-				log.Errorf("Can not update user email: %s", err)
-			}
-		} else {
-			log.Errorf("Can not send email: %s", err)
-		}
+// RemoveOldTweets removes tweets older than TweetTTL days and expired data export archives
+func (s SystemUseCase) RemoveOldTweets() error {
+	ctx := context.Background()
+	err := s.UserDatastore.RemoveOldTweets(ctx, s.Conf.TweetTTL)
+	if err != nil {
+		return err
 	}
-	return err
-}
 
-func (s SystemUseCase) RemoveOldTweets() error {
-	err := s.UserDatastore.RemoveOldTweets(context.Background(), s.Conf.TweetTTL)
+	n, err := s.UserDatastore.RemoveExpiredDataExports(ctx)
+	if err == nil {
+		log.Infof("Removed %d expired data exports", n)
+	}
 	return err
 }
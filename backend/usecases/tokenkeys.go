@@ -0,0 +1,42 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// rotateTokenKeysBatch - number of twitter accounts re-encrypted in one transaction
+const rotateTokenKeysBatch = 100
+
+// RotateTokenKeys re-encrypts tokens of all twitter accounts with the current key.
+// Every batch is committed separately, the app keeps working with both keys meanwhile.
+// Rows locked during the rotation are skipped, an error is returned if some of them are left with old keys.
+func (s SystemUseCase) RotateTokenKeys() (uint, error) {
+	var total uint
+	for {
+		n, err := s.UserDatastore.RotateTwitterUserKeys(context.Background(), rotateTokenKeysBatch)
+		if err != nil {
+			log.Errorf("Can not rotate token keys, got error %s", err)
+			return total, err
+		}
+
+		if n == 0 {
+			break
+		}
+		total += n
+		log.Infof("Rotated token keys of %d twitter accounts", total)
+	}
+
+	left, err := s.UserDatastore.CountTwitterUsersToRotate(context.Background())
+	if err != nil {
+		log.Errorf("Can not count not rotated twitter accounts, got error %s", err)
+		return total, err
+	}
+
+	if left > 0 {
+		return total, fmt.Errorf("Tokens of %d twitter accounts are still encrypted with old keys, run the rotation again", left)
+	}
+	return total, nil
+}
@@ -0,0 +1,32 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRotateTokenKeys(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	datastoreMock.On("RotateTwitterUserKeys", mock.Anything, uint(rotateTokenKeysBatch)).Return(uint(rotateTokenKeysBatch), nil).Twice()
+	datastoreMock.On("RotateTwitterUserKeys", mock.Anything, uint(rotateTokenKeysBatch)).Return(uint(7), nil).Once()
+	datastoreMock.On("RotateTwitterUserKeys", mock.Anything, uint(rotateTokenKeysBatch)).Return(uint(0), nil).Once()
+	datastoreMock.On("CountTwitterUsersToRotate", mock.Anything).Return(uint(0), nil)
+
+	n, err := s.RotateTokenKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2*rotateTokenKeysBatch+7), n)
+	datastoreMock.AssertNumberOfCalls(t, "RotateTwitterUserKeys", 4)
+}
+
+func TestRotateTokenKeysLockedLeft(t *testing.T) {
+	s, datastoreMock, _ := getSyncUseCase()
+	datastoreMock.On("RotateTwitterUserKeys", mock.Anything, uint(rotateTokenKeysBatch)).Return(uint(7), nil).Once()
+	datastoreMock.On("RotateTwitterUserKeys", mock.Anything, uint(rotateTokenKeysBatch)).Return(uint(0), nil).Once()
+	datastoreMock.On("CountTwitterUsersToRotate", mock.Anything).Return(uint(2), nil)
+
+	n, err := s.RotateTokenKeys()
+	assert.Error(t, err)
+	assert.Equal(t, uint(7), n)
+}
@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// getSubscriptionTwitterUser returns the linked twitter account fetching timelines of the subscription,
+// the primary account is used if the subscription doesn't choose one or the chosen one isn't linked anymore
+func getSubscriptionTwitterUser(ctx context.Context, datastore models.UserDatastore, subscription models.Subscription) (models.TwitterUser, error) {
+	if subscription.TwitterAccountID != "" {
+		user, err := datastore.GetTwitterUserByID(ctx, subscription.TwitterAccountID)
+		if err == nil && user.UserID == subscription.UserID {
+			return user, nil
+		}
+
+		if err != nil && errors.GetErrorCode(err) != errors.NotFound {
+			return user, err
+		}
+		log.Warningf("Twitter account %s of %s is not linked, using the primary account", subscription.TwitterAccountID, subscription)
+	}
+
+	return datastore.GetTwitterUser(ctx, subscription.UserID)
+}
+
+// checkSubscriptionTwitterAccount returns an error if the twitter account chosen for the subscription isn't linked to its user
+func (u UserUseCase) checkSubscriptionTwitterAccount(ctx context.Context, subscription models.Subscription) error {
+	if subscription.TwitterAccountID == "" {
+		return nil
+	}
+
+	user, err := u.UserDatastore.GetTwitterUserByID(ctx, subscription.TwitterAccountID)
+	if err != nil && errors.GetErrorCode(err) != errors.NotFound {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if err != nil || user.UserID != subscription.UserID {
+		msg := fmt.Sprintf("Twitter account %s is not linked", subscription.TwitterAccountID)
+		return errors.NewValidation(msg, map[string]string{"twitter_account_id": "must be a linked account"})
+	}
+	return nil
+}
+
+// LinkTwitterAccount links the twitter account to the user or updates tokens of the linked one.
+// An account linked to another user can't be linked, that user has to unlink it first.
+func (u UserUseCase) LinkTwitterAccount(ctx context.Context, userID uuid.UUID, twitterID, screenName, accessToken, tokenSecret string) (models.TwitterUser, error) {
+	twitterUser, err := u.UserDatastore.GetTwitterUserByID(ctx, twitterID)
+	if err == nil {
+		if twitterUser.UserID != userID {
+			log.Warningf("%s is linked to another user, can not link it to %s", twitterUser, userID)
+			return twitterUser, errors.NewConflict("Twitter account is linked to another user")
+		}
+
+		twitterUser.AccessToken = accessToken
+		twitterUser.TokenSecret = tokenSecret
+		twitterUser.ScreenName = screenName
+		twitterUser, err = u.UserDatastore.UpdateTwitterUser(ctx, twitterUser)
+		if err != nil {
+			return twitterUser, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+		}
+		return twitterUser, nil
+	}
+
+	if errors.GetErrorCode(err) != errors.NotFound {
+		return twitterUser, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	accounts, err := u.UserDatastore.GetTwitterUsers(ctx, userID)
+	if err != nil {
+		return models.TwitterUser{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	twitterUser = models.TwitterUser{
+		UserID:      userID,
+		TwitterID:   twitterID,
+		AccessToken: accessToken,
+		TokenSecret: tokenSecret,
+		ScreenName:  screenName,
+		Primary:     len(accounts) == 0,
+	}
+	twitterUser, err = u.UserDatastore.InsertTwitterUser(ctx, twitterUser)
+	if err != nil {
+		return twitterUser, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	log.Infof("Linked %s", twitterUser)
+	return twitterUser, nil
+}
+
+// GetTwitterAccounts returns twitter accounts linked to the user, the primary one is the first
+func (u UserUseCase) GetTwitterAccounts(ctx context.Context, userID uuid.UUID) ([]models.TwitterUser, error) {
+	accounts, err := u.UserDatastore.GetTwitterUsers(ctx, userID)
+	if err != nil {
+		return accounts, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return accounts, nil
+}
+
+func (u UserUseCase) getLinkedTwitterAccount(ctx context.Context, userID uuid.UUID, twitterID string) (models.TwitterUser, error) {
+	twitterUser, err := u.UserDatastore.GetTwitterUserByID(ctx, twitterID)
+	if err != nil {
+		return twitterUser, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if twitterUser.UserID != userID {
+		return twitterUser, errors.NewNotFound(fmt.Sprintf("Twitter account %s is not linked", twitterID))
+	}
+	return twitterUser, nil
+}
+
+// GetTwitterAccountSubscriptions returns subscriptions fetching timelines with the linked twitter account
+func (u UserUseCase) GetTwitterAccountSubscriptions(ctx context.Context, userID uuid.UUID, twitterID string) ([]models.Subscription, error) {
+	twitterUser, err := u.getLinkedTwitterAccount(ctx, userID, twitterID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := u.UserDatastore.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	res := make([]models.Subscription, 0)
+	for _, s := range subscriptions {
+		if s.TwitterAccountID == twitterID || (twitterUser.Primary && s.TwitterAccountID == "") {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+// UnlinkTwitterAccount unlinks not primary twitter account, its subscriptions switch to the primary account.
+// The account isn't unlinked if subscriptions use it unless force is set, the subscriptions are returned in both cases.
+func (u UserUseCase) UnlinkTwitterAccount(ctx context.Context, userID uuid.UUID, twitterID string, force bool) ([]models.Subscription, error) {
+	subscriptions, err := u.GetTwitterAccountSubscriptions(ctx, userID, twitterID)
+	if err != nil {
+		return nil, err
+	}
+
+	twitterUser, err := u.getLinkedTwitterAccount(ctx, userID, twitterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twitterUser.Primary {
+		return subscriptions, errors.NewConflict("The primary Twitter account can not be unlinked")
+	}
+
+	if len(subscriptions) > 0 && !force {
+		msg := fmt.Sprintf("%d subscriptions use the Twitter account", len(subscriptions))
+		return subscriptions, errors.NewConflict(msg)
+	}
+
+	unlinked, err := u.UserDatastore.UnlinkTwitterUser(ctx, userID, twitterID)
+	if err != nil {
+		return subscriptions, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if !unlinked {
+		return subscriptions, errors.NewNotFound(fmt.Sprintf("Twitter account %s is not linked", twitterID))
+	}
+
+	log.Infof("Unlinked %s, %d subscriptions switched to the primary account", twitterUser, len(subscriptions))
+	return subscriptions, nil
+}
@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/dmtr/mail_me_all/backend/config"
+	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/errors"
+	"github.com/dmtr/mail_me_all/backend/mocks"
+	"github.com/dmtr/mail_me_all/backend/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getTwitterAccountUseCase() (*UserUseCase, *mocks.UserDatastore) {
+	conf := config.GetConfig()
+	datastoreMock := new(mocks.UserDatastore)
+	return NewUserUseCase(datastoreMock, nil, &conf), datastoreMock
+}
+
+func TestLinkTwitterAccount(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	primary := models.TwitterUser{UserID: userID, TwitterID: "111", Primary: true}
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "222").Return(models.TwitterUser{}, &db.DbError{Err: sql.ErrNoRows})
+	datastoreMock.On("GetTwitterUsers", mock.Anything, userID).Return([]models.TwitterUser{primary}, nil)
+
+	linked := models.TwitterUser{UserID: userID, TwitterID: "222", ScreenName: "second", AccessToken: "token", TokenSecret: "secret"}
+	datastoreMock.On("InsertTwitterUser", mock.Anything, linked).Return(linked, nil)
+
+	res, err := u.LinkTwitterAccount(context.Background(), userID, "222", "second", "token", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, linked, res)
+	assert.False(t, res.Primary)
+}
+
+func TestLinkTwitterAccountOfAnotherUser(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	other := models.TwitterUser{UserID: uuid.New(), TwitterID: "222", Primary: true}
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "222").Return(other, nil)
+
+	_, err := u.LinkTwitterAccount(context.Background(), uuid.New(), "222", "second", "token", "secret")
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "InsertTwitterUser", 0)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateTwitterUser", 0)
+}
+
+func TestUnlinkTwitterAccount(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	linked := models.TwitterUser{UserID: userID, TwitterID: "222"}
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "222").Return(linked, nil)
+
+	subscriptions := []models.Subscription{
+		{ID: uuid.New(), UserID: userID, Title: "primary"},
+		{ID: uuid.New(), UserID: userID, Title: "protected", TwitterAccountID: "222"},
+	}
+	datastoreMock.On("GetSubscriptions", mock.Anything, userID).Return(subscriptions, nil)
+	datastoreMock.On("UnlinkTwitterUser", mock.Anything, userID, "222").Return(true, nil)
+
+	res, err := u.UnlinkTwitterAccount(context.Background(), userID, "222", false)
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	assert.Equal(t, subscriptions[1:], res)
+	datastoreMock.AssertNumberOfCalls(t, "UnlinkTwitterUser", 0)
+
+	res, err = u.UnlinkTwitterAccount(context.Background(), userID, "222", true)
+	assert.NoError(t, err)
+	assert.Equal(t, subscriptions[1:], res)
+	datastoreMock.AssertNumberOfCalls(t, "UnlinkTwitterUser", 1)
+}
+
+func TestUnlinkPrimaryTwitterAccount(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	primary := models.TwitterUser{UserID: userID, TwitterID: "111", Primary: true}
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "111").Return(primary, nil)
+	datastoreMock.On("GetSubscriptions", mock.Anything, userID).Return([]models.Subscription{}, nil)
+
+	_, err := u.UnlinkTwitterAccount(context.Background(), userID, "111", true)
+	assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "UnlinkTwitterUser", 0)
+}
+
+func TestSubscriptionTwitterUser(t *testing.T) {
+	_, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	primary := models.TwitterUser{UserID: userID, TwitterID: "111", Primary: true}
+	linked := models.TwitterUser{UserID: userID, TwitterID: "222"}
+	datastoreMock.On("GetTwitterUser", mock.Anything, userID).Return(primary, nil)
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "222").Return(linked, nil)
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "333").Return(models.TwitterUser{UserID: uuid.New(), TwitterID: "333"}, nil)
+
+	user, err := getSubscriptionTwitterUser(context.Background(), datastoreMock, models.Subscription{UserID: userID})
+	assert.NoError(t, err)
+	assert.Equal(t, primary, user)
+
+	user, err = getSubscriptionTwitterUser(context.Background(), datastoreMock, models.Subscription{UserID: userID, TwitterAccountID: "222"})
+	assert.NoError(t, err)
+	assert.Equal(t, linked, user)
+
+	user, err = getSubscriptionTwitterUser(context.Background(), datastoreMock, models.Subscription{UserID: userID, TwitterAccountID: "333"})
+	assert.NoError(t, err)
+	assert.Equal(t, primary, user)
+}
+
+func TestAddSubscriptionWithNotLinkedTwitterAccount(t *testing.T) {
+	u, datastoreMock := getTwitterAccountUseCase()
+	userID := uuid.New()
+	datastoreMock.On("GetUser", mock.Anything, userID).Return(models.User{ID: userID}, nil)
+	datastoreMock.On("GetTwitterUserByID", mock.Anything, "333").Return(models.TwitterUser{UserID: uuid.New(), TwitterID: "333"}, nil)
+
+	_, err := u.AddSubscription(context.Background(), models.Subscription{UserID: userID, TwitterAccountID: "333"})
+	assert.Equal(t, errors.Validation, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "InsertSubscription", 0)
+}
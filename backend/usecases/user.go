@@ -14,8 +14,6 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const ConfirmationEmailSubj = "Email address confirmation"
-
 // UserUseCase implementation
 type UserUseCase struct {
 	UserDatastore models.UserDatastore
@@ -42,11 +40,11 @@ func (u UserUseCase) SignInWithTwitter(ctx context.Context, twitterID, name, ema
 	user := models.User{Name: name}
 	var profileUrl string
 
+	// without the screen name the user's verified email is returned
 	req := pb.UserInfoRequest{
 		TwitterId:    twitterID,
 		AccessToken:  accessToken,
 		AccessSecret: tokenSecret,
-		ScreenName:   screenName,
 	}
 	if userInfo, err := u.RpcClient.GetUserInfo(context.Background(), &req); err != nil {
 		log.Errorf("Can not get user info: %s", err)
@@ -79,6 +77,7 @@ func (u UserUseCase) SignInWithTwitter(ctx context.Context, twitterID, name, ema
 		twitterUser.AccessToken = accessToken
 		twitterUser.TokenSecret = tokenSecret
 		twitterUser.ProfileIMGURL = profileUrl
+		twitterUser.ScreenName = screenName
 
 		if _, err = u.UserDatastore.UpdateTwitterUser(ctx, twitterUser); err != nil {
 			return models.User{}, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
@@ -95,6 +94,8 @@ func (u UserUseCase) SignInWithTwitter(ctx context.Context, twitterID, name, ema
 			AccessToken:   accessToken,
 			TokenSecret:   tokenSecret,
 			ProfileIMGURL: profileUrl,
+			ScreenName:    screenName,
+			Primary:       true,
 		}
 
 		if _, err = u.UserDatastore.InsertTwitterUser(ctx, twUser); err != nil {
@@ -123,7 +124,7 @@ func (u UserUseCase) SearchTwitterUsers(ctx context.Context, userID uuid.UUID, q
 	res, err := u.RpcClient.SearchUsers(context.Background(), &req)
 	if err != nil {
 		log.Errorf("Can not find users: %s", err)
-		return nil, err
+		return nil, errors.FromGRPCError(err)
 	}
 
 	users := make([]models.TwitterUserSearchResult, 0, len(res.Users))
@@ -146,6 +147,16 @@ func (u UserUseCase) AddSubscription(ctx context.Context, subscription models.Su
 		return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
 	}
 
+	if err := u.checkSubscriptionTwitterAccount(ctx, subscription); err != nil {
+		return subscription, err
+	}
+
+	if subscription.EreaderEmail != "" {
+		if err := u.saveSubscriptionEmail(ctx, subscription.UserID, subscription.EreaderEmail); err != nil {
+			return subscription, err
+		}
+	}
+
 	s, err := u.UserDatastore.InsertSubscription(ctx, subscription)
 	if err != nil {
 		return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
@@ -181,15 +192,12 @@ func (u UserUseCase) GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]
 	return s, nil
 }
 
-func (u UserUseCase) UpdateSubscription(ctx context.Context, userID uuid.UUID, subscription models.Subscription) (models.Subscription, error) {
-	if userID != subscription.UserID {
-		err := fmt.Errorf("User %s can not edit subscription %s", userID, subscription)
-		return subscription, NewUseCaseError(err.Error(), errors.AuthRequired)
-	}
-
+// saveSubscriptionEmail adds the subscription address to the user's emails,
+// the address must be confirmed before anything is sent to it
+func (u UserUseCase) saveSubscriptionEmail(ctx context.Context, userID uuid.UUID, address string) error {
 	userEmail := models.UserEmail{
-		UserID: subscription.UserID,
-		Email:  subscription.Email,
+		UserID: userID,
+		Email:  address,
 	}
 
 	email, err := u.UserDatastore.GetUserEmail(ctx, userEmail)
@@ -197,16 +205,45 @@ func (u UserUseCase) UpdateSubscription(ctx context.Context, userID uuid.UUID, s
 		e := err.(*db.DbError)
 		if e.HasNoRows() {
 			userEmail.Status = models.EmailStatusNew
-			email, err = u.UserDatastore.InsertUserEmail(ctx, userEmail)
+			_, err = u.UserDatastore.InsertUserEmail(ctx, userEmail)
 			if err != nil {
-				return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+				return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
 			}
 		} else {
-			return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+			return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+		}
+	} else if email.UserID != userID {
+		log.Warningf("Email %s belongs to another user %s", address, email)
+		return errors.NewForbidden("Email belongs to another user")
+	}
+	return nil
+}
+
+func (u UserUseCase) UpdateSubscription(ctx context.Context, userID uuid.UUID, subscription models.Subscription) (models.Subscription, error) {
+	if userID != subscription.UserID {
+		err := fmt.Errorf("User %s can not edit subscription %s", userID, subscription)
+		return subscription, NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	current, err := u.getOwnSubscription(ctx, userID, subscription.ID)
+	if err != nil {
+		return subscription, err
+	}
+	// the paused state is changed only by pausing or resuming the subscription
+	subscription.Paused = current.Paused
+
+	if err := u.checkSubscriptionTwitterAccount(ctx, subscription); err != nil {
+		return subscription, err
+	}
+
+	if err := u.saveSubscriptionEmail(ctx, subscription.UserID, subscription.Email); err != nil {
+		return subscription, err
+	}
+
+	if subscription.EreaderEmail != "" {
+		if err := u.saveSubscriptionEmail(ctx, subscription.UserID, subscription.EreaderEmail); err != nil {
+			return subscription, err
 		}
-	} else if email.UserID != subscription.UserID {
-		log.Warningf("Email %s belongs to another user %s", subscription.Email, email)
-		return subscription, NewUseCaseError("Email belongs to another user", errors.AuthRequired)
 	}
 
 	s, err := u.UserDatastore.UpdateSubscription(ctx, subscription)
@@ -236,17 +273,90 @@ func (u UserUseCase) DeleteSubscription(ctx context.Context, userID uuid.UUID, s
 	return nil
 }
 
+// DeleteAccount purges the account: all user's rows are removed together with tweets and subscription users
+// nobody else references, the deletion audit is recorded. Twitter tokens are invalidated once the rows are gone,
+// tokens which can't be invalidated don't fail the deletion, they are counted in the audit.
 func (u UserUseCase) DeleteAccount(ctx context.Context, userID uuid.UUID) error {
-	err := u.UserDatastore.RemoveUser(ctx, userID)
+	twitterUsers, err := u.UserDatastore.GetTwitterUsers(ctx, userID)
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	deletion, err := u.UserDatastore.RemoveUser(ctx, models.AccountDeletion{UserID: userID})
+	if err != nil {
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	for _, t := range twitterUsers {
+		req := pb.InvalidateTokenRequest{AccessToken: t.AccessToken, AccessSecret: t.TokenSecret, TwitterId: t.TwitterID}
+		if _, err := u.RpcClient.InvalidateToken(ctx, &req); err != nil {
+			log.Errorf("Can not invalidate token of %s, got error %s", t, err)
+			deletion.TokensFailed++
+			continue
+		}
+		deletion.TokensRevoked++
+	}
+
+	if len(twitterUsers) > 0 {
+		deletion, err = u.UserDatastore.UpdateAccountDeletion(ctx, deletion)
+		if err != nil {
+			log.Errorf("Can not update %s, got error %s", deletion, err)
+		}
+	}
+
+	log.Infof("Purged %s", deletion)
+	return nil
+}
+
+func (u UserUseCase) UpdateEmailStatus(ctx context.Context, email, status string) error {
+	_, err := u.UserDatastore.UpdateUserEmail(ctx, models.UserEmail{Email: email, Status: status})
 	if err != nil {
 		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
 	}
 
-	return err
+	log.Infof("Email %s status changed to %s", email, status)
+	return nil
 }
 
-func (u UserUseCase) parseToken(token string) (models.UserEmail, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+// UseWebhookToken records the token of the email provider webhook, returns Conflict if the webhook is replayed
+func (u UserUseCase) UseWebhookToken(ctx context.Context, token string) error {
+	err := u.UserDatastore.InsertWebhookToken(ctx, token)
+	if err != nil {
+		if e, ok := err.(*db.DbError); ok && e.IsUniqueViolationError() {
+			return errors.NewConflict(fmt.Sprintf("Webhook token %s is used", token))
+		}
+		return NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+	return nil
+}
+
+func (u UserUseCase) GetSubscriptionIssues(ctx context.Context, userID uuid.UUID, subscriptionID uuid.UUID, limit, offset uint) ([]models.SubscriptionState, uint, error) {
+	subscription, err := u.UserDatastore.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	if userID != subscription.UserID {
+		err := fmt.Errorf("User %s can not view subscription %s", userID, subscription)
+		return nil, 0, NewUseCaseError(err.Error(), errors.AuthRequired)
+	}
+
+	total, err := u.UserDatastore.CountSubscriptionStates(ctx, subscriptionID)
+	if err != nil {
+		return nil, 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	issues, err := u.UserDatastore.GetSubscriptionStates(ctx, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, 0, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+	}
+
+	return issues, total, nil
+}
+
+func (u UserUseCase) parseUnsubscribeToken(token string) (uuid.UUID, error) {
+	var claims UnsubscribeClaims
+	t, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 		}
@@ -254,39 +364,62 @@ func (u UserUseCase) parseToken(token string) (models.UserEmail, error) {
 		return []byte(u.Conf.EncryptKey), nil
 	})
 
-	if claims, ok := t.Claims.(jwt.MapClaims); ok && t.Valid {
-		email := claims["email"]
-		userID := claims["user_id"]
-		uid, err := uuid.Parse(userID.(string))
-		if err != nil {
-			return models.UserEmail{}, err
-		}
-		return models.UserEmail{Email: email.(string), UserID: uid}, nil
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if !t.Valid || claims.Subject != unsubscribeSubject {
+		return uuid.UUID{}, fmt.Errorf("Invalid unsubscribe token")
 	}
-	return models.UserEmail{}, err
+
+	return uuid.Parse(claims.SubscriptionID)
 }
 
-func (u UserUseCase) ConfirmEmail(ctx context.Context, token string) error {
-	emailToConfirm, err := u.parseToken(token)
+func (u UserUseCase) Unsubscribe(ctx context.Context, token string, remove bool) (models.Subscription, error) {
+	subscriptionID, err := u.parseUnsubscribeToken(token)
+	if err != nil {
+		return models.Subscription{}, NewUseCaseError(err.Error(), errors.BadRequest)
+	}
+
+	subscription, err := u.UserDatastore.GetSubscription(ctx, subscriptionID)
 	if err != nil {
-		return err
+		return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
 	}
 
-	emailFromDB, err := u.UserDatastore.GetUserEmail(ctx, emailToConfirm)
+	if remove {
+		err = u.UserDatastore.DeleteSubscription(ctx, subscription)
+		if err != nil {
+			return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
+		}
+		log.Infof("Subscription %s removed by unsubscribe link", subscription)
+		return subscription, nil
+	}
+
+	if subscription.Paused {
+		return subscription, nil
+	}
+
+	subscription.Paused = true
+	subscription, err = u.UserDatastore.UpdateSubscription(ctx, subscription)
 	if err != nil {
-		return err
+		return subscription, NewUseCaseError(err.Error(), errors.GetErrorCode(err))
 	}
 
-	if emailToConfirm.UserID != emailFromDB.UserID {
-		log.Errorf("Emails not matching %s %s", emailToConfirm, emailFromDB)
-		return NewUseCaseError("Can't confirm email", errors.AuthRequired)
+	log.Infof("Subscription %s paused by unsubscribe link", subscription)
+	return subscription, nil
+}
+
+func (u UserUseCase) GetIssueEpub(ctx context.Context, userID uuid.UUID, subscriptionStateID uint) (models.Attachment, error) {
+	issue, err := u.GetIssue(ctx, userID, subscriptionStateID)
+	if err != nil {
+		return models.Attachment{}, err
 	}
 
-	emailToConfirm.Status = models.EmailStatusConfirmed
-	_, err = u.UserDatastore.UpdateUserEmail(ctx, emailToConfirm)
+	book, err := renderEpub(u.Conf.TemplatePath, issue.Subscription, issue.State, issue.Tweets, fetchImage)
 	if err != nil {
-		return err
+		log.Errorf("Can not render epub for %s, got error %s", issue.State.String(), err)
+		return models.Attachment{}, NewUseCaseError(err.Error(), errors.ServerError)
 	}
 
-	return nil
+	return book, nil
 }
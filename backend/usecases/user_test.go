@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/dmtr/mail_me_all/backend/config"
 	"github.com/dmtr/mail_me_all/backend/db"
+	"github.com/dmtr/mail_me_all/backend/errors"
 	"github.com/dmtr/mail_me_all/backend/mocks"
 	"github.com/dmtr/mail_me_all/backend/models"
 	pb "github.com/dmtr/mail_me_all/backend/rpc"
@@ -57,7 +59,6 @@ func testSignUpWithTwitterOk(t *testing.T, usecases *models.UseCases, datastoreM
 		TwitterId:    twitterUserID,
 		AccessToken:  accessToken,
 		AccessSecret: tokenSecret,
-		ScreenName:   screenName,
 	}
 
 	res := pb.UserInfo{
@@ -82,6 +83,8 @@ func testSignUpWithTwitterOk(t *testing.T, usecases *models.UseCases, datastoreM
 		AccessToken:   accessToken,
 		TokenSecret:   tokenSecret,
 		ProfileIMGURL: res.ProfileImageUrl,
+		ScreenName:    screenName,
+		Primary:       true,
 	}
 	datastoreMock.On("InsertTwitterUser", mock.Anything, twitterUser).Return(twitterUser, nil)
 
@@ -109,7 +112,6 @@ func testSignInWithTwitterOk(t *testing.T, usecases *models.UseCases, datastoreM
 		TwitterId:    twitterUserID,
 		AccessToken:  accessToken,
 		AccessSecret: tokenSecret,
-		ScreenName:   screenName,
 	}
 
 	res := pb.UserInfo{
@@ -140,6 +142,7 @@ func testSignInWithTwitterOk(t *testing.T, usecases *models.UseCases, datastoreM
 		AccessToken:   accessToken,
 		TokenSecret:   tokenSecret,
 		ProfileIMGURL: res.ProfileImageUrl,
+		ScreenName:    screenName,
 	}
 	datastoreMock.On("UpdateTwitterUser", mock.Anything, updatedTwitterUser).Return(updatedTwitterUser, nil)
 
@@ -154,18 +157,24 @@ func testSignInWithTwitterOk(t *testing.T, usecases *models.UseCases, datastoreM
 	clientMock.AssertNumberOfCalls(t, "GetUserInfo", 1)
 }
 
+func getTestEmailConfirmationToken(t *testing.T, usecases *models.UseCases) string {
+	token, err := usecases.SystemUseCase.(*SystemUseCase).getEmailConfirmationToken("nonce", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	return token
+}
+
 func testConfirmEmailOk(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
 	uid := uuid.New()
 	email := "test@example.com"
+	confirmation := models.EmailConfirmation{ID: 1, UserID: uid, Email: email}
+	datastoreMock.On("UseEmailConfirmation", mock.Anything, hashToken("nonce")).Return(confirmation, nil)
+
 	userEmail := models.UserEmail{
 		UserID: uid,
 		Email:  email,
-		Status: models.EmailStatusNew,
+		Status: models.EmailStatusSent,
 	}
-	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(userEmail, nil)
-
-	token, err := usecases.GetToken(email, uid.String())
-	assert.NoError(t, err)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: email}).Return(userEmail, nil)
 
 	confirmedEmail := models.UserEmail{
 		UserID: uid,
@@ -174,33 +183,187 @@ func testConfirmEmailOk(t *testing.T, usecases *models.UseCases, datastoreMock *
 	}
 	datastoreMock.On("UpdateUserEmail", mock.Anything, confirmedEmail).Return(confirmedEmail, nil)
 
-	err = usecases.ConfirmEmail(context.Background(), token)
+	err := usecases.ConfirmEmail(context.Background(), getTestEmailConfirmationToken(t, usecases))
 	assert.NoError(t, err)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 1)
+}
+
+func testConfirmEmailUsedLink(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	datastoreMock.On("UseEmailConfirmation", mock.Anything, hashToken("nonce")).Return(models.EmailConfirmation{}, &db.DbError{Err: sql.ErrNoRows})
+
+	err := usecases.ConfirmEmail(context.Background(), getTestEmailConfirmationToken(t, usecases))
+	assert.Error(t, err)
+	assert.Equal(t, errors.AuthRequired, errors.GetErrorCode(err))
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 0)
 }
 
 func testConfirmEmailFailedUsersNotMatch(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
-	uid := uuid.New()
 	email := "test@example.com"
+	confirmation := models.EmailConfirmation{ID: 1, UserID: uuid.New(), Email: email}
+	datastoreMock.On("UseEmailConfirmation", mock.Anything, hashToken("nonce")).Return(confirmation, nil)
+
 	userEmail := models.UserEmail{
-		UserID: uid,
+		UserID: uuid.New(),
 		Email:  email,
-		Status: models.EmailStatusNew,
+		Status: models.EmailStatusSent,
 	}
 	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(userEmail, nil)
 
-	token, err := usecases.GetToken(email, uuid.New().String())
+	err := usecases.ConfirmEmail(context.Background(), getTestEmailConfirmationToken(t, usecases))
+	assert.Error(t, err)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 0)
+}
+
+func testUpdateSubscriptionSuppressedEmail(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid := uuid.New()
+	subscription := models.Subscription{ID: uuid.New(), UserID: uid, Title: "test", Email: "test@example.com", Day: "monday"}
+	datastoreMock.On("GetSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+	userEmail := models.UserEmail{
+		UserID: uid,
+		Email:  subscription.Email,
+		Status: models.EmailStatusBounced,
+	}
+	datastoreMock.On("GetUserEmail", mock.Anything, mock.Anything).Return(userEmail, nil)
+	datastoreMock.On("UpdateSubscription", mock.Anything, subscription).Return(subscription, nil)
+
+	_, err := usecases.UpdateSubscription(context.Background(), uid, subscription)
+	assert.NoError(t, err)
+	datastoreMock.AssertNotCalled(t, "UpdateUserEmail", mock.Anything, mock.Anything)
+}
+
+func testReactivateEmail(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid := uuid.New()
+	userEmail := models.UserEmail{UserID: uid, Email: "test@example.com", Status: models.EmailStatusBounced}
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: userEmail.Email}).Return(userEmail, nil)
+
+	newEmail := userEmail
+	newEmail.Status = models.EmailStatusNew
+	datastoreMock.On("UpdateUserEmail", mock.Anything, newEmail).Return(newEmail, nil)
+
+	email, err := usecases.ReactivateEmail(context.Background(), uid, userEmail.Email)
 	assert.NoError(t, err)
+	assert.Equal(t, models.EmailStatusNew, email.Status)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateUserEmail", 1)
+}
+
+func testReactivateEmailComplained(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid := uuid.New()
+	for _, status := range []string{models.EmailStatusComplained, models.EmailStatusUnsubscribed} {
+		userEmail := models.UserEmail{UserID: uid, Email: "test@example.com", Status: status}
+		datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{Email: userEmail.Email}).Return(userEmail, nil).Once()
+
+		_, err := usecases.ReactivateEmail(context.Background(), uid, userEmail.Email)
+		assert.Error(t, err)
+		assert.Equal(t, errors.Conflict, errors.GetErrorCode(err))
+	}
+	datastoreMock.AssertNotCalled(t, "UpdateUserEmail", mock.Anything, mock.Anything)
+}
+
+func testUpdateSubscriptionEreaderEmail(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	uid := uuid.New()
+	subscription := models.Subscription{
+		ID: uuid.New(), UserID: uid, Title: "test", Email: "test@example.com", EreaderEmail: "test@kindle.com", Day: "monday"}
+	datastoreMock.On("GetSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{UserID: uid, Email: subscription.Email}).Return(
+		models.UserEmail{UserID: uid, Email: subscription.Email, Status: models.EmailStatusConfirmed}, nil)
+	datastoreMock.On("GetUserEmail", mock.Anything, models.UserEmail{UserID: uid, Email: subscription.EreaderEmail}).Return(
+		models.UserEmail{}, &db.DbError{Err: sql.ErrNoRows})
+
+	ereaderEmail := models.UserEmail{UserID: uid, Email: subscription.EreaderEmail, Status: models.EmailStatusNew}
+	datastoreMock.On("InsertUserEmail", mock.Anything, ereaderEmail).Return(ereaderEmail, nil)
+	datastoreMock.On("UpdateSubscription", mock.Anything, subscription).Return(subscription, nil)
+
+	_, err := usecases.UpdateSubscription(context.Background(), uid, subscription)
+	assert.NoError(t, err)
+	datastoreMock.AssertNumberOfCalls(t, "InsertUserEmail", 1)
+}
+
+func testUnsubscribePause(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	subscription := models.Subscription{ID: uuid.New(), UserID: uuid.New(), Title: "test"}
+	token, err := usecases.SystemUseCase.(*SystemUseCase).getUnsubscribeToken(subscription.ID)
+	assert.NoError(t, err)
+
+	datastoreMock.On("GetSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+	paused := subscription
+	paused.Paused = true
+	datastoreMock.On("UpdateSubscription", mock.Anything, paused).Return(paused, nil)
+
+	s, err := usecases.Unsubscribe(context.Background(), token, false)
+	assert.NoError(t, err)
+	assert.True(t, s.Paused)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateSubscription", 1)
+	datastoreMock.AssertNumberOfCalls(t, "DeleteSubscription", 0)
+}
+
+func testUnsubscribeRemove(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	subscription := models.Subscription{ID: uuid.New(), UserID: uuid.New(), Title: "test"}
+	token, err := usecases.SystemUseCase.(*SystemUseCase).getUnsubscribeToken(subscription.ID)
+	assert.NoError(t, err)
+
+	datastoreMock.On("GetSubscription", mock.Anything, subscription.ID).Return(subscription, nil)
+	datastoreMock.On("DeleteSubscription", mock.Anything, subscription).Return(nil)
+
+	_, err = usecases.Unsubscribe(context.Background(), token, true)
+	assert.NoError(t, err)
+	datastoreMock.AssertNumberOfCalls(t, "DeleteSubscription", 1)
+}
+
+func testUnsubscribeWrongToken(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	_, err := usecases.Unsubscribe(context.Background(), getTestEmailConfirmationToken(t, usecases), true)
+	assert.Error(t, err)
+	datastoreMock.AssertNumberOfCalls(t, "GetSubscription", 0)
+}
+
+func testDeleteAccountInvalidatesTokens(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	userID := uuid.New()
+	datastoreMock.On("GetTwitterUsers", mock.Anything, userID).Return([]models.TwitterUser{
+		{UserID: userID, TwitterID: "111", AccessToken: "token", TokenSecret: "secret", Primary: true},
+		{UserID: userID, TwitterID: "222", AccessToken: "revoked", TokenSecret: "secret"},
+	}, nil)
+	clientMock.On("InvalidateToken", mock.Anything, &pb.InvalidateTokenRequest{AccessToken: "token", AccessSecret: "secret", TwitterId: "111"}).Return(&pb.InvalidateTokenResponse{}, nil)
+	clientMock.On("InvalidateToken", mock.Anything, &pb.InvalidateTokenRequest{AccessToken: "revoked", AccessSecret: "secret", TwitterId: "222"}).Return(nil, fmt.Errorf("Invalid token"))
+
+	// a token which can't be invalidated doesn't fail the deletion
+	deletion := models.AccountDeletion{ID: 1, UserID: userID}
+	datastoreMock.On("RemoveUser", mock.Anything, models.AccountDeletion{UserID: userID}).Return(deletion, nil)
+	updated := models.AccountDeletion{ID: 1, UserID: userID, TokensRevoked: 1, TokensFailed: 1}
+	datastoreMock.On("UpdateAccountDeletion", mock.Anything, updated).Return(updated, nil)
+
+	err := usecases.DeleteAccount(context.Background(), userID)
+	assert.NoError(t, err)
+	clientMock.AssertNumberOfCalls(t, "InvalidateToken", 2)
+	datastoreMock.AssertNumberOfCalls(t, "RemoveUser", 1)
+	datastoreMock.AssertNumberOfCalls(t, "UpdateAccountDeletion", 1)
+}
+
+func testDeleteAccountRemoveFailed(t *testing.T, usecases *models.UseCases, datastoreMock *mocks.UserDatastore, clientMock *mocks.TwProxyServiceClient) {
+	userID := uuid.New()
+	datastoreMock.On("GetTwitterUsers", mock.Anything, userID).Return([]models.TwitterUser{
+		{UserID: userID, TwitterID: "111", AccessToken: "token", TokenSecret: "secret", Primary: true},
+	}, nil)
+	datastoreMock.On("RemoveUser", mock.Anything, mock.Anything).Return(models.AccountDeletion{}, &db.DbError{Err: fmt.Errorf("db error")})
 
-	err = usecases.ConfirmEmail(context.Background(), token)
+	err := usecases.DeleteAccount(context.Background(), userID)
 	assert.Error(t, err)
+	clientMock.AssertNumberOfCalls(t, "InvalidateToken", 0)
 }
 
 func TestUseCases(t *testing.T) {
 	tests := map[string]testFunc{
-		"TestSignUpWithTwitterOk":             testSignUpWithTwitterOk,
-		"TestSignInWithTwitterOk":             testSignInWithTwitterOk,
-		"TestConfirmEmailOk":                  testConfirmEmailOk,
-		"TestConfirmEmailFailedUsersNotMatch": testConfirmEmailFailedUsersNotMatch,
+		"TestSignUpWithTwitterOk":               testSignUpWithTwitterOk,
+		"TestSignInWithTwitterOk":               testSignInWithTwitterOk,
+		"TestConfirmEmailOk":                    testConfirmEmailOk,
+		"TestConfirmEmailFailedUsersNotMatch":   testConfirmEmailFailedUsersNotMatch,
+		"TestConfirmEmailUsedLink":              testConfirmEmailUsedLink,
+		"TestUpdateSubscriptionSuppressedEmail": testUpdateSubscriptionSuppressedEmail,
+		"TestReactivateEmail":                   testReactivateEmail,
+		"TestReactivateEmailComplained":         testReactivateEmailComplained,
+		"TestUpdateSubscriptionEreaderEmail":    testUpdateSubscriptionEreaderEmail,
+		"TestUnsubscribePause":                  testUnsubscribePause,
+		"TestUnsubscribeRemove":                 testUnsubscribeRemove,
+		"TestUnsubscribeWrongToken":             testUnsubscribeWrongToken,
+		"TestDeleteAccountInvalidatesTokens":    testDeleteAccountInvalidatesTokens,
+		"TestDeleteAccountRemoveFailed":         testDeleteAccountRemoveFailed,
 	}
 	runTests(tests, t)
 }
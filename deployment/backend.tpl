@@ -62,11 +62,28 @@ spec:
              secretKeyRef:
                name: mgapikey
                key: mgapikey 
+         - name: MAILME_APP_MG_WEBHOOK_KEY
+           valueFrom:
+             secretKeyRef:
+               name: mgwebhookkey
+               key: mgwebhookkey
          - name: MAILME_APP_FROM
            valueFrom:
              secretKeyRef:
                name: mgfrom
                key: mgfrom 
+         - name: MAILME_APP_TOKEN_KEYS
+           valueFrom:
+             secretKeyRef:
+               name: tokenkeys
+               key: keys
+               optional: true
+         - name: MAILME_APP_TOKEN_KEY_ID
+           valueFrom:
+             secretKeyRef:
+               name: tokenkeys
+               key: current
+               optional: true
         ports:
         - containerPort: 8000
         resources:
@@ -112,6 +129,18 @@ spec:
                key: dsn
          - name: ENCRYPT_KEY
            value: ${ENCRYPT_KEY}
+         - name: MAILME_APP_TOKEN_KEYS
+           valueFrom:
+             secretKeyRef:
+               name: tokenkeys
+               key: keys
+               optional: true
+         - name: MAILME_APP_TOKEN_KEY_ID
+           valueFrom:
+             secretKeyRef:
+               name: tokenkeys
+               key: current
+               optional: true
         resources:
           requests:
             memory: "64Mi"
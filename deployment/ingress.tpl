@@ -25,3 +25,19 @@ spec:
         backend:
           serviceName: backend
           servicePort: 8000
+      - path: /webhooks/*
+        backend:
+          serviceName: backend
+          servicePort: 8000
+      - path: /unsubscribe
+        backend:
+          serviceName: backend
+          servicePort: 8000
+      - path: /issues/*
+        backend:
+          serviceName: backend
+          servicePort: 8000
+      - path: /shared/*
+        backend:
+          serviceName: backend
+          servicePort: 8000
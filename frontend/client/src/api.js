@@ -9,7 +9,10 @@ function getError(e) {
     if (data && typeof data === "object") {
       return {
         code: data.code,
-        message: data.message
+        type: data.error,
+        message: data.message,
+        fields: data.fields || {},
+        retryAfter: data.retry_after
       };
     } else {
       return {
@@ -108,3 +111,326 @@ export async function deleteAccount() {
     return new ApiResult(null, error);
   }
 }
+
+const sendNowErrors = {
+  409: "Scheduled delivery is in progress, try again later",
+  429: "Daily limit of on-demand issues is reached"
+};
+
+// progress is streamed as Server-Sent Events, EventSource can't POST so the stream is read with fetch
+export async function sendSubscriptionNow(subscriptionId, onProgress) {
+  try {
+    const response = await fetch(`api/subscriptions/${subscriptionId}/send-now`, {
+      method: "POST",
+      credentials: "same-origin"
+    });
+    if (!response.ok) {
+      const message = _.get(sendNowErrors, response.status, defaultErorr);
+      return new ApiResult(null, { message: message });
+    }
+
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = "";
+    let last = null;
+    for (;;) {
+      const { done, value } = await reader.read();
+      if (done) {
+        break;
+      }
+      buffer += decoder.decode(value, { stream: true });
+      const events = buffer.split("\n\n");
+      buffer = events.pop();
+      _.forEach(events, e => {
+        const data = _.find(e.split("\n"), l => l.startsWith("data:"));
+        if (data) {
+          last = JSON.parse(data.slice(5));
+          onProgress(last);
+        }
+      });
+    }
+    return new ApiResult(last, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error);
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function pauseSubscription(subscriptionId) {
+  try {
+    const response = await axios.post(`api/subscriptions/${subscriptionId}/pause`);
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function resumeSubscription(subscriptionId, mode) {
+  try {
+    const response = await axios.post(`api/subscriptions/${subscriptionId}/resume`, { mode: mode });
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function setVacation(start, end) {
+  try {
+    const response = await axios.put(`api/user/vacation`, { start: start, end: end });
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function removeVacation() {
+  try {
+    const response = await axios.delete(`api/user/vacation`);
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function getAPITokens() {
+  try {
+    const response = await axios.get(`api/tokens`);
+    return new ApiResult(response.data["tokens"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function createAPIToken(name, scope) {
+  try {
+    const response = await axios.post(`api/tokens`, { name: name, scope: scope });
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function revokeAPIToken(tokenId) {
+  try {
+    const response = await axios.delete(`api/tokens/${tokenId}`);
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function getDataExports() {
+  try {
+    const response = await axios.get(`api/user/export`);
+    return new ApiResult(response.data["exports"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function exportUserData() {
+  try {
+    const response = await axios.post(`api/user/export`);
+    return new ApiResult(response.data["export"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function getSessions() {
+  try {
+    const response = await axios.get(`api/sessions`);
+    return new ApiResult(response.data["sessions"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function revokeSession(sessionId) {
+  try {
+    const response = await axios.delete(`api/sessions/${sessionId}`);
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function revokeSessions() {
+  try {
+    const response = await axios.delete(`api/sessions`);
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function getTwitterAccounts() {
+  try {
+    const response = await axios.get(`api/twitter-accounts`);
+    return new ApiResult(response.data["accounts"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function unlinkTwitterAccount(accountId, force) {
+  try {
+    const response = await axios.delete(`api/twitter-accounts/${accountId}`, { params: { force: force } });
+    return new ApiResult(response.data["subscriptions"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function getEmails() {
+  try {
+    const response = await axios.get("api/emails");
+    return new ApiResult(response.data["emails"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function addEmail(email) {
+  try {
+    const response = await axios.post("api/emails", { email: email });
+    return new ApiResult(response.data["email"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function deleteEmail(email, reassignTo) {
+  try {
+    const params = reassignTo ? { reassign_to: reassignTo } : {};
+    const response = await axios.delete(`api/emails/${encodeURIComponent(email)}`, { params: params });
+    return new ApiResult(response.data["subscriptions"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function resendConfirmationEmail(email) {
+  try {
+    const response = await axios.post(`api/emails/${encodeURIComponent(email)}/resend`);
+    return new ApiResult(response.data["email"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function reactivateEmail(email) {
+  try {
+    const response = await axios.post(`api/emails/${encodeURIComponent(email)}/reactivate`);
+    return new ApiResult(response.data["email"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function getTwitterLists() {
+  try {
+    const response = await axios.get("api/twitter-lists");
+    return new ApiResult(response.data["lists"], null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function importTwitterList(listId, request) {
+  try {
+    const response = await axios.post(`api/twitter-lists/${listId}/import`, request);
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function importTwitterFriends(request) {
+  try {
+    const response = await axios.post("api/twitter-friends/import", request);
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
+
+export async function requestMagicLink(email) {
+  try {
+    const response = await axios.post("signin/email/link", { email: email });
+    return new ApiResult(response.data, null);
+  } catch (error) {
+    /* eslint-disable no-console */
+    console.log(error.toJSON());
+    /* eslint-enable no-console */
+    return new ApiResult(null, error);
+  }
+}
@@ -6,7 +6,13 @@ import {
   createSubscription,
   updateSubscription,
   deleteSubscription,
-  deleteAccount
+  deleteAccount,
+  pauseSubscription,
+  resumeSubscription,
+  setVacation,
+  removeVacation,
+  importTwitterList,
+  importTwitterFriends
 } from "../../api";
 
 const state = {
@@ -17,6 +23,7 @@ const state = {
 const getters = {
   isUserLoaded: state => (state.user ? true : false),
   isUserSignedIn: state => state.user && state.user.signedIn,
+  user: state => state.user,
   subscriptions: state => state.subscriptions,
   email: state =>
     state.subscriptions.length
@@ -64,6 +71,46 @@ const actions = {
     }
   },
 
+  async pauseSubscription({ commit }, subscription) {
+    const res = await pauseSubscription(subscription.id);
+    if (!res.error) {
+      commit("replaceSubscription", res.data);
+    } else {
+      handle401(commit, res);
+    }
+    return res;
+  },
+
+  async resumeSubscription({ commit }, { subscription, mode }) {
+    const res = await resumeSubscription(subscription.id, mode);
+    if (!res.error) {
+      commit("replaceSubscription", res.data);
+    } else {
+      handle401(commit, res);
+    }
+    return res;
+  },
+
+  async setVacation({ commit }, { start, end }) {
+    const res = await setVacation(start, end);
+    if (!res.error) {
+      commit("setUser", res.data);
+    } else {
+      handle401(commit, res);
+    }
+    return res;
+  },
+
+  async removeVacation({ commit }) {
+    const res = await removeVacation();
+    if (!res.error) {
+      commit("setUser", res.data);
+    } else {
+      handle401(commit, res);
+    }
+    return res;
+  },
+
   async getSubscriptions({ commit }) {
     const res = await getSubscriptions();
     if (!res.error) {
@@ -74,6 +121,22 @@ const actions = {
     return res;
   },
 
+  async importTwitterUsers({ commit }, { listId, request }) {
+    const res = listId
+      ? await importTwitterList(listId, request)
+      : await importTwitterFriends(request);
+    if (!res.error) {
+      if (request.subscription_id) {
+        commit("replaceSubscription", res.data.subscription);
+      } else {
+        commit("addSubscription", res.data.subscription);
+      }
+    } else {
+      handle401(commit, res);
+    }
+    return res;
+  },
+
   async deleteAccount({ commit }) {
     const res = await deleteAccount();
     if (!res.error) {
@@ -103,6 +166,12 @@ const mutations = {
     state.subscriptions = subscriptions;
   },
 
+  replaceSubscription(state, subscription) {
+    state.subscriptions = _.map(state.subscriptions, s =>
+      s.id === subscription.id ? subscription : s
+    );
+  },
+
   removeSubscription(state, subscription) {
     state.subscriptions = _.filter(
       state.subscriptions,